@@ -0,0 +1,182 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"time"
+)
+
+// defaultSamplingInterval is the firmware sampling interval until one is
+// set.
+const defaultSamplingInterval = 19 * time.Millisecond
+
+// analogFilter decides which reports of an analog pin are passed on to
+// subscribers, value channels and callbacks. It is guarded by inputMu.
+type analogFilter struct {
+	// minDelta is the change needed to pass a report on, or zero to pass
+	// every report.
+	minDelta int
+	// interval is the time wanted between reports, or zero to pass every
+	// report.
+	interval time.Duration
+	// last is the last reading passed on, if any has been, and lastTime
+	// when it was received.
+	last     int
+	lastTime time.Time
+	passed   bool
+}
+
+// SetAnalogChangeOnly passes reports of an analog pin on to subscribers,
+// value channels and callbacks only when the reading differs by at least
+// minDelta from the last one passed on, rather than every sampling
+// interval. The first report is always passed on. The cached reading, as
+// returned by AnalogRead, still follows every report. A minDelta of zero
+// turns change filtering off.
+func (c *FirmataClient) SetAnalogChangeOnly(pin byte, minDelta int) {
+	c.inputMu.Lock()
+	defer c.inputMu.Unlock()
+	if minDelta < 0 {
+		minDelta = 0
+	}
+	c.analogFilter(pin).minDelta = minDelta
+	c.dropIdleFilter(pin)
+}
+
+// AnalogChangeOnly returns the minimum change set for pin with
+// SetAnalogChangeOnly, or zero if its reports are not filtered.
+func (c *FirmataClient) AnalogChangeOnly(pin byte) int {
+	c.inputMu.Lock()
+	defer c.inputMu.Unlock()
+	if f, ok := c.analogFilters[pin]; ok {
+		return f.minDelta
+	}
+	return 0
+}
+
+// SetAnalogReportRate passes reports of an analog pin on once every
+// interval, dropping those in between, so a slow sensor gives a slow
+// stream. Only the reports passed on are limited: the board sampling
+// interval is shortened if a pin asks for reports faster than it, but is
+// never made longer than the one set with SetAnalogSamplingInterval, or
+// the firmware default, so other pins are not slowed. An interval of zero
+// passes every report again.
+func (c *FirmataClient) SetAnalogReportRate(pin byte, interval time.Duration) error {
+	if interval < 0 {
+		interval = 0
+	}
+	c.inputMu.Lock()
+	c.analogFilter(pin).interval = interval
+	c.dropIdleFilter(pin)
+	var fastest time.Duration
+	for _, f := range c.analogFilters {
+		if f.interval > 0 && (fastest == 0 || f.interval < fastest) {
+			fastest = f.interval
+		}
+	}
+	c.inputMu.Unlock()
+
+	c.configMu.Lock()
+	current, user := c.config.samplingInterval, c.config.userSampling
+	c.configMu.Unlock()
+	ms := int64(user)
+	if ms == 0 {
+		ms = defaultSamplingInterval.Milliseconds()
+	}
+	if fastest > 0 && fastest.Milliseconds() < ms {
+		ms = fastest.Milliseconds()
+		if ms < 1 {
+			ms = 1
+		}
+	}
+	if current == 0 && user == 0 && ms == defaultSamplingInterval.Milliseconds() {
+		// Still at the firmware default.
+		return nil
+	}
+	if byte(ms) == current {
+		return nil
+	}
+	return c.setSamplingInterval(byte(ms))
+}
+
+// AnalogReportRate returns the interval set for pin with
+// SetAnalogReportRate, or zero if every report is passed on.
+func (c *FirmataClient) AnalogReportRate(pin byte) time.Duration {
+	c.inputMu.Lock()
+	defer c.inputMu.Unlock()
+	if f, ok := c.analogFilters[pin]; ok {
+		return f.interval
+	}
+	return 0
+}
+
+// samplingInterval returns the sampling interval last set, in
+// milliseconds, or zero if it has not been.
+func (c *FirmataClient) samplingInterval() byte {
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
+	return c.config.samplingInterval
+}
+
+// analogFilter returns the filter of pin, creating it if needed. inputMu
+// is held.
+func (c *FirmataClient) analogFilter(pin byte) *analogFilter {
+	if c.analogFilters == nil {
+		c.analogFilters = make(map[byte]*analogFilter)
+	}
+	f := c.analogFilters[pin]
+	if f == nil {
+		f = &analogFilter{}
+		c.analogFilters[pin] = f
+	}
+	return f
+}
+
+// dropIdleFilter removes the filter of pin if it no longer filters
+// anything. inputMu is held.
+func (c *FirmataClient) dropIdleFilter(pin byte) {
+	if f, ok := c.analogFilters[pin]; ok && f.minDelta == 0 && f.interval == 0 {
+		delete(c.analogFilters, pin)
+	}
+}
+
+// filterAnalog returns true if a reading of pin received at t should be
+// passed on, and the previous reading passed on. inputMu is held.
+func (c *FirmataClient) filterAnalog(pin byte, value int, previous int, t time.Time) (bool, int) {
+	f, ok := c.analogFilters[pin]
+	if !ok {
+		return true, previous
+	}
+	if f.passed {
+		previous = f.last
+		delta := value - f.last
+		if delta < 0 {
+			delta = -delta
+		}
+		if delta < f.minDelta {
+			return false, previous
+		}
+		// Allow half a sampling interval of jitter, so reports due at
+		// about the interval are not each put off to the one after.
+		sampling := defaultSamplingInterval
+		if ms := c.samplingInterval(); ms > 0 {
+			sampling = time.Duration(ms) * time.Millisecond
+		}
+		if f.interval > 0 && t.Sub(f.lastTime) < f.interval-sampling/2 {
+			return false, previous
+		}
+	}
+	f.last, f.lastTime, f.passed = value, t, true
+	return true, previous
+}
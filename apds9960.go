@@ -0,0 +1,279 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"time"
+)
+
+// Gesture is a swipe detected by an Apds9960.
+type Gesture byte
+
+const (
+	GestureNone Gesture = iota
+	GestureUp
+	GestureDown
+	GestureLeft
+	GestureRight
+)
+
+func (g Gesture) String() string {
+	switch g {
+	case GestureNone:
+		return "None"
+	case GestureUp:
+		return "Up"
+	case GestureDown:
+		return "Down"
+	case GestureLeft:
+		return "Left"
+	case GestureRight:
+		return "Right"
+	}
+	return fmt.Sprintf("Unknown gesture (0x%x)", byte(g))
+}
+
+const (
+	// Apds9960Address is the I2C address of an APDS9960.
+	Apds9960Address = 0x39
+
+	apdsEnable  = 0x80
+	apdsAtime   = 0x81
+	apdsWtime   = 0x83
+	apdsPpulse  = 0x8e
+	apdsControl = 0x8f
+	apdsConfig2 = 0x90
+	apdsId      = 0x92
+	apdsCdata   = 0x94
+	apdsPdata   = 0x9c
+	apdsGpenth  = 0xa0
+	apdsGexth   = 0xa1
+	apdsGconf1  = 0xa2
+	apdsGconf2  = 0xa3
+	apdsGpulse  = 0xa6
+	apdsGconf4  = 0xab
+	apdsGflvl   = 0xae
+	apdsGstatus = 0xaf
+	apdsGfifo   = 0xfc
+
+	apdsPowerOn  = 0x01
+	apdsAlsOn    = 0x02
+	apdsProxOn   = 0x04
+	apdsWaitOn   = 0x08
+	apdsGestOn   = 0x40
+	apdsGvalid   = 0x01
+	apdsGmode    = 0x01
+	apdsIdValue  = 0xab
+	apdsMinCount = 10
+	// apdsSensitivity is the change in up/down or left/right balance, in
+	// percent, needed to register a swipe.
+	apdsSensitivity = 40
+	// apdsFifoChunk is the most gesture datasets fetched in one read.
+	apdsFifoChunk = 7
+)
+
+// Apds9960 is a Broadcom APDS9960 proximity, light, colour and gesture
+// sensor.
+type Apds9960 struct {
+	// The client.
+	Client *FirmataClient
+	// The I2C address of the device.
+	Address byte
+
+	enable byte
+	poller *poller
+}
+
+// Init checks the device ID and enables proximity and ambient light
+// sensing with 4x gain and 100mA LED drive.
+func (d *Apds9960) Init() error {
+	id, err := d.readReg(apdsId)
+	if err != nil {
+		return err
+	}
+	if id != apdsIdValue {
+		return fmt.Errorf("unexpected device ID 0x%x", id)
+	}
+	for _, rv := range [][2]byte{
+		{apdsEnable, 0x00},
+		{apdsAtime, 0xdb},  // 103ms integration
+		{apdsWtime, 0xf6},  // 27ms wait
+		{apdsPpulse, 0x87}, // 16us, 8 pulses
+		{apdsControl, 0x09},
+		{apdsConfig2, 0x01},
+	} {
+		if err := d.Client.I2CWrite(d.Address, rv[0], rv[1]); err != nil {
+			return err
+		}
+	}
+	d.enable = apdsPowerOn | apdsAlsOn | apdsProxOn
+	return d.Client.I2CWrite(d.Address, apdsEnable, d.enable)
+}
+
+// Proximity returns the proximity reading, from 0 (far) to 255 (near).
+func (d *Apds9960) Proximity() (byte, error) {
+	return d.readReg(apdsPdata)
+}
+
+// Light returns the clear, red, green and blue ambient light readings.
+func (d *Apds9960) Light() (c, r, g, b uint16, err error) {
+	data, err := d.Client.I2CRead(d.Address, apdsCdata, 8)
+	if err != nil {
+		return
+	}
+	if len(data) < 8 {
+		err = fmt.Errorf("short colour data read")
+		return
+	}
+	u16 := func(i int) uint16 { return uint16(data[i]) | uint16(data[i+1])<<8 }
+	return u16(0), u16(2), u16(4), u16(6), nil
+}
+
+// Gestures enables the gesture engine and polls it every interval,
+// sending each decoded swipe on the returned channel until StopGestures
+// is called.
+func (d *Apds9960) Gestures(interval time.Duration) (<-chan Gesture, error) {
+	for _, rv := range [][2]byte{
+		{apdsGpenth, 40},
+		{apdsGexth, 30},
+		{apdsGconf1, 0x40}, // interrupt at 4 datasets
+		{apdsGconf2, 0x41}, // 4x gain, 100mA, 2.8ms wait
+		{apdsGpulse, 0xc9}, // 32us, 10 pulses
+		{apdsGconf4, 0x00},
+	} {
+		if err := d.Client.I2CWrite(d.Address, rv[0], rv[1]); err != nil {
+			return nil, err
+		}
+	}
+	d.enable |= apdsWaitOn | apdsGestOn
+	if err := d.Client.I2CWrite(d.Address, apdsEnable, d.enable); err != nil {
+		return nil, err
+	}
+
+	gestures := make(chan Gesture, 10)
+	d.poller.Stop()
+	d.poller = newPoller()
+	go func(p *poller) {
+		defer close(gestures)
+		var datasets [][4]byte
+		p.every(d.Client.Clock(), interval, func() {
+			more, active, err := d.readFifo()
+			if err != nil {
+				d.Client.Log.Warn("APDS9960 gesture read: %s", err.Error())
+				return
+			}
+			datasets = append(datasets, more...)
+			if active || len(datasets) == 0 {
+				return
+			}
+			if g := decodeGesture(datasets); g != GestureNone {
+				pollSend(p, gestures, g)
+			}
+			datasets = nil
+		})
+	}(d.poller)
+	return gestures, nil
+}
+
+// StopGestures stops gesture polling, closing the channel, and disables
+// the gesture engine. It does not wait for polling to end, so may be
+// called from the loop reading the gestures.
+func (d *Apds9960) StopGestures() error {
+	d.poller.Stop()
+	d.enable &^= apdsWaitOn | apdsGestOn
+	return d.Client.I2CWrite(d.Address, apdsEnable, d.enable)
+}
+
+// readFifo drains the gesture FIFO, and reports whether the gesture
+// engine is still active.
+func (d *Apds9960) readFifo() (datasets [][4]byte, active bool, err error) {
+	status, err := d.readReg(apdsGstatus)
+	if err != nil || status&apdsGvalid == 0 {
+		conf, cerr := d.readReg(apdsGconf4)
+		if err == nil {
+			err = cerr
+		}
+		return nil, conf&apdsGmode > 0, err
+	}
+	level, err := d.readReg(apdsGflvl)
+	if err != nil {
+		return
+	}
+	for n := int(level); n > 0; {
+		count := n
+		if count > apdsFifoChunk {
+			count = apdsFifoChunk
+		}
+		var data []byte
+		if data, err = d.Client.I2CRead(d.Address, apdsGfifo, 4*count); err != nil {
+			return
+		}
+		for i := 0; i+3 < len(data); i += 4 {
+			datasets = append(datasets, [4]byte{data[i], data[i+1], data[i+2], data[i+3]})
+		}
+		n -= count
+	}
+	return datasets, true, nil
+}
+
+// decodeGesture compares the up/down and left/right balance at the start
+// and end of a gesture. Datasets are up, down, left, right.
+func decodeGesture(datasets [][4]byte) Gesture {
+	var first, last *[4]byte
+	for i := range datasets {
+		ds := &datasets[i]
+		if ds[0] > apdsMinCount && ds[1] > apdsMinCount && ds[2] > apdsMinCount && ds[3] > apdsMinCount {
+			if first == nil {
+				first = ds
+			}
+			last = ds
+		}
+	}
+	if first == nil || first == last {
+		return GestureNone
+	}
+	ratio := func(a, b byte) int { return (int(a) - int(b)) * 100 / (int(a) + int(b)) }
+	ud := ratio(last[0], last[1]) - ratio(first[0], first[1])
+	lr := ratio(last[2], last[3]) - ratio(first[2], first[3])
+	abs := func(v int) int {
+		if v < 0 {
+			return -v
+		}
+		return v
+	}
+	switch {
+	case abs(ud) >= abs(lr) && ud <= -apdsSensitivity:
+		return GestureUp
+	case abs(ud) >= abs(lr) && ud >= apdsSensitivity:
+		return GestureDown
+	case abs(lr) > abs(ud) && lr <= -apdsSensitivity:
+		return GestureLeft
+	case abs(lr) > abs(ud) && lr >= apdsSensitivity:
+		return GestureRight
+	}
+	return GestureNone
+}
+
+func (d *Apds9960) readReg(reg byte) (byte, error) {
+	data, err := d.Client.I2CRead(d.Address, int(reg), 1)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) < 1 {
+		return 0, fmt.Errorf("short read of register 0x%x", reg)
+	}
+	return data[0], nil
+}
@@ -0,0 +1,191 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+)
+
+// Batch collects commands to send to the board in a single write, which
+// is much quicker than one write per command over USB serial. Commands
+// are checked as they are added, and the first error is returned by
+// Commit, which then sends nothing. A Batch is not safe for concurrent
+// use.
+type Batch struct {
+	c   *FirmataClient
+	ops []batchOp
+	err error
+}
+
+// batchOp is one command. encode returns its frame, given the digital port
+// state, which it may change. done updates the client once it is sent.
+type batchOp struct {
+	encode func(ports *[16]byte) []byte
+	done   func()
+}
+
+// Batch returns an empty batch of commands.
+func (c *FirmataClient) Batch() *Batch {
+	return &Batch{c: c}
+}
+
+// Len returns the number of commands in the batch.
+func (b *Batch) Len() int {
+	return len(b.ops)
+}
+
+func (b *Batch) add(encode func(ports *[16]byte) []byte, done func()) {
+	if b.err == nil {
+		b.ops = append(b.ops, batchOp{encode, done})
+	}
+}
+
+func (b *Batch) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// SetPinMode adds setting the mode of pin.
+func (b *Batch) SetPinMode(pin byte, mode PinMode) {
+	if err := b.c.checkMode(pin, mode); err != nil {
+		b.fail(err)
+		return
+	}
+	b.add(func(*[16]byte) []byte {
+		return []byte{byte(SetPinMode), pin & 0x7F, byte(mode)}
+	}, func() { b.c.recordMode(pin, mode) })
+}
+
+// DigitalWrite adds setting a digital output. The other pins of the port
+// keep the values they have when the batch is committed.
+func (b *Batch) DigitalWrite(pin uint, val bool) {
+	if pin/8 >= 16 || pin >= uint(len(b.c.capabilities())) {
+		b.fail(fmt.Errorf("%w %v", ErrInvalidPin, pin))
+		return
+	}
+	port, bit := pin/8, byte(1)<<(pin%8)
+	b.add(func(ports *[16]byte) []byte {
+		if val {
+			ports[port] |= bit
+		} else {
+			ports[port] &^= bit
+		}
+		data := to7Bit(ports[port])
+		return []byte{byte(DigitalMessage) | byte(port), data[0], data[1]}
+	}, nil)
+}
+
+// AnalogWrite adds setting a PWM or servo output.
+func (b *Batch) AnalogWrite(pin uint, value byte) {
+	if pin >= 16 {
+		b.fail(fmt.Errorf("%w %v", ErrInvalidPin, pin))
+		return
+	}
+	b.add(func(*[16]byte) []byte {
+		data := to7Bit(value)
+		return []byte{byte(AnalogMessage) | byte(pin), data[0], data[1]}
+	}, func() {
+		b.c.recordConfig(func(cfg *boardConfig) { cfg.analogOutputs[byte(pin)] = value })
+	})
+}
+
+// EnableDigitalInput adds enabling or disabling reporting of the port
+// pin is on.
+func (b *Batch) EnableDigitalInput(pin uint, val bool) {
+	if pin/8 >= 16 || pin >= uint(len(b.c.capabilities())) {
+		b.fail(fmt.Errorf("%w %v", ErrInvalidPin, pin))
+		return
+	}
+	port := byte(pin / 8)
+	var on byte
+	if val {
+		on = 1
+	}
+	b.add(func(*[16]byte) []byte {
+		return []byte{byte(EnableDigitalInput) | port, on}
+	}, func() {
+		b.c.recordConfig(func(cfg *boardConfig) { cfg.digitalReports[port] = val })
+	})
+}
+
+// EnableAnalogInput adds enabling or disabling reporting of an analog pin.
+func (b *Batch) EnableAnalogInput(pin uint, val bool) {
+	pinChannels, _ := b.c.analogChannels()
+	ch, ok := pinChannels[int(pin)]
+	if !ok || ch >= 16 {
+		b.fail(fmt.Errorf("%w: pin %v is not an analog pin", ErrUnsupportedPinMode, pin))
+		return
+	}
+	var on byte
+	if val {
+		on = 1
+	}
+	b.add(func(*[16]byte) []byte {
+		return []byte{byte(EnableAnalogInput) | ch, on}
+	}, func() {
+		b.c.recordConfig(func(cfg *boardConfig) { cfg.analogReports[byte(pin)] = val })
+	})
+}
+
+// SysEx adds a SysEx message. data must already be 7 bit encoded.
+func (b *Batch) SysEx(cmd SysExCommand, data ...byte) {
+	b.add(func(*[16]byte) []byte {
+		frame := append([]byte{byte(StartSysEx), byte(cmd)}, data...)
+		return append(frame, byte(EndSysEx))
+	}, nil)
+}
+
+// Commit sends the batch in one write. The client records the new pin
+// modes and outputs only if the write succeeds. The batch is emptied, so
+// it can be reused.
+func (b *Batch) Commit() error {
+	ops, err := b.ops, b.err
+	b.ops, b.err = nil, nil
+	if err != nil {
+		return err
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+	c := b.c
+	if c.closed() {
+		return ErrNotConnected
+	}
+
+	// Digital writes are encoded against the port state at commit, with
+	// outputMu held so no other write changes it meanwhile.
+	c.outputMu.Lock()
+	defer c.outputMu.Unlock()
+	ports := c.digitalPinState
+	var buf []byte
+	now := c.now()
+	for _, op := range ops {
+		frame := op.encode(&ports)
+		c.observeFrame(Sent, now, frame)
+		buf = append(buf, frame...)
+	}
+	c.Log.Trace("Batch send %v commands, %v bytes\n", len(ops), len(buf))
+	if err := c.write(buf); err != nil {
+		return err
+	}
+	c.digitalPinState = ports
+	for _, op := range ops {
+		if op.done != nil {
+			op.done()
+		}
+	}
+	return nil
+}
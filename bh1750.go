@@ -0,0 +1,131 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Bh1750Mode is the measurement resolution of a Bh1750.
+type Bh1750Mode byte
+
+const (
+	// Bh1750Address is the I2C address of a BH1750 with ADDR low. It is
+	// 0x5c with ADDR high.
+	Bh1750Address = 0x23
+
+	// Bh1750High measures in 1 lx steps, taking 120ms.
+	Bh1750High Bh1750Mode = 0x10
+	// Bh1750High2 measures in 0.5 lx steps, taking 120ms.
+	Bh1750High2 Bh1750Mode = 0x11
+	// Bh1750Low measures in 4 lx steps, taking 16ms.
+	Bh1750Low Bh1750Mode = 0x13
+
+	bh1750PowerOn = 0x01
+	bh1750Reset   = 0x07
+)
+
+// Bh1750 is a ROHM BH1750 ambient light sensor, run in continuous mode.
+type Bh1750 struct {
+	// The client.
+	Client *FirmataClient
+	// The I2C address of the device.
+	Address byte
+
+	mode   Bh1750Mode
+	poller *poller
+}
+
+// Init powers on the sensor and starts continuous measurement in mode.
+func (d *Bh1750) Init(mode Bh1750Mode) error {
+	if err := d.Client.I2CWrite(d.Address, bh1750PowerOn); err != nil {
+		return err
+	}
+	if err := d.Client.I2CWrite(d.Address, bh1750Reset); err != nil {
+		return err
+	}
+	return d.SetMode(mode)
+}
+
+// SetMode changes the measurement resolution.
+func (d *Bh1750) SetMode(mode Bh1750Mode) error {
+	switch mode {
+	case Bh1750High, Bh1750High2, Bh1750Low:
+	default:
+		return fmt.Errorf("invalid BH1750 mode 0x%x", byte(mode))
+	}
+	if err := d.Client.I2CWrite(d.Address, byte(mode)); err != nil {
+		return err
+	}
+	d.mode = mode
+	d.Client.sleep(d.measurementTime())
+	return nil
+}
+
+// Lux returns the latest measurement in lux.
+func (d *Bh1750) Lux() (float64, error) {
+	data, err := d.Client.I2CRead(d.Address, I2CNoRegister, 2)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) < 2 {
+		return 0, fmt.Errorf("short measurement read")
+	}
+	lux := float64(uint16(data[0])<<8|uint16(data[1])) / 1.2
+	if d.mode == Bh1750High2 {
+		lux /= 2
+	}
+	return lux, nil
+}
+
+// Watch reads the sensor every interval, sending the reading on the
+// returned channel whenever it differs by at least threshold lux from the
+// last one sent. The first reading is always sent. Watching continues
+// until StopWatching is called, or Watch is called again.
+func (d *Bh1750) Watch(interval time.Duration, threshold float64) <-chan float64 {
+	readings := make(chan float64, 10)
+	d.poller.Stop()
+	d.poller = newPoller()
+	go func(p *poller) {
+		defer close(readings)
+		last := math.NaN()
+		p.every(d.Client.Clock(), interval, func() {
+			lux, err := d.Lux()
+			if err != nil {
+				d.Client.Log.Warn("BH1750 read: %s", err.Error())
+			} else if math.IsNaN(last) || math.Abs(lux-last) >= threshold {
+				if pollSend(p, readings, lux) {
+					last = lux
+				}
+			}
+		})
+	}(d.poller)
+	return readings
+}
+
+// StopWatching stops the readings started by Watch, closing the channel.
+// It does not block, so may be called from the loop reading them.
+func (d *Bh1750) StopWatching() {
+	d.poller.Stop()
+}
+
+func (d *Bh1750) measurementTime() time.Duration {
+	if d.mode == Bh1750Low {
+		return 24 * time.Millisecond
+	}
+	return 180 * time.Millisecond
+}
@@ -0,0 +1,249 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// Bme280Address is the default I2C address of a BME280 or BMP280. The
+	// alternate address is 0x77.
+	Bme280Address = 0x76
+
+	bme280ChipId    = 0xd0
+	bme280CalibTp   = 0x88
+	bme280CalibH1   = 0xa1
+	bme280CalibH2   = 0xe1
+	bme280CtrlHum   = 0xf2
+	bme280CtrlMeas  = 0xf4
+	bme280Config    = 0xf5
+	bme280PressData = 0xf7
+
+	bme280Id = 0x60
+	bmp280Id = 0x58
+)
+
+// Bme280Reading is a compensated reading from a Bme280.
+type Bme280Reading struct {
+	// Time is when the reading was received.
+	Time time.Time
+	// Temperature is the air temperature.
+	Temperature Temperature
+	// Pressure is the air pressure in Pa.
+	Pressure float64
+	// Humidity is the relative humidity in percent. It is always zero on
+	// a BMP280, which has no humidity sensor.
+	Humidity float64
+}
+
+// Bme280 is a Bosch BME280 temperature, pressure and humidity sensor, or
+// the BMP280 which lacks humidity.
+type Bme280 struct {
+	// The client.
+	Client *FirmataClient
+	// The I2C address of the device.
+	Address byte
+	// Unit is the unit of readings, or UnitDefault for the client's.
+	Unit TemperatureUnit
+
+	mu       sync.Mutex
+	stream   *poller
+	humidity bool
+	t1       uint16
+	t2, t3   int16
+	p1       uint16
+	p2, p3   int16
+	p4, p5   int16
+	p6, p7   int16
+	p8, p9   int16
+	h1, h3   uint8
+	h2       int16
+	h4, h5   int16
+	h6       int8
+}
+
+// Init checks the chip ID, reads the calibration data and starts the
+// device sampling continuously with 1x oversampling of each measurement.
+func (d *Bme280) Init() error {
+	id, err := d.read(bme280ChipId, 1)
+	if err != nil {
+		return err
+	}
+	switch id[0] {
+	case bme280Id:
+		d.humidity = true
+	case bmp280Id:
+		d.humidity = false
+	default:
+		return fmt.Errorf("unknown chip ID 0x%x", id[0])
+	}
+
+	c, err := d.read(bme280CalibTp, 24)
+	if err != nil {
+		return err
+	}
+	u16 := func(i int) uint16 { return uint16(c[i]) | uint16(c[i+1])<<8 }
+	d.t1, d.t2, d.t3 = u16(0), int16(u16(2)), int16(u16(4))
+	d.p1, d.p2, d.p3 = u16(6), int16(u16(8)), int16(u16(10))
+	d.p4, d.p5, d.p6 = int16(u16(12)), int16(u16(14)), int16(u16(16))
+	d.p7, d.p8, d.p9 = int16(u16(18)), int16(u16(20)), int16(u16(22))
+
+	if d.humidity {
+		h1, err := d.read(bme280CalibH1, 1)
+		if err != nil {
+			return err
+		}
+		h, err := d.read(bme280CalibH2, 7)
+		if err != nil {
+			return err
+		}
+		d.h1 = h1[0]
+		d.h2 = int16(uint16(h[0]) | uint16(h[1])<<8)
+		d.h3 = h[2]
+		d.h4 = int16(int8(h[3]))<<4 | int16(h[4]&0xf)
+		d.h5 = int16(int8(h[5]))<<4 | int16(h[4]>>4)
+		d.h6 = int8(h[6])
+
+		// Humidity oversampling only takes effect after ctrl_meas is written.
+		if err := d.Client.I2CWrite(d.Address, bme280CtrlHum, 0x01); err != nil {
+			return err
+		}
+	}
+	// 0.5ms standby, filter off.
+	if err := d.Client.I2CWrite(d.Address, bme280Config, 0x00); err != nil {
+		return err
+	}
+	// Temperature and pressure oversampling 1x, normal mode.
+	return d.Client.I2CWrite(d.Address, bme280CtrlMeas, 0x01<<5|0x01<<2|0x03)
+}
+
+// Read returns the latest reading.
+func (d *Bme280) Read() (Bme280Reading, error) {
+	data, err := d.read(bme280PressData, d.dataSize())
+	if err != nil {
+		return Bme280Reading{}, err
+	}
+	return d.compensate(data, d.Client.now()), nil
+}
+
+// Stream delivers a reading every sampling interval, set with
+// SetAnalogSamplingInterval, until StopStream is called.
+func (d *Bme280) Stream() (<-chan Bme280Reading, error) {
+	replies, err := d.Client.I2CReadContinuous(d.Address, bme280PressData, d.dataSize())
+	if err != nil {
+		return nil, err
+	}
+	readings := make(chan Bme280Reading, 10)
+	p := newPoller()
+	d.mu.Lock()
+	d.stream.Stop()
+	d.stream = p
+	d.mu.Unlock()
+	go func() {
+		defer close(readings)
+		for {
+			select {
+			case r, ok := <-replies:
+				if !ok {
+					return
+				}
+				if len(r.Data) < d.dataSize() {
+					continue
+				}
+				if !pollSend(p, readings, d.compensate(r.Data, r.Time)) {
+					return
+				}
+			case <-p.stopped():
+				return
+			}
+		}
+	}()
+	return readings, nil
+}
+
+// StopStream stops the readings started by Stream, closing the channel.
+func (d *Bme280) StopStream() error {
+	d.mu.Lock()
+	d.stream.Stop()
+	d.mu.Unlock()
+	return d.Client.I2CStopReading(d.Address)
+}
+
+func (d *Bme280) dataSize() int {
+	if d.humidity {
+		return 8
+	}
+	return 6
+}
+
+func (d *Bme280) read(register int, count int) ([]byte, error) {
+	data, err := d.Client.I2CRead(d.Address, register, count)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < count {
+		return nil, fmt.Errorf("short read of register 0x%x: got %d bytes, want %d", register, len(data), count)
+	}
+	return data, nil
+}
+
+// compensate applies the datasheet compensation formulas to raw data,
+// read at t.
+func (d *Bme280) compensate(data []byte, t time.Time) Bme280Reading {
+	adcP := float64(int32(data[0])<<12 | int32(data[1])<<4 | int32(data[2])>>4)
+	adcT := float64(int32(data[3])<<12 | int32(data[4])<<4 | int32(data[5])>>4)
+
+	v1 := (adcT/16384 - float64(d.t1)/1024) * float64(d.t2)
+	v2 := (adcT/131072 - float64(d.t1)/8192)
+	v2 = v2 * v2 * float64(d.t3)
+	tFine := v1 + v2
+	r := Bme280Reading{
+		Time:        t,
+		Temperature: NewTemperature(float32(tFine/5120), temperatureUnit(d.Client, d.Unit)),
+	}
+
+	v1 = tFine/2 - 64000
+	v2 = v1 * v1 * float64(d.p6) / 32768
+	v2 = v2 + v1*float64(d.p5)*2
+	v2 = v2/4 + float64(d.p4)*65536
+	v1 = (float64(d.p3)*v1*v1/524288 + float64(d.p2)*v1) / 524288
+	v1 = (1 + v1/32768) * float64(d.p1)
+	if v1 != 0 {
+		p := 1048576 - adcP
+		p = (p - v2/4096) * 6250 / v1
+		v1 = float64(d.p9) * p * p / 2147483648
+		v2 = p * float64(d.p8) / 32768
+		r.Pressure = p + (v1+v2+float64(d.p7))/16
+	}
+
+	if d.humidity {
+		adcH := float64(int32(data[6])<<8 | int32(data[7]))
+		h := tFine - 76800
+		h = (adcH - (float64(d.h4)*64 + float64(d.h5)/16384*h)) *
+			(float64(d.h2) / 65536 * (1 + float64(d.h6)/67108864*h*(1+float64(d.h3)/67108864*h)))
+		h = h * (1 - float64(d.h1)*h/524288)
+		switch {
+		case h > 100:
+			h = 100
+		case h < 0:
+			h = 0
+		}
+		r.Humidity = h
+	}
+	return r
+}
@@ -0,0 +1,430 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BoardConfig is a complete setup of a board, meant to be kept in a JSON
+// file, read with LoadBoardConfig, or a YAML one, read by the firmatayaml
+// package, and applied at startup with ApplyBoardConfig or Configure:
+//
+//	{
+//	  "board": "Uno",
+//	  "pins": {
+//	    "D13": {"label": "led", "mode": "output", "value": 1},
+//	    "D2":  {"label": "door", "mode": "pullup", "report": true},
+//	    "A0":  {"mode": "analog", "report": true,
+//	            "calibration": {"type": "voltage", "bits": 10, "vref": 5}}
+//	  },
+//	  "i2c_delay": 0,
+//	  "drivers": [
+//	    {"name": "outside", "type": "bme280", "settings": {"address": 119}}
+//	  ]
+//	}
+//
+// Unlike a Snapshot, which records what the client has done, it names
+// pins and modes and describes calibrations and drivers.
+type BoardConfig struct {
+	// Board is the name of the board profile to use, such as "Uno", or
+	// empty to keep the detected one.
+	Board string `json:"board,omitempty"`
+	// Pins is the setup of each pin, by number, board pin name or label.
+	Pins map[string]*PinConfig `json:"pins,omitempty"`
+	// SamplingInterval is the analog sampling interval in milliseconds, or
+	// zero to leave it.
+	SamplingInterval byte `json:"sampling_interval,omitempty"`
+	// I2CDelay is the I2C read delay, or nil to leave I2C unconfigured.
+	I2CDelay *int `json:"i2c_delay,omitempty"`
+	// Drivers are the drivers to attach, in order.
+	Drivers []*DriverConfig `json:"drivers,omitempty"`
+}
+
+// PinConfig is the setup of a pin in a BoardConfig.
+type PinConfig struct {
+	// Label is the label to give the pin, as for LabelPin.
+	Label string `json:"label,omitempty"`
+	// Mode is the mode to set, as for ParsePinMode.
+	Mode string `json:"mode,omitempty"`
+	// Value is the level, 0 or 1, to drive an output to, or the duty or
+	// angle to write to a PWM or servo pin.
+	Value *int `json:"value,omitempty"`
+	// Report enables reporting of the input. Reporting of a digital pin
+	// is enabled for its whole port.
+	Report bool `json:"report,omitempty"`
+	// Calibration is the calibration of an analog input.
+	Calibration *Calibration `json:"calibration,omitempty"`
+}
+
+// Calibration describes an AnalogTransfer, so it can be kept in a
+// BoardConfig. Type is one of:
+//
+//	voltage     VoltageTransfer(Bits, Vref)
+//	linear      LinearTransfer(Raw[0], Values[0], Raw[1], Values[1])
+//	polynomial  PolynomialTransfer(Coefficients...)
+type Calibration struct {
+	Type         string    `json:"type"`
+	Bits         uint      `json:"bits,omitempty"`
+	Vref         float64   `json:"vref,omitempty"`
+	Raw          []int     `json:"raw,omitempty"`
+	Values       []float64 `json:"values,omitempty"`
+	Coefficients []float64 `json:"coefficients,omitempty"`
+}
+
+// Transfer returns the transfer function k describes.
+func (k *Calibration) Transfer() (AnalogTransfer, error) {
+	switch k.Type {
+	case "voltage":
+		if k.Bits == 0 || k.Bits > 16 {
+			return nil, fmt.Errorf("Bad voltage calibration resolution %d bits", k.Bits)
+		}
+		return VoltageTransfer(k.Bits, k.Vref), nil
+	case "linear":
+		if len(k.Raw) != 2 || len(k.Values) != 2 || k.Raw[0] == k.Raw[1] {
+			return nil, fmt.Errorf("Linear calibration needs two different raw readings and their values")
+		}
+		return LinearTransfer(k.Raw[0], k.Values[0], k.Raw[1], k.Values[1]), nil
+	case "polynomial":
+		if len(k.Coefficients) == 0 {
+			return nil, fmt.Errorf("Polynomial calibration has no coefficients")
+		}
+		return PolynomialTransfer(k.Coefficients...), nil
+	}
+	return nil, fmt.Errorf("Unknown calibration type %q", k.Type)
+}
+
+// DriverConfig is a driver in a BoardConfig.
+type DriverConfig struct {
+	// Name is the name to get the driver by, with Driver.
+	Name string `json:"name"`
+	// Type is the driver type, one of DriverTypes.
+	Type string `json:"type"`
+	// Settings are the settings of the driver. For most types they are
+	// the exported fields of the driver, such as Address.
+	Settings json.RawMessage `json:"settings,omitempty"`
+}
+
+// attachedDriver is a driver attached with AttachDriver.
+type attachedDriver struct {
+	config DriverConfig
+	driver interface{}
+}
+
+// UnmarshalBoardConfig decodes and checks a BoardConfig. Unknown fields
+// are errors, so mistakes in a hand written file are not ignored.
+func UnmarshalBoardConfig(data []byte) (*BoardConfig, error) {
+	b := &BoardConfig{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	if err := d.Decode(b); err != nil {
+		return nil, fmt.Errorf("Bad board config: %w", err)
+	}
+	if err := b.check(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Marshal encodes b as indented JSON, to be read by UnmarshalBoardConfig.
+func (b *BoardConfig) Marshal() ([]byte, error) {
+	return json.MarshalIndent(b, "", "  ")
+}
+
+// check checks what can be checked of b without a board.
+func (b *BoardConfig) check() error {
+	if b.Board != "" && findBoard(b.Board) == nil {
+		return fmt.Errorf("Unknown board %q", b.Board)
+	}
+	for name, p := range b.Pins {
+		if p == nil {
+			continue
+		}
+		if p.Mode != "" {
+			if _, err := ParsePinMode(p.Mode); err != nil {
+				return fmt.Errorf("Pin %s: %w", name, err)
+			}
+		}
+		if p.Calibration != nil {
+			if _, err := p.Calibration.Transfer(); err != nil {
+				return fmt.Errorf("Pin %s: %w", name, err)
+			}
+		}
+	}
+	names := make(map[string]bool)
+	for _, d := range b.Drivers {
+		if d.Name == "" {
+			return fmt.Errorf("Driver of type %q has no name", d.Type)
+		}
+		if names[d.Name] {
+			return fmt.Errorf("Driver %q is configured twice", d.Name)
+		}
+		names[d.Name] = true
+		if driverFactory(d.Type) == nil {
+			return fmt.Errorf("Driver %q has unknown type %q", d.Name, d.Type)
+		}
+	}
+	return nil
+}
+
+func findBoard(name string) *Board {
+	for _, b := range Boards {
+		if strings.EqualFold(b.Name, name) {
+			return b
+		}
+	}
+	return nil
+}
+
+// BoardConfig returns the current setup of the board: its board profile,
+// pin labels and modes, outputs, reporting, the calibrations and drivers
+// set by ApplyBoardConfig or AttachDriver, and the I2C and sampling
+// setup. Calibrations set with SetCalibration cannot be described and are
+// left out, as is reporting of a port with no pin set to an input.
+func (c *FirmataClient) BoardConfig() *BoardConfig {
+	s := c.Snapshot()
+	b := &BoardConfig{
+		Pins:             make(map[string]*PinConfig),
+		SamplingInterval: s.SamplingInterval,
+		I2CDelay:         s.I2CDelay,
+	}
+	if board := c.Board(); board != nil {
+		b.Board = board.Name
+	}
+	pin := func(n byte) *PinConfig {
+		key := strconv.Itoa(int(n))
+		if b.Pins[key] == nil {
+			b.Pins[key] = &PinConfig{}
+		}
+		return b.Pins[key]
+	}
+
+	c.modeMu.Lock()
+	for n, label := range c.pinLabels {
+		pin(n).Label = label
+	}
+	c.modeMu.Unlock()
+
+	digitalReports := make(map[byte]bool)
+	for _, port := range s.DigitalReports {
+		digitalReports[port] = true
+	}
+	for n, mode := range s.Modes {
+		p := pin(n)
+		p.Mode = mode.Name()
+		switch mode {
+		case Output:
+			v := 0
+			if s.DigitalOutputs[n] {
+				v = 1
+			}
+			p.Value = &v
+		case PWM, Servo:
+			if duty, ok := s.AnalogOutputs[n]; ok {
+				v := int(duty)
+				p.Value = &v
+			}
+		case Input, Pullup:
+			p.Report = digitalReports[n/8]
+		}
+	}
+	for _, n := range s.AnalogReports {
+		pin(n).Report = true
+	}
+
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
+	for n, k := range c.config.calibrations {
+		calibration := *k
+		pin(n).Calibration = &calibration
+	}
+	for _, d := range c.config.drivers {
+		config := d.config
+		config.Settings = append(json.RawMessage(nil), d.config.Settings...)
+		b.Drivers = append(b.Drivers, &config)
+	}
+	return b
+}
+
+// ApplyBoardConfig sets up the board as b describes: the board profile,
+// then pin labels, modes, outputs and calibrations, the sampling interval
+// and I2C, reporting, and finally the drivers. It stops at the first
+// error.
+func (c *FirmataClient) ApplyBoardConfig(b *BoardConfig) error {
+	if err := b.check(); err != nil {
+		return err
+	}
+	if b.Board != "" {
+		c.SetBoard(findBoard(b.Board))
+	}
+	type pinConfig struct {
+		n byte
+		*PinConfig
+	}
+	var pins []pinConfig
+	for name, p := range b.Pins {
+		if p == nil {
+			continue
+		}
+		n, err := c.PinNumber(name)
+		if err != nil {
+			return err
+		}
+		pins = append(pins, pinConfig{n, p})
+	}
+	sort.Slice(pins, func(i, j int) bool { return pins[i].n < pins[j].n })
+
+	for _, p := range pins {
+		if p.Label != "" {
+			if err := c.LabelPin(p.Label, p.n); err != nil {
+				return err
+			}
+		}
+	}
+	for _, p := range pins {
+		if p.Mode == "" {
+			continue
+		}
+		mode, _ := ParsePinMode(p.Mode)
+		if err := c.SetPinMode(p.n, mode); err != nil {
+			return fmt.Errorf("apply mode of pin %v: %w", c.PinLabel(p.n), err)
+		}
+	}
+	for _, p := range pins {
+		if p.Value == nil {
+			continue
+		}
+		var err error
+		if mode, _ := c.Pin(p.n).Mode(); mode == PWM || mode == Servo {
+			if *p.Value < 0 || *p.Value > 255 {
+				err = fmt.Errorf("Bad value %d", *p.Value)
+			} else {
+				err = c.AnalogWrite(uint(p.n), byte(*p.Value))
+			}
+		} else {
+			err = c.DigitalWrite(uint(p.n), *p.Value != 0)
+		}
+		if err != nil {
+			return fmt.Errorf("apply output of pin %v: %w", c.PinLabel(p.n), err)
+		}
+	}
+	for _, p := range pins {
+		if p.Calibration == nil {
+			continue
+		}
+		fn, _ := p.Calibration.Transfer()
+		c.SetCalibration(p.n, fn)
+		calibration := *p.Calibration
+		c.recordConfig(func(cfg *boardConfig) { cfg.calibrations[p.n] = &calibration })
+	}
+	if b.SamplingInterval > 0 {
+		if err := c.SetAnalogSamplingInterval(b.SamplingInterval); err != nil {
+			return fmt.Errorf("apply sampling interval: %w", err)
+		}
+	}
+	if b.I2CDelay != nil {
+		if err := c.I2CConfig(*b.I2CDelay); err != nil {
+			return fmt.Errorf("apply I2C: %w", err)
+		}
+	}
+	for _, p := range pins {
+		if !p.Report {
+			continue
+		}
+		var err error
+		if mode, _ := c.Pin(p.n).Mode(); mode == Analog {
+			err = c.EnableAnalogInput(uint(p.n), true)
+		} else {
+			err = c.EnableDigitalInput(uint(p.n), true)
+		}
+		if err != nil {
+			return fmt.Errorf("apply reporting of pin %v: %w", c.PinLabel(p.n), err)
+		}
+	}
+	for _, d := range b.Drivers {
+		if _, err := c.AttachDriver(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AttachDriver creates and initialises a driver, recording it for
+// BoardConfig and Driver. It replaces any driver of the same name.
+func (c *FirmataClient) AttachDriver(d *DriverConfig) (interface{}, error) {
+	factory := driverFactory(d.Type)
+	if factory == nil {
+		return nil, fmt.Errorf("Driver %q has unknown type %q", d.Name, d.Type)
+	}
+	driver, err := factory(c, d.Settings)
+	if err != nil {
+		return nil, fmt.Errorf("attach driver %q: %w", d.Name, err)
+	}
+	a := &attachedDriver{config: *d, driver: driver}
+	a.config.Settings = append(json.RawMessage(nil), d.Settings...)
+	c.recordConfig(func(cfg *boardConfig) {
+		for i, old := range cfg.drivers {
+			if old.config.Name == d.Name {
+				cfg.drivers[i] = a
+				return
+			}
+		}
+		cfg.drivers = append(cfg.drivers, a)
+	})
+	return driver, nil
+}
+
+// DetachDriver forgets the attached driver called name, closing it first
+// if it has a Close method, as a Relay does. It returns false if there is
+// none.
+func (c *FirmataClient) DetachDriver(name string) bool {
+	c.configMu.Lock()
+	var driver interface{}
+	for i, d := range c.config.drivers {
+		if d.config.Name == name {
+			driver = d.driver
+			c.config.drivers = append(c.config.drivers[:i], c.config.drivers[i+1:]...)
+			break
+		}
+	}
+	c.configMu.Unlock()
+	if driver == nil {
+		return false
+	}
+	if d, ok := driver.(interface{ Close() error }); ok {
+		if err := d.Close(); err != nil {
+			c.Log.Warn("Detaching driver %q: %s", name, err.Error())
+		}
+	}
+	return true
+}
+
+// Driver returns the attached driver called name, such as a *Bme280, or
+// nil if there is none.
+func (c *FirmataClient) Driver(name string) interface{} {
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
+	for _, d := range c.config.drivers {
+		if d.config.Name == name {
+			return d.driver
+		}
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Board is the pin layout of a board, mapping the names printed on it to
+// Firmata pin numbers.
+type Board struct {
+	// Name is the name of the board.
+	Name string
+	// Pins is the number of pins Firmata reports for the board.
+	Pins int
+	// Names maps pin names, such as "D2", "A0" or "SDA", to pin numbers.
+	Names map[string]byte
+	// Capabilities lists the pins supporting each mode besides Input and
+	// Output.
+	Capabilities map[PinMode][]byte
+}
+
+// Pin returns the number of the pin called name. Names are not case
+// sensitive.
+func (b *Board) Pin(name string) (byte, bool) {
+	n, ok := b.Names[strings.ToUpper(name)]
+	return n, ok
+}
+
+// Supports returns true if the board has mode on pin. Every pin supports
+// Input and Output.
+func (b *Board) Supports(pin byte, mode PinMode) bool {
+	if int(pin) >= b.Pins {
+		return false
+	}
+	if mode == Input || mode == Output {
+		return true
+	}
+	for _, p := range b.Capabilities[mode] {
+		if p == pin {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	BoardUno = &Board{
+		Name:  "Uno",
+		Pins:  20,
+		Names: boardNames(14, 14, 6, map[string]byte{"LED_BUILTIN": 13, "SDA": 18, "SCL": 19, "SS": 10, "MOSI": 11, "MISO": 12, "SCK": 13, "RX": 0, "TX": 1}),
+		Capabilities: map[PinMode][]byte{
+			Analog: pinRange(14, 19),
+			PWM:    {3, 5, 6, 9, 10, 11},
+			Servo:  pinRange(2, 19),
+			I2C:    {18, 19},
+		},
+	}
+	BoardNano = &Board{
+		Name:  "Nano",
+		Pins:  22,
+		Names: boardNames(14, 14, 8, map[string]byte{"LED_BUILTIN": 13, "SDA": 18, "SCL": 19, "SS": 10, "MOSI": 11, "MISO": 12, "SCK": 13, "RX": 0, "TX": 1}),
+		Capabilities: map[PinMode][]byte{
+			Analog: pinRange(14, 21),
+			PWM:    {3, 5, 6, 9, 10, 11},
+			Servo:  pinRange(2, 19),
+			I2C:    {18, 19},
+		},
+	}
+	BoardMega = &Board{
+		Name:  "Mega",
+		Pins:  70,
+		Names: boardNames(54, 54, 16, map[string]byte{"LED_BUILTIN": 13, "SDA": 20, "SCL": 21, "SS": 53, "MOSI": 51, "MISO": 50, "SCK": 52, "RX": 0, "TX": 1}),
+		Capabilities: map[PinMode][]byte{
+			Analog: pinRange(54, 69),
+			PWM:    append(pinRange(2, 13), 44, 45, 46),
+			Servo:  pinRange(2, 69),
+			I2C:    {20, 21},
+		},
+	}
+	BoardLeonardo = &Board{
+		Name:  "Leonardo",
+		Pins:  30,
+		Names: boardNames(14, 18, 6, map[string]byte{"LED_BUILTIN": 13, "SDA": 2, "SCL": 3, "SS": 17, "MOSI": 16, "MISO": 14, "SCK": 15, "RX": 0, "TX": 1}),
+		Capabilities: map[PinMode][]byte{
+			Analog: append(pinRange(18, 23), 24, 25, 26, 27, 28, 29),
+			PWM:    {3, 5, 6, 9, 10, 11, 13},
+			Servo:  append(pinRange(2, 13), pinRange(18, 23)...),
+			I2C:    {2, 3},
+		},
+	}
+	BoardNodeMcu = &Board{
+		Name: "ESP8266 NodeMCU",
+		Pins: 18,
+		Names: map[string]byte{
+			"D0": 16, "D1": 5, "D2": 4, "D3": 0, "D4": 2, "D5": 14, "D6": 12, "D7": 13, "D8": 15,
+			"RX": 3, "TX": 1, "A0": 17, "LED_BUILTIN": 2, "SDA": 4, "SCL": 5,
+			"SS": 15, "MOSI": 13, "MISO": 12, "SCK": 14,
+		},
+		Capabilities: map[PinMode][]byte{
+			Analog: {17},
+			PWM:    {0, 1, 2, 3, 4, 5, 12, 13, 14, 15},
+			Servo:  {0, 1, 2, 3, 4, 5, 12, 13, 14, 15},
+			I2C:    {4, 5},
+		},
+	}
+	BoardEsp32 = &Board{
+		Name:  "ESP32",
+		Pins:  40,
+		Names: esp32Names(),
+		Capabilities: map[PinMode][]byte{
+			Analog: {0, 2, 4, 12, 13, 14, 15, 25, 26, 27, 32, 33, 34, 35, 36, 39},
+			PWM:    esp32Outputs,
+			Servo:  esp32Outputs,
+			I2C:    {21, 22},
+		},
+	}
+
+	// Boards are the known boards, in the order they are tried by
+	// auto-detection.
+	Boards = []*Board{BoardUno, BoardNano, BoardMega, BoardLeonardo, BoardNodeMcu, BoardEsp32}
+)
+
+// esp32Outputs are the ESP32 GPIOs which can drive an output, leaving out
+// those used for flash.
+var esp32Outputs = []byte{0, 1, 2, 3, 4, 5, 12, 13, 14, 15, 16, 17, 18, 19, 21, 22, 23, 25, 26, 27, 32, 33}
+
+// boardNames names digital pins D0 up to digital, and analog pins from
+// A0 at pin firstAnalog, then adds extra.
+func boardNames(digital, firstAnalog, analog int, extra map[string]byte) map[string]byte {
+	names := make(map[string]byte)
+	for i := 0; i < digital; i++ {
+		names[fmt.Sprintf("D%d", i)] = byte(i)
+	}
+	for i := 0; i < analog; i++ {
+		names[fmt.Sprintf("A%d", i)] = byte(firstAnalog + i)
+	}
+	for k, v := range extra {
+		names[k] = v
+	}
+	return names
+}
+
+// esp32Names names ESP32 pins by GPIO number, and by the ADC channel
+// names of the Arduino core.
+func esp32Names() map[string]byte {
+	names := map[string]byte{
+		"A0": 36, "A3": 39, "A4": 32, "A5": 33, "A6": 34, "A7": 35,
+		"A10": 4, "A11": 0, "A12": 2, "A13": 15, "A14": 13, "A15": 12,
+		"A16": 14, "A17": 27, "A18": 25, "A19": 26,
+		"LED_BUILTIN": 2, "SDA": 21, "SCL": 22,
+		"SS": 5, "MOSI": 23, "MISO": 19, "SCK": 18, "RX": 3, "TX": 1,
+	}
+	for i := 0; i < 40; i++ {
+		names[fmt.Sprintf("D%d", i)] = byte(i)
+		names[fmt.Sprintf("GPIO%d", i)] = byte(i)
+	}
+	return names
+}
+
+func pinRange(first, last byte) []byte {
+	var pins []byte
+	for p := first; p <= last; p++ {
+		pins = append(pins, p)
+	}
+	return pins
+}
+
+// detectBoard guesses the board from the firmware name, or failing that,
+// the number of pins reported.
+func detectBoard(firmware string, pins int) *Board {
+	name := strings.ToUpper(firmware)
+	for _, key := range []struct {
+		match string
+		board *Board
+	}{
+		{"ESP32", BoardEsp32},
+		{"ESP8266", BoardNodeMcu},
+		{"MEGA", BoardMega},
+		{"LEONARDO", BoardLeonardo},
+		{"NANO", BoardNano},
+		{"UNO", BoardUno},
+	} {
+		if strings.Contains(name, key.match) {
+			return key.board
+		}
+	}
+	for _, b := range Boards {
+		if b.Pins == pins {
+			return b
+		}
+	}
+	return nil
+}
+
+// Board returns the board profile, selected with SetBoard or detected
+// from the firmware. It is nil for an unknown board.
+func (c *FirmataClient) Board() *Board {
+	c.modeMu.Lock()
+	defer c.modeMu.Unlock()
+	if c.board == nil {
+		c.board = detectBoard(c.firmware(), len(c.capabilities()))
+	}
+	return c.board
+}
+
+// SetBoard selects the board profile, overriding detection.
+func (c *FirmataClient) SetBoard(b *Board) {
+	c.modeMu.Lock()
+	defer c.modeMu.Unlock()
+	c.board = b
+}
+
+// PinNumber resolves a pin name to its number. The name may be a number,
+// a label given with LabelPin, or a name from the board profile.
+func (c *FirmataClient) PinNumber(name string) (byte, error) {
+	if n, err := strconv.ParseUint(name, 10, 8); err == nil {
+		return byte(n), nil
+	}
+	if n, ok := c.labelledPin(name); ok {
+		return n, nil
+	}
+	b := c.Board()
+	if b == nil {
+		return 0, fmt.Errorf("Unknown board, cannot find pin %q", name)
+	}
+	n, ok := b.Pin(name)
+	if !ok {
+		return 0, fmt.Errorf("No pin %q on %v", name, b.Name)
+	}
+	return n, nil
+}
+
+// NamedPin returns the pin called name, as for PinNumber.
+func (c *FirmataClient) NamedPin(name string) (*Pin, error) {
+	n, err := c.PinNumber(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.Pin(n), nil
+}
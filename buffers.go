@@ -0,0 +1,170 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+// The queues between the reader and the consumers of what it receives,
+// for WithQueue and Drops. These are also the queue names passed to
+// MetricsSink.QueueDepth.
+const (
+	// QueueValues is the channel given to WithValues. Its size is that of
+	// the channel, and by default it blocks, so every value is delivered.
+	QueueValues = "values"
+	// QueueListeners are the value channels of helpers such as Button and
+	// Joystick.
+	QueueListeners = "listeners"
+	// QueueSerial is the channel of GetSerialData.
+	QueueSerial = "serial"
+	// QueueI2C are the channels of I2CReadContinuous.
+	QueueI2C = "i2c"
+	// QueueCallbacks holds callbacks waiting for a worker.
+	QueueCallbacks = "callbacks"
+	// QueueErrors is the Errors channel.
+	QueueErrors = "errors"
+	// QueueSubscriptions are the channels of SubscribeDigital and
+	// SubscribeAnalog. Their sizes and policies are those given when
+	// subscribing, not set by WithQueue.
+	QueueSubscriptions = "subscriptions"
+	// QueueWrites are the frames waiting for the writer, with a queue of
+	// the size for each priority. It blocks by default; any other policy
+	// fails writes with ErrQueueFull when the queue is full.
+	QueueWrites = "writes"
+)
+
+// QueueConfig is the size of a queue, and what happens when it is full.
+// A Block policy holds up the reader, and so every other report and reply
+// from the board, until the consumer catches up.
+type QueueConfig struct {
+	Size   int
+	Policy OverflowPolicy
+}
+
+// defaultQueues are the queue sizes and policies used unless changed with
+// WithQueue.
+var defaultQueues = map[string]QueueConfig{
+	QueueValues:    {Policy: Block},
+	QueueListeners: {Size: 10, Policy: DropNewest},
+	QueueSerial:    {Size: 10, Policy: DropNewest},
+	QueueI2C:       {Size: 10, Policy: DropNewest},
+	QueueCallbacks: {Size: callbackQueue, Policy: DropNewest},
+	QueueErrors:    {Size: protocolErrorBuffer, Policy: DropOldest},
+	QueueWrites:    {Size: writeQueue, Policy: Block},
+}
+
+// WithQueue sets the size and overflow policy of a queue. A size of zero
+// keeps the default size.
+func WithQueue(queue string, size int, policy OverflowPolicy) Option {
+	return func(o *options) {
+		if o.queues == nil {
+			o.queues = make(map[string]QueueConfig)
+		}
+		o.queues[queue] = QueueConfig{Size: size, Policy: policy}
+	}
+}
+
+// queueConfigs returns the default queue configs overridden by those in
+// the options.
+func queueConfigs(o *options) map[string]QueueConfig {
+	queues := make(map[string]QueueConfig, len(defaultQueues))
+	for name, q := range defaultQueues {
+		queues[name] = q
+	}
+	for name, q := range o.queues {
+		if q.Size <= 0 {
+			q.Size = queues[name].Size
+		}
+		queues[name] = q
+	}
+	return queues
+}
+
+// Queue returns the size and overflow policy of a queue.
+func (c *FirmataClient) Queue(queue string) QueueConfig {
+	return c.queues[queue]
+}
+
+// Drops returns the number of items dropped from a queue because it was
+// full.
+func (c *FirmataClient) Drops(queue string) uint64 {
+	c.dropMu.Lock()
+	defer c.dropMu.Unlock()
+	return c.drops[queue]
+}
+
+// AllDrops returns the number of items dropped from each queue which has
+// dropped any.
+func (c *FirmataClient) AllDrops() map[string]uint64 {
+	c.dropMu.Lock()
+	defer c.dropMu.Unlock()
+	drops := make(map[string]uint64, len(c.drops))
+	for name, n := range c.drops {
+		drops[name] = n
+	}
+	return drops
+}
+
+func (c *FirmataClient) dropped(queue string) {
+	c.dropMu.Lock()
+	defer c.dropMu.Unlock()
+	if c.drops == nil {
+		c.drops = make(map[string]uint64)
+	}
+	c.drops[queue]++
+}
+
+// deliver sends v on ch, a channel of queue, applying the queue overflow
+// policy. It returns false if v, or an older item to make room for it,
+// was dropped.
+func deliver[T any](c *FirmataClient, queue string, ch chan T, v T) bool {
+	return deliverPolicy(c, queue, c.queues[queue].Policy, c.done, ch, v)
+}
+
+// deliverPolicy is deliver with the overflow policy given, rather than
+// that of queue. A Block policy gives up once done is closed.
+func deliverPolicy[T any](c *FirmataClient, queue string, policy OverflowPolicy, done <-chan bool, ch chan T, v T) bool {
+	defer c.queueDepth(queue, len(ch))
+	switch policy {
+	case Block:
+		select {
+		case ch <- v:
+			return true
+		case <-done:
+			return false
+		}
+	case DropOldest:
+		select {
+		case ch <- v:
+			return true
+		default:
+		}
+		select {
+		case <-ch:
+			c.dropped(queue)
+		default:
+		}
+		select {
+		case ch <- v:
+		default:
+			c.dropped(queue)
+		}
+		return false
+	}
+	select {
+	case ch <- v:
+		return true
+	default:
+		c.dropped(queue)
+		return false
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"time"
+)
+
+// ButtonEventType is the kind of action seen on a Button.
+type ButtonEventType byte
+
+const (
+	ButtonPress ButtonEventType = iota
+	ButtonRelease
+	ButtonLongPress
+	ButtonDoubleClick
+)
+
+func (t ButtonEventType) String() string {
+	switch t {
+	case ButtonPress:
+		return "Press"
+	case ButtonRelease:
+		return "Release"
+	case ButtonLongPress:
+		return "LongPress"
+	case ButtonDoubleClick:
+		return "DoubleClick"
+	}
+	return fmt.Sprintf("Unknown button event (0x%x)", byte(t))
+}
+
+// ButtonEvent reports an action on a Button.
+type ButtonEvent struct {
+	// Type is the kind of action.
+	Type ButtonEventType
+	// Time is when the action was recognised.
+	Time time.Time
+}
+
+// Button is a push button on a digital input. Every press and release is
+// reported. A LongPress follows the Press when the button is held, and a
+// DoubleClick follows the second Press of two in quick succession.
+type Button struct {
+	// The client.
+	Client *FirmataClient
+	// The pin the button is on.
+	Pin byte
+	// ActiveHigh is set for buttons which pull the pin high when pressed.
+	// Otherwise the internal pullup is enabled and a low input is pressed.
+	ActiveHigh bool
+	// Debounce is how long the input must be stable before a change is
+	// accepted.
+	Debounce time.Duration
+	// LongPress is how long the button must be held for a LongPress.
+	LongPress time.Duration
+	// DoubleClick is the longest time from a release to the next press for
+	// the press to be a DoubleClick.
+	DoubleClick time.Duration
+
+	events chan ButtonEvent
+	poller *poller
+}
+
+// NewButton creates a button on pin with default timings of 20ms
+// debounce, 1s long press and 300ms double click.
+func NewButton(client *FirmataClient, pin byte) *Button {
+	return &Button{
+		Client:      client,
+		Pin:         pin,
+		Debounce:    20 * time.Millisecond,
+		LongPress:   time.Second,
+		DoubleClick: 300 * time.Millisecond,
+	}
+}
+
+// Start configures the pin and returns the channel of button events.
+func (b *Button) Start() (<-chan ButtonEvent, error) {
+	var err error
+	if b.ActiveHigh {
+		err = b.Client.SetPinMode(b.Pin, Input)
+	} else {
+		err = b.Client.SetPullup(b.Pin)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := b.Client.EnableDigitalInput(uint(b.Pin), true); err != nil {
+		return nil, err
+	}
+	b.events = make(chan ButtonEvent, 10)
+	b.poller = newPoller()
+	go b.watch(b.Client.addValueListener(), b.events, b.poller)
+	return b.events, nil
+}
+
+// Stop stops watching the button and closes the event channel. It does not
+// block, so may be called from the loop reading the events.
+func (b *Button) Stop() {
+	b.poller.Stop()
+}
+
+func (b *Button) watch(values chan FirmataValue, events chan ButtonEvent, p *poller) {
+	defer close(events)
+	defer b.Client.removeValueListener(values)
+
+	pressed := b.pressed()
+	raw := pressed
+	var debounce, long <-chan time.Time
+	var released time.Time
+	for {
+		select {
+		case v, ok := <-values:
+			if !ok {
+				return
+			}
+			if v.IsAnalog() || int(v.valueType&0x0F) != int(b.Pin/8) {
+				continue
+			}
+			raw = b.pressed()
+			if raw != pressed {
+				debounce = b.Client.after(b.Debounce)
+			} else {
+				debounce = nil
+			}
+		case now := <-debounce:
+			debounce = nil
+			pressed = raw
+			if !pressed {
+				long = nil
+				released = now
+				if !pollSend(p, events, ButtonEvent{ButtonRelease, now}) {
+					return
+				}
+				continue
+			}
+			long = b.Client.after(b.LongPress)
+			if !pollSend(p, events, ButtonEvent{ButtonPress, now}) {
+				return
+			}
+			if !released.IsZero() && now.Sub(released) <= b.DoubleClick {
+				released = time.Time{}
+				if !pollSend(p, events, ButtonEvent{ButtonDoubleClick, now}) {
+					return
+				}
+			}
+		case now := <-long:
+			long = nil
+			if !pollSend(p, events, ButtonEvent{ButtonLongPress, now}) {
+				return
+			}
+		case <-p.stopped():
+			return
+		}
+	}
+}
+
+// pressed reads the cached pin state.
+func (b *Button) pressed() bool {
+	high, _ := b.Client.DigitalRead(uint(b.Pin))
+	return high == b.ActiveHigh
+}
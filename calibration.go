@@ -0,0 +1,87 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+// AnalogTransfer converts a raw analog reading to a voltage or other
+// engineering unit.
+type AnalogTransfer func(raw int) float64
+
+// VoltageTransfer converts readings of an ADC with the given resolution in
+// bits and reference voltage to volts.
+func VoltageTransfer(bits uint, vref float64) AnalogTransfer {
+	full := float64(int(1)<<bits - 1)
+	return func(raw int) float64 {
+		return float64(raw) * vref / full
+	}
+}
+
+// LinearTransfer is a two point calibration, mapping raw1 to value1 and
+// raw2 to value2 and interpolating or extrapolating linearly.
+func LinearTransfer(raw1 int, value1 float64, raw2 int, value2 float64) AnalogTransfer {
+	slope := (value2 - value1) / float64(raw2-raw1)
+	return func(raw int) float64 {
+		return value1 + float64(raw-raw1)*slope
+	}
+}
+
+// PolynomialTransfer evaluates a polynomial of the raw reading, with
+// coefficients from the constant term upwards.
+func PolynomialTransfer(coefficients ...float64) AnalogTransfer {
+	return func(raw int) float64 {
+		var v float64
+		x := float64(raw)
+		for i := len(coefficients) - 1; i >= 0; i-- {
+			v = v*x + coefficients[i]
+		}
+		return v
+	}
+}
+
+// SetCalibration attaches a transfer function to an analog pin, used for
+// the Scaled value of its events and by AnalogReadScaled. A nil function
+// removes the calibration.
+func (c *FirmataClient) SetCalibration(pin byte, fn AnalogTransfer) {
+	c.recordConfig(func(cfg *boardConfig) { delete(cfg.calibrations, pin) })
+	c.inputMu.Lock()
+	defer c.inputMu.Unlock()
+	if fn == nil {
+		delete(c.calibrations, pin)
+		return
+	}
+	if c.calibrations == nil {
+		c.calibrations = make(map[byte]AnalogTransfer)
+	}
+	c.calibrations[pin] = fn
+}
+
+// Read the last reported value of an analog input pin, converted by its
+// calibration.
+func (c *FirmataClient) AnalogReadScaled(pin uint) (float64, error) {
+	raw, err := c.AnalogRead(pin)
+	if err != nil {
+		return 0, err
+	}
+	c.inputMu.Lock()
+	defer c.inputMu.Unlock()
+	return c.scale(byte(pin), raw), nil
+}
+
+// scale applies the calibration of pin to raw. inputMu is held.
+func (c *FirmataClient) scale(pin byte, raw int) float64 {
+	if fn, ok := c.calibrations[pin]; ok {
+		return fn(raw)
+	}
+	return float64(raw)
+}
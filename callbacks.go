@@ -0,0 +1,225 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// callbackWorkers is the number of goroutines running callbacks.
+	callbackWorkers = 4
+	// callbackQueue is the default number of callbacks which can be
+	// waiting for a worker before further ones are dropped.
+	callbackQueue = 100
+)
+
+// OnDigitalChange registers fn to be called with the new value whenever a
+// digital input report shows pin has changed. Reporting must be enabled
+// for the pin with EnableDigitalInput. The returned function removes the
+// callback.
+//
+// Callbacks run on a pool of worker goroutines, not the reader, so a slow
+// callback does not hold up the board. Callbacks may run concurrently and
+// are not guaranteed to run in the order the changes were reported.
+func (c *FirmataClient) OnDigitalChange(pin byte, fn func(value bool)) (remove func()) {
+	c.callbackMu.Lock()
+	defer c.callbackMu.Unlock()
+	if c.digitalCallbacks == nil {
+		c.digitalCallbacks = make(map[byte]map[int]func(bool))
+	}
+	if c.digitalCallbacks[pin] == nil {
+		c.digitalCallbacks[pin] = make(map[int]func(bool))
+	}
+	id := c.addCallback()
+	c.digitalCallbacks[pin][id] = fn
+	return func() {
+		c.callbackMu.Lock()
+		defer c.callbackMu.Unlock()
+		delete(c.digitalCallbacks[pin], id)
+	}
+}
+
+// OnAnalogChange registers fn to be called with the new value whenever an
+// analog input report for pin differs from the previous one. Reporting
+// must be enabled for the pin with EnableAnalogInput. Callbacks run as for
+// OnDigitalChange.
+func (c *FirmataClient) OnAnalogChange(pin byte, fn func(value int)) (remove func()) {
+	c.callbackMu.Lock()
+	defer c.callbackMu.Unlock()
+	if c.analogCallbacks == nil {
+		c.analogCallbacks = make(map[byte]map[int]func(int))
+	}
+	if c.analogCallbacks[pin] == nil {
+		c.analogCallbacks[pin] = make(map[int]func(int))
+	}
+	id := c.addCallback()
+	c.analogCallbacks[pin][id] = fn
+	return func() {
+		c.callbackMu.Lock()
+		defer c.callbackMu.Unlock()
+		delete(c.analogCallbacks[pin], id)
+	}
+}
+
+// OnSysex registers fn to be called with the payload of every SysEx
+// message of type cmd received from the board, still 7 bit encoded, and
+// the time it was received. The message is also handled by the client as
+// normal. Callbacks run as for OnDigitalChange.
+//
+// The payload is a pooled buffer, shared by the callbacks for the message
+// and reused once they have all returned, so fn must not modify it or
+// keep it after returning. Clients created with WithSysexCopy instead
+// give each message its own copy.
+func (c *FirmataClient) OnSysex(cmd SysExCommand, fn func(data []byte, received time.Time)) (remove func()) {
+	c.callbackMu.Lock()
+	defer c.callbackMu.Unlock()
+	if c.sysexCallbacks == nil {
+		c.sysexCallbacks = make(map[SysExCommand]map[int]func([]byte, time.Time))
+	}
+	if c.sysexCallbacks[cmd] == nil {
+		c.sysexCallbacks[cmd] = make(map[int]func([]byte, time.Time))
+	}
+	id := c.addCallback()
+	c.sysexCallbacks[cmd][id] = fn
+	return func() {
+		c.callbackMu.Lock()
+		defer c.callbackMu.Unlock()
+		delete(c.sysexCallbacks[cmd], id)
+	}
+}
+
+// OnConnectionChange registers fn to be called with false when the
+// connection to the board is lost, and with true once a client made with
+// WithReconnect has reconnected and reset the board. Closing the client
+// does not call it. Callbacks run as for OnDigitalChange.
+func (c *FirmataClient) OnConnectionChange(fn func(connected bool)) (remove func()) {
+	c.callbackMu.Lock()
+	defer c.callbackMu.Unlock()
+	if c.connectionCallbacks == nil {
+		c.connectionCallbacks = make(map[int]func(bool))
+	}
+	id := c.addCallback()
+	c.connectionCallbacks[id] = fn
+	return func() {
+		c.callbackMu.Lock()
+		defer c.callbackMu.Unlock()
+		delete(c.connectionCallbacks, id)
+	}
+}
+
+// addCallback allocates a callback id, starting the workers on first use.
+// It is called with callbackMu held.
+func (c *FirmataClient) addCallback() int {
+	if c.callbackJobs == nil && !c.closed() {
+		c.callbackJobs = make(chan func(), c.Queue(QueueCallbacks).Size)
+		for i := 0; i < callbackWorkers; i++ {
+			go func(jobs chan func()) {
+				for job := range jobs {
+					job()
+				}
+			}(c.callbackJobs)
+		}
+	}
+	c.callbackId++
+	return c.callbackId
+}
+
+// queueCallback passes a callback to the workers, returning false if it
+// was not queued. It is called with callbackMu held.
+func (c *FirmataClient) queueCallback(job func()) bool {
+	if c.callbackJobs == nil {
+		return false
+	}
+	if !deliver(c, QueueCallbacks, c.callbackJobs, job) {
+		c.Log.Warn("Callback queue full, dropping callback. Slow callbacks?")
+		return false
+	}
+	return true
+}
+
+// digitalChanged queues the callbacks for the pins of port set in changed.
+func (c *FirmataClient) digitalChanged(port byte, changed byte, value byte) {
+	c.callbackMu.Lock()
+	defer c.callbackMu.Unlock()
+	for bit := byte(0); bit < 8; bit++ {
+		if changed&(1<<bit) == 0 {
+			continue
+		}
+		high := value&(1<<bit) > 0
+		for _, fn := range c.digitalCallbacks[port*8+bit] {
+			fn := fn
+			c.queueCallback(func() { fn(high) })
+		}
+	}
+}
+
+// analogChanged queues the callbacks for an analog pin.
+func (c *FirmataClient) analogChanged(pin byte, value int) {
+	c.callbackMu.Lock()
+	defer c.callbackMu.Unlock()
+	for _, fn := range c.analogCallbacks[pin] {
+		fn := fn
+		c.queueCallback(func() { fn(value) })
+	}
+}
+
+// connectionChanged queues the callbacks for a change of connection.
+func (c *FirmataClient) connectionChanged(connected bool) {
+	c.debug.connectionChanged(connected, c.now())
+	c.callbackMu.Lock()
+	defer c.callbackMu.Unlock()
+	for _, fn := range c.connectionCallbacks {
+		fn := fn
+		c.queueCallback(func() { fn(connected) })
+	}
+}
+
+// sysexReceived queues the callbacks for a SysEx message.
+func (c *FirmataClient) sysexReceived(cmd SysExCommand, data []byte) {
+	c.callbackMu.Lock()
+	defer c.callbackMu.Unlock()
+	if len(c.sysexCallbacks[cmd]) == 0 {
+		return
+	}
+	received := c.received
+	// The reader reuses its buffer, so the callbacks get a copy.
+	if c.sysexCopy {
+		payload := append([]byte(nil), data...)
+		for _, fn := range c.sysexCallbacks[cmd] {
+			fn := fn
+			c.queueCallback(func() { fn(payload, received) })
+		}
+		return
+	}
+	// The copy is pooled and goes back once the last callback is done. A
+	// callback dropped in favour of a newer one never releases it, and it
+	// is left to the garbage collector.
+	buf := getBuffer()
+	*buf = append(*buf, data...)
+	refs := int32(len(c.sysexCallbacks[cmd]))
+	release := func() {
+		if atomic.AddInt32(&refs, -1) == 0 {
+			putBuffer(buf)
+		}
+	}
+	for _, fn := range c.sysexCallbacks[cmd] {
+		fn := fn
+		if !c.queueCallback(func() { defer release(); fn(*buf, received) }) {
+			release()
+		}
+	}
+}
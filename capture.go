@@ -0,0 +1,224 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// captureInterval is the sampling interval asked for while capturing, in
+// milliseconds. Firmware with a higher minimum uses that instead.
+const captureInterval = 1
+
+// CaptureFrame is a block of consecutive readings of a captured pin.
+type CaptureFrame struct {
+	// Seq is the number of the frame, counting from zero. Frames which
+	// were overwritten before being read leave a gap.
+	Seq uint64
+	// Lost is the number of frames overwritten since the last one read.
+	Lost uint64
+	// Samples are the readings, oldest first.
+	Samples []int
+	// Start and End are when the first and last readings were received.
+	Start, End time.Time
+	// Gaps is the number of readings which arrived more than twice the
+	// mean spacing of readings so far after the one before, suggesting the
+	// board or link dropped reports.
+	Gaps int
+}
+
+// captureSlot is a frame in the ring.
+type captureSlot struct {
+	samples    []int
+	start, end time.Time
+	gaps       int
+}
+
+// AnalogCapture records an analog pin at the highest rate the board
+// manages into a ring of fixed size frames, allocated up front, for
+// oscilloscope style acquisition. If frames are not read fast enough the
+// oldest are overwritten, which shows as a gap in Seq.
+type AnalogCapture struct {
+	// The client.
+	Client *FirmataClient
+	// The pin being captured.
+	Pin byte
+
+	mu   sync.Mutex
+	ring []captureSlot
+	// write is the number of the frame being filled, and fill the
+	// readings in it so far. read is the next frame to read.
+	write, read uint64
+	fill        int
+	// first and last are when the first and latest readings were
+	// received, and count the readings so far.
+	first, last time.Time
+	count       int64
+	ready       chan bool
+	stopped     bool
+	// prevInterval is the sampling interval to restore when the capture
+	// stops.
+	prevInterval byte
+}
+
+// StartCapture sets pin to Analog, sets the shortest sampling interval and
+// starts capturing its readings into a ring of frames frames, each of
+// frameSize readings. Other analog pins which are reporting share the link,
+// so lower the rate. The capture is stopped when the client is closed.
+func (c *FirmataClient) StartCapture(pin byte, frameSize int, frames int) (*AnalogCapture, error) {
+	if frameSize <= 0 || frames < 2 {
+		return nil, fmt.Errorf("Capture needs a frame size above zero and at least 2 frames, have %v and %v", frameSize, frames)
+	}
+	a := &AnalogCapture{
+		Client:       c,
+		Pin:          pin,
+		ring:         make([]captureSlot, frames),
+		ready:        make(chan bool, 1),
+		prevInterval: c.samplingInterval(),
+	}
+	for i := range a.ring {
+		a.ring[i].samples = make([]int, frameSize)
+	}
+
+	c.inputMu.Lock()
+	if c.captures[pin] != nil {
+		c.inputMu.Unlock()
+		return nil, fmt.Errorf("Pin %v is already being captured", c.PinLabel(pin))
+	}
+	if c.captures == nil {
+		c.captures = make(map[byte]*AnalogCapture)
+	}
+	c.captures[pin] = a
+	c.inputMu.Unlock()
+
+	err := c.SetPinMode(pin, Analog)
+	if err == nil {
+		err = c.setSamplingInterval(captureInterval)
+	}
+	if err == nil {
+		err = c.EnableAnalogInput(uint(pin), true)
+	}
+	if err != nil {
+		c.inputMu.Lock()
+		delete(c.captures, pin)
+		c.inputMu.Unlock()
+		return nil, err
+	}
+	c.onClose(func() { a.Stop() })
+	return a, nil
+}
+
+// Read waits for the next whole frame and copies it into f, reusing the
+// capacity of f.Samples. It returns ErrNotConnected once the capture is
+// stopped and every frame has been read.
+func (a *AnalogCapture) Read(ctx context.Context, f *CaptureFrame) error {
+	for {
+		a.mu.Lock()
+		if a.read < a.write {
+			// Skip frames which have been overwritten.
+			if oldest := a.write - uint64(len(a.ring)) + 1; a.write >= uint64(len(a.ring)) && a.read < oldest {
+				f.Lost = oldest - a.read
+				a.read = oldest
+			} else {
+				f.Lost = 0
+			}
+			s := &a.ring[a.read%uint64(len(a.ring))]
+			f.Seq = a.read
+			f.Samples = append(f.Samples[:0], s.samples...)
+			f.Start, f.End, f.Gaps = s.start, s.end, s.gaps
+			a.read++
+			a.mu.Unlock()
+			return nil
+		}
+		stopped := a.stopped
+		a.mu.Unlock()
+		if stopped {
+			return fmt.Errorf("%w: capture of pin %v stopped", ErrNotConnected, a.Client.PinLabel(a.Pin))
+		}
+		select {
+		case <-a.ready:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Stop stops the capture and reporting of the pin, and restores the
+// sampling interval. Frames already captured can still be read.
+func (a *AnalogCapture) Stop() error {
+	c := a.Client
+	c.inputMu.Lock()
+	if c.captures[a.Pin] == a {
+		delete(c.captures, a.Pin)
+	}
+	c.inputMu.Unlock()
+	a.mu.Lock()
+	if a.stopped {
+		a.mu.Unlock()
+		return nil
+	}
+	a.stopped = true
+	a.mu.Unlock()
+	a.notify()
+	if c.closed() {
+		return nil
+	}
+	if err := c.EnableAnalogInput(uint(a.Pin), false); err != nil {
+		return err
+	}
+	prev := a.prevInterval
+	if prev == 0 {
+		prev = byte(defaultSamplingInterval / time.Millisecond)
+	}
+	return c.setSamplingInterval(prev)
+}
+
+// add records a reading. inputMu is held.
+func (a *AnalogCapture) add(value int, t time.Time) {
+	a.mu.Lock()
+	s := &a.ring[a.write%uint64(len(a.ring))]
+	if a.fill == 0 {
+		s.start, s.gaps = t, 0
+	}
+	if a.count == 0 {
+		a.first = t
+	} else if mean := t.Sub(a.first) / time.Duration(a.count); mean > 0 && t.Sub(a.last) > 2*mean {
+		s.gaps++
+	}
+	a.last = t
+	a.count++
+	s.samples[a.fill] = value
+	s.end = t
+	a.fill++
+	full := a.fill == len(s.samples)
+	if full {
+		a.fill = 0
+		a.write++
+	}
+	a.mu.Unlock()
+	if full {
+		a.notify()
+	}
+}
+
+func (a *AnalogCapture) notify() {
+	select {
+	case a.ready <- true:
+	default:
+	}
+}
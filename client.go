@@ -16,109 +16,314 @@ package firmata
 
 import (
   "code.google.com/p/log4go"
-  "github.com/tarm/goserial"
 
+  "context"
   "fmt"
   "io"
+  "sync"
   "time"
 )
 
 // Arduino Firmata client for golang
+//
+// All methods are safe to call from multiple goroutines. Writes go through
+// a single writer goroutine, so frames are never interleaved, and requests
+// which wait for a reply are serialised per bus so replies go to the right
+// caller. Each request is still a separate write: use the driver types,
+// or hold your own lock, where a sequence of writes must not be split.
 type FirmataClient struct {
   serialDev string
   baud      int
-  conn      *io.ReadWriteCloser
   Log       *log4go.Logger
+  writes    [numPriorities]chan writeRequest
+
+  // connMu guards conn, which is replaced on reconnection.
+  connMu     sync.Mutex
+  conn       io.ReadWriteCloser
+  dial       func() (io.ReadWriteCloser, error)
+  redialWait time.Duration
 
+  // stateMu guards what the board reports about itself, and settings
+  // read by many goroutines.
+  stateMu         sync.RWMutex
   protocolVersion []byte
   firmwareVersion []int
   firmwareName    string
+  firmwareReports int
+  // reported is closed when the board next reports its firmware,
+  // capabilities or analog mapping.
+  reported chan bool
+
+  versionMu      sync.Mutex
+  versionWaiters []chan time.Time
 
   ready             bool
   analogMappingDone bool
   capabilityDone    bool
 
-  digitalPinState [8]byte
+  outputMu        sync.Mutex
+  digitalPinState [16]byte
+
+  configMu sync.Mutex
+  config   boardConfig
 
   analogPinsChannelMap map[int]byte
   analogChannelPinsMap map[byte]int
   pinModes             []map[PinMode]interface{}
 
+  modeMu       sync.Mutex
+  currentModes map[byte]PinMode
+  board        *Board
+  labelPins    map[string]byte
+  pinLabels    map[byte]string
+
   valueChan  chan FirmataValue
   serialChan chan string
   spiChan    chan []byte
-  owChan    chan []byte
+  i2cChan    chan I2CResponse
+  owPins     map[byte]*oneWirePin
+
+  // busMu guards the serial, SPI and OneWire channels, and i2cMu the I2C
+  // ones. The request mutexes allow one request awaiting a reply per bus;
+  // each OneWire pin has its own.
+  busMu  sync.Mutex
+  i2cReq sync.Mutex
+  spiReq sync.Mutex
+  // i2cBulk keeps bulk I2C transfers from interleaving.
+  i2cBulk sync.Mutex
+
+  i2cMu        sync.Mutex
+  i2cListeners map[i2cQuery]*i2cListener
+  // i2cPending is the read I2CRead is waiting for, if any.
+  i2cPending *i2cQuery
+
+  inputMu        sync.Mutex
+  digitalInputs  [16]byte
+  digitalRaw     [16]byte
+  portReported   [16]bool
+  debounce       map[byte]time.Duration
+  debounceTimers map[byte]Timer
+  analogInputs   map[int]int
+  analogFilters  map[byte]*analogFilter
+  captures       map[byte]*AnalogCapture
+  calibrations   map[byte]AnalogTransfer
+  valueListeners map[chan FirmataValue]bool
+
+  metricsMu sync.Mutex
+  metrics   MetricsSink
+  tracer    RequestTracer
+
+  traceMu sync.Mutex
+  trace   io.Writer
+
+  debug debugState
+
+  errorMu sync.Mutex
+  errors  chan *ProtocolError
+
+  closeMu    sync.Mutex
+  closeHooks []func()
+  closeOnce  sync.Once
+  safeStates map[byte]bool
+  done       chan bool
+  readerDone chan bool
+
+  callbackMu          sync.Mutex
+  callbackId          int
+  digitalCallbacks    map[byte]map[int]func(bool)
+  analogCallbacks     map[byte]map[int]func(int)
+  sysexCallbacks      map[SysExCommand]map[int]func([]byte, time.Time)
+  connectionCallbacks map[int]func(bool)
+  callbackJobs        chan func()
+
+  subMu       sync.Mutex
+  digitalSubs map[*DigitalSubscription]bool
+  analogSubs  map[*AnalogSubscription]bool
+
+  effectMu sync.Mutex
+  effects  map[byte]*Effect
+  patterns *PatternPlayer
+
+  pulseMu sync.Mutex
+  pulses  map[byte]*pulse
+
+  temperatureUnit TemperatureUnit
+  timeout         time.Duration
+  clock           Clock
+  writeRate       int
+  writeBurst      int
+  writeBuffering  bool
+
+  // queues and sysexCopy are fixed once the client is created.
+  queues    map[string]QueueConfig
+  sysexCopy bool
+  dropMu sync.Mutex
+  drops  map[string]uint64
+
+  // received is when the message being parsed arrived. It is only used
+  // by the reader.
+  received time.Time
 }
 
 // Creates a new FirmataClient object and connects to the Arduino board
-// over specified serial port. This function blocks till a connection is
-// succesfullt established and pin mappings are retrieved.
-func NewClient(dev string, baud int, ch chan FirmataValue) (client *FirmataClient, err error) {
-  var conn io.ReadWriteCloser
-
-  c := &serial.Config{Name: dev, Baud: baud}
-  conn, err = serial.OpenPort(c)
-  if err != nil {
-    client.Log.Critical("Port open: %s", err.Error())
-    return
-  }
-  time.Sleep(1 * time.Second)
+// over transport, configured by opts. This function blocks till a
+// connection is succesfully established and pin mappings are retrieved,
+// giving up after 30 seconds unless WithConnectTimeout is given.
+func NewClient(transport io.ReadWriteCloser, opts ...Option) (*FirmataClient, error) {
+  o := newOptions(opts)
+  ctx, cancel := clockTimeout(context.Background(), o.clock, o.connectTimeout)
+  defer cancel()
+  return newClient(ctx, transport, o)
+}
+
+// NewClientContext is NewClient, giving up when ctx is done rather than
+// after the connect timeout. A TimeoutError is returned if the ctx
+// deadline passes.
+func NewClientContext(ctx context.Context, transport io.ReadWriteCloser, opts ...Option) (*FirmataClient, error) {
+  return newClient(ctx, transport, newOptions(opts))
+}
 
-  logger := make(log4go.Logger)
-  logger.AddFilter("stdout", log4go.FINE, log4go.NewConsoleLogWriter())
+func newClient(ctx context.Context, conn io.ReadWriteCloser, o *options) (client *FirmataClient, err error) {
+  logger := o.logger
+  if logger == nil {
+    l := make(log4go.Logger)
+    l.AddFilter("stdout", log4go.FINE, log4go.NewConsoleLogWriter())
+    logger = &l
+  }
   client = &FirmataClient{
-    serialDev: dev,
-    baud:      baud,
-    conn:      &conn,
-    Log:       &logger,
-    valueChan:  ch,
+    conn:       conn,
+    Log:        logger,
+    valueChan:  o.values,
+    board:      o.board,
+    timeout:    o.timeout,
+    clock:      o.clock,
+    metrics:    o.metrics,
+    tracer:     o.tracer,
+    trace:      o.trace,
+    dial:       o.dial,
+    redialWait: o.redialWait,
+    queues:     queueConfigs(o),
+    sysexCopy:  o.sysexCopy,
+    done:       make(chan bool),
+    readerDone: make(chan bool),
   }
-  go client.replyReader()
-
-  conn.Write([]byte{byte(SystemReset)})
-  t := time.NewTicker(time.Second)
-
-  for !(client.ready && client.analogMappingDone && client.capabilityDone) {
-    select {
-    case <-t.C:
-      //no-op
-    case <-time.After(time.Second * 15):
-      client.Log.Critical("No response in 30 seconds. Resetting arduino")
-      conn.Write([]byte{byte(SystemReset)})
-    case <-time.After(time.Second * 30):
-      client.Log.Critical("Unable to initialize connection")
-      conn.Close()
-      client = nil
+  client.SetWriteRate(o.writeRate, o.writeBurst)
+  client.startWriter()
+  go client.readLoop(conn)
+
+  if err = client.handshake(ctx, o.resetAfter); err != nil {
+    close(client.done)
+    conn.Close()
+    return nil, err
+  }
+  if o.samplingInterval > 0 {
+    if err = client.SetAnalogSamplingInterval(o.samplingInterval); err != nil {
+      client.Close()
+      return nil, err
     }
   }
+  client.SetWriteBuffering(o.writeBuffering)
 
   client.Log.Info("Client ready to use")
+  return client, nil
+}
 
-  return
+// handshake resets the board and waits for it to report its firmware,
+// capabilities and analog mapping, resetting it again every resetAfter.
+func (c *FirmataClient) handshake(ctx context.Context, resetAfter time.Duration) error {
+  ctx, done := c.startRequest(ctx, RequestInfo{Op: "connect", Command: CapabilityQuery, Pin: -1})
+  var err error
+  defer func() { done(len(c.capabilities()), err) }()
+
+  c.sendCommand([]byte{byte(SystemReset)})
+  reset := c.Clock().NewTicker(resetAfter)
+  defer reset.Stop()
+
+  for {
+    reported := c.stateReported()
+    if c.initialised() {
+      return nil
+    }
+    select {
+    case <-reported:
+    case <-reset.C():
+      c.Log.Critical("No response in %v. Resetting arduino", resetAfter)
+      c.sendCommand([]byte{byte(SystemReset)})
+    case <-ctx.Done():
+      c.Log.Critical("Unable to initialize connection")
+      err = requestError(ctx, "connect")
+      return err
+    case <-c.readerDone:
+      c.Log.Critical("Connection lost before the board was initialised")
+      err = closedError("connect")
+      return err
+    case <-c.done:
+      err = closedError("connect")
+      return err
+    }
+  }
 }
 
-// Close the serial connection to properly clean up after ourselves
+// Close the serial connection to properly clean up after ourselves,
+// giving the board up to 5 seconds. See CloseContext.
 // Usage: defer client.Close()
 func (c *FirmataClient) Close() {
-  (*c.conn).Close()
+  ctx, cancel := c.withTimeout(context.Background(), 5*time.Second)
+  defer cancel()
+  if err := c.CloseContext(ctx); err != nil {
+    c.Log.Warn("Close: %s", err.Error())
+  }
+}
+
+// onClose registers f to be run when the client is closed or the
+// connection to the board is lost.
+func (c *FirmataClient) onClose(f func()) {
+  c.closeMu.Lock()
+  defer c.closeMu.Unlock()
+  c.closeHooks = append(c.closeHooks, f)
+}
+
+// runCloseHooks runs, and then forgets, the functions given to onClose.
+func (c *FirmataClient) runCloseHooks() {
+  c.closeMu.Lock()
+  hooks := c.closeHooks
+  c.closeHooks = nil
+  c.closeMu.Unlock()
+  for _, f := range hooks {
+    f()
+  }
 }
 
 // Sets the Pin mode (input, output, etc.) for the Arduino pin
+// A mode the board did not report for the pin is refused with a
+// *PinModeError, rather than being ignored by the firmware.
 func (c *FirmataClient) SetPinMode(pin byte, mode PinMode) (err error) {
-  if c.pinModes[pin][mode] == nil {
-    err = fmt.Errorf("Pin mode %v not supported by pin %v", mode, pin)
+  if err = c.checkMode(pin, mode); err != nil {
     return
   }
   cmd := []byte{byte(SetPinMode), (pin & 0x7F), byte(mode)}
   err = c.sendCommand(cmd)
+  if err == nil {
+    c.recordMode(pin, mode)
+  }
   return
 }
 
+// recordMode notes the mode a pin has been set to.
+func (c *FirmataClient) recordMode(pin byte, mode PinMode) {
+  c.modeMu.Lock()
+  defer c.modeMu.Unlock()
+  if c.currentModes == nil {
+    c.currentModes = make(map[byte]PinMode)
+  }
+  c.currentModes[pin] = mode
+}
+
 // Specified if a digital Pin should be watched for input.
 // Values will be streamed back over a channel which can be retrieved by the GetValues() call
 func (c *FirmataClient) EnableDigitalInput(pin uint, val bool) (err error) {
-  if pin < 0 || pin > uint(len(c.pinModes)) {
-    err = fmt.Errorf("Invalid pin number %v\n", pin)
+  if pin >= uint(len(c.capabilities())) {
+    err = fmt.Errorf("%w %v", ErrInvalidPin, pin)
     return
   }
   port := (pin / 8) & 0x7F
@@ -131,17 +336,24 @@ func (c *FirmataClient) EnableDigitalInput(pin uint, val bool) (err error) {
     cmd := []byte{byte(EnableDigitalInput) | byte(port), 0x00}
     err = c.sendCommand(cmd)
   }
+  if err == nil {
+    c.recordConfig(func(cfg *boardConfig) { cfg.digitalReports[byte(port)] = val })
+  }
 
   return
 }
 
 // Set the value of a digital pin
 func (c *FirmataClient) DigitalWrite(pin uint, val bool) (err error) {
-  if pin < 0 || pin > uint(len(c.pinModes)) && c.pinModes[pin][Output] != nil {
-    err = fmt.Errorf("Invalid pin number %v\n", pin)
+  if pin/8 >= 16 || pin >= uint(len(c.capabilities())) {
+    err = fmt.Errorf("%w %v", ErrInvalidPin, pin)
     return
   }
-  port := (pin / 8) & 0x7F
+  // Hold the lock until the port is sent, so concurrent writes to pins of
+  // the same port reach the board in the order they change the state.
+  c.outputMu.Lock()
+  defer c.outputMu.Unlock()
+  port := (pin / 8) & 0x0F
   portData := &c.digitalPinState[port]
   pin = pin % 8
 
@@ -159,12 +371,16 @@ func (c *FirmataClient) DigitalWrite(pin uint, val bool) (err error) {
 // Specified if a analog Pin should be watched for input.
 // Values will be streamed back over a channel which can be retrieved by the GetValues() call
 func (c *FirmataClient) EnableAnalogInput(pin uint, val bool) (err error) {
-  if pin < 0 || pin > uint(len(c.pinModes)) && c.pinModes[pin][Analog] != nil {
-    err = fmt.Errorf("Invalid pin number %v\n", pin)
+  if pin > 0xFF {
+    err = fmt.Errorf("%w %v", ErrInvalidPin, pin)
+    return
+  }
+  if err = c.checkMode(byte(pin), Analog); err != nil {
     return
   }
 
-  ch := byte(c.analogPinsChannelMap[int(pin)])
+  pinChannels, _ := c.analogChannels()
+  ch := byte(pinChannels[int(pin)])
   c.Log.Debug("Enable analog inout on pin %v channel %v", pin, ch)
   if val {
     cmd := []byte{byte(EnableAnalogInput) | ch, 0x01}
@@ -173,42 +389,71 @@ func (c *FirmataClient) EnableAnalogInput(pin uint, val bool) (err error) {
     cmd := []byte{byte(EnableAnalogInput) | ch, 0x00}
     err = c.sendCommand(cmd)
   }
+  if err == nil {
+    c.recordConfig(func(cfg *boardConfig) { cfg.analogReports[byte(pin)] = val })
+  }
 
   return
 }
 
 // Set the value of a analog pin
 func (c *FirmataClient) AnalogWrite(pin uint, pinData byte) (err error) {
-  if pin < 0 || pin > uint(len(c.pinModes)) && c.pinModes[pin][Analog] != nil {
-    err = fmt.Errorf("Invalid pin number %v\n", pin)
+  if pin >= 16 || pin >= uint(len(c.capabilities())) {
+    err = fmt.Errorf("%w %v", ErrInvalidPin, pin)
     return
   }
 
   data := to7Bit(pinData)
   cmd := []byte{byte(AnalogMessage) | byte(pin), data[0], data[1]}
   err = c.sendCommand(cmd)
+  if err == nil {
+    c.recordConfig(func(cfg *boardConfig) { cfg.analogOutputs[byte(pin)] = pinData })
+  }
   return
 }
 
 func (c *FirmataClient) sendCommand(cmd []byte) (err error) {
-  bStr := ""
-  for _, b := range cmd {
-    bStr = bStr + fmt.Sprintf(" %#2x", b)
+  if c.closed() {
+    return ErrNotConnected
   }
-  c.Log.Trace("Command send%v\n", bStr)
-
-  _, err = (*c.conn).Write(cmd)
+  if c.logsTrace() {
+    c.Log.Trace("Command send%v\n", hexDump(cmd))
+  }
+  c.observeFrame(Sent, c.now(), cmd)
+
+  // Pin modes, outputs and reporting changes keep their order, so a write
+  // is never sent before the mode it needs. Resets and version queries,
+  // which wait for a reply, may go ahead of them.
+  prio := normalPriority
+  if cmd[0] == byte(SystemReset) || cmd[0] == byte(ReportVersion) {
+    prio = controlPriority
+  }
+  err = c.writeFrame(cmd, false, prio)
   return
 }
 
 // Sets the polling interval in milliseconds for analog pin samples
 func (c *FirmataClient) SetAnalogSamplingInterval(ms byte) (err error) {
+  err = c.setSamplingInterval(ms)
+  if err == nil {
+    c.recordConfig(func(cfg *boardConfig) { cfg.userSampling = ms })
+  }
+  return
+}
+
+// setSamplingInterval sets the board sampling interval, without changing
+// the one asked for with SetAnalogSamplingInterval.
+func (c *FirmataClient) setSamplingInterval(ms byte) (err error) {
   data := to7Bit(ms)
   err = c.sendSysEx(SamplingInterval, data[0], data[1])
+  if err == nil {
+    c.recordConfig(func(cfg *boardConfig) { cfg.samplingInterval = ms })
+  }
   return
 }
 
-// Get the channel to retrieve analog and digital pin values
+// Get the channel to retrieve analog and digital pin values, given with
+// WithValues. It is nil if none was given.
 func (c *FirmataClient) GetValues() <-chan FirmataValue {
   return c.valueChan
 }
@@ -0,0 +1,179 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for the client and everything driven by it:
+// request timeouts, debouncing, write pacing, pulses and effects, and the
+// polling of monitors and drivers. The default is the system clock. Tests
+// can give WithClock a fake clock, such as firmatatest.Clock, and advance
+// it rather than sleep.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// NewTimer creates a timer sending the time on its channel after d.
+	NewTimer(d time.Duration) Timer
+	// AfterFunc calls f in its own goroutine after d. The timer returned
+	// has no channel.
+	AfterFunc(d time.Duration, f func()) Timer
+	// NewTicker creates a ticker sending the time on its channel every d.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a timer created by a Clock, as time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker is a ticker created by a Clock, as time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// SystemClock is the Clock of the time package.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return systemTimer{time.AfterFunc(d, f)}
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTimer struct {
+	t *time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time        { return t.t.C }
+func (t systemTimer) Stop() bool                 { return t.t.Stop() }
+func (t systemTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+
+type systemTicker struct {
+	t *time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time { return t.t.C }
+func (t systemTicker) Stop()               { t.t.Stop() }
+
+// WithClock sets the clock of the client, the system clock by default.
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		if clock != nil {
+			o.clock = clock
+		}
+	}
+}
+
+// Clock returns the clock of the client.
+func (c *FirmataClient) Clock() Clock {
+	if c.clock == nil {
+		return SystemClock
+	}
+	return c.clock
+}
+
+func (c *FirmataClient) now() time.Time {
+	return c.Clock().Now()
+}
+
+func (c *FirmataClient) since(t time.Time) time.Duration {
+	return c.Clock().Now().Sub(t)
+}
+
+// after is time.After on the client clock.
+func (c *FirmataClient) after(d time.Duration) <-chan time.Time {
+	return c.Clock().NewTimer(d).C()
+}
+
+// sleep is time.Sleep on the client clock.
+func (c *FirmataClient) sleep(d time.Duration) {
+	sleep(c.Clock(), d)
+}
+
+// sleep is time.Sleep on clock.
+func sleep(clock Clock, d time.Duration) {
+	if d > 0 {
+		<-clock.NewTimer(d).C()
+	}
+}
+
+// withTimeout is context.WithTimeout on the client clock.
+func (c *FirmataClient) withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	return clockTimeout(ctx, c.Clock(), d)
+}
+
+// clockTimeout is context.WithTimeout on clock.
+func clockTimeout(ctx context.Context, clock Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	if clock == SystemClock {
+		return context.WithTimeout(ctx, d)
+	}
+	inner, cancel := context.WithCancel(ctx)
+	cc := &clockContext{Context: inner, deadline: clock.Now().Add(d)}
+	t := clock.AfterFunc(d, func() {
+		cc.mu.Lock()
+		if inner.Err() == nil {
+			cc.expired = true
+		}
+		cc.mu.Unlock()
+		cancel()
+	})
+	return cc, func() {
+		t.Stop()
+		cancel()
+	}
+}
+
+// clockContext is a context with a deadline on a Clock other than the
+// system one.
+type clockContext struct {
+	context.Context
+	deadline time.Time
+
+	mu      sync.Mutex
+	expired bool
+}
+
+func (c *clockContext) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+func (c *clockContext) Err() error {
+	err := c.Context.Err()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil && c.expired {
+		return context.DeadlineExceeded
+	}
+	return err
+}
@@ -0,0 +1,199 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// SetSafeState sets the level pin is driven to when the client is closed.
+// The pin must already be an output.
+func (c *FirmataClient) SetSafeState(pin byte, high bool) {
+	c.closeMu.Lock()
+	defer c.closeMu.Unlock()
+	if c.safeStates == nil {
+		c.safeStates = make(map[byte]bool)
+	}
+	c.safeStates[pin] = high
+}
+
+// ClearSafeState leaves pin as it is when the client is closed.
+func (c *FirmataClient) ClearSafeState(pin byte) {
+	c.closeMu.Lock()
+	defer c.closeMu.Unlock()
+	delete(c.safeStates, pin)
+}
+
+// CloseContext shuts down the client. Helpers which put their outputs in a
+// safe state do so, input reporting is stopped, pins given a safe state
+// with SetSafeState are set, and the connection is closed. Requests still
+// waiting for a reply fail, subscriptions, value channels and the Errors
+// channel are closed, and the reader and callback goroutines exit. The
+// channel given to WithValues is closed too, unless ctx is done before the
+// reader exits.
+//
+// An error is returned if the connection could not be closed cleanly, or
+// ctx is done before the reader exits. Calling it again does nothing.
+func (c *FirmataClient) CloseContext(ctx context.Context) error {
+	err := fmt.Errorf("%w: client already closed", ErrNotConnected)
+	c.closeOnce.Do(func() { err = c.shutdown(ctx) })
+	return err
+}
+
+func (c *FirmataClient) shutdown(ctx context.Context) error {
+	// Hooks go first, while the board can still be told to make outputs safe.
+	c.runCloseHooks()
+	c.stopReporting()
+	c.applySafeStates()
+	if err := c.Flush(); err != nil {
+		c.Log.Warn("Flush: %s", err.Error())
+	}
+	if f, ok := c.transport().(interface {
+		Flush() error
+	}); ok {
+		if err := f.Flush(); err != nil {
+			c.Log.Warn("Flush: %s", err.Error())
+		}
+	}
+
+	// Release requests waiting for replies before the reader goes away,
+	// and the reader if it is blocked on a continuous I2C read.
+	close(c.done)
+	c.i2cStopListeners(func(i2cQuery) bool { return true })
+	err := c.transport().Close()
+	select {
+	case <-c.readerDone:
+	case <-ctx.Done():
+		if err == nil {
+			err = requestError(ctx, "close")
+		}
+	}
+	c.stopListeners()
+	return err
+}
+
+// stopReporting disables digital and analog input reports from the board.
+func (c *FirmataClient) stopReporting() {
+	for port := 0; port < (len(c.capabilities())+7)/8 && port < 16; port++ {
+		if err := c.sendCommand([]byte{byte(EnableDigitalInput) | byte(port), 0x00}); err != nil {
+			c.Log.Warn("Unable to stop digital reporting: %s", err.Error())
+			return
+		}
+	}
+	// In channel order, so a close is the same each time and can be
+	// replayed.
+	var channels [16]bool
+	pinChannels, _ := c.analogChannels()
+	for _, ch := range pinChannels {
+		if ch < 16 {
+			channels[ch] = true
+		}
+	}
+	for ch := byte(0); ch < 16; ch++ {
+		if !channels[ch] {
+			continue
+		}
+		if err := c.sendCommand([]byte{byte(EnableAnalogInput) | ch, 0x00}); err != nil {
+			c.Log.Warn("Unable to stop analog reporting: %s", err.Error())
+			return
+		}
+	}
+}
+
+// applySafeStates sets the pins given to SetSafeState.
+func (c *FirmataClient) applySafeStates() {
+	c.closeMu.Lock()
+	states := c.safeStates
+	c.safeStates = nil
+	c.closeMu.Unlock()
+	pins := make([]int, 0, len(states))
+	for pin := range states {
+		pins = append(pins, int(pin))
+	}
+	sort.Ints(pins)
+	for _, p := range pins {
+		pin, high := byte(p), states[byte(p)]
+		if err := c.DigitalWrite(uint(pin), high); err != nil {
+			c.Log.Critical("Unable to put pin %v in safe state: %s", c.PinLabel(pin), err.Error())
+		}
+	}
+}
+
+// stopListeners closes everything fed by the reader, once it has stopped.
+func (c *FirmataClient) stopListeners() {
+	c.inputMu.Lock()
+	for ch := range c.valueListeners {
+		delete(c.valueListeners, ch)
+		close(ch)
+	}
+	// The reader sends on the WithValues channel without a lock, so it is
+	// only closed if the reader has exited.
+	select {
+	case <-c.readerDone:
+		if c.valueChan != nil {
+			close(c.valueChan)
+		}
+	default:
+	}
+	for pin, t := range c.debounceTimers {
+		t.Stop()
+		delete(c.debounceTimers, pin)
+	}
+	c.inputMu.Unlock()
+
+	c.subMu.Lock()
+	var digital []*DigitalSubscription
+	var analog []*AnalogSubscription
+	for s := range c.digitalSubs {
+		digital = append(digital, s)
+	}
+	for s := range c.analogSubs {
+		analog = append(analog, s)
+	}
+	c.subMu.Unlock()
+	for _, s := range digital {
+		s.Close()
+	}
+	for _, s := range analog {
+		s.Close()
+	}
+
+	c.stopErrors()
+
+	c.callbackMu.Lock()
+	if c.callbackJobs != nil {
+		close(c.callbackJobs)
+		c.callbackJobs = nil
+	}
+	c.callbackMu.Unlock()
+}
+
+// closed returns true once the client has started shutting down.
+func (c *FirmataClient) closed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// closedError is returned by a request abandoned because the client was
+// closed.
+func closedError(op string) error {
+	return fmt.Errorf("%s: %w", op, ErrNotConnected)
+}
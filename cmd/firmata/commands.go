@@ -0,0 +1,399 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/buxtronix/go-firmata"
+)
+
+// session is what commands run with.
+type session struct {
+	ctx    context.Context
+	client *firmata.FirmataClient
+	out    io.Writer
+}
+
+// command is a command of the tool.
+type command struct {
+	args, help string
+	// board is true if the command needs a connection to the board.
+	board bool
+	run   func(s *session, args []string) error
+}
+
+var commands map[string]*command
+
+func init() {
+	commands = map[string]*command{
+		"ports":   {"", "list the serial ports a board may be on", false, ports},
+		"info":    {"", "show the firmware and the modes of each pin", true, info},
+		"mode":    {"<pin> <mode>", "set the mode of a pin, such as input, output or pwm", true, mode},
+		"read":    {"<pin>", "read a digital or analog input", true, read},
+		"repl":    {"", "run commands interactively, with watches in the background", true, repl},
+		"write":   {"<pin> <0|1>", "drive a digital output low or high", true, write},
+		"pwm":     {"<pin> <0-255>", "write a PWM duty", true, pwm},
+		"servo":   {"<pin> <0-180>", "move a servo", true, servo},
+		"i2cscan": {"", "list the I2C devices which acknowledge their address", true, i2cScan},
+		"onewire": {"<pin>", "list the 1-Wire devices on a pin", true, oneWire},
+		"watch":   {"<pin>...", "print changes to inputs until interrupted", true, watch},
+	}
+}
+
+// printCommands lists the commands and their arguments, followed by extra
+// lines of a usage and its help separated by a tab.
+func printCommands(w io.Writer, extra ...string) {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, name := range names {
+		c := commands[name]
+		fmt.Fprintf(tw, "  %s %s\t%s\n", name, c.args, c.help)
+	}
+	for _, e := range extra {
+		fmt.Fprintf(tw, "  %s\n", e)
+	}
+	tw.Flush()
+}
+
+// nargs checks the number of arguments to a command.
+func nargs(args []string, n int) error {
+	if len(args) != n {
+		return fmt.Errorf("want %d arguments, got %d", n, len(args))
+	}
+	return nil
+}
+
+// pin resolves a pin name, returning true if it names an analog input.
+func (s *session) pin(name string) (byte, bool, error) {
+	n, err := s.client.PinNumber(name)
+	if err == nil {
+		if int(n) >= len(s.client.Pins()) {
+			return 0, false, fmt.Errorf("%w %v", firmata.ErrInvalidPin, n)
+		}
+		return n, isAnalogName(name), nil
+	}
+	// Without a board profile, find A<n> from the analog mapping.
+	if isAnalogName(name) {
+		channel, _ := strconv.Atoi(name[1:])
+		for _, p := range s.client.Pins() {
+			if p.AnalogChannel == channel {
+				return p.Number, true, nil
+			}
+		}
+	}
+	return 0, false, err
+}
+
+func isAnalogName(name string) bool {
+	if len(name) < 2 || (name[0] != 'A' && name[0] != 'a') {
+		return false
+	}
+	_, err := strconv.Atoi(name[1:])
+	return err == nil
+}
+
+func ports(s *session, args []string) error {
+	for _, p := range serialPorts() {
+		fmt.Fprintln(s.out, p)
+	}
+	return nil
+}
+
+func info(s *session, args []string) error {
+	f := s.client.Firmware()
+	fmt.Fprintf(s.out, "Firmware: %s %d.%d\n", f.Name, f.Major, f.Minor)
+	if b := s.client.Board(); b != nil {
+		fmt.Fprintf(s.out, "Board: %s\n", b.Name)
+	}
+	tw := tabwriter.NewWriter(s.out, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "PIN\tLABEL\tANALOG\tMODES\n")
+	for _, p := range s.client.Pins() {
+		var modes []string
+		for _, m := range p.Modes {
+			modes = append(modes, fmt.Sprintf("%s:%d", m.Name(), p.Resolutions[m]))
+		}
+		analog := "-"
+		if p.AnalogChannel >= 0 {
+			analog = fmt.Sprintf("A%d", p.AnalogChannel)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", p.Number, p.Label, analog, strings.Join(modes, " "))
+	}
+	return tw.Flush()
+}
+
+func mode(s *session, args []string) error {
+	if err := nargs(args, 2); err != nil {
+		return err
+	}
+	n, _, err := s.pin(args[0])
+	if err != nil {
+		return err
+	}
+	m, err := firmata.ParsePinMode(args[1])
+	if err != nil {
+		return err
+	}
+	if err := s.client.Pin(n).SetMode(m); err != nil {
+		return err
+	}
+	return s.client.Flush()
+}
+
+func read(s *session, args []string) error {
+	if err := nargs(args, 1); err != nil {
+		return err
+	}
+	n, analog, err := s.pin(args[0])
+	if err != nil {
+		return err
+	}
+	if err := s.startReporting(n, analog); err != nil {
+		return err
+	}
+	ctx := s.ctx
+	if t := s.client.Timeout(); t > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t)
+		defer cancel()
+	}
+	for {
+		if analog {
+			if v, ok := s.client.GetAnalog(uint(n)); ok {
+				fmt.Fprintln(s.out, v)
+				return nil
+			}
+		} else if v, ok := s.client.GetDigital(uint(n)); ok {
+			fmt.Fprintln(s.out, level(v))
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("no report of pin %v: %w", s.client.PinLabel(n), firmata.ErrTimeout)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// startReporting sets a pin to an input, unless it already is one, and
+// turns on its reports.
+func (s *session) startReporting(n byte, analog bool) error {
+	pin := s.client.Pin(n)
+	m, ok := pin.Mode()
+	want, enable := firmata.Input, s.client.EnableDigitalInput
+	if analog {
+		want, enable = firmata.Analog, s.client.EnableAnalogInput
+	}
+	if !ok || (m != want && !(m == firmata.Pullup && !analog)) {
+		if err := pin.SetMode(want); err != nil {
+			return err
+		}
+	}
+	if err := enable(uint(n), true); err != nil {
+		return err
+	}
+	return s.client.Flush()
+}
+
+// parseLevel parses a digital level.
+func parseLevel(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "1", "on", "true", "high":
+		return true, nil
+	case "0", "off", "false", "low":
+		return false, nil
+	}
+	return false, fmt.Errorf("bad level %q", s)
+}
+
+func level(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+func write(s *session, args []string) error {
+	if err := nargs(args, 2); err != nil {
+		return err
+	}
+	n, _, err := s.pin(args[0])
+	if err != nil {
+		return err
+	}
+	v, err := parseLevel(args[1])
+	if err != nil {
+		return err
+	}
+	if err := s.client.Pin(n).Write(v); err != nil {
+		return err
+	}
+	return s.client.Flush()
+}
+
+// byteArgs resolves the pin and byte value arguments of pwm and servo.
+func (s *session) byteArgs(args []string, max int) (byte, byte, error) {
+	if err := nargs(args, 2); err != nil {
+		return 0, 0, err
+	}
+	n, _, err := s.pin(args[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	v, err := strconv.Atoi(args[1])
+	if err != nil || v < 0 || v > max {
+		return 0, 0, fmt.Errorf("bad value %q, want 0 to %d", args[1], max)
+	}
+	return n, byte(v), nil
+}
+
+func pwm(s *session, args []string) error {
+	n, duty, err := s.byteArgs(args, 255)
+	if err != nil {
+		return err
+	}
+	if err := s.client.Pin(n).Pwm(duty); err != nil {
+		return err
+	}
+	return s.client.Flush()
+}
+
+func servo(s *session, args []string) error {
+	n, angle, err := s.byteArgs(args, 180)
+	if err != nil {
+		return err
+	}
+	if err := s.client.Pin(n).Servo(angle); err != nil {
+		return err
+	}
+	return s.client.Flush()
+}
+
+// i2cScanTimeout is how long the scan waits for each address.
+const i2cScanTimeout = 100 * time.Millisecond
+
+func i2cScan(s *session, args []string) error {
+	if err := s.client.I2CConfig(0); err != nil {
+		return err
+	}
+	found := 0
+	// 0x00-0x07 and 0x78-0x7f are reserved.
+	for addr := byte(0x08); addr < 0x78; addr++ {
+		ctx, cancel := context.WithTimeout(s.ctx, i2cScanTimeout)
+		_, err := s.client.I2CReadContext(ctx, addr, firmata.I2CNoRegister, 1)
+		cancel()
+		if s.ctx.Err() != nil {
+			return s.ctx.Err()
+		}
+		if err == nil {
+			fmt.Fprintf(s.out, "0x%02x\n", addr)
+			found++
+		}
+	}
+	if found == 0 {
+		fmt.Fprintln(s.out, "No devices found")
+	}
+	return nil
+}
+
+func oneWire(s *session, args []string) error {
+	if err := nargs(args, 1); err != nil {
+		return err
+	}
+	n, _, err := s.pin(args[0])
+	if err != nil {
+		return err
+	}
+	if err := s.client.OneWireConfig(n, firmata.OneWirePowerNormal); err != nil {
+		return err
+	}
+	addresses, err := s.client.OneWireSearchContext(s.ctx, n, firmata.OneWireSearch)
+	if err != nil {
+		return err
+	}
+	for _, a := range addresses {
+		crc := ""
+		if !a.Valid() {
+			crc = " (bad CRC)"
+		}
+		fmt.Fprintf(s.out, "%x%s\n", []byte(a), crc)
+	}
+	if len(addresses) == 0 {
+		fmt.Fprintln(s.out, "No devices found")
+	}
+	return nil
+}
+
+func watch(s *session, args []string) error {
+	if len(args) == 0 {
+		return errors.New("no pins to watch")
+	}
+	var digital, analog []byte
+	for _, name := range args {
+		n, a, err := s.pin(name)
+		if err != nil {
+			return err
+		}
+		if err := s.startReporting(n, a); err != nil {
+			return err
+		}
+		if a {
+			analog = append(analog, n)
+		} else {
+			digital = append(digital, n)
+		}
+	}
+	// A subscription to no pins would see every pin.
+	var dc <-chan firmata.DigitalEvent
+	var ac <-chan firmata.AnalogEvent
+	if len(digital) > 0 {
+		d := s.client.SubscribeDigital(16, firmata.DropOldest, digital...)
+		defer d.Close()
+		dc = d.C
+	}
+	if len(analog) > 0 {
+		a := s.client.SubscribeAnalog(16, firmata.DropOldest, analog...)
+		defer a.Close()
+		ac = a.C
+	}
+	for {
+		select {
+		case e, ok := <-dc:
+			if !ok {
+				return firmata.ErrNotConnected
+			}
+			fmt.Fprintf(s.out, "%s %s %d\n", e.Time.Format("15:04:05.000"), e.Label, level(e.Value))
+		case e, ok := <-ac:
+			if !ok {
+				return firmata.ErrNotConnected
+			}
+			fmt.Fprintf(s.out, "%s %s %d\n", e.Time.Format("15:04:05.000"), e.Label, e.Value)
+		case <-s.ctx.Done():
+			return nil
+		}
+	}
+}
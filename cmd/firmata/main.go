@@ -0,0 +1,127 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command firmata talks to a Firmata board from the command line, to check
+// a board and its wiring without writing a program:
+//
+//	firmata ports
+//	firmata -port /dev/ttyACM0 info
+//	firmata -port /dev/ttyACM0 write 13 1
+//	firmata -port /dev/ttyACM0 watch 2 A0
+//
+// Pins are given by number or by name, such as A0 or a label. A pin given
+// by its analog name is read as an analog input, and one given by number
+// as a digital input. Run firmata with no command to list the commands.
+//
+// The repl command gives a prompt to run commands at, for bench work:
+//
+//	$ firmata repl
+//	firmata> mode 13 output
+//	firmata> write 13 1
+//	firmata> watch a0
+//	15:04:05.000 14 512
+//	firmata> unwatch
+//
+// Watches there run in the background until unwatch, printing above the
+// prompt, and an interrupt stops the running command rather than the tool.
+//
+// The port may also be set with the FIRMATA_PORT environment variable.
+// Without either, the only serial port found is used.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"code.google.com/p/log4go"
+	"github.com/buxtronix/go-firmata"
+)
+
+var (
+	port    = flag.String("port", os.Getenv("FIRMATA_PORT"), "serial port of the board")
+	baud    = flag.Int("baud", 57600, "baud rate of the serial port")
+	timeout = flag.Duration("timeout", 5*time.Second, "time each request to the board may take")
+	verbose = flag.Bool("v", false, "log the client's progress")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: firmata [flags] <command> [args]\n\nCommands:\n")
+	printCommands(os.Stderr)
+	fmt.Fprintf(os.Stderr, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	os.Exit(run())
+}
+
+// run runs the command, returning the exit status.
+func run() int {
+	if flag.NArg() == 0 {
+		usage()
+		return 2
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "firmata: unknown command %q\n", flag.Arg(0))
+		usage()
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	s := &session{ctx: ctx, out: os.Stdout}
+	if cmd.board {
+		client, err := open()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "firmata: %v\n", err)
+			return 1
+		}
+		defer client.Close()
+		s.client = client
+	}
+	if err := cmd.run(s, flag.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "firmata: %s: %v\n", flag.Arg(0), err)
+		return 1
+	}
+	return 0
+}
+
+// open connects to the board on the port given by the flags.
+func open() (*firmata.FirmataClient, error) {
+	dev := *port
+	if dev == "" {
+		ports := serialPorts()
+		switch len(ports) {
+		case 0:
+			return nil, fmt.Errorf("no serial ports found, set one with -port")
+		case 1:
+			dev = ports[0]
+		default:
+			return nil, fmt.Errorf("several serial ports found, pick one with -port: %v", ports)
+		}
+	}
+	level := log4go.WARNING
+	if *verbose {
+		level = log4go.FINE
+	}
+	logger := log4go.NewDefaultLogger(level)
+	return firmata.Open(dev, *baud, firmata.WithLogger(&logger), firmata.WithTimeout(*timeout))
+}
@@ -0,0 +1,56 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+// portPatterns are the device names of USB serial ports, which boards
+// usually appear as.
+var portPatterns = []string{
+	"/dev/ttyACM*",
+	"/dev/ttyUSB*",
+	"/dev/ttyAMA*",
+	"/dev/cu.usbmodem*",
+	"/dev/cu.usbserial*",
+	"/dev/cu.wchusbserial*",
+}
+
+// serialPorts returns the serial ports a board may be on.
+func serialPorts() []string {
+	if runtime.GOOS == "windows" {
+		var ports []string
+		for i := 1; i <= 32; i++ {
+			name := fmt.Sprintf("COM%d", i)
+			if f, err := os.OpenFile(`\\.\`+name, os.O_RDWR, 0); err == nil {
+				f.Close()
+				ports = append(ports, name)
+			}
+		}
+		return ports
+	}
+	var ports []string
+	for _, p := range portPatterns {
+		matches, _ := filepath.Glob(p)
+		ports = append(ports, matches...)
+	}
+	sort.Strings(ports)
+	return ports
+}
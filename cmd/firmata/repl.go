@@ -0,0 +1,197 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/buxtronix/go-firmata"
+	"github.com/chzyer/readline"
+)
+
+// repl reads commands from the terminal and runs them until exit or end
+// of input. Watches run in the background, printing above the prompt,
+// until unwatch. Interrupting a command stops it rather than the tool.
+func repl(s *session, args []string) error {
+	var names []readline.PrefixCompleterInterface
+	for name := range commands {
+		if name != "repl" {
+			names = append(names, readline.PcItem(name))
+		}
+	}
+	names = append(names, readline.PcItem("unwatch"), readline.PcItem("help"), readline.PcItem("exit"))
+	config := &readline.Config{
+		Prompt:          "firmata> ",
+		AutoComplete:    readline.NewPrefixCompleter(names...),
+		InterruptPrompt: "^C",
+		EOFPrompt:       "exit",
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		config.HistoryFile = filepath.Join(home, ".firmata_history")
+	}
+	rl, err := readline.NewEx(config)
+	if err != nil {
+		return err
+	}
+	defer rl.Close()
+
+	r := &replState{client: s.client, out: rl.Stdout()}
+	defer r.unwatch()
+	f := s.client.Firmware()
+	fmt.Fprintf(r.out, "Connected to %s %d.%d. Type help for the commands.\n", f.Name, f.Major, f.Minor)
+	for {
+		line, err := rl.Readline()
+		if errors.Is(err, readline.ErrInterrupt) {
+			if line == "" {
+				fmt.Fprintln(r.out, "Type exit to quit.")
+			}
+			continue
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !r.run(strings.Fields(line)) {
+			return nil
+		}
+	}
+}
+
+// replState is the state of a REPL.
+type replState struct {
+	client *firmata.FirmataClient
+	out    io.Writer
+
+	mu      sync.Mutex
+	watches map[string]context.CancelFunc
+}
+
+// run runs a command line, returning false if it is exit.
+func (r *replState) run(args []string) bool {
+	if len(args) == 0 {
+		return true
+	}
+	name, args := strings.ToLower(args[0]), args[1:]
+	switch name {
+	case "exit", "quit":
+		return false
+	case "help", "?":
+		printCommands(r.out, "unwatch [<pin>...]\tstop watching pins, or every pin", "exit\tleave the REPL")
+		return true
+	case "repl":
+		fmt.Fprintln(r.out, "Already in the REPL")
+		return true
+	case "watch":
+		r.watch(args)
+		return true
+	case "unwatch":
+		r.unwatch(args...)
+		return true
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(r.out, "Unknown command %q, type help for the commands\n", name)
+		return true
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+	go func() {
+		select {
+		case <-interrupts:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	if err := cmd.run(&session{ctx: ctx, client: r.client, out: r.out}, args); err != nil {
+		fmt.Fprintf(r.out, "%s: %v\n", name, err)
+	}
+	return true
+}
+
+// watch starts a background watch of each pin not already watched.
+func (r *replState) watch(args []string) {
+	if len(args) == 0 {
+		r.mu.Lock()
+		var pins []string
+		for pin := range r.watches {
+			pins = append(pins, pin)
+		}
+		r.mu.Unlock()
+		sort.Strings(pins)
+		fmt.Fprintf(r.out, "Watching: %s\n", strings.Join(pins, " "))
+		return
+	}
+	s := &session{client: r.client, out: r.out}
+	for _, name := range args {
+		// Check the pin now, rather than in the background.
+		if _, _, err := s.pin(name); err != nil {
+			fmt.Fprintf(r.out, "watch: %v\n", err)
+			return
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.watches == nil {
+		r.watches = make(map[string]context.CancelFunc)
+	}
+	for _, name := range args {
+		name = strings.ToUpper(name)
+		if r.watches[name] != nil {
+			continue
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		r.watches[name] = cancel
+		go func(name string) {
+			err := watch(&session{ctx: ctx, client: r.client, out: r.out}, []string{name})
+			if err != nil {
+				fmt.Fprintf(r.out, "watch %s: %v\n", name, err)
+				r.unwatch(name)
+			}
+		}(name)
+	}
+}
+
+// unwatch stops watching pins, or every pin if there are none.
+func (r *replState) unwatch(pins ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(pins) == 0 {
+		for pin := range r.watches {
+			pins = append(pins, pin)
+		}
+	}
+	for _, pin := range pins {
+		pin = strings.ToUpper(pin)
+		if cancel := r.watches[pin]; cancel != nil {
+			cancel()
+			delete(r.watches, pin)
+		}
+	}
+}
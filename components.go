@@ -0,0 +1,390 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"sync"
+	"time"
+)
+
+// patternResolution is the ticker interval of the pattern player shared
+// by Leds.
+const patternResolution = 10 * time.Millisecond
+
+// Led is an LED on a digital or PWM pin. Like Pin, it sets the pin mode
+// each method needs, so simple programs need nothing more:
+//
+//	led := client.Led(13)
+//	led.Blink(500 * time.Millisecond)
+//
+// Starting a blink, fade or pulse replaces whichever the LED was already
+// running, as does turning it on or off.
+type Led struct {
+	client *FirmataClient
+	pin    byte
+
+	mu       sync.Mutex
+	duty     byte
+	blinking bool
+	effect   *Effect
+}
+
+// Led returns the LED on pin.
+func (c *FirmataClient) Led(pin byte) *Led {
+	return &Led{client: c, pin: pin}
+}
+
+// Pin returns the pin the LED is on.
+func (l *Led) Pin() *Pin {
+	return l.client.Pin(l.pin)
+}
+
+// On turns the LED fully on.
+func (l *Led) On() error {
+	return l.Set(true)
+}
+
+// Off turns the LED off.
+func (l *Led) Off() error {
+	return l.Set(false)
+}
+
+// Set turns the LED fully on or off.
+func (l *Led) Set(on bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stop()
+	if err := l.Pin().Write(on); err != nil {
+		return err
+	}
+	l.duty = 0
+	if on {
+		l.duty = 255
+	}
+	return nil
+}
+
+// Toggle turns the LED off if it was last left on, at any brightness, and
+// fully on otherwise.
+func (l *Led) Toggle() error {
+	return l.Set(!l.IsOn())
+}
+
+// IsOn returns whether the LED was last left on, at any brightness. A
+// blinking or pulsing LED is not on, and a fading one is on unless it is
+// fading off.
+func (l *Led) IsOn() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.duty > 0
+}
+
+// Brightness sets the pin to PWM and lights the LED at duty, from 0 (off)
+// to 255 (on).
+func (l *Led) Brightness(duty byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stop()
+	if err := l.Pin().Pwm(duty); err != nil {
+		return err
+	}
+	l.duty = duty
+	return nil
+}
+
+// Blink flashes the LED, on and off for period each, until it is stopped.
+func (l *Led) Blink(period time.Duration) error {
+	return l.Play(PatternBlink(period))
+}
+
+// Play flashes pattern on the LED until it is stopped.
+func (l *Led) Play(pattern Pattern) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stop()
+	l.duty = 0
+	if err := l.client.patternPlayer().Play(pattern, l.pin); err != nil {
+		return err
+	}
+	l.blinking = true
+	return nil
+}
+
+// Fade changes the brightness of the LED from its current brightness to
+// duty over d. It returns immediately.
+func (l *Led) Fade(duty byte, d time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	from := l.duty
+	l.stop()
+	return l.startEffect(duty, func() (*Effect, error) {
+		return l.client.Fade(l.pin, from, duty, d)
+	})
+}
+
+// FadeIn fades the LED fully on over d.
+func (l *Led) FadeIn(d time.Duration) error {
+	return l.Fade(255, d)
+}
+
+// FadeOut fades the LED off over d.
+func (l *Led) FadeOut(d time.Duration) error {
+	return l.Fade(0, d)
+}
+
+// Pulse smoothly brightens and dims the LED every period, until it is
+// stopped.
+func (l *Led) Pulse(period time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stop()
+	return l.startEffect(0, func() (*Effect, error) {
+		return l.client.Breathe(l.pin, 0, 255, period)
+	})
+}
+
+// Stop stops a blink, fade or pulse. A blinking LED is left off, and a
+// fading or pulsing one at its current brightness.
+func (l *Led) Stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stop()
+}
+
+// startEffect starts a PWM effect with start, recording the brightness the
+// LED is left at by it.
+func (l *Led) startEffect(duty byte, start func() (*Effect, error)) error {
+	e, err := start()
+	if err != nil {
+		return err
+	}
+	l.effect, l.duty = e, duty
+	return nil
+}
+
+// stop stops the pattern or effect the LED is running. It is called with
+// mu held.
+func (l *Led) stop() {
+	if l.blinking {
+		l.client.patternPlayer().StopPins(l.pin)
+		l.blinking = false
+	}
+	if l.effect != nil {
+		l.effect.Stop()
+		l.effect = nil
+	}
+}
+
+// patternPlayer returns the pattern player shared by Leds, creating it on
+// first use.
+func (c *FirmataClient) patternPlayer() *PatternPlayer {
+	c.effectMu.Lock()
+	defer c.effectMu.Unlock()
+	if c.patterns == nil {
+		c.patterns = NewPatternPlayer(c, patternResolution)
+	}
+	return c.patterns
+}
+
+// PushButton is a Button which calls handlers rather than sending events,
+// configured by chaining:
+//
+//	client.Button(2).
+//		OnPress(func() { led.On() }).
+//		OnRelease(func() { led.Off() })
+//
+// The button is started by the first handler added. Handlers are called in
+// order on a goroutine of the button, so a slow handler delays the next.
+type PushButton struct {
+	button *Button
+
+	mu       sync.Mutex
+	handlers map[ButtonEventType][]func()
+	pressed  bool
+	started  bool
+	err      error
+	// events are those of the running button, so events left from before
+	// a Stop are not handled.
+	events <-chan ButtonEvent
+}
+
+// Button returns the push button on pin, with the timings of NewButton.
+func (c *FirmataClient) Button(pin byte) *PushButton {
+	return &PushButton{button: NewButton(c, pin)}
+}
+
+// ActiveHigh is for buttons which pull the pin high when pressed, rather
+// than low against the internal pullup. It must be called before any
+// handler is added.
+func (b *PushButton) ActiveHigh() *PushButton {
+	b.button.ActiveHigh = true
+	return b
+}
+
+// HoldTime sets how long the button must be held for the OnHold handlers,
+// one second by default. It must be called before any handler is added.
+func (b *PushButton) HoldTime(d time.Duration) *PushButton {
+	b.button.LongPress = d
+	return b
+}
+
+// OnPress adds a handler called when the button is pressed.
+func (b *PushButton) OnPress(fn func()) *PushButton {
+	return b.on(ButtonPress, fn)
+}
+
+// OnRelease adds a handler called when the button is released.
+func (b *PushButton) OnRelease(fn func()) *PushButton {
+	return b.on(ButtonRelease, fn)
+}
+
+// OnHold adds a handler called when the button has been held for the hold
+// time.
+func (b *PushButton) OnHold(fn func()) *PushButton {
+	return b.on(ButtonLongPress, fn)
+}
+
+// OnDoubleClick adds a handler called on the second of two quick presses,
+// after the OnPress handlers.
+func (b *PushButton) OnDoubleClick(fn func()) *PushButton {
+	return b.on(ButtonDoubleClick, fn)
+}
+
+// IsPressed returns whether the button is held down.
+func (b *PushButton) IsPressed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.pressed
+}
+
+// Err returns the error starting the button, if it could not be started.
+// The error is also logged.
+func (b *PushButton) Err() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+// Stop stops watching the button and removes its handlers. Adding a
+// handler starts it again.
+func (b *PushButton) Stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.started {
+		b.button.Stop()
+	}
+	b.handlers, b.started, b.pressed, b.events = nil, false, false, nil
+}
+
+func (b *PushButton) on(t ButtonEventType, fn func()) *PushButton {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.handlers == nil {
+		b.handlers = make(map[ButtonEventType][]func())
+	}
+	b.handlers[t] = append(b.handlers[t], fn)
+	if !b.started {
+		events, err := b.button.Start()
+		if err != nil {
+			b.err = err
+			b.button.Client.Log.Warn("Button on pin %v: %s", b.button.Client.PinLabel(b.button.Pin), err.Error())
+			return b
+		}
+		b.started, b.err, b.events = true, nil, events
+		go b.dispatch(events)
+	}
+	return b
+}
+
+// dispatch calls the handlers for each event, until the button is stopped.
+func (b *PushButton) dispatch(events <-chan ButtonEvent) {
+	for e := range events {
+		b.mu.Lock()
+		if b.events != events {
+			b.mu.Unlock()
+			continue
+		}
+		switch e.Type {
+		case ButtonPress:
+			b.pressed = true
+		case ButtonRelease:
+			b.pressed = false
+		}
+		handlers := b.handlers[e.Type]
+		b.mu.Unlock()
+		for _, fn := range handlers {
+			fn()
+		}
+	}
+}
+
+// Sensor is an analog input, such as a potentiometer or light sensor:
+//
+//	client.Sensor(14).OnChange(func(value int) {
+//		led.Brightness(byte(value / 4))
+//	})
+//
+// The pin is set to Analog and reporting enabled when the sensor is made.
+type Sensor struct {
+	client *FirmataClient
+	pin    byte
+	err    error
+}
+
+// Sensor returns the analog sensor on pin.
+func (c *FirmataClient) Sensor(pin byte) *Sensor {
+	s := &Sensor{client: c, pin: pin}
+	if err := c.Pin(pin).ensureMode(Analog); err != nil {
+		s.fail(err)
+	} else if err := c.EnableAnalogInput(uint(pin), true); err != nil {
+		s.fail(err)
+	}
+	return s
+}
+
+// Value returns the last reading of the sensor.
+func (s *Sensor) Value() (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	return s.client.AnalogRead(uint(s.pin))
+}
+
+// Scaled returns the last reading of the sensor, converted by the
+// calibration of its pin.
+func (s *Sensor) Scaled() (float64, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	return s.client.AnalogReadScaled(uint(s.pin))
+}
+
+// OnChange adds a handler called with each reading which differs from the
+// previous one. Handlers run as for OnAnalogChange.
+func (s *Sensor) OnChange(fn func(value int)) *Sensor {
+	s.client.OnAnalogChange(s.pin, fn)
+	return s
+}
+
+// Err returns the error setting up the sensor, if it could not be set up.
+// The error is also logged.
+func (s *Sensor) Err() error {
+	return s.err
+}
+
+func (s *Sensor) fail(err error) {
+	s.err = err
+	s.client.Log.Warn("Sensor on pin %v: %s", s.client.PinLabel(s.pin), err.Error())
+}
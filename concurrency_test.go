@@ -0,0 +1,457 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"code.google.com/p/log4go"
+)
+
+// These tests use one client from many goroutines, and are meant to be
+// run with go test -race.
+
+// testBoard is the board end of a pipe to the client. It answers the
+// connection handshake, and an I2C read with the register read, and
+// fails the test if a frame from the client is cut off by another.
+type testBoard struct {
+	t    *testing.T
+	in   *io.PipeReader
+	out  *io.PipeWriter
+	done chan bool
+
+	mu      sync.Mutex
+	digital [16]byte
+	modes   map[byte]PinMode
+
+	// Replies are queued, so the board never blocks a client which is
+	// itself writing to the board.
+	outMu   sync.Mutex
+	outCond *sync.Cond
+	queued  []byte
+	closed  bool
+}
+
+// testConn is the client end of the pipes to a testBoard.
+type testConn struct {
+	io.Reader
+	io.Writer
+	closers []io.Closer
+}
+
+func (c testConn) Close() error {
+	for _, cl := range c.closers {
+		cl.Close()
+	}
+	return nil
+}
+
+func newTestBoard(t *testing.T) (*testBoard, io.ReadWriteCloser) {
+	fromClient, toBoard := io.Pipe()
+	fromBoard, toClient := io.Pipe()
+	b := &testBoard{
+		t:     t,
+		in:    fromClient,
+		out:   toClient,
+		done:  make(chan bool),
+		modes: make(map[byte]PinMode),
+	}
+	b.outCond = sync.NewCond(&b.outMu)
+	go b.run()
+	go b.write()
+	return b, testConn{fromBoard, toBoard, []io.Closer{fromBoard, toBoard, fromClient, toClient}}
+}
+
+func (b *testBoard) send(frame ...byte) {
+	b.outMu.Lock()
+	defer b.outMu.Unlock()
+	b.queued = append(b.queued, frame...)
+	b.outCond.Signal()
+}
+
+// write writes the queued replies to the client until the board stops.
+func (b *testBoard) write() {
+	b.outMu.Lock()
+	defer b.outMu.Unlock()
+	for {
+		for len(b.queued) == 0 && !b.closed {
+			b.outCond.Wait()
+		}
+		if b.closed {
+			return
+		}
+		out := b.queued
+		b.queued = nil
+		b.outMu.Unlock()
+		_, err := b.out.Write(out)
+		b.outMu.Lock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// run reads frames from the client until the pipe is closed.
+func (b *testBoard) run() {
+	defer close(b.done)
+	defer func() {
+		b.outMu.Lock()
+		b.closed = true
+		b.outCond.Signal()
+		b.outMu.Unlock()
+	}()
+	r := bufio.NewReader(b.in)
+	for {
+		cmd, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+		if cmd < 0x80 {
+			b.t.Errorf("data byte 0x%x outside a frame", cmd)
+			continue
+		}
+		var n int
+		switch {
+		case cmd == byte(StartSysEx):
+			n = -1
+		case cmd == byte(SystemReset) || cmd == byte(ReportVersion):
+			n = 0
+		case cmd == byte(SetPinMode) || cmd&0xF0 == byte(DigitalMessage) || cmd&0xF0 == byte(AnalogMessage):
+			n = 2
+		case cmd&0xF0 == byte(EnableAnalogInput) || cmd&0xF0 == byte(EnableDigitalInput):
+			n = 1
+		default:
+			b.t.Errorf("unexpected command byte 0x%x", cmd)
+			continue
+		}
+		var data []byte
+		for n != 0 {
+			d, err := r.ReadByte()
+			if err != nil {
+				return
+			}
+			if n < 0 && d == byte(EndSysEx) {
+				break
+			}
+			if d >= 0x80 && !(n < 0 && len(data) == 0) {
+				b.t.Errorf("frame 0x%x % x cut off by 0x%x", cmd, data, d)
+				break
+			}
+			data = append(data, d)
+			n--
+		}
+		b.handle(cmd, data)
+	}
+}
+
+func (b *testBoard) handle(cmd byte, data []byte) {
+	switch {
+	case cmd == byte(SystemReset):
+		b.send(byte(ReportVersion), 2, 5)
+		b.send(byte(StartSysEx), byte(ReportFirmware), 2, 5, 't', 0, 'e', 0, 's', 0, 't', 0, byte(EndSysEx))
+	case cmd == byte(SetPinMode):
+		b.mu.Lock()
+		b.modes[data[0]] = PinMode(data[1])
+		b.mu.Unlock()
+	case cmd&0xF0 == byte(DigitalMessage):
+		b.mu.Lock()
+		b.digital[cmd&0x0F] = data[0] | data[1]<<7
+		b.mu.Unlock()
+	case cmd == byte(StartSysEx) && len(data) > 0:
+		b.handleSysEx(SysExCommand(data[0]), data[1:])
+	}
+}
+
+func (b *testBoard) handleSysEx(cmd SysExCommand, data []byte) {
+	switch cmd {
+	case ReportFirmware:
+		b.send(byte(StartSysEx), byte(ReportFirmware), 2, 5, 't', 0, 'e', 0, 's', 0, 't', 0, byte(EndSysEx))
+	case AnalogMappingQuery:
+		// Pins 14 to 19 are analog inputs 0 to 5, as on an Uno.
+		frame := []byte{byte(StartSysEx), byte(AnalogMappingResponse)}
+		for pin := byte(0); pin < 20; pin++ {
+			if pin >= 14 {
+				frame = append(frame, pin-14)
+			} else {
+				frame = append(frame, 127)
+			}
+		}
+		b.send(append(frame, byte(EndSysEx))...)
+	case CapabilityQuery:
+		frame := []byte{byte(StartSysEx), byte(CapabilityResponse)}
+		for pin := byte(0); pin < 20; pin++ {
+			frame = append(frame, byte(Input), 1, byte(Output), 1, byte(PWM), 8)
+			if pin >= 14 {
+				frame = append(frame, byte(Analog), 10)
+			}
+			frame = append(frame, 127)
+		}
+		b.send(append(frame, byte(EndSysEx))...)
+	case I2CRequest:
+		if len(data) < 6 || I2CMode(data[1]) != I2CModeRead {
+			return
+		}
+		b.send(byte(StartSysEx), byte(I2CReply), data[0], 0, data[2], data[3], data[2], data[3], byte(EndSysEx))
+	}
+}
+
+// digitalPin returns the level the client last wrote to pin.
+func (b *testBoard) digitalPin(pin byte) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.digital[pin/8]&(1<<(pin%8)) != 0
+}
+
+// reportAnalog sends readings of every analog pin to the client until stop
+// is closed.
+func (b *testBoard) reportAnalog(stop chan bool, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for v := 0; ; v++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			for ch := byte(0); ch < 6; ch++ {
+				b.send(byte(AnalogMessage)|ch, byte(v&0x7f), byte(v>>7&0x07))
+			}
+			time.Sleep(100 * time.Microsecond)
+		}
+	}()
+}
+
+// newTestClient connects a client which logs nothing to a testBoard. The
+// client is closed at the end of the test.
+func newTestClient(t *testing.T) (*FirmataClient, *testBoard) {
+	b, conn := newTestBoard(t)
+	logger := make(log4go.Logger)
+	c, err := NewClient(conn, WithLogger(&logger), WithConnectTimeout(5*time.Second, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		c.Close()
+		conn.Close()
+		<-b.done
+	})
+	return c, b
+}
+
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); !cond(); {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	c, b := newTestClient(t)
+	var wg sync.WaitGroup
+	// Each writer toggles its own pin, ending high.
+	for pin := byte(2); pin < 10; pin++ {
+		wg.Add(1)
+		go func(p *Pin) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if err := p.SetMode(Output); err != nil {
+					t.Error(err)
+					return
+				}
+				if err := p.Write(i%2 == 1); err != nil {
+					t.Error(err)
+					return
+				}
+				p.Mode()
+			}
+		}(c.Pin(pin))
+	}
+	wg.Wait()
+	for pin := byte(2); pin < 10; pin++ {
+		pin := pin
+		eventually(t, "pin outputs", func() bool { return b.digitalPin(pin) })
+	}
+}
+
+func TestConcurrentI2C(t *testing.T) {
+	c, _ := newTestClient(t)
+	if err := c.I2CConfig(0); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				register := 0x20 + g*8 + i%8
+				data, err := c.I2CRead(0x40, register, 1)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if len(data) != 1 || int(data[0]) != register {
+					t.Errorf("read of register 0x%x got % x", register, data)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func TestConcurrentReads(t *testing.T) {
+	c, b := newTestClient(t)
+	stop := make(chan bool)
+	var background sync.WaitGroup
+	b.reportAnalog(stop, &background)
+	defer background.Wait()
+	defer close(stop)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				c.AnalogRead(uint(14 + g))
+				c.DigitalRead(uint(2 + g))
+				c.LabelPin("pin", byte(2+g))
+				c.PinLabel(byte(2 + i%8))
+			}
+		}(g)
+	}
+	wg.Wait()
+	eventually(t, "analog reports", func() bool {
+		_, err := c.AnalogRead(14)
+		return err == nil
+	})
+}
+
+func TestConcurrentEvents(t *testing.T) {
+	c, b := newTestClient(t)
+	if err := c.EnableDigitalInput(0, true); err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	changes := 0
+	remove := c.OnDigitalChange(2, func(bool) {
+		mu.Lock()
+		changes++
+		mu.Unlock()
+	})
+	defer remove()
+	c.OnAnalogChange(14, func(int) {})
+
+	stop := make(chan bool)
+	var background sync.WaitGroup
+	b.reportAnalog(stop, &background)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		for high := true; ; high = !high {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if high {
+				b.send(byte(DigitalMessage), 0x7c, 0)
+			} else {
+				b.send(byte(DigitalMessage), 0, 0)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	// Subscriptions come and go while events are sent to them.
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			policy := []OverflowPolicy{DropNewest, DropOldest}[g%2]
+			for i := 0; i < 10; i++ {
+				d := c.SubscribeDigital(2, policy)
+				a := c.SubscribeAnalog(2, policy, 14, 15)
+				for n := 0; n < 3; n++ {
+					select {
+					case <-d.C:
+					case <-a.C:
+					case <-time.After(time.Second):
+						t.Error("no events")
+						return
+					}
+				}
+				d.Close()
+				a.Close()
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(stop)
+	background.Wait()
+
+	eventually(t, "digital callbacks", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return changes > 0
+	})
+}
+
+func TestConcurrentClose(t *testing.T) {
+	c, _ := newTestClient(t)
+	if err := c.I2CConfig(0); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				var err error
+				if g%2 == 0 {
+					err = c.DigitalWrite(uint(2+g), i%2 == 0)
+				} else {
+					_, err = c.I2CRead(0x40, g, 1)
+				}
+				if errors.Is(err, ErrNotConnected) {
+					return
+				}
+			}
+		}(g)
+	}
+	time.Sleep(20 * time.Millisecond)
+	closed := make(chan bool)
+	go func() {
+		c.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not return")
+	}
+	wg.Wait()
+}
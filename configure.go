@@ -0,0 +1,156 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// LoadBoardConfig reads and checks a BoardConfig from a JSON file. The
+// firmatayaml package reads YAML files too.
+func LoadBoardConfig(path string) (*BoardConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return UnmarshalBoardConfig(data)
+}
+
+// Configure makes the board match b, so a deployment can be rewired by
+// editing its config file and configuring the board again:
+//
+//	config, err := firmatayaml.Load("board.yaml")
+//	...
+//	err = client.Configure(config)
+//
+// It is ApplyBoardConfig, after undoing what an earlier configuration set
+// that b does not: labels, reporting and calibrations b does not give a
+// pin are removed, and drivers b does not name are detached. Drivers b
+// names with the same type and settings are kept, rather than attached
+// again. Modes and outputs of pins b does not set are left as they are.
+func (c *FirmataClient) Configure(b *BoardConfig) error {
+	if err := b.check(); err != nil {
+		return err
+	}
+	if b.Board != "" {
+		c.SetBoard(findBoard(b.Board))
+	}
+	// Pins may be named by their labels, so are resolved before any label
+	// is removed.
+	next := *b
+	next.Pins = make(map[string]*PinConfig, len(b.Pins))
+	pins := make(map[byte]*PinConfig)
+	for name, p := range b.Pins {
+		if p == nil {
+			continue
+		}
+		n, err := c.PinNumber(name)
+		if err != nil {
+			return err
+		}
+		if pins[n] != nil {
+			return fmt.Errorf("Pin %v is configured twice", c.PinLabel(n))
+		}
+		pins[n] = p
+		next.Pins[strconv.Itoa(int(n))] = p
+	}
+	// Digital reporting is by port, so a port stays enabled while any pin
+	// of it is to be reported.
+	reportPorts := make(map[byte]bool)
+	for n, p := range pins {
+		if p.Report && !c.configuredAnalog(n, p) {
+			reportPorts[n/8] = true
+		}
+	}
+
+	current := c.BoardConfig()
+	for key, old := range current.Pins {
+		n64, _ := strconv.ParseUint(key, 10, 8)
+		n := byte(n64)
+		p := pins[n]
+		if p == nil {
+			p = &PinConfig{}
+		}
+		if old.Label != "" && old.Label != p.Label {
+			c.UnlabelPin(n)
+		}
+		if old.Calibration != nil && p.Calibration == nil {
+			c.SetCalibration(n, nil)
+		}
+		if !old.Report || p.Report {
+			continue
+		}
+		var err error
+		if mode, _ := c.Pin(n).Mode(); mode == Analog {
+			err = c.EnableAnalogInput(uint(n), false)
+		} else if !reportPorts[n/8] {
+			err = c.EnableDigitalInput(uint(n), false)
+		}
+		if err != nil {
+			return fmt.Errorf("remove reporting of pin %v: %w", c.PinLabel(n), err)
+		}
+	}
+
+	drivers := make(map[string]*DriverConfig)
+	for _, d := range b.Drivers {
+		drivers[d.Name] = d
+	}
+	kept := make(map[string]bool)
+	for _, old := range current.Drivers {
+		if d := drivers[old.Name]; d != nil && sameDriver(d, old) {
+			kept[old.Name] = true
+			continue
+		}
+		c.DetachDriver(old.Name)
+	}
+	next.Drivers = nil
+	for _, d := range b.Drivers {
+		if !kept[d.Name] {
+			next.Drivers = append(next.Drivers, d)
+		}
+	}
+	return c.ApplyBoardConfig(&next)
+}
+
+// configuredAnalog returns whether pin n will be an analog input once p
+// is applied.
+func (c *FirmataClient) configuredAnalog(n byte, p *PinConfig) bool {
+	if p.Mode != "" {
+		mode, _ := ParsePinMode(p.Mode)
+		return mode == Analog
+	}
+	mode, _ := c.Pin(n).Mode()
+	return mode == Analog
+}
+
+// sameDriver returns whether a and b are drivers of the same type with the
+// same settings, ignoring how the settings are laid out.
+func sameDriver(a, b *DriverConfig) bool {
+	if a.Type != b.Type {
+		return false
+	}
+	var sa, sb bytes.Buffer
+	if len(a.Settings) > 0 && json.Compact(&sa, a.Settings) != nil {
+		return false
+	}
+	if len(b.Settings) > 0 && json.Compact(&sb, b.Settings) != nil {
+		return false
+	}
+	return bytes.Equal(sa.Bytes(), sb.Bytes())
+}
@@ -70,6 +70,11 @@ const (
 	SerialFlush  SerialSubCommand = 0x30
 	SerialClose  SerialSubCommand = 0x40
 
+	I2CModeWrite          I2CMode = 0x00
+	I2CModeRead           I2CMode = 0x08
+	I2CModeReadContinuous I2CMode = 0x10
+	I2CModeStopReading    I2CMode = 0x18
+
 	SPIConfig SPISubCommand = 0x10
 	SPIComm   SPISubCommand = 0x20
 	
@@ -81,6 +86,10 @@ const (
   OneWireConfig OneWireSubCommand = 0x41
   OneWireSearch OneWireSubCommand = 0x40
   OneWireSearchAlarms OneWireSubCommand = 0x44
+  // Replies from the board.
+  OneWireSearchReply OneWireSubCommand = 0x42
+  OneWireReadReply OneWireSubCommand = 0x43
+  OneWireSearchAlarmsReply OneWireSubCommand = 0x45
 
   OneWirePowerNormal = 0x0
   OneWirePowerParasitic = 0x1
@@ -106,6 +115,7 @@ const (
 	Shift  PinMode = 0x05
 	I2C    PinMode = 0x06
 	SPI    PinMode = 0x07
+	Pullup PinMode = 0x0B
 )
 
 func (m PinMode) String() string {
@@ -124,6 +134,8 @@ func (m PinMode) String() string {
 		return fmt.Sprintf("Shift pin (0x%x)", byte(m))
 	case m == I2C:
 		return fmt.Sprintf("I2C pin (0x%x)", byte(m))
+	case m == Pullup:
+		return fmt.Sprintf("Pullup pin (0x%x)", byte(m))
 	}
 	return fmt.Sprintf("Unknown pin (0x%x)", byte(m))
 }
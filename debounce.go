@@ -0,0 +1,98 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"time"
+)
+
+// SetDebounce makes the client ignore changes of a digital input pin which
+// last less than d. A change is only passed to DigitalRead, callbacks and
+// subscriptions once the pin has held its new value for d, and is stamped
+// with the time it was first reported. Zero turns debouncing off. The raw
+// reports are still sent on the channel given to WithValues.
+func (c *FirmataClient) SetDebounce(pin byte, d time.Duration) {
+	c.inputMu.Lock()
+	defer c.inputMu.Unlock()
+	if d <= 0 {
+		delete(c.debounce, pin)
+		if tm, ok := c.debounceTimers[pin]; ok {
+			tm.Stop()
+			delete(c.debounceTimers, pin)
+		}
+		// Catch up with any change which was being held back.
+		c.commitDigital(pin/8, 1<<(pin%8), c.digitalRaw[pin/8], c.now())
+		return
+	}
+	if c.debounce == nil {
+		c.debounce = make(map[byte]time.Duration)
+	}
+	c.debounce[pin] = d
+}
+
+// Debounce returns the debounce time of pin.
+func (c *FirmataClient) Debounce(pin byte) time.Duration {
+	c.inputMu.Lock()
+	defer c.inputMu.Unlock()
+	return c.debounce[pin]
+}
+
+// debounceMask returns the pins of port with a debounce time. inputMu is
+// held.
+func (c *FirmataClient) debounceMask(port byte) (mask byte) {
+	for bit := byte(0); bit < 8; bit++ {
+		if c.debounce[port*8+bit] > 0 {
+			mask |= 1 << bit
+		}
+	}
+	return
+}
+
+// debounceBit starts, restarts or cancels the debounce timer of a pin
+// after a report. bounced is set if the report changed the pin. inputMu is
+// held.
+func (c *FirmataClient) debounceBit(port byte, bit byte, bounced bool, t time.Time) {
+	pin := port*8 + bit
+	mask := byte(1) << bit
+	tm, pending := c.debounceTimers[pin]
+	if (c.digitalRaw[port]^c.digitalInputs[port])&mask == 0 {
+		// Back to the accepted value before the timer ran out.
+		if pending {
+			tm.Stop()
+			delete(c.debounceTimers, pin)
+		}
+		return
+	}
+	if pending {
+		if !bounced {
+			return
+		}
+		tm.Stop()
+	}
+	if c.debounceTimers == nil {
+		c.debounceTimers = make(map[byte]Timer)
+	}
+	var timer Timer
+	timer = c.Clock().AfterFunc(c.debounce[pin], func() {
+		c.inputMu.Lock()
+		defer c.inputMu.Unlock()
+		if c.debounceTimers[pin] != timer {
+			return
+		}
+		delete(c.debounceTimers, pin)
+		c.commitDigital(port, mask, c.digitalRaw[port], t)
+	})
+	c.debounceTimers[pin] = timer
+}
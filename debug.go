@@ -0,0 +1,252 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// debugFrames is the number of recent frames kept for DebugInfo.
+const debugFrames = 32
+
+// DebugInfo is the internal state of a client, for inspecting a
+// misbehaving one. It is encoded as JSON by DebugHandler and DebugVar.
+type DebugInfo struct {
+	// Connected is false once the connection is lost, until it is made
+	// again, and once the client is closed.
+	Connected bool `json:"connected"`
+	// ConnectionChanged is when the connection was last lost or made
+	// again, or nil if it has not changed.
+	ConnectionChanged *time.Time `json:"connection_changed,omitempty"`
+	// Firmware is the firmware name and version, and Board the name of
+	// the board profile.
+	Firmware string `json:"firmware"`
+	Board    string `json:"board,omitempty"`
+	// Queues are the client queues, by name.
+	Queues map[string]DebugQueue `json:"queues"`
+	// ProtocolErrors counts the protocol errors, by type.
+	ProtocolErrors map[string]uint64 `json:"protocol_errors,omitempty"`
+	// LastProtocolError is the most recent protocol error.
+	LastProtocolError string `json:"last_protocol_error,omitempty"`
+	// Requests counts the requests to the board, by operation.
+	Requests map[string]DebugRequests `json:"requests,omitempty"`
+	// Frames are the most recent frames sent and received, oldest first.
+	Frames []DebugFrame `json:"frames"`
+	// Pins are the pins with their modes and last known values.
+	Pins []DebugPin `json:"pins"`
+}
+
+// DebugQueue is the state of a client queue.
+type DebugQueue struct {
+	Size   int    `json:"size"`
+	Policy string `json:"policy"`
+	// Depth is the number of items waiting when the queue was last used.
+	Depth int    `json:"depth"`
+	Drops uint64 `json:"drops"`
+}
+
+// DebugRequests counts the requests of an operation, such as "I2C read".
+type DebugRequests struct {
+	Count     uint64     `json:"count"`
+	Errors    uint64     `json:"errors"`
+	LastError string     `json:"last_error,omitempty"`
+	ErrorTime *time.Time `json:"error_time,omitempty"`
+}
+
+// DebugFrame is a frame sent to or received from the board.
+type DebugFrame struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"`
+	Command   string    `json:"command"`
+	Data      string    `json:"data"`
+}
+
+// DebugPin is a pin and its last known value.
+type DebugPin struct {
+	Number byte   `json:"number"`
+	Label  string `json:"label,omitempty"`
+	Mode   string `json:"mode,omitempty"`
+	Value  *int   `json:"value,omitempty"`
+}
+
+// debugState is what the client keeps for DebugInfo that it does not keep
+// anyway.
+type debugState struct {
+	mu                sync.Mutex
+	lost              bool
+	changed           time.Time
+	frames            [debugFrames]TraceFrame
+	nextFrame         int
+	depths            map[string]int
+	protocolErrors    map[ProtocolErrorType]uint64
+	lastProtocolError string
+	requests          map[string]*DebugRequests
+}
+
+// frame keeps a frame, reusing the buffer of the one it replaces since
+// data is the reader's.
+func (s *debugState) frame(d TraceDirection, t time.Time, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := &s.frames[s.nextFrame]
+	f.Direction, f.Time = d, t
+	f.Data = append(f.Data[:0], data...)
+	s.nextFrame = (s.nextFrame + 1) % debugFrames
+}
+
+func (s *debugState) queueDepth(queue string, depth int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.depths == nil {
+		s.depths = make(map[string]int)
+	}
+	s.depths[queue] = depth
+}
+
+func (s *debugState) protocolError(e *ProtocolError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.protocolErrors == nil {
+		s.protocolErrors = make(map[ProtocolErrorType]uint64)
+	}
+	s.protocolErrors[e.Type]++
+	s.lastProtocolError = e.Error()
+}
+
+func (s *debugState) requestDone(op string, err error, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.requests == nil {
+		s.requests = make(map[string]*DebugRequests)
+	}
+	r := s.requests[op]
+	if r == nil {
+		r = &DebugRequests{}
+		s.requests[op] = r
+	}
+	r.Count++
+	if err != nil {
+		r.Errors++
+		r.LastError = err.Error()
+		r.ErrorTime = &t
+	}
+}
+
+func (s *debugState) connectionChanged(connected bool, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lost = !connected
+	s.changed = t
+}
+
+// DebugInfo returns the current internal state of the client.
+func (c *FirmataClient) DebugInfo() *DebugInfo {
+	f := c.Firmware()
+	info := &DebugInfo{
+		Firmware: fmt.Sprintf("%s %d.%d", f.Name, f.Major, f.Minor),
+		Queues:   make(map[string]DebugQueue),
+	}
+	if b := c.Board(); b != nil {
+		info.Board = b.Name
+	}
+	drops := c.AllDrops()
+
+	s := &c.debug
+	s.mu.Lock()
+	info.Connected = !s.lost && !c.closed()
+	if !s.changed.IsZero() {
+		changed := s.changed
+		info.ConnectionChanged = &changed
+	}
+	for name, q := range c.queues {
+		info.Queues[name] = DebugQueue{
+			Size:   q.Size,
+			Policy: q.Policy.String(),
+			Depth:  s.depths[name],
+			Drops:  drops[name],
+		}
+	}
+	if len(s.protocolErrors) > 0 {
+		info.ProtocolErrors = make(map[string]uint64)
+		for t, n := range s.protocolErrors {
+			info.ProtocolErrors[t.String()] = n
+		}
+	}
+	info.LastProtocolError = s.lastProtocolError
+	if len(s.requests) > 0 {
+		info.Requests = make(map[string]DebugRequests)
+		for op, r := range s.requests {
+			info.Requests[op] = *r
+		}
+	}
+	for i := 0; i < debugFrames; i++ {
+		f := s.frames[(s.nextFrame+i)%debugFrames]
+		if f.Data == nil {
+			continue
+		}
+		info.Frames = append(info.Frames, DebugFrame{
+			Time:      f.Time,
+			Direction: f.Direction.String(),
+			Command:   f.Command(),
+			Data:      fmt.Sprintf("% x", f.Data),
+		})
+	}
+	s.mu.Unlock()
+
+	for _, p := range c.Pins() {
+		pin := DebugPin{Number: p.Number, Label: p.Label}
+		if p.Label == fmt.Sprint(p.Number) {
+			pin.Label = ""
+		}
+		if p.ModeSet {
+			pin.Mode = p.Mode.Name()
+		}
+		if p.HasValue {
+			v := p.Value
+			pin.Value = &v
+		}
+		info.Pins = append(info.Pins, pin)
+	}
+	return info
+}
+
+// DebugHandler returns an http.Handler serving DebugInfo as JSON, to
+// mount on a debug or admin server:
+//
+//	http.Handle("/debug/firmata", client.DebugHandler())
+func (c *FirmataClient) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := json.MarshalIndent(c.DebugInfo(), "", "  ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	})
+}
+
+// DebugVar returns an expvar.Var of DebugInfo, to publish under a name of
+// the caller's choosing alongside the other variables at /debug/vars:
+//
+//	expvar.Publish("firmata", client.DebugVar())
+func (c *FirmataClient) DebugVar() expvar.Var {
+	return expvar.Func(func() interface{} { return c.DebugInfo() })
+}
@@ -0,0 +1,301 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"io"
+)
+
+const (
+	// maxSysExSize is the longest SysEx message accepted. A longer one is
+	// taken to have lost its end byte, and is dropped.
+	maxSysExSize = 16 * 1024
+	// maxJunk is the number of discarded data bytes kept for reporting.
+	maxJunk = 64
+)
+
+// decodeState is where the decoder is in the byte stream.
+type decodeState byte
+
+const (
+	// awaitCommand is between messages, skipping data bytes.
+	awaitCommand decodeState = iota
+	// inMessage is collecting the data bytes of a fixed length message.
+	inMessage
+	// inSysEx is collecting a SysEx message, up to its end byte.
+	inSysEx
+)
+
+// decoder splits the byte stream from the board into frames. It is a
+// state machine fed a byte at a time, so after garbage, such as boot noise
+// or the tail of a frame cut off by a reconnect, it picks up again at the
+// next command byte instead of misreading what follows.
+//
+// Only command bytes have the top bit set, so a data byte between messages
+// is skipped, and a command byte inside a message cuts it off and starts
+// the next. The exception is the byte after StartSysEx, which is the SysEx
+// command and may have the top bit set, as SysExSPI does.
+type decoder struct {
+	// lengths gives the length of the messages in the stream, for the
+	// direction it goes in.
+	lengths func(b byte) (int, bool)
+	state   decodeState
+	frame   []byte
+	// want is the number of data bytes inMessage still needs.
+	want int
+	// junk is the start of the run of data bytes being skipped, and
+	// skipped its full length.
+	junk    []byte
+	skipped int
+
+	// onFrame is called with each complete frame, which is only valid
+	// during the call.
+	onFrame func(frame []byte)
+	// onError is called with bytes which were discarded, and why.
+	onError func(t ProtocolErrorType, err error, data []byte)
+}
+
+func newDecoder(onFrame func([]byte), onError func(ProtocolErrorType, error, []byte)) *decoder {
+	return &decoder{
+		lengths: messageLength,
+		frame:   make([]byte, 0, readBufferSize),
+		junk:    make([]byte, 0, maxJunk),
+		onFrame: onFrame,
+		onError: onError,
+	}
+}
+
+// messageLength returns the number of data bytes which follow command byte
+// b, or -1 for a SysEx message, and false if b does not start a message the
+// board sends.
+func messageLength(b byte) (int, bool) {
+	switch {
+	case b == byte(StartSysEx):
+		return -1, true
+	case b == byte(ReportVersion):
+		return 2, true
+	case b&0xF0 == byte(DigitalMessage) || b&0xF0 == byte(AnalogMessage):
+		return 2, true
+	}
+	return 0, false
+}
+
+// sentMessageLength is messageLength for messages the client sends.
+func sentMessageLength(b byte) (int, bool) {
+	switch {
+	case b == byte(StartSysEx):
+		return -1, true
+	case b == byte(ReportVersion) || b == byte(SystemReset):
+		return 0, true
+	case b == byte(SetPinMode):
+		return 2, true
+	case b&0xF0 == byte(DigitalMessage) || b&0xF0 == byte(AnalogMessage):
+		return 2, true
+	case b&0xF0 == byte(EnableAnalogInput) || b&0xF0 == byte(EnableDigitalInput):
+		return 1, true
+	}
+	return 0, false
+}
+
+// write feeds data to the decoder.
+func (d *decoder) write(data []byte) {
+	for _, b := range data {
+		d.feed(b)
+	}
+}
+
+func (d *decoder) feed(b byte) {
+	if b&0x80 == 0 || d.state == inSysEx && len(d.frame) == 1 {
+		switch d.state {
+		case awaitCommand:
+			if len(d.junk) < maxJunk {
+				d.junk = append(d.junk, b)
+			}
+			d.skipped++
+		case inMessage:
+			d.frame = append(d.frame, b)
+			if d.want--; d.want == 0 {
+				d.complete()
+			}
+		case inSysEx:
+			if len(d.frame) >= maxSysExSize {
+				d.fail(MalformedMessage, fmt.Errorf("SysEx message longer than %v bytes", maxSysExSize))
+				return
+			}
+			d.frame = append(d.frame, b)
+		}
+		return
+	}
+
+	if d.state == inSysEx && b == byte(EndSysEx) {
+		d.frame = append(d.frame, b)
+		d.complete()
+		return
+	}
+	if d.state != awaitCommand {
+		d.fail(MalformedMessage, fmt.Errorf("%v cut off by command byte %#x", FirmataCommand(d.frame[0]), b))
+	}
+	d.reportJunk()
+	d.frame = append(d.frame[:0], b)
+	n, ok := d.lengths(b)
+	switch {
+	case !ok:
+		d.fail(UnexpectedCommand, fmt.Errorf("unexpected command byte %#x", b))
+	case n < 0:
+		d.state = inSysEx
+	case n == 0:
+		d.complete()
+	default:
+		d.state, d.want = inMessage, n
+	}
+}
+
+// end is called when the stream ends with err, reporting anything left
+// over.
+func (d *decoder) end(err error) {
+	d.reportJunk()
+	if d.state != awaitCommand {
+		d.fail(ShortRead, err)
+	}
+}
+
+func (d *decoder) complete() {
+	d.state = awaitCommand
+	d.onFrame(d.frame)
+	d.frame = d.frame[:0]
+}
+
+// fail discards the frame being assembled.
+func (d *decoder) fail(t ProtocolErrorType, err error) {
+	d.state = awaitCommand
+	d.onError(t, err, d.frame)
+	d.frame = d.frame[:0]
+}
+
+func (d *decoder) reportJunk() {
+	if d.skipped == 0 {
+		return
+	}
+	d.onError(UnexpectedCommand, fmt.Errorf("skipped %v data bytes outside a message", d.skipped), d.junk)
+	d.junk = d.junk[:0]
+	d.skipped = 0
+}
+
+// Message is a frame decoded by a Decoder, or bytes it discarded.
+type Message struct {
+	// Frame is the whole message, including the command byte and for SysEx
+	// the start and end bytes, or for an error the bytes discarded.
+	Frame []byte
+	// Err is set for bytes which were discarded, rather than decoded.
+	Err *ProtocolError
+}
+
+// Command returns the message command, without the port, pin or channel
+// of digital and analog messages.
+func (m Message) Command() FirmataCommand {
+	if m.Err != nil || len(m.Frame) == 0 {
+		return 0
+	}
+	cmd := FirmataCommand(m.Frame[0])
+	if cmd < StartSysEx {
+		cmd &= 0xF0
+	}
+	return cmd
+}
+
+// Channel returns the port, pin or channel of a digital, analog or report
+// enabling message, which is in the low bits of the command byte.
+func (m Message) Channel() byte {
+	if m.Command() >= StartSysEx {
+		return 0
+	}
+	return m.Frame[0] & 0x0F
+}
+
+// SysEx returns the SysEx command of a SysEx message.
+func (m Message) SysEx() SysExCommand {
+	if m.Command() != StartSysEx || len(m.Frame) < 3 {
+		return 0
+	}
+	return SysExCommand(m.Frame[1])
+}
+
+// Data returns the data bytes of the message: the payload of a SysEx
+// message, without the command and end byte.
+func (m Message) Data() []byte {
+	switch {
+	case m.Err != nil || len(m.Frame) == 0:
+		return nil
+	case m.Command() == StartSysEx:
+		if len(m.Frame) < 3 {
+			return nil
+		}
+		return m.Frame[2 : len(m.Frame)-1]
+	}
+	return m.Frame[1:]
+}
+
+func (m Message) String() string {
+	if m.Err != nil {
+		return m.Err.Error()
+	}
+	return fmt.Sprintf("%s: % x", TraceFrame{Data: m.Frame}.Command(), m.Frame)
+}
+
+// Decoder splits a Firmata byte stream into messages, as the client does,
+// for building transports and protocol analysers. Garbage in the stream
+// is reported and skipped, and decoding picks up again at the next
+// command byte. A Decoder is not safe for concurrent use.
+type Decoder struct {
+	d   *decoder
+	out []Message
+}
+
+// NewDecoder creates a decoder for traffic going in direction dir:
+// Received for messages from the board, Sent for those from the client.
+func NewDecoder(dir TraceDirection) *Decoder {
+	d := &Decoder{}
+	d.d = newDecoder(func(frame []byte) {
+		d.out = append(d.out, Message{Frame: append([]byte(nil), frame...)})
+	}, func(t ProtocolErrorType, err error, data []byte) {
+		e := &ProtocolError{Type: t, Err: err, Data: append([]byte(nil), data...)}
+		d.out = append(d.out, Message{Frame: e.Data, Err: e})
+	})
+	if dir == Sent {
+		d.d.lengths = sentMessageLength
+	}
+	return d
+}
+
+// Feed decodes data, returning the messages it completes. A message may
+// be split across calls. The messages are the caller's to keep.
+func (d *Decoder) Feed(data []byte) []Message {
+	d.out = nil
+	d.d.write(data)
+	return d.out
+}
+
+// End ends the stream, returning errors for any skipped bytes and any
+// incomplete message, which is a ShortRead with err, or io.ErrUnexpectedEOF
+// if err is nil. The decoder can be fed again.
+func (d *Decoder) End(err error) []Message {
+	if err == nil {
+		err = io.ErrUnexpectedEOF
+	}
+	d.out = nil
+	d.d.end(err)
+	return d.out
+}
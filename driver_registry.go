@@ -0,0 +1,230 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DriverFactory creates and initialises a driver from its settings in a
+// BoardConfig, which may be empty.
+type DriverFactory func(client *FirmataClient, settings json.RawMessage) (interface{}, error)
+
+var (
+	driversMu sync.RWMutex
+	// drivers are the driver types of BoardConfig. The settings of most
+	// are the exported fields of the driver, which default to those of
+	// its datasheet.
+	drivers = map[string]DriverFactory{
+		"apds9960": structDriver(func(c *FirmataClient) *Apds9960 { return &Apds9960{Client: c, Address: Apds9960Address} }),
+		"bme280":   structDriver(func(c *FirmataClient) *Bme280 { return &Bme280{Client: c, Address: Bme280Address} }),
+		"ds3231":   structDriver(func(c *FirmataClient) *Ds3231 { return &Ds3231{Client: c, Address: Ds3231Address} }),
+		"hd44780": structDriver(func(c *FirmataClient) *Hd44780 {
+			return &Hd44780{Client: c, Address: Hd44780Address, Cols: 16, Rows: 2}
+		}),
+		"htu21d":   structDriver(func(c *FirmataClient) *Htu21d { return &Htu21d{Client: c, Address: Htu21dAddress} }),
+		"max31865": structDriver(func(c *FirmataClient) *Max31865 { return &Max31865{Client: c, Nominal: 100, Reference: 430} }),
+		"mcp23017": structDriver(func(c *FirmataClient) *Mcp23017 { return &Mcp23017{Client: c, Address: Mcp23017Address} }),
+		"mcp4725":  structDriver(func(c *FirmataClient) *Mcp4725 { return &Mcp4725{Client: c, Address: Mcp4725Address} }),
+		"sht31":    structDriver(func(c *FirmataClient) *Sht31 { return &Sht31{Client: c, Address: Sht31Address} }),
+		"ssd1306": structDriver(func(c *FirmataClient) *Ssd1306 {
+			return &Ssd1306{Client: c, Address: Ssd1306Address, Width: 128, Height: 64}
+		}),
+		"shift_register": structDriver(func(c *FirmataClient) *ShiftRegister { return &ShiftRegister{Client: c, Count: 1} }),
+		"vl53l0x":        structDriver(func(c *FirmataClient) *Vl53l0x { return &Vl53l0x{Client: c, Address: Vl53l0xAddress} }),
+		"bh1750":         newBh1750Driver,
+		"mpu6050":        newMpu6050Driver,
+		"pca9685":        newPca9685Driver,
+		"button":         newButtonDriver,
+		"relay":          newRelayDriver,
+		"rgbled":         newRgbLedDriver,
+		"onewire":        newOneWireDriver,
+	}
+)
+
+// RegisterDriver sets the factory used for drivers of type typ in a
+// BoardConfig, replacing any existing one.
+func RegisterDriver(typ string, factory DriverFactory) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	drivers[typ] = factory
+}
+
+// DriverTypes returns the driver types a BoardConfig may use, sorted.
+func DriverTypes() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	var types []string
+	for typ := range drivers {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func driverFactory(typ string) DriverFactory {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+	return drivers[typ]
+}
+
+// decodeSettings decodes driver settings into v, rejecting unknown fields
+// so a misspelt setting is not silently left at its default.
+func decodeSettings(settings json.RawMessage, v interface{}) error {
+	if len(bytes.TrimSpace(settings)) == 0 {
+		return nil
+	}
+	d := json.NewDecoder(bytes.NewReader(settings))
+	d.DisallowUnknownFields()
+	if err := d.Decode(v); err != nil {
+		return fmt.Errorf("Bad driver settings: %w", err)
+	}
+	return nil
+}
+
+// structDriver is the factory of a driver whose settings are its exported
+// fields, calling its Init method if it has one.
+func structDriver[T any](newDriver func(c *FirmataClient) *T) DriverFactory {
+	return func(c *FirmataClient, settings json.RawMessage) (interface{}, error) {
+		d := newDriver(c)
+		if err := decodeSettings(settings, d); err != nil {
+			return nil, err
+		}
+		if i, ok := interface{}(d).(interface{ Init() error }); ok {
+			if err := i.Init(); err != nil {
+				return nil, err
+			}
+		}
+		return d, nil
+	}
+}
+
+func newBh1750Driver(c *FirmataClient, settings json.RawMessage) (interface{}, error) {
+	s := struct {
+		Address byte
+		Mode    Bh1750Mode
+	}{Bh1750Address, Bh1750High}
+	if err := decodeSettings(settings, &s); err != nil {
+		return nil, err
+	}
+	d := &Bh1750{Client: c, Address: s.Address}
+	if err := d.Init(s.Mode); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func newMpu6050Driver(c *FirmataClient, settings json.RawMessage) (interface{}, error) {
+	s := struct {
+		Address byte
+		Accel   AccelRange
+		Gyro    GyroRange
+	}{Address: Mpu6050Address}
+	if err := decodeSettings(settings, &s); err != nil {
+		return nil, err
+	}
+	d := &Mpu6050{Client: c, Address: s.Address}
+	if err := d.Init(s.Accel, s.Gyro); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func newPca9685Driver(c *FirmataClient, settings json.RawMessage) (interface{}, error) {
+	// 50Hz suits servos, the usual load.
+	s := struct {
+		Address   byte
+		Frequency float64
+	}{Pca9685Address, 50}
+	if err := decodeSettings(settings, &s); err != nil {
+		return nil, err
+	}
+	d := &Pca9685{Client: c, Address: s.Address}
+	if err := d.Init(s.Frequency); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// newButtonDriver creates a Button, which is left for its user to Start
+// as that returns its events.
+func newButtonDriver(c *FirmataClient, settings json.RawMessage) (interface{}, error) {
+	// The settings are the fields of the button, over its defaults.
+	b := NewButton(c, 0)
+	if err := decodeSettings(settings, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func newRelayDriver(c *FirmataClient, settings json.RawMessage) (interface{}, error) {
+	var s struct {
+		Pin                  byte
+		ActiveLow, SafeState bool
+	}
+	if err := decodeSettings(settings, &s); err != nil {
+		return nil, err
+	}
+	return NewRelay(c, s.Pin, s.ActiveLow, s.SafeState)
+}
+
+func newRgbLedDriver(c *FirmataClient, settings json.RawMessage) (interface{}, error) {
+	var s struct {
+		RedPin, GreenPin, BluePin byte
+		CommonAnode               bool
+		Gamma                     float64
+	}
+	if err := decodeSettings(settings, &s); err != nil {
+		return nil, err
+	}
+	l, err := NewRgbLed(c, s.RedPin, s.GreenPin, s.BluePin)
+	if err != nil {
+		return nil, err
+	}
+	l.CommonAnode = s.CommonAnode
+	if s.Gamma > 0 {
+		l.Gamma = s.Gamma
+	}
+	return l, nil
+}
+
+// newOneWireDriver configures the bus on the pin and returns the driver
+// NewOneWireDevice gives for the address, a hex string.
+func newOneWireDriver(c *FirmataClient, settings json.RawMessage) (interface{}, error) {
+	var s struct {
+		Pin     byte
+		Address string
+		Power   byte
+	}
+	if err := decodeSettings(settings, &s); err != nil {
+		return nil, err
+	}
+	address, err := hex.DecodeString(s.Address)
+	if err != nil || len(address) != 8 {
+		return nil, fmt.Errorf("Bad OneWire address %q", s.Address)
+	}
+	if !OneWireAddress(address).Valid() {
+		return nil, fmt.Errorf("OneWire address %q: %w", s.Address, ErrBadCrc)
+	}
+	if err := c.OneWireConfig(s.Pin, s.Power); err != nil {
+		return nil, err
+	}
+	return NewOneWireDevice(c, s.Pin, address), nil
+}
@@ -0,0 +1,213 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const (
+	// Family codes of the supported EEPROMs.
+	Ds2431Family = 0x2d
+	Ds2433Family = 0x23
+
+	ds243xWriteScratchpad = 0x0f
+	ds243xReadScratchpad  = 0xaa
+	ds243xCopyScratchpad  = 0x55
+	ds243xReadMemory      = 0xf0
+
+	// ds243xReadChunk is the most data fetched by a single read request.
+	ds243xReadChunk = 32
+	// ds243xProgramMs is the worst case copy scratchpad programming time.
+	ds243xProgramMs = 10
+)
+
+// Ds243x is a Maxim DS2431 (1Kbit) or DS2433 (4Kbit) EEPROM.
+type Ds243x struct {
+	// The client.
+	Client *FirmataClient
+	// The pin the bus is on.
+	Pin byte
+	// The address of the device on the bus.
+	Address OneWireAddress
+}
+
+// Size returns the memory size of the device in bytes.
+func (d *Ds243x) Size() int {
+	if d.Address[0] == Ds2433Family {
+		return 512
+	}
+	return 128
+}
+
+// RowSize returns the size of the scratchpad, which is the unit of writes.
+func (d *Ds243x) RowSize() int {
+	if d.Address[0] == Ds2433Family {
+		return 32
+	}
+	return 8
+}
+
+// Read reads length bytes of memory starting at offset.
+func (d *Ds243x) Read(offset int, length int) ([]byte, error) {
+	if offset < 0 || length < 0 || offset+length > d.Size() {
+		return nil, fmt.Errorf("read of %d bytes at 0x%x is outside the %d byte memory", length, offset, d.Size())
+	}
+	var data []byte
+	for length > 0 {
+		n := length
+		if n > ds243xReadChunk {
+			n = ds243xReadChunk
+		}
+		req := OneWireRequest{
+			Command:       OW_RESET | OW_SELECT | OW_WRITE | OW_READ,
+			Address:       d.Address,
+			ReadCount:     int32(n),
+			CorrelationId: 0x1234,
+			Data:          []byte{ds243xReadMemory, byte(offset), byte(offset >> 8)},
+		}
+		resp, err := d.Client.OneWireCommand(d.Pin, req)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp) < n+2 {
+			return nil, fmt.Errorf("short read at 0x%x: got %d bytes, want %d", offset, len(resp)-2, n)
+		}
+		data = append(data, resp[2:n+2]...)
+		offset += n
+		length -= n
+	}
+	return data, nil
+}
+
+// Write writes data to memory starting at offset, which must be aligned
+// to RowSize. A trailing partial row is merged with the current memory
+// contents before being written.
+func (d *Ds243x) Write(offset int, data []byte) error {
+	row := d.RowSize()
+	if offset%row != 0 {
+		return fmt.Errorf("write offset 0x%x is not aligned to the %d byte row size", offset, row)
+	}
+	if offset < 0 || offset+len(data) > d.Size() {
+		return fmt.Errorf("write of %d bytes at 0x%x is outside the %d byte memory", len(data), offset, d.Size())
+	}
+	if rem := len(data) % row; rem != 0 {
+		tail, err := d.Read(offset+len(data), row-rem)
+		if err != nil {
+			return err
+		}
+		data = append(append([]byte{}, data...), tail...)
+	}
+	for i := 0; i < len(data); i += row {
+		if err := d.writeRow(offset+i, data[i:i+row]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeRow writes one row via the scratchpad, verifying it before copying
+// it into memory.
+func (d *Ds243x) writeRow(offset int, data []byte) error {
+	ta1, ta2 := byte(offset), byte(offset>>8)
+
+	// Write scratchpad, reading back the inverted CRC16 of the transfer.
+	cmd := append([]byte{ds243xWriteScratchpad, ta1, ta2}, data...)
+	req := OneWireRequest{
+		Command:       OW_RESET | OW_SELECT | OW_WRITE | OW_READ,
+		Address:       d.Address,
+		ReadCount:     2,
+		CorrelationId: 0x1234,
+		Data:          cmd,
+	}
+	resp, err := d.Client.OneWireCommand(d.Pin, req)
+	if err != nil {
+		return err
+	}
+	if len(resp) < 4 {
+		return fmt.Errorf("short write scratchpad response at 0x%x", offset)
+	}
+	if err := ds243xCheckCrc(d.Client, cmd, resp[2:4]); err != nil {
+		return err
+	}
+
+	// Read scratchpad to confirm the target address and contents.
+	req = OneWireRequest{
+		Command:       OW_RESET | OW_SELECT | OW_WRITE | OW_READ,
+		Address:       d.Address,
+		ReadCount:     int32(3 + len(data) + 2),
+		CorrelationId: 0x1234,
+		Data:          []byte{ds243xReadScratchpad},
+	}
+	resp, err = d.Client.OneWireCommand(d.Pin, req)
+	if err != nil {
+		return err
+	}
+	if len(resp) < int(req.ReadCount)+2 {
+		return fmt.Errorf("short read scratchpad response at 0x%x", offset)
+	}
+	scratch := resp[2 : req.ReadCount+2]
+	payload := scratch[:len(scratch)-2]
+	if err := ds243xCheckCrc(d.Client, append([]byte{ds243xReadScratchpad}, payload...), scratch[len(scratch)-2:]); err != nil {
+		return err
+	}
+	es := payload[2]
+	if payload[0] != ta1 || payload[1] != ta2 {
+		return fmt.Errorf("scratchpad address mismatch! Wrote 0x%x, read 0x%x", offset, int(payload[0])|int(payload[1])<<8)
+	}
+	if es&0x20 > 0 {
+		return fmt.Errorf("scratchpad partial write at 0x%x (E/S 0x%x)", offset, es)
+	}
+	if !bytes.Equal(payload[3:], data) {
+		return fmt.Errorf("scratchpad data mismatch at 0x%x! Wrote 0x%x, read 0x%x", offset, data, payload[3:])
+	}
+
+	// Copy scratchpad with the authorization pattern, then check the
+	// device reports a successful copy.
+	req = OneWireRequest{
+		Command:       OW_RESET | OW_SELECT | OW_WRITE | OW_DELAY,
+		Address:       d.Address,
+		CorrelationId: 0x1234,
+		DelayMs:       ds243xProgramMs,
+		Data:          []byte{ds243xCopyScratchpad, ta1, ta2, es},
+	}
+	if _, err := d.Client.OneWireCommand(d.Pin, req); err != nil {
+		return err
+	}
+	req = OneWireRequest{
+		Command:       OW_READ,
+		ReadCount:     1,
+		CorrelationId: 0x1234,
+	}
+	resp, err = d.Client.OneWireCommand(d.Pin, req)
+	if err != nil {
+		return err
+	}
+	if len(resp) < 3 || (resp[2] != 0xaa && resp[2] != 0x55) {
+		return fmt.Errorf("copy scratchpad failed at 0x%x", offset)
+	}
+	return nil
+}
+
+// ds243xCheckCrc verifies the inverted CRC16 sent by the device over data.
+func ds243xCheckCrc(client *FirmataClient, data []byte, crcBytes []byte) error {
+	crc := ^(uint16(crcBytes[0]) | uint16(crcBytes[1])<<8)
+	c := OneWireCrc16(data)
+	if c != crc {
+		return client.crcError(crc, c, data)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"time"
+)
+
+// AlarmMatch selects which fields of the alarm time must match the clock
+// for a Ds3231 alarm to fire.
+type AlarmMatch byte
+
+const (
+	// AlarmEvery fires every second for alarm 1, or every minute for
+	// alarm 2.
+	AlarmEvery AlarmMatch = iota
+	// AlarmMatchSeconds fires when the seconds match. Alarm 1 only.
+	AlarmMatchSeconds
+	// AlarmMatchMinutes fires when the minutes (and seconds) match.
+	AlarmMatchMinutes
+	// AlarmMatchHours fires when the hours, minutes (and seconds) match.
+	AlarmMatchHours
+	// AlarmMatchDate fires when the day of the month and time match.
+	AlarmMatchDate
+)
+
+const (
+	// Ds3231Address is the I2C address of the DS3231 and DS1307.
+	Ds3231Address = 0x68
+
+	rtcTime    = 0x00
+	rtcAlarm1  = 0x07
+	rtcAlarm2  = 0x0b
+	rtcControl = 0x0e
+	rtcStatus  = 0x0f
+
+	// rtcIntcn routes alarms to the INT/SQW pin.
+	rtcIntcn = 0x04
+	// rtcHalt is the DS1307 clock halt bit in the seconds register.
+	rtcHalt = 0x80
+	// rtcCentury is the DS3231 century bit in the month register.
+	rtcCentury = 0x80
+)
+
+// Ds3231 is a Maxim DS3231 real-time clock, or the DS1307 which has no
+// alarms. Times are stored in 24 hour format.
+type Ds3231 struct {
+	// The client.
+	Client *FirmataClient
+	// The I2C address of the device.
+	Address byte
+	// Ds1307 is set if the device is a DS1307.
+	Ds1307 bool
+	// Location is the time zone the clock is kept in, UTC if nil.
+	Location *time.Location
+}
+
+// GetTime reads the current time from the clock.
+func (d *Ds3231) GetTime() (time.Time, error) {
+	data, err := d.Client.I2CRead(d.Address, rtcTime, 7)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(data) < 7 {
+		return time.Time{}, fmt.Errorf("short time register read")
+	}
+	if d.Ds1307 && data[0]&rtcHalt > 0 {
+		return time.Time{}, fmt.Errorf("clock is halted, set the time to start it")
+	}
+	year := 2000 + fromBcd(data[6])
+	if !d.Ds1307 && data[5]&rtcCentury > 0 {
+		year += 100
+	}
+	t := time.Date(year, time.Month(fromBcd(data[5]&0x1f)), fromBcd(data[4]&0x3f),
+		rtcHour(data[2]), fromBcd(data[1]&0x7f), fromBcd(data[0]&0x7f), 0, d.location())
+	return t, nil
+}
+
+// SetTime sets the clock, starting it if it was halted.
+func (d *Ds3231) SetTime(t time.Time) error {
+	t = t.In(d.location())
+	if t.Year() < 2000 || t.Year() > 2199 || (d.Ds1307 && t.Year() > 2099) {
+		return fmt.Errorf("year %v is out of range", t.Year())
+	}
+	month := toBcd(int(t.Month()))
+	if t.Year() >= 2100 {
+		month |= rtcCentury
+	}
+	return d.Client.I2CWrite(d.Address, rtcTime,
+		toBcd(t.Second()), toBcd(t.Minute()), toBcd(t.Hour()),
+		byte(t.Weekday())+1, toBcd(t.Day()), month, toBcd(t.Year()%100))
+}
+
+// SetAlarm sets alarm 1 or 2 to fire at t, matching the fields selected
+// by match, and enables it on the INT/SQW pin.
+func (d *Ds3231) SetAlarm(alarm int, t time.Time, match AlarmMatch) error {
+	if d.Ds1307 {
+		return fmt.Errorf("%w: the DS1307 has no alarms", ErrFeatureMissing)
+	}
+	t = t.In(d.location())
+	// Each register has a mask bit which, when set, excludes the field
+	// from the match.
+	regs := []byte{toBcd(t.Second()), toBcd(t.Minute()), toBcd(t.Hour()), toBcd(t.Day())}
+	for i := range regs {
+		if i >= int(match) {
+			regs[i] |= 0x80
+		}
+	}
+	reg := byte(rtcAlarm1)
+	switch alarm {
+	case 1:
+	case 2:
+		if match == AlarmMatchSeconds {
+			return fmt.Errorf("alarm 2 cannot match seconds")
+		}
+		reg, regs = rtcAlarm2, regs[1:]
+	default:
+		return fmt.Errorf("invalid alarm %v", alarm)
+	}
+	if err := d.Client.I2CWrite(d.Address, append([]byte{reg}, regs...)...); err != nil {
+		return err
+	}
+	if err := d.ClearAlarm(alarm); err != nil {
+		return err
+	}
+	ctl, err := d.Client.I2CRead(d.Address, rtcControl, 1)
+	if err != nil {
+		return err
+	}
+	if len(ctl) < 1 {
+		return fmt.Errorf("short control register read")
+	}
+	return d.Client.I2CWrite(d.Address, rtcControl, ctl[0]|rtcIntcn|byte(alarm))
+}
+
+// AlarmFired reports whether alarm 1 or 2 has fired since it was last
+// cleared.
+func (d *Ds3231) AlarmFired(alarm int) (bool, error) {
+	status, err := d.Client.I2CRead(d.Address, rtcStatus, 1)
+	if err != nil {
+		return false, err
+	}
+	if len(status) < 1 {
+		return false, fmt.Errorf("short status register read")
+	}
+	return status[0]&byte(alarm) > 0, nil
+}
+
+// ClearAlarm clears the flag of alarm 1 or 2, releasing the INT/SQW pin.
+func (d *Ds3231) ClearAlarm(alarm int) error {
+	if alarm != 1 && alarm != 2 {
+		return fmt.Errorf("invalid alarm %v", alarm)
+	}
+	status, err := d.Client.I2CRead(d.Address, rtcStatus, 1)
+	if err != nil {
+		return err
+	}
+	if len(status) < 1 {
+		return fmt.Errorf("short status register read")
+	}
+	return d.Client.I2CWrite(d.Address, rtcStatus, status[0]&^byte(alarm))
+}
+
+func (d *Ds3231) location() *time.Location {
+	if d.Location == nil {
+		return time.UTC
+	}
+	return d.Location
+}
+
+// rtcHour decodes an hours register in either 12 or 24 hour format.
+func rtcHour(b byte) int {
+	if b&0x40 == 0 {
+		return fromBcd(b & 0x3f)
+	}
+	h := fromBcd(b&0x1f) % 12
+	if b&0x20 > 0 {
+		h += 12
+	}
+	return h
+}
+
+func toBcd(v int) byte {
+	return byte(v/10<<4 | v%10)
+}
+
+func fromBcd(b byte) int {
+	return int(b>>4)*10 + int(b&0xf)
+}
@@ -0,0 +1,103 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Edge selects the transitions of a digital input to watch for.
+type Edge byte
+
+const (
+	Rising  Edge = 0x1
+	Falling Edge = 0x2
+	Both    Edge = Rising | Falling
+)
+
+func (e Edge) String() string {
+	switch e {
+	case Rising:
+		return "Rising"
+	case Falling:
+		return "Falling"
+	case Both:
+		return "Both"
+	}
+	return fmt.Sprintf("Unknown edge (0x%x)", byte(e))
+}
+
+// EdgeWatch is a stream of transitions of a digital input.
+type EdgeWatch struct {
+	// C receives an event for each matching transition.
+	C <-chan DigitalEvent
+
+	sub  *DigitalSubscription
+	stop chan bool
+	once sync.Once
+}
+
+// WatchEdge enables reporting of pin and sends its transitions matching
+// edge on the returned watch. If minInterval is non-zero, transitions
+// less than minInterval after the last one sent are dropped.
+func (c *FirmataClient) WatchEdge(pin byte, edge Edge, minInterval time.Duration) (*EdgeWatch, error) {
+	if edge&Both == 0 {
+		return nil, fmt.Errorf("No edge selected")
+	}
+	if err := c.EnableDigitalInput(uint(pin), true); err != nil {
+		return nil, err
+	}
+	out := make(chan DigitalEvent, 10)
+	w := &EdgeWatch{
+		C:    out,
+		sub:  c.SubscribeDigital(10, DropOldest, pin),
+		stop: make(chan bool),
+	}
+	go func() {
+		defer close(out)
+		var last time.Time
+		for {
+			select {
+			case e, ok := <-w.sub.C:
+				if !ok {
+					return
+				}
+				if (e.Value && edge&Rising == 0) || (!e.Value && edge&Falling == 0) {
+					continue
+				}
+				if minInterval > 0 && !last.IsZero() && e.Time.Sub(last) < minInterval {
+					continue
+				}
+				last = e.Time
+				select {
+				case out <- e:
+				case <-w.stop:
+					return
+				}
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+	return w, nil
+}
+
+// Stop stops the watch and closes C. Stopping it again does nothing.
+func (w *EdgeWatch) Stop() {
+	w.sub.Close()
+	w.once.Do(func() { close(w.stop) })
+}
@@ -0,0 +1,86 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors returned by the client and drivers wrap one of these, so can be
+// tested with errors.Is. ErrTimeout is with the timeout handling.
+var (
+	// ErrNotConnected is returned once the client has been closed.
+	ErrNotConnected = errors.New("not connected")
+	// ErrBadCrc is returned when data from a device fails its CRC check.
+	// The error is a *CrcError.
+	ErrBadCrc = errors.New("crc mismatch")
+	// ErrUnsupportedPinMode is returned when a pin is used in a mode it
+	// does not support.
+	ErrUnsupportedPinMode = errors.New("pin mode not supported")
+	// ErrInvalidPin is returned for a pin number the board or device does
+	// not have.
+	ErrInvalidPin = errors.New("invalid pin number")
+	// ErrFeatureMissing is returned when a request needs a feature which
+	// has not been configured, or which the firmware lacks.
+	ErrFeatureMissing = errors.New("feature not available")
+)
+
+// CrcError is returned when data from a device fails its CRC check.
+type CrcError struct {
+	// Received is the CRC sent by the device, and Calculated the CRC of
+	// Data.
+	Received, Calculated uint16
+	// Data is the data the CRC covers.
+	Data []byte
+}
+
+func (e *CrcError) Error() string {
+	return fmt.Sprintf("crc mismatch! Received 0x%x, calculated 0x%x! [0x%x]", e.Received, e.Calculated, e.Data)
+}
+
+// Unwrap makes errors.Is(err, ErrBadCrc) true.
+func (e *CrcError) Unwrap() error {
+	return ErrBadCrc
+}
+
+// PinModeError is returned when a pin is set to a mode the board does not
+// report it supports.
+type PinModeError struct {
+	// Pin is the pin number, and Label its label.
+	Pin   byte
+	Label string
+	// Mode is the mode requested.
+	Mode PinMode
+	// Supported are the modes the pin does support.
+	Supported []PinMode
+}
+
+func (e *PinModeError) Error() string {
+	modes := make([]string, len(e.Supported))
+	for i, m := range e.Supported {
+		modes[i] = m.String()
+	}
+	if len(modes) == 0 {
+		return fmt.Sprintf("%v: %v by pin %v, which supports no modes", ErrUnsupportedPinMode, e.Mode, e.Label)
+	}
+	return fmt.Sprintf("%v: %v by pin %v, which supports %v", ErrUnsupportedPinMode, e.Mode, e.Label, strings.Join(modes, ", "))
+}
+
+// Unwrap makes errors.Is(err, ErrUnsupportedPinMode) true.
+func (e *PinModeError) Unwrap() error {
+	return ErrUnsupportedPinMode
+}
@@ -0,0 +1,237 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// OverflowPolicy is what a subscription does with an event when its
+// buffer is full.
+type OverflowPolicy byte
+
+const (
+	// DropNewest discards the new event.
+	DropNewest OverflowPolicy = iota
+	// DropOldest discards the oldest buffered event to make room.
+	DropOldest
+	// Block waits for the subscriber to make room. This holds up the
+	// reader, and so every other report and reply from the board.
+	Block
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case DropNewest:
+		return "DropNewest"
+	case DropOldest:
+		return "DropOldest"
+	case Block:
+		return "Block"
+	}
+	return fmt.Sprintf("Unknown overflow policy (0x%x)", byte(p))
+}
+
+// DigitalEvent reports a change of a digital input pin.
+type DigitalEvent struct {
+	// Pin is the pin number.
+	Pin byte
+	// Label is the pin label, or its number if it has none.
+	Label string
+	// Value is the new value, and Previous the one before it.
+	Value, Previous bool
+	// Time is when the report was received.
+	Time time.Time
+}
+
+// AnalogEvent reports a reading of an analog input pin.
+type AnalogEvent struct {
+	// Pin is the pin number.
+	Pin byte
+	// Label is the pin label, or its number if it has none.
+	Label string
+	// Value is the new reading, and Previous the one before it. Previous is
+	// zero for the first reading.
+	Value, Previous int
+	// Scaled is Value converted by the pin calibration, or Value itself if
+	// the pin has none.
+	Scaled float64
+	// Time is when the report was received.
+	Time time.Time
+}
+
+// DigitalSubscription is a stream of DigitalEvents.
+type DigitalSubscription struct {
+	// C receives the events.
+	C <-chan DigitalEvent
+
+	c      *FirmataClient
+	ch     chan DigitalEvent
+	pins   map[byte]bool
+	policy OverflowPolicy
+	mu     sync.Mutex
+	closed bool
+	done   chan bool
+	once   sync.Once
+}
+
+// AnalogSubscription is a stream of AnalogEvents.
+type AnalogSubscription struct {
+	// C receives the events.
+	C <-chan AnalogEvent
+
+	c      *FirmataClient
+	ch     chan AnalogEvent
+	pins   map[byte]bool
+	policy OverflowPolicy
+	mu     sync.Mutex
+	closed bool
+	done   chan bool
+	once   sync.Once
+}
+
+// SubscribeDigital returns a stream of changes to the digital input pins,
+// or to every pin if none are given, buffering up to buffer events and
+// applying policy when the buffer is full. Reporting must be enabled for
+// the pins with EnableDigitalInput.
+func (c *FirmataClient) SubscribeDigital(buffer int, policy OverflowPolicy, pins ...byte) *DigitalSubscription {
+	s := &DigitalSubscription{
+		c:      c,
+		ch:     make(chan DigitalEvent, buffer),
+		pins:   pinSet(pins),
+		policy: policy,
+		done:   make(chan bool),
+	}
+	s.C = s.ch
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	if c.digitalSubs == nil {
+		c.digitalSubs = make(map[*DigitalSubscription]bool)
+	}
+	c.digitalSubs[s] = true
+	return s
+}
+
+// SubscribeAnalog returns a stream of readings of the analog pins, or of
+// every pin if none are given, as for SubscribeDigital. Reporting must be
+// enabled for the pins with EnableAnalogInput.
+func (c *FirmataClient) SubscribeAnalog(buffer int, policy OverflowPolicy, pins ...byte) *AnalogSubscription {
+	s := &AnalogSubscription{
+		c:      c,
+		ch:     make(chan AnalogEvent, buffer),
+		pins:   pinSet(pins),
+		policy: policy,
+		done:   make(chan bool),
+	}
+	s.C = s.ch
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	if c.analogSubs == nil {
+		c.analogSubs = make(map[*AnalogSubscription]bool)
+	}
+	c.analogSubs[s] = true
+	return s
+}
+
+// Close ends the subscription and closes C.
+func (s *DigitalSubscription) Close() {
+	// Release a blocked send first, as it holds the locks.
+	s.once.Do(func() { close(s.done) })
+	s.c.subMu.Lock()
+	delete(s.c.digitalSubs, s)
+	s.c.subMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// Close ends the subscription and closes C.
+func (s *AnalogSubscription) Close() {
+	// Release a blocked send first, as it holds the locks.
+	s.once.Do(func() { close(s.done) })
+	s.c.subMu.Lock()
+	delete(s.c.analogSubs, s)
+	s.c.subMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+func (s *DigitalSubscription) send(e DigitalEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || (len(s.pins) > 0 && !s.pins[e.Pin]) {
+		return
+	}
+	deliverPolicy(s.c, QueueSubscriptions, s.policy, s.done, s.ch, e)
+}
+
+func (s *AnalogSubscription) send(e AnalogEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || (len(s.pins) > 0 && !s.pins[e.Pin]) {
+		return
+	}
+	deliverPolicy(s.c, QueueSubscriptions, s.policy, s.done, s.ch, e)
+}
+
+// publishDigital sends events for the pins of port set in changed.
+func (c *FirmataClient) publishDigital(port byte, changed byte, value byte, t time.Time) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	if len(c.digitalSubs) == 0 {
+		return
+	}
+	for bit := byte(0); bit < 8; bit++ {
+		if changed&(1<<bit) == 0 {
+			continue
+		}
+		pin := port*8 + bit
+		high := value&(1<<bit) > 0
+		e := DigitalEvent{Pin: pin, Label: c.PinLabel(pin), Value: high, Previous: !high, Time: t}
+		for s := range c.digitalSubs {
+			s.send(e)
+		}
+	}
+}
+
+// publishAnalog sends an event for a reading of an analog pin.
+func (c *FirmataClient) publishAnalog(pin byte, value, previous int, t time.Time) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	if len(c.analogSubs) == 0 {
+		return
+	}
+	e := AnalogEvent{Pin: pin, Label: c.PinLabel(pin), Value: value, Previous: previous, Scaled: c.scale(pin, value), Time: t}
+	for s := range c.analogSubs {
+		s.send(e)
+	}
+}
+
+func pinSet(pins []byte) map[byte]bool {
+	set := make(map[byte]bool)
+	for _, p := range pins {
+		set[p] = true
+	}
+	return set
+}
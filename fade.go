@@ -0,0 +1,128 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// fadeStep is the interval between PWM writes of an effect.
+const fadeStep = 20 * time.Millisecond
+
+// Effect is a PWM effect running on a pin.
+type Effect struct {
+	stop chan bool
+	done chan bool
+	once sync.Once
+}
+
+// Stop cancels the effect, leaving the pin at its current duty, and waits
+// for it to finish.
+func (e *Effect) Stop() {
+	e.once.Do(func() { close(e.stop) })
+	<-e.done
+}
+
+// Done is closed when the effect finishes or is stopped.
+func (e *Effect) Done() <-chan bool {
+	return e.done
+}
+
+// Fade sets pin to PWM and changes its duty from from to to over d. It
+// returns immediately. Any effect already running on the pin is stopped.
+func (c *FirmataClient) Fade(pin byte, from, to byte, d time.Duration) (*Effect, error) {
+	return c.startEffect(pin, func(elapsed time.Duration) (byte, bool) {
+		if elapsed >= d {
+			return to, false
+		}
+		f := float64(elapsed) / float64(d)
+		return byte(float64(from) + (float64(to)-float64(from))*f + 0.5), true
+	})
+}
+
+// Breathe sets pin to PWM and smoothly varies its duty between min and max
+// and back every period, until stopped. It returns immediately. Any effect
+// already running on the pin is stopped. The period must be positive and
+// min no more than max.
+func (c *FirmataClient) Breathe(pin byte, min, max byte, period time.Duration) (*Effect, error) {
+	if period <= 0 {
+		return nil, fmt.Errorf("Breathe period %v is not positive", period)
+	}
+	if min > max {
+		return nil, fmt.Errorf("Breathe minimum %v is above maximum %v", min, max)
+	}
+	return c.startEffect(pin, func(elapsed time.Duration) (byte, bool) {
+		phase := float64(elapsed%period) / float64(period)
+		f := (1 - math.Cos(2*math.Pi*phase)) / 2
+		return byte(float64(min) + (float64(max)-float64(min))*f + 0.5), true
+	})
+}
+
+// startEffect runs step on pin every fadeStep, writing the duty it
+// returns, until it returns false or the effect is stopped.
+func (c *FirmataClient) startEffect(pin byte, step func(time.Duration) (byte, bool)) (*Effect, error) {
+	p := c.Pin(pin)
+	if err := p.ensureMode(PWM); err != nil {
+		return nil, err
+	}
+	e := &Effect{stop: make(chan bool), done: make(chan bool)}
+	c.effectMu.Lock()
+	old := c.effects[pin]
+	if c.effects == nil {
+		c.effects = make(map[byte]*Effect)
+	}
+	c.effects[pin] = e
+	c.effectMu.Unlock()
+	if old != nil {
+		old.Stop()
+	}
+
+	go func() {
+		defer close(e.done)
+		defer func() {
+			c.effectMu.Lock()
+			if c.effects[pin] == e {
+				delete(c.effects, pin)
+			}
+			c.effectMu.Unlock()
+		}()
+		t := c.Clock().NewTicker(fadeStep)
+		defer t.Stop()
+		start := c.now()
+		last := -1
+		for {
+			duty, more := step(c.since(start))
+			if int(duty) != last {
+				if err := c.AnalogWrite(uint(pin), duty); err != nil {
+					c.Log.Warn("PWM effect on pin %v: %s", c.PinLabel(pin), err.Error())
+					return
+				}
+				last = int(duty)
+			}
+			if !more {
+				return
+			}
+			select {
+			case <-t.C():
+			case <-e.stop:
+				return
+			}
+		}
+	}()
+	return e, nil
+}
@@ -0,0 +1,444 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package firmataconform checks that a board's firmware follows the
+// Firmata protocol, for validating third party firmwares:
+//
+//	report := firmataconform.Run(ctx, client, &firmataconform.Config{
+//		Wires: []firmataconform.Wire{{Output: 2, Input: 3}},
+//	})
+//	fmt.Print(report)
+//
+// Each feature is reported as passed, failed or skipped. Round trips need
+// pins wired together, as a board cannot otherwise read back its outputs;
+// they are skipped if none are given. The simulated board of firmatatest
+// can be wired with Board.Wire to run the whole suite.
+//
+// The suite changes pin modes and drives outputs, so run it on a board
+// with nothing attached but the wires, or limit it with Config.Pins.
+package firmataconform
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/buxtronix/go-firmata"
+)
+
+// Status is the outcome of a feature.
+type Status int
+
+const (
+	Pass Status = iota
+	Fail
+	Skip
+)
+
+func (s Status) String() string {
+	switch s {
+	case Pass:
+		return "PASS"
+	case Fail:
+		return "FAIL"
+	case Skip:
+		return "SKIP"
+	}
+	return fmt.Sprintf("Unknown Status (0x%x)", int(s))
+}
+
+// Wire is a pair of pins wired together.
+type Wire struct {
+	// Output is driven by the suite, and Input read back.
+	Output, Input byte
+}
+
+// Config configures the suite.
+type Config struct {
+	// Pins are the pins whose modes may be changed. If nil, all pins
+	// are. Wired pins are always used.
+	Pins []byte
+	// Wires are digital pins wired together, for digital round trips.
+	Wires []Wire
+	// AnalogWires are PWM outputs wired to analog inputs through a low
+	// pass filter, for analog round trips.
+	AnalogWires []Wire
+	// Settle is how long to wait for an input to follow its output, or
+	// for an analog input to be reported. The default is 500ms.
+	Settle time.Duration
+	// Tolerance is how far an analog round trip may read from the value
+	// written, as a fraction of full scale. The default is 0.1.
+	Tolerance float64
+}
+
+// Result is the outcome of a feature of the suite.
+type Result struct {
+	// Feature is the name of the feature, such as "capabilities".
+	Feature string
+	Status  Status
+	// Detail explains a failure or skip, or summarises what passed.
+	Detail string
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%v %s: %s", r.Status, r.Feature, r.Detail)
+}
+
+// Report is the results of a run of the suite.
+type Report struct {
+	// Firmware is the firmware the board reported.
+	Firmware firmata.FirmwareInfo
+	Results  []Result
+}
+
+// Passed returns true if no feature failed.
+func (r *Report) Passed() bool {
+	for _, res := range r.Results {
+		if res.Status == Fail {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the report with a line per feature.
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "firmware %s %d.%d\n", r.Firmware.Name, r.Firmware.Major, r.Firmware.Minor)
+	for _, res := range r.Results {
+		fmt.Fprintln(&b, res.String())
+	}
+	return b.String()
+}
+
+// suite is a run of the suite.
+type suite struct {
+	ctx    context.Context
+	client firmata.Firmata
+	config Config
+	pins   map[byte]firmata.PinInfo
+}
+
+// features is the features of the suite, in the order they are run.
+var features = []struct {
+	name string
+	run  func(s *suite) (Status, string)
+}{
+	{"version", (*suite).version},
+	{"firmware", (*suite).firmware},
+	{"capabilities", (*suite).capabilities},
+	{"analog mapping", (*suite).analogMapping},
+	{"pin modes", (*suite).pinModes},
+	{"sampling interval", (*suite).samplingInterval},
+	{"analog input", (*suite).analogInput},
+	{"digital round trip", (*suite).digitalRoundTrip},
+	{"analog round trip", (*suite).analogRoundTrip},
+}
+
+// Run runs the suite against the board client is connected to. A nil
+// config runs it with the defaults and no wires. Features are run in
+// order, and all are run even if some fail, until ctx is done.
+func Run(ctx context.Context, client firmata.Firmata, config *Config) *Report {
+	s := &suite{ctx: ctx, client: client}
+	if config != nil {
+		s.config = *config
+	}
+	if s.config.Settle <= 0 {
+		s.config.Settle = 500 * time.Millisecond
+	}
+	if s.config.Tolerance <= 0 {
+		s.config.Tolerance = 0.1
+	}
+	r := &Report{}
+	for _, f := range features {
+		res := Result{Feature: f.name, Status: Skip}
+		if err := ctx.Err(); err != nil {
+			res.Detail = err.Error()
+		} else {
+			res.Status, res.Detail = f.run(s)
+		}
+		r.Results = append(r.Results, res)
+	}
+	r.Firmware = client.Firmware()
+	return r
+}
+
+func (s *suite) version() (Status, string) {
+	rtt, err := s.client.Ping(s.ctx)
+	if err != nil {
+		return Fail, fmt.Sprintf("no version report: %v", err)
+	}
+	return Pass, fmt.Sprintf("reported in %v", rtt)
+}
+
+func (s *suite) firmware() (Status, string) {
+	info, err := s.client.QueryFirmware(s.ctx)
+	if err != nil {
+		return Fail, fmt.Sprintf("no firmware report: %v", err)
+	}
+	if info.Name == "" {
+		return Fail, "firmware name is empty"
+	}
+	return Pass, fmt.Sprintf("%s %d.%d", info.Name, info.Major, info.Minor)
+}
+
+func (s *suite) capabilities() (Status, string) {
+	if err := s.client.QueryCapabilities(s.ctx); err != nil {
+		return Fail, fmt.Sprintf("no capability response: %v", err)
+	}
+	pins := s.client.Pins()
+	if len(pins) == 0 {
+		return Fail, "no pins reported"
+	}
+	s.pins = make(map[byte]firmata.PinInfo)
+	for _, p := range pins {
+		s.pins[p.Number] = p
+		for _, m := range p.Modes {
+			r := p.Resolutions[m]
+			switch m {
+			case firmata.Input, firmata.Output, firmata.Pullup:
+				if r != 1 {
+					return Fail, fmt.Sprintf("pin %d reports %v with resolution %d, not 1", p.Number, m, r)
+				}
+			default:
+				if r < 1 || r > 32 {
+					return Fail, fmt.Sprintf("pin %d reports %v with resolution %d", p.Number, m, r)
+				}
+			}
+		}
+	}
+	return Pass, fmt.Sprintf("%d pins", len(pins))
+}
+
+func (s *suite) analogMapping() (Status, string) {
+	if err := s.client.QueryAnalogMapping(s.ctx); err != nil {
+		return Fail, fmt.Sprintf("no analog mapping response: %v", err)
+	}
+	channels := make(map[int]byte)
+	for _, p := range s.client.Pins() {
+		if p.AnalogChannel < 0 {
+			if p.Supports(firmata.Analog) {
+				return Fail, fmt.Sprintf("pin %d supports %v but has no analog channel", p.Number, firmata.Analog)
+			}
+			continue
+		}
+		if other, ok := channels[p.AnalogChannel]; ok {
+			return Fail, fmt.Sprintf("pins %d and %d are both analog channel %d", other, p.Number, p.AnalogChannel)
+		}
+		channels[p.AnalogChannel] = p.Number
+		if !p.Supports(firmata.Analog) {
+			return Fail, fmt.Sprintf("pin %d is analog channel %d but does not support %v", p.Number, p.AnalogChannel, firmata.Analog)
+		}
+	}
+	return Pass, fmt.Sprintf("%d analog channels", len(channels))
+}
+
+// testedModes are the modes the pin mode feature sets. Bus modes need
+// further configuration to be meaningful, so are left out.
+var testedModes = []firmata.PinMode{firmata.Input, firmata.Output, firmata.Pullup, firmata.Analog, firmata.PWM}
+
+func (s *suite) pinModes() (Status, string) {
+	if s.pins == nil {
+		return Skip, "no capabilities"
+	}
+	set := 0
+	for _, pin := range s.usablePins() {
+		p := s.pins[pin]
+		for _, m := range testedModes {
+			if !p.Supports(m) {
+				continue
+			}
+			if err := s.client.SetPinMode(pin, m); err != nil {
+				return Fail, fmt.Sprintf("setting pin %d to %v: %v", pin, m, err)
+			}
+			set++
+		}
+		if err := s.restore(pin); err != nil {
+			return Fail, fmt.Sprintf("restoring pin %d: %v", pin, err)
+		}
+	}
+	if set == 0 {
+		return Skip, "no pins to set"
+	}
+	// A firmware which mishandles a mode tends to hang or reset.
+	if _, err := s.client.Ping(s.ctx); err != nil {
+		return Fail, fmt.Sprintf("board stopped responding after %d mode changes: %v", set, err)
+	}
+	return Pass, fmt.Sprintf("%d mode changes", set)
+}
+
+func (s *suite) samplingInterval() (Status, string) {
+	if err := s.client.SetAnalogSamplingInterval(19); err != nil {
+		return Fail, err.Error()
+	}
+	if _, err := s.client.Ping(s.ctx); err != nil {
+		return Fail, fmt.Sprintf("board stopped responding: %v", err)
+	}
+	return Pass, "set to 19ms"
+}
+
+func (s *suite) analogInput() (Status, string) {
+	if s.pins == nil {
+		return Skip, "no capabilities"
+	}
+	read := 0
+	for _, pin := range s.usablePins() {
+		p := s.pins[pin]
+		if !p.Supports(firmata.Analog) || p.AnalogChannel < 0 {
+			continue
+		}
+		if err := s.client.SetPinMode(pin, firmata.Analog); err != nil {
+			return Fail, fmt.Sprintf("setting pin %d to %v: %v", pin, firmata.Analog, err)
+		}
+		if err := s.client.EnableAnalogInput(uint(pin), true); err != nil {
+			return Fail, fmt.Sprintf("enabling pin %d: %v", pin, err)
+		}
+		var value int
+		ok := s.waitFor(func() bool {
+			var reported bool
+			value, reported = s.client.GetAnalog(uint(pin))
+			return reported
+		})
+		s.client.EnableAnalogInput(uint(pin), false)
+		if !ok {
+			return Fail, fmt.Sprintf("pin %d not reported within %v", pin, s.config.Settle)
+		}
+		if full := 1<<uint(p.Resolutions[firmata.Analog]) - 1; value < 0 || value > full {
+			return Fail, fmt.Sprintf("pin %d reported %d, outside 0 to %d", pin, value, full)
+		}
+		read++
+	}
+	if read == 0 {
+		return Skip, "no analog pins"
+	}
+	return Pass, fmt.Sprintf("%d pins reported", read)
+}
+
+func (s *suite) digitalRoundTrip() (Status, string) {
+	if len(s.config.Wires) == 0 {
+		return Skip, "no wires"
+	}
+	for _, w := range s.config.Wires {
+		if err := s.client.SetPinMode(w.Output, firmata.Output); err != nil {
+			return Fail, fmt.Sprintf("setting pin %d to %v: %v", w.Output, firmata.Output, err)
+		}
+		if err := s.client.SetPinMode(w.Input, firmata.Input); err != nil {
+			return Fail, fmt.Sprintf("setting pin %d to %v: %v", w.Input, firmata.Input, err)
+		}
+		if err := s.client.EnableDigitalInput(uint(w.Input), true); err != nil {
+			return Fail, fmt.Sprintf("enabling pin %d: %v", w.Input, err)
+		}
+		for _, level := range []bool{true, false, true, false} {
+			if err := s.client.DigitalWrite(uint(w.Output), level); err != nil {
+				return Fail, fmt.Sprintf("writing pin %d: %v", w.Output, err)
+			}
+			s.client.Flush()
+			if !s.waitFor(func() bool {
+				v, ok := s.client.GetDigital(uint(w.Input))
+				return ok && v == level
+			}) {
+				return Fail, fmt.Sprintf("pin %d did not read %v within %v of writing it to pin %d", w.Input, level, s.config.Settle, w.Output)
+			}
+		}
+		s.client.EnableDigitalInput(uint(w.Input), false)
+		s.restore(w.Output)
+	}
+	return Pass, fmt.Sprintf("%d wires", len(s.config.Wires))
+}
+
+func (s *suite) analogRoundTrip() (Status, string) {
+	if len(s.config.AnalogWires) == 0 {
+		return Skip, "no analog wires"
+	}
+	if s.pins == nil {
+		return Skip, "no capabilities"
+	}
+	for _, w := range s.config.AnalogWires {
+		res := s.pins[w.Input].Resolutions[firmata.Analog]
+		if res == 0 || !s.pins[w.Output].Supports(firmata.PWM) {
+			return Fail, fmt.Sprintf("pins %d and %d are not a PWM output and an analog input", w.Output, w.Input)
+		}
+		full := float64(int(1)<<uint(res) - 1)
+		if err := s.client.SetPinMode(w.Output, firmata.PWM); err != nil {
+			return Fail, fmt.Sprintf("setting pin %d to %v: %v", w.Output, firmata.PWM, err)
+		}
+		if err := s.client.SetPinMode(w.Input, firmata.Analog); err != nil {
+			return Fail, fmt.Sprintf("setting pin %d to %v: %v", w.Input, firmata.Analog, err)
+		}
+		if err := s.client.EnableAnalogInput(uint(w.Input), true); err != nil {
+			return Fail, fmt.Sprintf("enabling pin %d: %v", w.Input, err)
+		}
+		for _, duty := range []byte{0, 128, 255} {
+			if err := s.client.AnalogWrite(uint(w.Output), duty); err != nil {
+				return Fail, fmt.Sprintf("writing pin %d: %v", w.Output, err)
+			}
+			s.client.Flush()
+			want := float64(duty) / 255 * full
+			var got int
+			if !s.waitFor(func() bool {
+				v, ok := s.client.GetAnalog(uint(w.Input))
+				got = v
+				return ok && float64(v) >= want-s.config.Tolerance*full && float64(v) <= want+s.config.Tolerance*full
+			}) {
+				return Fail, fmt.Sprintf("pin %d read %d, not about %.0f, within %v of writing %d to pin %d", w.Input, got, want, s.config.Settle, duty, w.Output)
+			}
+		}
+		s.client.EnableAnalogInput(uint(w.Input), false)
+		s.restore(w.Output)
+	}
+	return Pass, fmt.Sprintf("%d analog wires", len(s.config.AnalogWires))
+}
+
+// usablePins returns the pins whose modes may be changed, in order.
+func (s *suite) usablePins() []byte {
+	var pins []byte
+	if s.config.Pins != nil {
+		for _, pin := range s.config.Pins {
+			if _, ok := s.pins[pin]; ok {
+				pins = append(pins, pin)
+			}
+		}
+		return pins
+	}
+	for n := 0; n < 256; n++ {
+		if _, ok := s.pins[byte(n)]; ok {
+			pins = append(pins, byte(n))
+		}
+	}
+	return pins
+}
+
+// restore leaves pin as an input, so it drives nothing.
+func (s *suite) restore(pin byte) error {
+	if !s.pins[pin].Supports(firmata.Input) {
+		return nil
+	}
+	return s.client.SetPinMode(pin, firmata.Input)
+}
+
+// waitFor polls cond until it is true, returning false if it is not
+// within the settle time.
+func (s *suite) waitFor(cond func() bool) bool {
+	deadline := time.Now().Add(s.config.Settle)
+	for {
+		if cond() {
+			return true
+		}
+		if time.Now().After(deadline) || s.ctx.Err() != nil {
+			return false
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
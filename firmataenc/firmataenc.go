@@ -0,0 +1,333 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package firmataenc builds Firmata frames as bytes, without a connection:
+// the messages a client sends to a board, and those a board sends back.
+// It is for simulators, tests and tools which speak the protocol.
+//
+// Each function returns a whole frame, including for SysEx messages the
+// start and end bytes. Values are masked to the width of their field, so
+// the frames are always well formed.
+//
+//	conn.Write(firmataenc.SetPinMode(13, firmata.Output))
+//	conn.Write(firmataenc.DigitalMessage(1, 0x20))
+package firmataenc
+
+import (
+	"github.com/buxtronix/go-firmata"
+)
+
+// noChannel is the analog mapping of a pin with no analog channel, and the
+// end of a pin in a capability response.
+const noChannel = 0x7F
+
+// lsb and msb return the low and high 7 bits of a 14 bit value.
+func lsb(v int) byte { return byte(v & 0x7F) }
+func msb(v int) byte { return byte((v >> 7) & 0x7F) }
+
+// bytes7 appends each byte of data as two 7 bit bytes.
+func bytes7(frame []byte, data []byte) []byte {
+	for _, b := range data {
+		frame = append(frame, b&0x7F, b>>7)
+	}
+	return frame
+}
+
+// SysEx returns a SysEx message of type cmd with data, which must already
+// be 7 bit encoded. Data bytes with the top bit set are masked. The
+// command is not, as SysExSPI has the top bit set.
+func SysEx(cmd firmata.SysExCommand, data ...byte) []byte {
+	frame := make([]byte, 0, len(data)+3)
+	frame = append(frame, byte(firmata.StartSysEx), byte(cmd))
+	for _, b := range data {
+		frame = append(frame, b&0x7F)
+	}
+	return append(frame, byte(firmata.EndSysEx))
+}
+
+// DigitalMessage returns a digital message setting, or reporting, the
+// levels of the 8 pins of port.
+func DigitalMessage(port byte, value byte) []byte {
+	return []byte{byte(firmata.DigitalMessage) | port&0x0F, value & 0x7F, value >> 7}
+}
+
+// AnalogMessage returns an analog message writing, or reporting, a value
+// of up to 14 bits for pin or, from the board, analog channel pin.
+func AnalogMessage(pin byte, value int) []byte {
+	return []byte{byte(firmata.AnalogMessage) | pin&0x0F, lsb(value), msb(value)}
+}
+
+// ReportAnalog returns a message enabling or disabling reports of analog
+// channel.
+func ReportAnalog(channel byte, on bool) []byte {
+	return []byte{byte(firmata.EnableAnalogInput) | channel&0x0F, flag(on)}
+}
+
+// ReportDigital returns a message enabling or disabling reports of the
+// digital pins of port.
+func ReportDigital(port byte, on bool) []byte {
+	return []byte{byte(firmata.EnableDigitalInput) | port&0x0F, flag(on)}
+}
+
+func flag(on bool) byte {
+	if on {
+		return 1
+	}
+	return 0
+}
+
+// SetPinMode returns a message setting the mode of pin.
+func SetPinMode(pin byte, mode firmata.PinMode) []byte {
+	return []byte{byte(firmata.SetPinMode), pin & 0x7F, byte(mode) & 0x7F}
+}
+
+// SystemReset returns a message resetting the board.
+func SystemReset() []byte {
+	return []byte{byte(firmata.SystemReset)}
+}
+
+// QueryVersion returns a message asking for the protocol version.
+func QueryVersion() []byte {
+	return []byte{byte(firmata.ReportVersion)}
+}
+
+// Version returns the board's report of its protocol version.
+func Version(major, minor byte) []byte {
+	return []byte{byte(firmata.ReportVersion), major & 0x7F, minor & 0x7F}
+}
+
+// QueryFirmware returns a message asking for the firmware name and
+// version.
+func QueryFirmware() []byte {
+	return SysEx(firmata.ReportFirmware)
+}
+
+// Firmware returns the board's report of its firmware name and version.
+func Firmware(name string, major, minor byte) []byte {
+	data := []byte{major, minor}
+	for _, c := range name {
+		data = append(data, lsb(int(c)), msb(int(c)))
+	}
+	return SysEx(firmata.ReportFirmware, data...)
+}
+
+// StringData returns a string message, sent by the board to log text.
+func StringData(s string) []byte {
+	var data []byte
+	for _, c := range s {
+		data = append(data, lsb(int(c)), msb(int(c)))
+	}
+	return SysEx(firmata.StringData, data...)
+}
+
+// QueryCapabilities returns a message asking for the modes of every pin.
+func QueryCapabilities() []byte {
+	return SysEx(firmata.CapabilityQuery)
+}
+
+// Capability is the modes a pin supports, with their resolution in bits.
+type Capability map[firmata.PinMode]int
+
+// Capabilities returns the board's report of the modes of its pins, in
+// pin order. The modes of each pin are listed in mode order.
+func Capabilities(pins []Capability) []byte {
+	var data []byte
+	for _, p := range pins {
+		for m := 0; m < noChannel; m++ {
+			if r, ok := p[firmata.PinMode(m)]; ok {
+				data = append(data, byte(m), byte(r))
+			}
+		}
+		data = append(data, noChannel)
+	}
+	return SysEx(firmata.CapabilityResponse, data...)
+}
+
+// QueryAnalogMapping returns a message asking which pins are analog
+// inputs.
+func QueryAnalogMapping() []byte {
+	return SysEx(firmata.AnalogMappingQuery)
+}
+
+// AnalogMapping returns the board's report of the analog channel of each
+// of its pins, in pin order, with -1 for pins which have none.
+func AnalogMapping(channels []int) []byte {
+	data := make([]byte, len(channels))
+	for i, ch := range channels {
+		if ch < 0 || ch >= noChannel {
+			ch = noChannel
+		}
+		data[i] = byte(ch)
+	}
+	return SysEx(firmata.AnalogMappingResponse, data...)
+}
+
+// QueryPinState returns a message asking for the mode and state of pin.
+func QueryPinState(pin byte) []byte {
+	return SysEx(firmata.PinStateQuery, pin)
+}
+
+// PinState returns the board's report of the mode and state of pin. The
+// state is sent in as many 7 bit bytes as it needs.
+func PinState(pin byte, mode firmata.PinMode, state int) []byte {
+	data := []byte{pin, byte(mode)}
+	for {
+		data = append(data, lsb(state))
+		if state >>= 7; state <= 0 {
+			break
+		}
+	}
+	return SysEx(firmata.PinStateResponse, data...)
+}
+
+// ExtendedAnalog returns a message writing value to pin, for pins and
+// values too large for an analog message.
+func ExtendedAnalog(pin byte, value int) []byte {
+	data := []byte{pin}
+	for {
+		data = append(data, lsb(value))
+		if value >>= 7; value <= 0 {
+			break
+		}
+	}
+	return SysEx(firmata.ExtendedAnalog, data...)
+}
+
+// SamplingInterval returns a message setting the analog sampling interval,
+// in milliseconds.
+func SamplingInterval(ms int) []byte {
+	return SysEx(firmata.SamplingInterval, lsb(ms), msb(ms))
+}
+
+// ServoConfig returns a message setting the pulse range of a servo on pin,
+// in microseconds.
+func ServoConfig(pin byte, minPulse, maxPulse int) []byte {
+	return SysEx(firmata.ServoConfig, pin, lsb(minPulse), msb(minPulse), lsb(maxPulse), msb(maxPulse))
+}
+
+// I2CConfig returns a message setting the delay, in microseconds, between
+// writing an I2C register and reading it.
+func I2CConfig(delay int) []byte {
+	return SysEx(firmata.I2CConfig, lsb(delay), msb(delay))
+}
+
+// I2CWrite returns a message writing data to the I2C device at address.
+func I2CWrite(address byte, data ...byte) []byte {
+	return SysEx(firmata.I2CRequest, bytes7([]byte{address, byte(firmata.I2CModeWrite)}, data)...)
+}
+
+// I2CRead returns a message reading count bytes from register of the I2C
+// device at address, or from the device itself if register is
+// firmata.I2CNoRegister.
+func I2CRead(address byte, register int, count int) []byte {
+	return i2cRead(address, firmata.I2CModeRead, register, count)
+}
+
+// I2CReadContinuous is I2CRead, asking the board to repeat the read every
+// sampling interval.
+func I2CReadContinuous(address byte, register int, count int) []byte {
+	return i2cRead(address, firmata.I2CModeReadContinuous, register, count)
+}
+
+func i2cRead(address byte, mode firmata.I2CMode, register int, count int) []byte {
+	data := []byte{address, byte(mode)}
+	if register != firmata.I2CNoRegister {
+		data = append(data, lsb(register), msb(register))
+	}
+	return SysEx(firmata.I2CRequest, append(data, lsb(count), msb(count))...)
+}
+
+// I2CStopReading returns a message stopping continuous reads from the I2C
+// device at address.
+func I2CStopReading(address byte) []byte {
+	return SysEx(firmata.I2CRequest, address, byte(firmata.I2CModeStopReading))
+}
+
+// I2CReply returns the board's reply to a read of register, which is zero
+// for reads with no register, of the I2C device at address.
+func I2CReply(address byte, register int, data []byte) []byte {
+	return SysEx(firmata.I2CReply, bytes7([]byte{address & 0x7F, address >> 7, lsb(register), msb(register)}, data)...)
+}
+
+// SPIConfig returns a message enabling SPI with chip select csPin, in
+// mode, one of the SPI_MODE constants.
+func SPIConfig(csPin byte, mode byte) []byte {
+	return SysEx(firmata.SysExSPI, bytes7([]byte{byte(firmata.SPIConfig)}, []byte{csPin, mode})...)
+}
+
+// SPITransfer returns a message writing data to the SPI device on csPin,
+// which the board answers with the bytes read, built by SPIReply.
+func SPITransfer(csPin byte, data []byte) []byte {
+	return SysEx(firmata.SysExSPI, bytes7(bytes7([]byte{byte(firmata.SPIComm)}, []byte{csPin}), data)...)
+}
+
+// SPIReply returns the board's reply to an SPI transfer on csPin.
+func SPIReply(csPin byte, data []byte) []byte {
+	return SPITransfer(csPin, data)
+}
+
+// SerialConfig returns a message configuring serial port at baud, as sent
+// by the client: with a 1024 byte buffer and reads ending at a newline.
+func SerialConfig(port firmata.SerialPort, baud int) []byte {
+	return SysEx(firmata.Serial,
+		byte(firmata.SerialConfig)|byte(port),
+		lsb(baud), msb(baud), lsb(baud>>14),
+		lsb(1024), msb(1024), 0,
+		'\n', 0)
+}
+
+// SerialReply returns the board's report of data read from serial port.
+func SerialReply(port firmata.SerialPort, data []byte) []byte {
+	return SysEx(firmata.Serial, bytes7([]byte{byte(firmata.SerialComm) | byte(port)}, data)...)
+}
+
+// OneWireConfig returns a message configuring pin as a OneWire bus, with
+// power one of the OneWirePower constants.
+func OneWireConfig(pin byte, power byte) []byte {
+	return SysEx(firmata.SysExOneWire, byte(firmata.OneWireConfig), pin, power)
+}
+
+// OneWireSearch returns a message searching the OneWire bus on pin, with
+// mode firmata.OneWireSearch or firmata.OneWireSearchAlarms.
+func OneWireSearch(pin byte, mode firmata.OneWireSubCommand) []byte {
+	return SysEx(firmata.SysExOneWire, byte(mode), pin)
+}
+
+// OneWireCommand returns a message running request on the OneWire bus on
+// pin.
+func OneWireCommand(pin byte, request firmata.OneWireRequest) []byte {
+	return SysEx(firmata.SysExOneWire, append([]byte{byte(request.Command), pin}, request.Encode()...)...)
+}
+
+// OneWireSearchReply returns the board's reply to a search of the OneWire
+// bus on pin, with mode firmata.OneWireSearch or
+// firmata.OneWireSearchAlarms, listing addresses.
+func OneWireSearchReply(pin byte, mode firmata.OneWireSubCommand, addresses []firmata.OneWireAddress) []byte {
+	reply := firmata.OneWireSearchReply
+	if mode == firmata.OneWireSearchAlarms {
+		reply = firmata.OneWireSearchAlarmsReply
+	}
+	var data []byte
+	for _, a := range addresses {
+		data = append(data, a...)
+	}
+	return SysEx(firmata.SysExOneWire, append([]byte{byte(reply), pin}, firmata.To7BitMulti(data)...)...)
+}
+
+// OneWireReadReply returns the board's reply to a OneWire read on pin,
+// with the correlation id of the request and the bytes read.
+func OneWireReadReply(pin byte, correlationId int, data []byte) []byte {
+	d := append([]byte{byte(correlationId), byte(correlationId >> 8)}, data...)
+	return SysEx(firmata.SysExOneWire, append([]byte{byte(firmata.OneWireReadReply), pin}, firmata.To7BitMulti(d)...)...)
+}
@@ -0,0 +1,395 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmatagrpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/buxtronix/go-firmata"
+	"google.golang.org/grpc"
+)
+
+// DefaultTimeout is the time calls without a context may take.
+const DefaultTimeout = 5 * time.Second
+
+// Client is a board served by a Server. It keeps the inputs up to date
+// from the Reports stream, reopening it if it fails, so GetDigital and
+// the other cached reads do not make calls.
+type Client struct {
+	rpc FirmataClient
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu       sync.Mutex
+	timeout  time.Duration
+	firmware firmata.FirmwareInfo
+	digital  map[uint]bool
+	analog   map[uint]int
+}
+
+var _ firmata.Firmata = (*Client)(nil)
+
+// NewClient connects to the server on cc, querying the firmware and
+// starting the Reports stream.
+func NewClient(ctx context.Context, cc grpc.ClientConnInterface) (*Client, error) {
+	c := &Client{
+		rpc:     NewFirmataClient(cc),
+		done:    make(chan struct{}),
+		timeout: DefaultTimeout,
+		digital: make(map[uint]bool),
+		analog:  make(map[uint]int),
+	}
+	if _, err := c.QueryFirmware(ctx); err != nil {
+		return nil, err
+	}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	go c.reports()
+	return c, nil
+}
+
+// SetTimeout sets the time calls without a context may take.
+func (c *Client) SetTimeout(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timeout = d
+}
+
+// Timeout returns the time calls without a context may take.
+func (c *Client) Timeout() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.timeout
+}
+
+// call returns a context for a call without one.
+func (c *Client) call() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.ctx, c.Timeout())
+}
+
+// reports keeps the inputs up to date until the client is closed.
+func (c *Client) reports() {
+	defer close(c.done)
+	for {
+		stream, err := c.rpc.Reports(c.ctx, &Empty{})
+		for err == nil {
+			var r *Report
+			if r, err = stream.Recv(); err == nil {
+				c.mu.Lock()
+				if r.Analog {
+					c.analog[uint(r.Pin)] = int(r.Value)
+				} else {
+					c.digital[uint(r.Pin)] = r.Value != 0
+				}
+				c.mu.Unlock()
+			}
+		}
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (c *Client) SetPinMode(pin byte, mode firmata.PinMode) error {
+	ctx, cancel := c.call()
+	defer cancel()
+	_, err := c.rpc.SetPinMode(ctx, &PinModeRequest{Pin: uint32(pin), Mode: uint32(mode)})
+	return fromStatus(err)
+}
+
+func (c *Client) SetPullup(pin byte) error {
+	ctx, cancel := c.call()
+	defer cancel()
+	_, err := c.rpc.SetPullup(ctx, &PinRequest{Pin: uint32(pin)})
+	return fromStatus(err)
+}
+
+func (c *Client) DigitalWrite(pin uint, val bool) error {
+	ctx, cancel := c.call()
+	defer cancel()
+	_, err := c.rpc.DigitalWrite(ctx, &DigitalRequest{Pin: uint32(pin), Value: val})
+	return fromStatus(err)
+}
+
+// DigitalRead returns the last reported value of a digital input pin, or
+// false if there is none.
+func (c *Client) DigitalRead(pin uint) (bool, error) {
+	v, _ := c.GetDigital(pin)
+	return v, nil
+}
+
+func (c *Client) AnalogWrite(pin uint, pinData byte) error {
+	ctx, cancel := c.call()
+	defer cancel()
+	_, err := c.rpc.AnalogWrite(ctx, &AnalogRequest{Pin: uint32(pin), Value: uint32(pinData)})
+	return fromStatus(err)
+}
+
+// AnalogRead returns the last reported reading of an analog input pin, or
+// 0 if there is none.
+func (c *Client) AnalogRead(pin uint) (int, error) {
+	v, _ := c.GetAnalog(pin)
+	return v, nil
+}
+
+func (c *Client) EnableDigitalInput(pin uint, val bool) error {
+	ctx, cancel := c.call()
+	defer cancel()
+	_, err := c.rpc.EnableDigitalInput(ctx, &DigitalRequest{Pin: uint32(pin), Value: val})
+	return fromStatus(err)
+}
+
+func (c *Client) EnableAnalogInput(pin uint, val bool) error {
+	ctx, cancel := c.call()
+	defer cancel()
+	_, err := c.rpc.EnableAnalogInput(ctx, &DigitalRequest{Pin: uint32(pin), Value: val})
+	return fromStatus(err)
+}
+
+// GetDigital returns the cached value of a digital input pin. The second
+// result is false if the server has not reported the pin.
+func (c *Client) GetDigital(pin uint) (value bool, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok = c.digital[pin]
+	return
+}
+
+// GetAnalog returns the cached reading of an analog input pin. The second
+// result is false if the server has not reported the pin.
+func (c *Client) GetAnalog(pin uint) (value int, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok = c.analog[pin]
+	return
+}
+
+func (c *Client) SetAnalogSamplingInterval(ms byte) error {
+	ctx, cancel := c.call()
+	defer cancel()
+	_, err := c.rpc.SetAnalogSamplingInterval(ctx, &SamplingRequest{Ms: uint32(ms)})
+	return fromStatus(err)
+}
+
+func (c *Client) I2CConfig(delay int) error {
+	ctx, cancel := c.call()
+	defer cancel()
+	_, err := c.rpc.I2CConfig(ctx, &I2CConfigRequest{Delay: int32(delay)})
+	return fromStatus(err)
+}
+
+func (c *Client) I2CWrite(address byte, data ...byte) error {
+	ctx, cancel := c.call()
+	defer cancel()
+	_, err := c.rpc.I2CWrite(ctx, &I2CRequest{Address: uint32(address), Data: data})
+	return fromStatus(err)
+}
+
+func (c *Client) I2CRead(address byte, register int, count int) ([]byte, error) {
+	ctx, cancel := c.call()
+	defer cancel()
+	return c.I2CReadContext(ctx, address, register, count)
+}
+
+func (c *Client) I2CReadContext(ctx context.Context, address byte, register int, count int) ([]byte, error) {
+	d, err := c.rpc.I2CRead(ctx, &I2CRequest{Address: uint32(address), Register: int32(register), Count: int32(count)})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return d.Data, nil
+}
+
+// I2CReadContinuous starts a continuous read. The channel is closed when
+// I2CStopReading is called for the device, the stream fails or the client
+// is closed.
+func (c *Client) I2CReadContinuous(address byte, register int, count int) (<-chan firmata.I2CResponse, error) {
+	stream, err := c.rpc.I2CReadContinuous(c.ctx, &I2CRequest{Address: uint32(address), Register: int32(register), Count: int32(count)})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	ch := make(chan firmata.I2CResponse, 16)
+	go func() {
+		defer close(ch)
+		for {
+			r, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			select {
+			case ch <- firmata.I2CResponse{
+				Address:  byte(r.Address),
+				Register: int(r.Register),
+				Data:     r.Data,
+				Time:     fromUnixNano(r.TimeUnixNano),
+			}:
+			case <-c.ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (c *Client) I2CStopReading(address byte) error {
+	ctx, cancel := c.call()
+	defer cancel()
+	_, err := c.rpc.I2CStopReading(ctx, &I2CRequest{Address: uint32(address)})
+	return fromStatus(err)
+}
+
+func (c *Client) I2CBulkWrite(ctx context.Context, address byte, register int, data []byte, o *firmata.I2CBulkOptions) error {
+	_, err := c.rpc.I2CBulkWrite(ctx, &I2CRequest{Address: uint32(address), Register: int32(register), Data: data, Bulk: bulkOptionsProto(o)})
+	return fromStatus(err)
+}
+
+func (c *Client) I2CBulkRead(ctx context.Context, address byte, register int, count int, o *firmata.I2CBulkOptions) ([]byte, error) {
+	d, err := c.rpc.I2CBulkRead(ctx, &I2CRequest{Address: uint32(address), Register: int32(register), Count: int32(count), Bulk: bulkOptionsProto(o)})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return d.Data, nil
+}
+
+func (c *Client) OneWireConfig(csPin byte, owPowerMode byte) error {
+	ctx, cancel := c.call()
+	defer cancel()
+	_, err := c.rpc.OneWireConfig(ctx, &OneWireConfigRequest{Pin: uint32(csPin), PowerMode: uint32(owPowerMode)})
+	return fromStatus(err)
+}
+
+func (c *Client) OneWireSearch(csPin byte, owSearchMode firmata.OneWireSubCommand) ([]firmata.OneWireAddress, error) {
+	ctx, cancel := c.call()
+	defer cancel()
+	return c.OneWireSearchContext(ctx, csPin, owSearchMode)
+}
+
+func (c *Client) OneWireSearchContext(ctx context.Context, csPin byte, owSearchMode firmata.OneWireSubCommand) ([]firmata.OneWireAddress, error) {
+	r, err := c.rpc.OneWireSearch(ctx, &OneWireSearchRequest{Pin: uint32(csPin), SearchMode: uint32(owSearchMode)})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	addresses := make([]firmata.OneWireAddress, len(r.Addresses))
+	for i, a := range r.Addresses {
+		addresses[i] = a
+	}
+	return addresses, nil
+}
+
+func (c *Client) OneWireCommand(csPin byte, request firmata.OneWireRequest) ([]byte, error) {
+	ctx, cancel := c.call()
+	defer cancel()
+	return c.OneWireCommandContext(ctx, csPin, request)
+}
+
+func (c *Client) OneWireCommandContext(ctx context.Context, csPin byte, request firmata.OneWireRequest) ([]byte, error) {
+	d, err := c.rpc.OneWireCommand(ctx, &OneWireCommandRequest{
+		Pin:           uint32(csPin),
+		Command:       uint32(request.Command),
+		Address:       request.Address,
+		ReadCount:     request.ReadCount,
+		CorrelationId: request.CorrelationId,
+		DelayMs:       request.DelayMs,
+		Data:          request.Data,
+	})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+	return d.Data, nil
+}
+
+func (c *Client) OneWireRelease(csPin byte) error {
+	ctx, cancel := c.call()
+	defer cancel()
+	_, err := c.rpc.OneWireRelease(ctx, &PinRequest{Pin: uint32(csPin)})
+	return fromStatus(err)
+}
+
+// Firmware returns the firmware last queried.
+func (c *Client) Firmware() firmata.FirmwareInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.firmware
+}
+
+func (c *Client) QueryFirmware(ctx context.Context) (firmata.FirmwareInfo, error) {
+	r, err := c.rpc.QueryFirmware(ctx, &Empty{})
+	if err != nil {
+		return firmata.FirmwareInfo{}, fromStatus(err)
+	}
+	f := firmata.FirmwareInfo{Name: r.Name, Major: int(r.Major), Minor: int(r.Minor)}
+	c.mu.Lock()
+	c.firmware = f
+	c.mu.Unlock()
+	return f, nil
+}
+
+func (c *Client) QueryCapabilities(ctx context.Context) error {
+	_, err := c.rpc.QueryCapabilities(ctx, &Empty{})
+	return fromStatus(err)
+}
+
+func (c *Client) QueryAnalogMapping(ctx context.Context) error {
+	_, err := c.rpc.QueryAnalogMapping(ctx, &Empty{})
+	return fromStatus(err)
+}
+
+// Pins describes every pin of the board, or returns nil if the server
+// cannot be reached.
+func (c *Client) Pins() []firmata.PinInfo {
+	ctx, cancel := c.call()
+	defer cancel()
+	r, err := c.rpc.Pins(ctx, &Empty{})
+	if err != nil {
+		return nil
+	}
+	pins := make([]firmata.PinInfo, len(r.Pins))
+	for i, p := range r.Pins {
+		pins[i] = pinInfo(p)
+	}
+	return pins
+}
+
+// Ping measures the round trip time to the board, through the server.
+func (c *Client) Ping(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	if _, err := c.rpc.Ping(ctx, &Empty{}); err != nil {
+		return 0, fromStatus(err)
+	}
+	return time.Since(start), nil
+}
+
+func (c *Client) Flush() error {
+	ctx, cancel := c.call()
+	defer cancel()
+	_, err := c.rpc.Flush(ctx, &Empty{})
+	return fromStatus(err)
+}
+
+// CloseContext stops the streams of the client, leaving the server and
+// board running. The connection to the server is not closed.
+func (c *Client) CloseContext(ctx context.Context) error {
+	c.cancel()
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,1821 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.0
+// 	protoc        (unknown)
+// source: firmata.proto
+
+package firmatagrpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Empty struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *Empty) Reset() {
+	*x = Empty{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_firmata_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Empty) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Empty) ProtoMessage() {}
+
+func (x *Empty) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Empty.ProtoReflect.Descriptor instead.
+func (*Empty) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{0}
+}
+
+type PinRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pin uint32 `protobuf:"varint,1,opt,name=pin,proto3" json:"pin,omitempty"`
+}
+
+func (x *PinRequest) Reset() {
+	*x = PinRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_firmata_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PinRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PinRequest) ProtoMessage() {}
+
+func (x *PinRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PinRequest.ProtoReflect.Descriptor instead.
+func (*PinRequest) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PinRequest) GetPin() uint32 {
+	if x != nil {
+		return x.Pin
+	}
+	return 0
+}
+
+type PinModeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pin  uint32 `protobuf:"varint,1,opt,name=pin,proto3" json:"pin,omitempty"`
+	Mode uint32 `protobuf:"varint,2,opt,name=mode,proto3" json:"mode,omitempty"`
+}
+
+func (x *PinModeRequest) Reset() {
+	*x = PinModeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_firmata_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PinModeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PinModeRequest) ProtoMessage() {}
+
+func (x *PinModeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PinModeRequest.ProtoReflect.Descriptor instead.
+func (*PinModeRequest) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PinModeRequest) GetPin() uint32 {
+	if x != nil {
+		return x.Pin
+	}
+	return 0
+}
+
+func (x *PinModeRequest) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+// DigitalRequest is a level to write, or whether to enable reporting.
+type DigitalRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pin   uint32 `protobuf:"varint,1,opt,name=pin,proto3" json:"pin,omitempty"`
+	Value bool   `protobuf:"varint,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *DigitalRequest) Reset() {
+	*x = DigitalRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_firmata_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DigitalRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DigitalRequest) ProtoMessage() {}
+
+func (x *DigitalRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DigitalRequest.ProtoReflect.Descriptor instead.
+func (*DigitalRequest) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DigitalRequest) GetPin() uint32 {
+	if x != nil {
+		return x.Pin
+	}
+	return 0
+}
+
+func (x *DigitalRequest) GetValue() bool {
+	if x != nil {
+		return x.Value
+	}
+	return false
+}
+
+type AnalogRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pin   uint32 `protobuf:"varint,1,opt,name=pin,proto3" json:"pin,omitempty"`
+	Value uint32 `protobuf:"varint,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *AnalogRequest) Reset() {
+	*x = AnalogRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_firmata_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AnalogRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnalogRequest) ProtoMessage() {}
+
+func (x *AnalogRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnalogRequest.ProtoReflect.Descriptor instead.
+func (*AnalogRequest) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AnalogRequest) GetPin() uint32 {
+	if x != nil {
+		return x.Pin
+	}
+	return 0
+}
+
+func (x *AnalogRequest) GetValue() uint32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+type SamplingRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ms uint32 `protobuf:"varint,1,opt,name=ms,proto3" json:"ms,omitempty"`
+}
+
+func (x *SamplingRequest) Reset() {
+	*x = SamplingRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_firmata_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SamplingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SamplingRequest) ProtoMessage() {}
+
+func (x *SamplingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SamplingRequest.ProtoReflect.Descriptor instead.
+func (*SamplingRequest) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SamplingRequest) GetMs() uint32 {
+	if x != nil {
+		return x.Ms
+	}
+	return 0
+}
+
+type I2CConfigRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Delay int32 `protobuf:"varint,1,opt,name=delay,proto3" json:"delay,omitempty"`
+}
+
+func (x *I2CConfigRequest) Reset() {
+	*x = I2CConfigRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_firmata_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *I2CConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*I2CConfigRequest) ProtoMessage() {}
+
+func (x *I2CConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use I2CConfigRequest.ProtoReflect.Descriptor instead.
+func (*I2CConfigRequest) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *I2CConfigRequest) GetDelay() int32 {
+	if x != nil {
+		return x.Delay
+	}
+	return 0
+}
+
+type I2CBulkOptions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ChunkSize     int32 `protobuf:"varint,1,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"`
+	FixedRegister bool  `protobuf:"varint,2,opt,name=fixed_register,json=fixedRegister,proto3" json:"fixed_register,omitempty"`
+	RegisterWidth int32 `protobuf:"varint,3,opt,name=register_width,json=registerWidth,proto3" json:"register_width,omitempty"`
+	PageSize      int32 `protobuf:"varint,4,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	WriteDelayNs  int64 `protobuf:"varint,5,opt,name=write_delay_ns,json=writeDelayNs,proto3" json:"write_delay_ns,omitempty"`
+}
+
+func (x *I2CBulkOptions) Reset() {
+	*x = I2CBulkOptions{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_firmata_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *I2CBulkOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*I2CBulkOptions) ProtoMessage() {}
+
+func (x *I2CBulkOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use I2CBulkOptions.ProtoReflect.Descriptor instead.
+func (*I2CBulkOptions) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *I2CBulkOptions) GetChunkSize() int32 {
+	if x != nil {
+		return x.ChunkSize
+	}
+	return 0
+}
+
+func (x *I2CBulkOptions) GetFixedRegister() bool {
+	if x != nil {
+		return x.FixedRegister
+	}
+	return false
+}
+
+func (x *I2CBulkOptions) GetRegisterWidth() int32 {
+	if x != nil {
+		return x.RegisterWidth
+	}
+	return 0
+}
+
+func (x *I2CBulkOptions) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *I2CBulkOptions) GetWriteDelayNs() int64 {
+	if x != nil {
+		return x.WriteDelayNs
+	}
+	return 0
+}
+
+// I2CRequest is a request to the device at address. Register is -1 for
+// none.
+type I2CRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Address  uint32          `protobuf:"varint,1,opt,name=address,proto3" json:"address,omitempty"`
+	Register int32           `protobuf:"zigzag32,2,opt,name=register,proto3" json:"register,omitempty"`
+	Data     []byte          `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Count    int32           `protobuf:"varint,4,opt,name=count,proto3" json:"count,omitempty"`
+	Bulk     *I2CBulkOptions `protobuf:"bytes,5,opt,name=bulk,proto3" json:"bulk,omitempty"`
+}
+
+func (x *I2CRequest) Reset() {
+	*x = I2CRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_firmata_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *I2CRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*I2CRequest) ProtoMessage() {}
+
+func (x *I2CRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use I2CRequest.ProtoReflect.Descriptor instead.
+func (*I2CRequest) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *I2CRequest) GetAddress() uint32 {
+	if x != nil {
+		return x.Address
+	}
+	return 0
+}
+
+func (x *I2CRequest) GetRegister() int32 {
+	if x != nil {
+		return x.Register
+	}
+	return 0
+}
+
+func (x *I2CRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *I2CRequest) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *I2CRequest) GetBulk() *I2CBulkOptions {
+	if x != nil {
+		return x.Bulk
+	}
+	return nil
+}
+
+type I2CReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Address      uint32 `protobuf:"varint,1,opt,name=address,proto3" json:"address,omitempty"`
+	Register     int32  `protobuf:"zigzag32,2,opt,name=register,proto3" json:"register,omitempty"`
+	Data         []byte `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	TimeUnixNano int64  `protobuf:"varint,4,opt,name=time_unix_nano,json=timeUnixNano,proto3" json:"time_unix_nano,omitempty"`
+}
+
+func (x *I2CReply) Reset() {
+	*x = I2CReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_firmata_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *I2CReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*I2CReply) ProtoMessage() {}
+
+func (x *I2CReply) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use I2CReply.ProtoReflect.Descriptor instead.
+func (*I2CReply) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *I2CReply) GetAddress() uint32 {
+	if x != nil {
+		return x.Address
+	}
+	return 0
+}
+
+func (x *I2CReply) GetRegister() int32 {
+	if x != nil {
+		return x.Register
+	}
+	return 0
+}
+
+func (x *I2CReply) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *I2CReply) GetTimeUnixNano() int64 {
+	if x != nil {
+		return x.TimeUnixNano
+	}
+	return 0
+}
+
+type Data struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *Data) Reset() {
+	*x = Data{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_firmata_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Data) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Data) ProtoMessage() {}
+
+func (x *Data) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Data.ProtoReflect.Descriptor instead.
+func (*Data) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Data) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type OneWireConfigRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pin       uint32 `protobuf:"varint,1,opt,name=pin,proto3" json:"pin,omitempty"`
+	PowerMode uint32 `protobuf:"varint,2,opt,name=power_mode,json=powerMode,proto3" json:"power_mode,omitempty"`
+}
+
+func (x *OneWireConfigRequest) Reset() {
+	*x = OneWireConfigRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_firmata_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OneWireConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OneWireConfigRequest) ProtoMessage() {}
+
+func (x *OneWireConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OneWireConfigRequest.ProtoReflect.Descriptor instead.
+func (*OneWireConfigRequest) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *OneWireConfigRequest) GetPin() uint32 {
+	if x != nil {
+		return x.Pin
+	}
+	return 0
+}
+
+func (x *OneWireConfigRequest) GetPowerMode() uint32 {
+	if x != nil {
+		return x.PowerMode
+	}
+	return 0
+}
+
+type OneWireSearchRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pin        uint32 `protobuf:"varint,1,opt,name=pin,proto3" json:"pin,omitempty"`
+	SearchMode uint32 `protobuf:"varint,2,opt,name=search_mode,json=searchMode,proto3" json:"search_mode,omitempty"`
+}
+
+func (x *OneWireSearchRequest) Reset() {
+	*x = OneWireSearchRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_firmata_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OneWireSearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OneWireSearchRequest) ProtoMessage() {}
+
+func (x *OneWireSearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OneWireSearchRequest.ProtoReflect.Descriptor instead.
+func (*OneWireSearchRequest) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *OneWireSearchRequest) GetPin() uint32 {
+	if x != nil {
+		return x.Pin
+	}
+	return 0
+}
+
+func (x *OneWireSearchRequest) GetSearchMode() uint32 {
+	if x != nil {
+		return x.SearchMode
+	}
+	return 0
+}
+
+type OneWireAddresses struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Addresses [][]byte `protobuf:"bytes,1,rep,name=addresses,proto3" json:"addresses,omitempty"`
+}
+
+func (x *OneWireAddresses) Reset() {
+	*x = OneWireAddresses{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_firmata_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OneWireAddresses) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OneWireAddresses) ProtoMessage() {}
+
+func (x *OneWireAddresses) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OneWireAddresses.ProtoReflect.Descriptor instead.
+func (*OneWireAddresses) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *OneWireAddresses) GetAddresses() [][]byte {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+type OneWireCommandRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pin           uint32 `protobuf:"varint,1,opt,name=pin,proto3" json:"pin,omitempty"`
+	Command       uint32 `protobuf:"varint,2,opt,name=command,proto3" json:"command,omitempty"`
+	Address       []byte `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	ReadCount     int32  `protobuf:"varint,4,opt,name=read_count,json=readCount,proto3" json:"read_count,omitempty"`
+	CorrelationId int32  `protobuf:"varint,5,opt,name=correlation_id,json=correlationId,proto3" json:"correlation_id,omitempty"`
+	DelayMs       int32  `protobuf:"varint,6,opt,name=delay_ms,json=delayMs,proto3" json:"delay_ms,omitempty"`
+	Data          []byte `protobuf:"bytes,7,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *OneWireCommandRequest) Reset() {
+	*x = OneWireCommandRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_firmata_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OneWireCommandRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OneWireCommandRequest) ProtoMessage() {}
+
+func (x *OneWireCommandRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OneWireCommandRequest.ProtoReflect.Descriptor instead.
+func (*OneWireCommandRequest) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *OneWireCommandRequest) GetPin() uint32 {
+	if x != nil {
+		return x.Pin
+	}
+	return 0
+}
+
+func (x *OneWireCommandRequest) GetCommand() uint32 {
+	if x != nil {
+		return x.Command
+	}
+	return 0
+}
+
+func (x *OneWireCommandRequest) GetAddress() []byte {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *OneWireCommandRequest) GetReadCount() int32 {
+	if x != nil {
+		return x.ReadCount
+	}
+	return 0
+}
+
+func (x *OneWireCommandRequest) GetCorrelationId() int32 {
+	if x != nil {
+		return x.CorrelationId
+	}
+	return 0
+}
+
+func (x *OneWireCommandRequest) GetDelayMs() int32 {
+	if x != nil {
+		return x.DelayMs
+	}
+	return 0
+}
+
+func (x *OneWireCommandRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type FirmwareInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name  string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Major int32  `protobuf:"varint,2,opt,name=major,proto3" json:"major,omitempty"`
+	Minor int32  `protobuf:"varint,3,opt,name=minor,proto3" json:"minor,omitempty"`
+}
+
+func (x *FirmwareInfo) Reset() {
+	*x = FirmwareInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_firmata_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FirmwareInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FirmwareInfo) ProtoMessage() {}
+
+func (x *FirmwareInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FirmwareInfo.ProtoReflect.Descriptor instead.
+func (*FirmwareInfo) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *FirmwareInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *FirmwareInfo) GetMajor() int32 {
+	if x != nil {
+		return x.Major
+	}
+	return 0
+}
+
+func (x *FirmwareInfo) GetMinor() int32 {
+	if x != nil {
+		return x.Minor
+	}
+	return 0
+}
+
+type PinMode struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Mode       uint32 `protobuf:"varint,1,opt,name=mode,proto3" json:"mode,omitempty"`
+	Resolution int32  `protobuf:"varint,2,opt,name=resolution,proto3" json:"resolution,omitempty"`
+}
+
+func (x *PinMode) Reset() {
+	*x = PinMode{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_firmata_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PinMode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PinMode) ProtoMessage() {}
+
+func (x *PinMode) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PinMode.ProtoReflect.Descriptor instead.
+func (*PinMode) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *PinMode) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+func (x *PinMode) GetResolution() int32 {
+	if x != nil {
+		return x.Resolution
+	}
+	return 0
+}
+
+type PinInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Number        uint32 `protobuf:"varint,1,opt,name=number,proto3" json:"number,omitempty"`
+	Label         string `protobuf:"bytes,2,opt,name=label,proto3" json:"label,omitempty"`
+	AnalogChannel int32  `protobuf:"zigzag32,3,opt,name=analog_channel,json=analogChannel,proto3" json:"analog_channel,omitempty"`
+	// Modes are the supported modes, in order, with their resolutions.
+	Modes    []*PinMode `protobuf:"bytes,4,rep,name=modes,proto3" json:"modes,omitempty"`
+	Mode     uint32     `protobuf:"varint,5,opt,name=mode,proto3" json:"mode,omitempty"`
+	ModeSet  bool       `protobuf:"varint,6,opt,name=mode_set,json=modeSet,proto3" json:"mode_set,omitempty"`
+	Value    int32      `protobuf:"varint,7,opt,name=value,proto3" json:"value,omitempty"`
+	HasValue bool       `protobuf:"varint,8,opt,name=has_value,json=hasValue,proto3" json:"has_value,omitempty"`
+}
+
+func (x *PinInfo) Reset() {
+	*x = PinInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_firmata_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PinInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PinInfo) ProtoMessage() {}
+
+func (x *PinInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PinInfo.ProtoReflect.Descriptor instead.
+func (*PinInfo) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *PinInfo) GetNumber() uint32 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *PinInfo) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *PinInfo) GetAnalogChannel() int32 {
+	if x != nil {
+		return x.AnalogChannel
+	}
+	return 0
+}
+
+func (x *PinInfo) GetModes() []*PinMode {
+	if x != nil {
+		return x.Modes
+	}
+	return nil
+}
+
+func (x *PinInfo) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+func (x *PinInfo) GetModeSet() bool {
+	if x != nil {
+		return x.ModeSet
+	}
+	return false
+}
+
+func (x *PinInfo) GetValue() int32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *PinInfo) GetHasValue() bool {
+	if x != nil {
+		return x.HasValue
+	}
+	return false
+}
+
+type PinList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pins []*PinInfo `protobuf:"bytes,1,rep,name=pins,proto3" json:"pins,omitempty"`
+}
+
+func (x *PinList) Reset() {
+	*x = PinList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_firmata_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PinList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PinList) ProtoMessage() {}
+
+func (x *PinList) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PinList.ProtoReflect.Descriptor instead.
+func (*PinList) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *PinList) GetPins() []*PinInfo {
+	if x != nil {
+		return x.Pins
+	}
+	return nil
+}
+
+// Report is a digital input level, as a value of 0 or 1, or an analog
+// input reading.
+type Report struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pin          uint32 `protobuf:"varint,1,opt,name=pin,proto3" json:"pin,omitempty"`
+	Analog       bool   `protobuf:"varint,2,opt,name=analog,proto3" json:"analog,omitempty"`
+	Value        int32  `protobuf:"varint,3,opt,name=value,proto3" json:"value,omitempty"`
+	TimeUnixNano int64  `protobuf:"varint,4,opt,name=time_unix_nano,json=timeUnixNano,proto3" json:"time_unix_nano,omitempty"`
+}
+
+func (x *Report) Reset() {
+	*x = Report{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_firmata_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Report) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Report) ProtoMessage() {}
+
+func (x *Report) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Report.ProtoReflect.Descriptor instead.
+func (*Report) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *Report) GetPin() uint32 {
+	if x != nil {
+		return x.Pin
+	}
+	return 0
+}
+
+func (x *Report) GetAnalog() bool {
+	if x != nil {
+		return x.Analog
+	}
+	return false
+}
+
+func (x *Report) GetValue() int32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *Report) GetTimeUnixNano() int64 {
+	if x != nil {
+		return x.TimeUnixNano
+	}
+	return 0
+}
+
+var File_firmata_proto protoreflect.FileDescriptor
+
+var file_firmata_proto_rawDesc = []byte{
+	0x0a, 0x0d, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x07, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x22, 0x07, 0x0a, 0x05, 0x45, 0x6d, 0x70, 0x74,
+	0x79, 0x22, 0x1e, 0x0a, 0x0a, 0x50, 0x69, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x10, 0x0a, 0x03, 0x70, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x70, 0x69,
+	0x6e, 0x22, 0x36, 0x0a, 0x0e, 0x50, 0x69, 0x6e, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x03, 0x70, 0x69, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x22, 0x38, 0x0a, 0x0e, 0x44, 0x69, 0x67,
+	0x69, 0x74, 0x61, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x70,
+	0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x70, 0x69, 0x6e, 0x12, 0x14, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x22, 0x37, 0x0a, 0x0d, 0x41, 0x6e, 0x61, 0x6c, 0x6f, 0x67, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x03, 0x70, 0x69, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x21, 0x0a, 0x0f,
+	0x53, 0x61, 0x6d, 0x70, 0x6c, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x0e, 0x0a, 0x02, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x02, 0x6d, 0x73, 0x22,
+	0x28, 0x0a, 0x10, 0x49, 0x32, 0x43, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x64, 0x65, 0x6c, 0x61, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x05, 0x64, 0x65, 0x6c, 0x61, 0x79, 0x22, 0xc0, 0x01, 0x0a, 0x0e, 0x49, 0x32,
+	0x43, 0x42, 0x75, 0x6c, 0x6b, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1d, 0x0a, 0x0a,
+	0x63, 0x68, 0x75, 0x6e, 0x6b, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x09, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x25, 0x0a, 0x0e, 0x66,
+	0x69, 0x78, 0x65, 0x64, 0x5f, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x0d, 0x66, 0x69, 0x78, 0x65, 0x64, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74,
+	0x65, 0x72, 0x12, 0x25, 0x0a, 0x0e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x5f, 0x77,
+	0x69, 0x64, 0x74, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x72, 0x65, 0x67, 0x69,
+	0x73, 0x74, 0x65, 0x72, 0x57, 0x69, 0x64, 0x74, 0x68, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x61, 0x67,
+	0x65, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x70, 0x61,
+	0x67, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x24, 0x0a, 0x0e, 0x77, 0x72, 0x69, 0x74, 0x65, 0x5f,
+	0x64, 0x65, 0x6c, 0x61, 0x79, 0x5f, 0x6e, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c,
+	0x77, 0x72, 0x69, 0x74, 0x65, 0x44, 0x65, 0x6c, 0x61, 0x79, 0x4e, 0x73, 0x22, 0x99, 0x01, 0x0a,
+	0x0a, 0x49, 0x32, 0x43, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x61,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x61, 0x64,
+	0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65,
+	0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x11, 0x52, 0x08, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65,
+	0x72, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2b, 0x0a, 0x04, 0x62,
+	0x75, 0x6c, 0x6b, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x66, 0x69, 0x72, 0x6d,
+	0x61, 0x74, 0x61, 0x2e, 0x49, 0x32, 0x43, 0x42, 0x75, 0x6c, 0x6b, 0x4f, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x52, 0x04, 0x62, 0x75, 0x6c, 0x6b, 0x22, 0x7a, 0x0a, 0x08, 0x49, 0x32, 0x43, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x1a,
+	0x0a, 0x08, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x11,
+	0x52, 0x08, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61,
+	0x74, 0x61, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x24,
+	0x0a, 0x0e, 0x74, 0x69, 0x6d, 0x65, 0x5f, 0x75, 0x6e, 0x69, 0x78, 0x5f, 0x6e, 0x61, 0x6e, 0x6f,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x74, 0x69, 0x6d, 0x65, 0x55, 0x6e, 0x69, 0x78,
+	0x4e, 0x61, 0x6e, 0x6f, 0x22, 0x1a, 0x0a, 0x04, 0x44, 0x61, 0x74, 0x61, 0x12, 0x12, 0x0a, 0x04,
+	0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61,
+	0x22, 0x47, 0x0a, 0x14, 0x4f, 0x6e, 0x65, 0x57, 0x69, 0x72, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x69, 0x6e, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x70, 0x69, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x6f,
+	0x77, 0x65, 0x72, 0x5f, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09,
+	0x70, 0x6f, 0x77, 0x65, 0x72, 0x4d, 0x6f, 0x64, 0x65, 0x22, 0x49, 0x0a, 0x14, 0x4f, 0x6e, 0x65,
+	0x57, 0x69, 0x72, 0x65, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03,
+	0x70, 0x69, 0x6e, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x65, 0x61, 0x72, 0x63, 0x68, 0x5f, 0x6d, 0x6f,
+	0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x73, 0x65, 0x61, 0x72, 0x63, 0x68,
+	0x4d, 0x6f, 0x64, 0x65, 0x22, 0x30, 0x0a, 0x10, 0x4f, 0x6e, 0x65, 0x57, 0x69, 0x72, 0x65, 0x41,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x61, 0x64, 0x64, 0x72,
+	0x65, 0x73, 0x73, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x09, 0x61, 0x64, 0x64,
+	0x72, 0x65, 0x73, 0x73, 0x65, 0x73, 0x22, 0xd2, 0x01, 0x0a, 0x15, 0x4f, 0x6e, 0x65, 0x57, 0x69,
+	0x72, 0x65, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x10, 0x0a, 0x03, 0x70, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x70,
+	0x69, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x18, 0x0a, 0x07,
+	0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x61,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x65, 0x61, 0x64, 0x5f, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x72, 0x65, 0x61, 0x64,
+	0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x25, 0x0a, 0x0e, 0x63, 0x6f, 0x72, 0x72, 0x65, 0x6c, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x63,
+	0x6f, 0x72, 0x72, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x12, 0x19, 0x0a, 0x08,
+	0x64, 0x65, 0x6c, 0x61, 0x79, 0x5f, 0x6d, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07,
+	0x64, 0x65, 0x6c, 0x61, 0x79, 0x4d, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x4e, 0x0a, 0x0c, 0x46,
+	0x69, 0x72, 0x6d, 0x77, 0x61, 0x72, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x12, 0x0a, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12,
+	0x14, 0x0a, 0x05, 0x6d, 0x61, 0x6a, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05,
+	0x6d, 0x61, 0x6a, 0x6f, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x6d, 0x69, 0x6e, 0x6f, 0x72, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6d, 0x69, 0x6e, 0x6f, 0x72, 0x22, 0x3d, 0x0a, 0x07, 0x50,
+	0x69, 0x6e, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x12, 0x1e, 0x0a, 0x0a, 0x72, 0x65,
+	0x73, 0x6f, 0x6c, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a,
+	0x72, 0x65, 0x73, 0x6f, 0x6c, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0xe8, 0x01, 0x0a, 0x07, 0x50,
+	0x69, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x14,
+	0x0a, 0x05, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6c,
+	0x61, 0x62, 0x65, 0x6c, 0x12, 0x25, 0x0a, 0x0e, 0x61, 0x6e, 0x61, 0x6c, 0x6f, 0x67, 0x5f, 0x63,
+	0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x11, 0x52, 0x0d, 0x61, 0x6e,
+	0x61, 0x6c, 0x6f, 0x67, 0x43, 0x68, 0x61, 0x6e, 0x6e, 0x65, 0x6c, 0x12, 0x26, 0x0a, 0x05, 0x6d,
+	0x6f, 0x64, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x66, 0x69, 0x72,
+	0x6d, 0x61, 0x74, 0x61, 0x2e, 0x50, 0x69, 0x6e, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x05, 0x6d, 0x6f,
+	0x64, 0x65, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x6d, 0x6f, 0x64, 0x65, 0x5f,
+	0x73, 0x65, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x6d, 0x6f, 0x64, 0x65, 0x53,
+	0x65, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x68, 0x61, 0x73, 0x5f,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x68, 0x61, 0x73,
+	0x56, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x2f, 0x0a, 0x07, 0x50, 0x69, 0x6e, 0x4c, 0x69, 0x73, 0x74,
+	0x12, 0x24, 0x0a, 0x04, 0x70, 0x69, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10,
+	0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x50, 0x69, 0x6e, 0x49, 0x6e, 0x66, 0x6f,
+	0x52, 0x04, 0x70, 0x69, 0x6e, 0x73, 0x22, 0x6e, 0x0a, 0x06, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74,
+	0x12, 0x10, 0x0a, 0x03, 0x70, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x70,
+	0x69, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x6e, 0x61, 0x6c, 0x6f, 0x67, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x06, 0x61, 0x6e, 0x61, 0x6c, 0x6f, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x12, 0x24, 0x0a, 0x0e, 0x74, 0x69, 0x6d, 0x65, 0x5f, 0x75, 0x6e, 0x69, 0x78, 0x5f, 0x6e, 0x61,
+	0x6e, 0x6f, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x74, 0x69, 0x6d, 0x65, 0x55, 0x6e,
+	0x69, 0x78, 0x4e, 0x61, 0x6e, 0x6f, 0x32, 0xeb, 0x0a, 0x0a, 0x07, 0x46, 0x69, 0x72, 0x6d, 0x61,
+	0x74, 0x61, 0x12, 0x35, 0x0a, 0x0a, 0x53, 0x65, 0x74, 0x50, 0x69, 0x6e, 0x4d, 0x6f, 0x64, 0x65,
+	0x12, 0x17, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x50, 0x69, 0x6e, 0x4d, 0x6f,
+	0x64, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x66, 0x69, 0x72, 0x6d,
+	0x61, 0x74, 0x61, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x30, 0x0a, 0x09, 0x53, 0x65, 0x74,
+	0x50, 0x75, 0x6c, 0x6c, 0x75, 0x70, 0x12, 0x13, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61,
+	0x2e, 0x50, 0x69, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x66, 0x69,
+	0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x37, 0x0a, 0x0c, 0x44,
+	0x69, 0x67, 0x69, 0x74, 0x61, 0x6c, 0x57, 0x72, 0x69, 0x74, 0x65, 0x12, 0x17, 0x2e, 0x66, 0x69,
+	0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x44, 0x69, 0x67, 0x69, 0x74, 0x61, 0x6c, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x45,
+	0x6d, 0x70, 0x74, 0x79, 0x12, 0x35, 0x0a, 0x0b, 0x41, 0x6e, 0x61, 0x6c, 0x6f, 0x67, 0x57, 0x72,
+	0x69, 0x74, 0x65, 0x12, 0x16, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x41, 0x6e,
+	0x61, 0x6c, 0x6f, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x66, 0x69,
+	0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x3d, 0x0a, 0x12, 0x45,
+	0x6e, 0x61, 0x62, 0x6c, 0x65, 0x44, 0x69, 0x67, 0x69, 0x74, 0x61, 0x6c, 0x49, 0x6e, 0x70, 0x75,
+	0x74, 0x12, 0x17, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x44, 0x69, 0x67, 0x69,
+	0x74, 0x61, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x66, 0x69, 0x72,
+	0x6d, 0x61, 0x74, 0x61, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x3c, 0x0a, 0x11, 0x45, 0x6e,
+	0x61, 0x62, 0x6c, 0x65, 0x41, 0x6e, 0x61, 0x6c, 0x6f, 0x67, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x12,
+	0x17, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x44, 0x69, 0x67, 0x69, 0x74, 0x61,
+	0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61,
+	0x74, 0x61, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x45, 0x0a, 0x19, 0x53, 0x65, 0x74, 0x41,
+	0x6e, 0x61, 0x6c, 0x6f, 0x67, 0x53, 0x61, 0x6d, 0x70, 0x6c, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x74,
+	0x65, 0x72, 0x76, 0x61, 0x6c, 0x12, 0x18, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e,
+	0x53, 0x61, 0x6d, 0x70, 0x6c, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x0e, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12,
+	0x36, 0x0a, 0x09, 0x49, 0x32, 0x43, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x19, 0x2e, 0x66,
+	0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x49, 0x32, 0x43, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74,
+	0x61, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x2f, 0x0a, 0x08, 0x49, 0x32, 0x43, 0x57, 0x72,
+	0x69, 0x74, 0x65, 0x12, 0x13, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x49, 0x32,
+	0x43, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61,
+	0x74, 0x61, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x2d, 0x0a, 0x07, 0x49, 0x32, 0x43, 0x52,
+	0x65, 0x61, 0x64, 0x12, 0x13, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x49, 0x32,
+	0x43, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0d, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61,
+	0x74, 0x61, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x12, 0x3d, 0x0a, 0x11, 0x49, 0x32, 0x43, 0x52, 0x65,
+	0x61, 0x64, 0x43, 0x6f, 0x6e, 0x74, 0x69, 0x6e, 0x75, 0x6f, 0x75, 0x73, 0x12, 0x13, 0x2e, 0x66,
+	0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x49, 0x32, 0x43, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x11, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x49, 0x32, 0x43, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x30, 0x01, 0x12, 0x35, 0x0a, 0x0e, 0x49, 0x32, 0x43, 0x53, 0x74, 0x6f,
+	0x70, 0x52, 0x65, 0x61, 0x64, 0x69, 0x6e, 0x67, 0x12, 0x13, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61,
+	0x74, 0x61, 0x2e, 0x49, 0x32, 0x43, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e,
+	0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x33, 0x0a,
+	0x0c, 0x49, 0x32, 0x43, 0x42, 0x75, 0x6c, 0x6b, 0x57, 0x72, 0x69, 0x74, 0x65, 0x12, 0x13, 0x2e,
+	0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x49, 0x32, 0x43, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x45, 0x6d, 0x70,
+	0x74, 0x79, 0x12, 0x31, 0x0a, 0x0b, 0x49, 0x32, 0x43, 0x42, 0x75, 0x6c, 0x6b, 0x52, 0x65, 0x61,
+	0x64, 0x12, 0x13, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x49, 0x32, 0x43, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0d, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61,
+	0x2e, 0x44, 0x61, 0x74, 0x61, 0x12, 0x3e, 0x0a, 0x0d, 0x4f, 0x6e, 0x65, 0x57, 0x69, 0x72, 0x65,
+	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x1d, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61,
+	0x2e, 0x4f, 0x6e, 0x65, 0x57, 0x69, 0x72, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e,
+	0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x49, 0x0a, 0x0d, 0x4f, 0x6e, 0x65, 0x57, 0x69, 0x72, 0x65,
+	0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x12, 0x1d, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61,
+	0x2e, 0x4f, 0x6e, 0x65, 0x57, 0x69, 0x72, 0x65, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e,
+	0x4f, 0x6e, 0x65, 0x57, 0x69, 0x72, 0x65, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x65, 0x73,
+	0x12, 0x3f, 0x0a, 0x0e, 0x4f, 0x6e, 0x65, 0x57, 0x69, 0x72, 0x65, 0x43, 0x6f, 0x6d, 0x6d, 0x61,
+	0x6e, 0x64, 0x12, 0x1e, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x4f, 0x6e, 0x65,
+	0x57, 0x69, 0x72, 0x65, 0x43, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x0d, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x44, 0x61, 0x74,
+	0x61, 0x12, 0x35, 0x0a, 0x0e, 0x4f, 0x6e, 0x65, 0x57, 0x69, 0x72, 0x65, 0x52, 0x65, 0x6c, 0x65,
+	0x61, 0x73, 0x65, 0x12, 0x13, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x50, 0x69,
+	0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61,
+	0x74, 0x61, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x36, 0x0a, 0x0d, 0x51, 0x75, 0x65, 0x72,
+	0x79, 0x46, 0x69, 0x72, 0x6d, 0x77, 0x61, 0x72, 0x65, 0x12, 0x0e, 0x2e, 0x66, 0x69, 0x72, 0x6d,
+	0x61, 0x74, 0x61, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x15, 0x2e, 0x66, 0x69, 0x72, 0x6d,
+	0x61, 0x74, 0x61, 0x2e, 0x46, 0x69, 0x72, 0x6d, 0x77, 0x61, 0x72, 0x65, 0x49, 0x6e, 0x66, 0x6f,
+	0x12, 0x33, 0x0a, 0x11, 0x51, 0x75, 0x65, 0x72, 0x79, 0x43, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c,
+	0x69, 0x74, 0x69, 0x65, 0x73, 0x12, 0x0e, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e,
+	0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x0e, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e,
+	0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x34, 0x0a, 0x12, 0x51, 0x75, 0x65, 0x72, 0x79, 0x41, 0x6e,
+	0x61, 0x6c, 0x6f, 0x67, 0x4d, 0x61, 0x70, 0x70, 0x69, 0x6e, 0x67, 0x12, 0x0e, 0x2e, 0x66, 0x69,
+	0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x0e, 0x2e, 0x66, 0x69,
+	0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x28, 0x0a, 0x04, 0x50,
+	0x69, 0x6e, 0x73, 0x12, 0x0e, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x45, 0x6d,
+	0x70, 0x74, 0x79, 0x1a, 0x10, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x50, 0x69,
+	0x6e, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x26, 0x0a, 0x04, 0x50, 0x69, 0x6e, 0x67, 0x12, 0x0e, 0x2e,
+	0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x0e, 0x2e,
+	0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x27, 0x0a,
+	0x05, 0x46, 0x6c, 0x75, 0x73, 0x68, 0x12, 0x0e, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61,
+	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x1a, 0x0e, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61,
+	0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x2c, 0x0a, 0x07, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74,
+	0x73, 0x12, 0x0e, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x45, 0x6d, 0x70, 0x74,
+	0x79, 0x1a, 0x0f, 0x2e, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2e, 0x52, 0x65, 0x70, 0x6f,
+	0x72, 0x74, 0x30, 0x01, 0x42, 0x2d, 0x5a, 0x2b, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
+	0x6f, 0x6d, 0x2f, 0x62, 0x75, 0x78, 0x74, 0x72, 0x6f, 0x6e, 0x69, 0x78, 0x2f, 0x67, 0x6f, 0x2d,
+	0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x2f, 0x66, 0x69, 0x72, 0x6d, 0x61, 0x74, 0x61, 0x67,
+	0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_firmata_proto_rawDescOnce sync.Once
+	file_firmata_proto_rawDescData = file_firmata_proto_rawDesc
+)
+
+func file_firmata_proto_rawDescGZIP() []byte {
+	file_firmata_proto_rawDescOnce.Do(func() {
+		file_firmata_proto_rawDescData = protoimpl.X.CompressGZIP(file_firmata_proto_rawDescData)
+	})
+	return file_firmata_proto_rawDescData
+}
+
+var file_firmata_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
+var file_firmata_proto_goTypes = []interface{}{
+	(*Empty)(nil),                 // 0: firmata.Empty
+	(*PinRequest)(nil),            // 1: firmata.PinRequest
+	(*PinModeRequest)(nil),        // 2: firmata.PinModeRequest
+	(*DigitalRequest)(nil),        // 3: firmata.DigitalRequest
+	(*AnalogRequest)(nil),         // 4: firmata.AnalogRequest
+	(*SamplingRequest)(nil),       // 5: firmata.SamplingRequest
+	(*I2CConfigRequest)(nil),      // 6: firmata.I2CConfigRequest
+	(*I2CBulkOptions)(nil),        // 7: firmata.I2CBulkOptions
+	(*I2CRequest)(nil),            // 8: firmata.I2CRequest
+	(*I2CReply)(nil),              // 9: firmata.I2CReply
+	(*Data)(nil),                  // 10: firmata.Data
+	(*OneWireConfigRequest)(nil),  // 11: firmata.OneWireConfigRequest
+	(*OneWireSearchRequest)(nil),  // 12: firmata.OneWireSearchRequest
+	(*OneWireAddresses)(nil),      // 13: firmata.OneWireAddresses
+	(*OneWireCommandRequest)(nil), // 14: firmata.OneWireCommandRequest
+	(*FirmwareInfo)(nil),          // 15: firmata.FirmwareInfo
+	(*PinMode)(nil),               // 16: firmata.PinMode
+	(*PinInfo)(nil),               // 17: firmata.PinInfo
+	(*PinList)(nil),               // 18: firmata.PinList
+	(*Report)(nil),                // 19: firmata.Report
+}
+var file_firmata_proto_depIdxs = []int32{
+	7,  // 0: firmata.I2CRequest.bulk:type_name -> firmata.I2CBulkOptions
+	16, // 1: firmata.PinInfo.modes:type_name -> firmata.PinMode
+	17, // 2: firmata.PinList.pins:type_name -> firmata.PinInfo
+	2,  // 3: firmata.Firmata.SetPinMode:input_type -> firmata.PinModeRequest
+	1,  // 4: firmata.Firmata.SetPullup:input_type -> firmata.PinRequest
+	3,  // 5: firmata.Firmata.DigitalWrite:input_type -> firmata.DigitalRequest
+	4,  // 6: firmata.Firmata.AnalogWrite:input_type -> firmata.AnalogRequest
+	3,  // 7: firmata.Firmata.EnableDigitalInput:input_type -> firmata.DigitalRequest
+	3,  // 8: firmata.Firmata.EnableAnalogInput:input_type -> firmata.DigitalRequest
+	5,  // 9: firmata.Firmata.SetAnalogSamplingInterval:input_type -> firmata.SamplingRequest
+	6,  // 10: firmata.Firmata.I2CConfig:input_type -> firmata.I2CConfigRequest
+	8,  // 11: firmata.Firmata.I2CWrite:input_type -> firmata.I2CRequest
+	8,  // 12: firmata.Firmata.I2CRead:input_type -> firmata.I2CRequest
+	8,  // 13: firmata.Firmata.I2CReadContinuous:input_type -> firmata.I2CRequest
+	8,  // 14: firmata.Firmata.I2CStopReading:input_type -> firmata.I2CRequest
+	8,  // 15: firmata.Firmata.I2CBulkWrite:input_type -> firmata.I2CRequest
+	8,  // 16: firmata.Firmata.I2CBulkRead:input_type -> firmata.I2CRequest
+	11, // 17: firmata.Firmata.OneWireConfig:input_type -> firmata.OneWireConfigRequest
+	12, // 18: firmata.Firmata.OneWireSearch:input_type -> firmata.OneWireSearchRequest
+	14, // 19: firmata.Firmata.OneWireCommand:input_type -> firmata.OneWireCommandRequest
+	1,  // 20: firmata.Firmata.OneWireRelease:input_type -> firmata.PinRequest
+	0,  // 21: firmata.Firmata.QueryFirmware:input_type -> firmata.Empty
+	0,  // 22: firmata.Firmata.QueryCapabilities:input_type -> firmata.Empty
+	0,  // 23: firmata.Firmata.QueryAnalogMapping:input_type -> firmata.Empty
+	0,  // 24: firmata.Firmata.Pins:input_type -> firmata.Empty
+	0,  // 25: firmata.Firmata.Ping:input_type -> firmata.Empty
+	0,  // 26: firmata.Firmata.Flush:input_type -> firmata.Empty
+	0,  // 27: firmata.Firmata.Reports:input_type -> firmata.Empty
+	0,  // 28: firmata.Firmata.SetPinMode:output_type -> firmata.Empty
+	0,  // 29: firmata.Firmata.SetPullup:output_type -> firmata.Empty
+	0,  // 30: firmata.Firmata.DigitalWrite:output_type -> firmata.Empty
+	0,  // 31: firmata.Firmata.AnalogWrite:output_type -> firmata.Empty
+	0,  // 32: firmata.Firmata.EnableDigitalInput:output_type -> firmata.Empty
+	0,  // 33: firmata.Firmata.EnableAnalogInput:output_type -> firmata.Empty
+	0,  // 34: firmata.Firmata.SetAnalogSamplingInterval:output_type -> firmata.Empty
+	0,  // 35: firmata.Firmata.I2CConfig:output_type -> firmata.Empty
+	0,  // 36: firmata.Firmata.I2CWrite:output_type -> firmata.Empty
+	10, // 37: firmata.Firmata.I2CRead:output_type -> firmata.Data
+	9,  // 38: firmata.Firmata.I2CReadContinuous:output_type -> firmata.I2CReply
+	0,  // 39: firmata.Firmata.I2CStopReading:output_type -> firmata.Empty
+	0,  // 40: firmata.Firmata.I2CBulkWrite:output_type -> firmata.Empty
+	10, // 41: firmata.Firmata.I2CBulkRead:output_type -> firmata.Data
+	0,  // 42: firmata.Firmata.OneWireConfig:output_type -> firmata.Empty
+	13, // 43: firmata.Firmata.OneWireSearch:output_type -> firmata.OneWireAddresses
+	10, // 44: firmata.Firmata.OneWireCommand:output_type -> firmata.Data
+	0,  // 45: firmata.Firmata.OneWireRelease:output_type -> firmata.Empty
+	15, // 46: firmata.Firmata.QueryFirmware:output_type -> firmata.FirmwareInfo
+	0,  // 47: firmata.Firmata.QueryCapabilities:output_type -> firmata.Empty
+	0,  // 48: firmata.Firmata.QueryAnalogMapping:output_type -> firmata.Empty
+	18, // 49: firmata.Firmata.Pins:output_type -> firmata.PinList
+	0,  // 50: firmata.Firmata.Ping:output_type -> firmata.Empty
+	0,  // 51: firmata.Firmata.Flush:output_type -> firmata.Empty
+	19, // 52: firmata.Firmata.Reports:output_type -> firmata.Report
+	28, // [28:53] is the sub-list for method output_type
+	3,  // [3:28] is the sub-list for method input_type
+	3,  // [3:3] is the sub-list for extension type_name
+	3,  // [3:3] is the sub-list for extension extendee
+	0,  // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_firmata_proto_init() }
+func file_firmata_proto_init() {
+	if File_firmata_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_firmata_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Empty); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_firmata_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PinRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_firmata_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PinModeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_firmata_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DigitalRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_firmata_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AnalogRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_firmata_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SamplingRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_firmata_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*I2CConfigRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_firmata_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*I2CBulkOptions); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_firmata_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*I2CRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_firmata_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*I2CReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_firmata_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Data); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_firmata_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*OneWireConfigRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_firmata_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*OneWireSearchRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_firmata_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*OneWireAddresses); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_firmata_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*OneWireCommandRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_firmata_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FirmwareInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_firmata_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PinMode); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_firmata_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PinInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_firmata_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PinList); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_firmata_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Report); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_firmata_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   20,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_firmata_proto_goTypes,
+		DependencyIndexes: file_firmata_proto_depIdxs,
+		MessageInfos:      file_firmata_proto_msgTypes,
+	}.Build()
+	File_firmata_proto = out.File
+	file_firmata_proto_rawDesc = nil
+	file_firmata_proto_goTypes = nil
+	file_firmata_proto_depIdxs = nil
+}
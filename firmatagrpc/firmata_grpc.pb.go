@@ -0,0 +1,1123 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: firmata.proto
+
+package firmatagrpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	Firmata_SetPinMode_FullMethodName                = "/firmata.Firmata/SetPinMode"
+	Firmata_SetPullup_FullMethodName                 = "/firmata.Firmata/SetPullup"
+	Firmata_DigitalWrite_FullMethodName              = "/firmata.Firmata/DigitalWrite"
+	Firmata_AnalogWrite_FullMethodName               = "/firmata.Firmata/AnalogWrite"
+	Firmata_EnableDigitalInput_FullMethodName        = "/firmata.Firmata/EnableDigitalInput"
+	Firmata_EnableAnalogInput_FullMethodName         = "/firmata.Firmata/EnableAnalogInput"
+	Firmata_SetAnalogSamplingInterval_FullMethodName = "/firmata.Firmata/SetAnalogSamplingInterval"
+	Firmata_I2CConfig_FullMethodName                 = "/firmata.Firmata/I2CConfig"
+	Firmata_I2CWrite_FullMethodName                  = "/firmata.Firmata/I2CWrite"
+	Firmata_I2CRead_FullMethodName                   = "/firmata.Firmata/I2CRead"
+	Firmata_I2CReadContinuous_FullMethodName         = "/firmata.Firmata/I2CReadContinuous"
+	Firmata_I2CStopReading_FullMethodName            = "/firmata.Firmata/I2CStopReading"
+	Firmata_I2CBulkWrite_FullMethodName              = "/firmata.Firmata/I2CBulkWrite"
+	Firmata_I2CBulkRead_FullMethodName               = "/firmata.Firmata/I2CBulkRead"
+	Firmata_OneWireConfig_FullMethodName             = "/firmata.Firmata/OneWireConfig"
+	Firmata_OneWireSearch_FullMethodName             = "/firmata.Firmata/OneWireSearch"
+	Firmata_OneWireCommand_FullMethodName            = "/firmata.Firmata/OneWireCommand"
+	Firmata_OneWireRelease_FullMethodName            = "/firmata.Firmata/OneWireRelease"
+	Firmata_QueryFirmware_FullMethodName             = "/firmata.Firmata/QueryFirmware"
+	Firmata_QueryCapabilities_FullMethodName         = "/firmata.Firmata/QueryCapabilities"
+	Firmata_QueryAnalogMapping_FullMethodName        = "/firmata.Firmata/QueryAnalogMapping"
+	Firmata_Pins_FullMethodName                      = "/firmata.Firmata/Pins"
+	Firmata_Ping_FullMethodName                      = "/firmata.Firmata/Ping"
+	Firmata_Flush_FullMethodName                     = "/firmata.Firmata/Flush"
+	Firmata_Reports_FullMethodName                   = "/firmata.Firmata/Reports"
+)
+
+// FirmataClient is the client API for Firmata service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Firmata serves a board connected to another machine. The calls mirror
+// those of the Go client, and fail with these codes:
+//
+//	NOT_FOUND            the pin is not a pin of the board
+//	INVALID_ARGUMENT     the pin does not support the mode
+//	FAILED_PRECONDITION  the feature is not configured or not in the firmware
+//	DEADLINE_EXCEEDED    the board did not reply in time
+//	UNAVAILABLE          the server has lost the board
+//	DATA_LOSS            data from a device failed its CRC check
+type FirmataClient interface {
+	SetPinMode(ctx context.Context, in *PinModeRequest, opts ...grpc.CallOption) (*Empty, error)
+	SetPullup(ctx context.Context, in *PinRequest, opts ...grpc.CallOption) (*Empty, error)
+	DigitalWrite(ctx context.Context, in *DigitalRequest, opts ...grpc.CallOption) (*Empty, error)
+	AnalogWrite(ctx context.Context, in *AnalogRequest, opts ...grpc.CallOption) (*Empty, error)
+	EnableDigitalInput(ctx context.Context, in *DigitalRequest, opts ...grpc.CallOption) (*Empty, error)
+	EnableAnalogInput(ctx context.Context, in *DigitalRequest, opts ...grpc.CallOption) (*Empty, error)
+	SetAnalogSamplingInterval(ctx context.Context, in *SamplingRequest, opts ...grpc.CallOption) (*Empty, error)
+	I2CConfig(ctx context.Context, in *I2CConfigRequest, opts ...grpc.CallOption) (*Empty, error)
+	I2CWrite(ctx context.Context, in *I2CRequest, opts ...grpc.CallOption) (*Empty, error)
+	I2CRead(ctx context.Context, in *I2CRequest, opts ...grpc.CallOption) (*Data, error)
+	// I2CReadContinuous streams the replies of a continuous read until the
+	// call is cancelled or I2CStopReading is called for the device.
+	I2CReadContinuous(ctx context.Context, in *I2CRequest, opts ...grpc.CallOption) (Firmata_I2CReadContinuousClient, error)
+	I2CStopReading(ctx context.Context, in *I2CRequest, opts ...grpc.CallOption) (*Empty, error)
+	I2CBulkWrite(ctx context.Context, in *I2CRequest, opts ...grpc.CallOption) (*Empty, error)
+	I2CBulkRead(ctx context.Context, in *I2CRequest, opts ...grpc.CallOption) (*Data, error)
+	OneWireConfig(ctx context.Context, in *OneWireConfigRequest, opts ...grpc.CallOption) (*Empty, error)
+	OneWireSearch(ctx context.Context, in *OneWireSearchRequest, opts ...grpc.CallOption) (*OneWireAddresses, error)
+	OneWireCommand(ctx context.Context, in *OneWireCommandRequest, opts ...grpc.CallOption) (*Data, error)
+	OneWireRelease(ctx context.Context, in *PinRequest, opts ...grpc.CallOption) (*Empty, error)
+	QueryFirmware(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*FirmwareInfo, error)
+	QueryCapabilities(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
+	QueryAnalogMapping(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
+	Pins(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*PinList, error)
+	// Ping pings the board from the server.
+	Ping(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
+	Flush(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
+	// Reports streams the last known value of each input, then every
+	// change to the digital inputs and every analog input reading, until
+	// the call is cancelled.
+	Reports(ctx context.Context, in *Empty, opts ...grpc.CallOption) (Firmata_ReportsClient, error)
+}
+
+type firmataClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFirmataClient(cc grpc.ClientConnInterface) FirmataClient {
+	return &firmataClient{cc}
+}
+
+func (c *firmataClient) SetPinMode(ctx context.Context, in *PinModeRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Firmata_SetPinMode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) SetPullup(ctx context.Context, in *PinRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Firmata_SetPullup_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) DigitalWrite(ctx context.Context, in *DigitalRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Firmata_DigitalWrite_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) AnalogWrite(ctx context.Context, in *AnalogRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Firmata_AnalogWrite_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) EnableDigitalInput(ctx context.Context, in *DigitalRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Firmata_EnableDigitalInput_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) EnableAnalogInput(ctx context.Context, in *DigitalRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Firmata_EnableAnalogInput_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) SetAnalogSamplingInterval(ctx context.Context, in *SamplingRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Firmata_SetAnalogSamplingInterval_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) I2CConfig(ctx context.Context, in *I2CConfigRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Firmata_I2CConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) I2CWrite(ctx context.Context, in *I2CRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Firmata_I2CWrite_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) I2CRead(ctx context.Context, in *I2CRequest, opts ...grpc.CallOption) (*Data, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Data)
+	err := c.cc.Invoke(ctx, Firmata_I2CRead_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) I2CReadContinuous(ctx context.Context, in *I2CRequest, opts ...grpc.CallOption) (Firmata_I2CReadContinuousClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Firmata_ServiceDesc.Streams[0], Firmata_I2CReadContinuous_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &firmataI2CReadContinuousClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Firmata_I2CReadContinuousClient interface {
+	Recv() (*I2CReply, error)
+	grpc.ClientStream
+}
+
+type firmataI2CReadContinuousClient struct {
+	grpc.ClientStream
+}
+
+func (x *firmataI2CReadContinuousClient) Recv() (*I2CReply, error) {
+	m := new(I2CReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *firmataClient) I2CStopReading(ctx context.Context, in *I2CRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Firmata_I2CStopReading_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) I2CBulkWrite(ctx context.Context, in *I2CRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Firmata_I2CBulkWrite_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) I2CBulkRead(ctx context.Context, in *I2CRequest, opts ...grpc.CallOption) (*Data, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Data)
+	err := c.cc.Invoke(ctx, Firmata_I2CBulkRead_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) OneWireConfig(ctx context.Context, in *OneWireConfigRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Firmata_OneWireConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) OneWireSearch(ctx context.Context, in *OneWireSearchRequest, opts ...grpc.CallOption) (*OneWireAddresses, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OneWireAddresses)
+	err := c.cc.Invoke(ctx, Firmata_OneWireSearch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) OneWireCommand(ctx context.Context, in *OneWireCommandRequest, opts ...grpc.CallOption) (*Data, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Data)
+	err := c.cc.Invoke(ctx, Firmata_OneWireCommand_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) OneWireRelease(ctx context.Context, in *PinRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Firmata_OneWireRelease_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) QueryFirmware(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*FirmwareInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FirmwareInfo)
+	err := c.cc.Invoke(ctx, Firmata_QueryFirmware_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) QueryCapabilities(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Firmata_QueryCapabilities_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) QueryAnalogMapping(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Firmata_QueryAnalogMapping_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) Pins(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*PinList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PinList)
+	err := c.cc.Invoke(ctx, Firmata_Pins_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) Ping(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Firmata_Ping_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) Flush(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, Firmata_Flush_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) Reports(ctx context.Context, in *Empty, opts ...grpc.CallOption) (Firmata_ReportsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Firmata_ServiceDesc.Streams[1], Firmata_Reports_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &firmataReportsClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Firmata_ReportsClient interface {
+	Recv() (*Report, error)
+	grpc.ClientStream
+}
+
+type firmataReportsClient struct {
+	grpc.ClientStream
+}
+
+func (x *firmataReportsClient) Recv() (*Report, error) {
+	m := new(Report)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// FirmataServer is the server API for Firmata service.
+// All implementations must embed UnimplementedFirmataServer
+// for forward compatibility
+//
+// Firmata serves a board connected to another machine. The calls mirror
+// those of the Go client, and fail with these codes:
+//
+//	NOT_FOUND            the pin is not a pin of the board
+//	INVALID_ARGUMENT     the pin does not support the mode
+//	FAILED_PRECONDITION  the feature is not configured or not in the firmware
+//	DEADLINE_EXCEEDED    the board did not reply in time
+//	UNAVAILABLE          the server has lost the board
+//	DATA_LOSS            data from a device failed its CRC check
+type FirmataServer interface {
+	SetPinMode(context.Context, *PinModeRequest) (*Empty, error)
+	SetPullup(context.Context, *PinRequest) (*Empty, error)
+	DigitalWrite(context.Context, *DigitalRequest) (*Empty, error)
+	AnalogWrite(context.Context, *AnalogRequest) (*Empty, error)
+	EnableDigitalInput(context.Context, *DigitalRequest) (*Empty, error)
+	EnableAnalogInput(context.Context, *DigitalRequest) (*Empty, error)
+	SetAnalogSamplingInterval(context.Context, *SamplingRequest) (*Empty, error)
+	I2CConfig(context.Context, *I2CConfigRequest) (*Empty, error)
+	I2CWrite(context.Context, *I2CRequest) (*Empty, error)
+	I2CRead(context.Context, *I2CRequest) (*Data, error)
+	// I2CReadContinuous streams the replies of a continuous read until the
+	// call is cancelled or I2CStopReading is called for the device.
+	I2CReadContinuous(*I2CRequest, Firmata_I2CReadContinuousServer) error
+	I2CStopReading(context.Context, *I2CRequest) (*Empty, error)
+	I2CBulkWrite(context.Context, *I2CRequest) (*Empty, error)
+	I2CBulkRead(context.Context, *I2CRequest) (*Data, error)
+	OneWireConfig(context.Context, *OneWireConfigRequest) (*Empty, error)
+	OneWireSearch(context.Context, *OneWireSearchRequest) (*OneWireAddresses, error)
+	OneWireCommand(context.Context, *OneWireCommandRequest) (*Data, error)
+	OneWireRelease(context.Context, *PinRequest) (*Empty, error)
+	QueryFirmware(context.Context, *Empty) (*FirmwareInfo, error)
+	QueryCapabilities(context.Context, *Empty) (*Empty, error)
+	QueryAnalogMapping(context.Context, *Empty) (*Empty, error)
+	Pins(context.Context, *Empty) (*PinList, error)
+	// Ping pings the board from the server.
+	Ping(context.Context, *Empty) (*Empty, error)
+	Flush(context.Context, *Empty) (*Empty, error)
+	// Reports streams the last known value of each input, then every
+	// change to the digital inputs and every analog input reading, until
+	// the call is cancelled.
+	Reports(*Empty, Firmata_ReportsServer) error
+	mustEmbedUnimplementedFirmataServer()
+}
+
+// UnimplementedFirmataServer must be embedded to have forward compatible implementations.
+type UnimplementedFirmataServer struct {
+}
+
+func (UnimplementedFirmataServer) SetPinMode(context.Context, *PinModeRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetPinMode not implemented")
+}
+func (UnimplementedFirmataServer) SetPullup(context.Context, *PinRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetPullup not implemented")
+}
+func (UnimplementedFirmataServer) DigitalWrite(context.Context, *DigitalRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DigitalWrite not implemented")
+}
+func (UnimplementedFirmataServer) AnalogWrite(context.Context, *AnalogRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AnalogWrite not implemented")
+}
+func (UnimplementedFirmataServer) EnableDigitalInput(context.Context, *DigitalRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnableDigitalInput not implemented")
+}
+func (UnimplementedFirmataServer) EnableAnalogInput(context.Context, *DigitalRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnableAnalogInput not implemented")
+}
+func (UnimplementedFirmataServer) SetAnalogSamplingInterval(context.Context, *SamplingRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetAnalogSamplingInterval not implemented")
+}
+func (UnimplementedFirmataServer) I2CConfig(context.Context, *I2CConfigRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method I2CConfig not implemented")
+}
+func (UnimplementedFirmataServer) I2CWrite(context.Context, *I2CRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method I2CWrite not implemented")
+}
+func (UnimplementedFirmataServer) I2CRead(context.Context, *I2CRequest) (*Data, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method I2CRead not implemented")
+}
+func (UnimplementedFirmataServer) I2CReadContinuous(*I2CRequest, Firmata_I2CReadContinuousServer) error {
+	return status.Errorf(codes.Unimplemented, "method I2CReadContinuous not implemented")
+}
+func (UnimplementedFirmataServer) I2CStopReading(context.Context, *I2CRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method I2CStopReading not implemented")
+}
+func (UnimplementedFirmataServer) I2CBulkWrite(context.Context, *I2CRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method I2CBulkWrite not implemented")
+}
+func (UnimplementedFirmataServer) I2CBulkRead(context.Context, *I2CRequest) (*Data, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method I2CBulkRead not implemented")
+}
+func (UnimplementedFirmataServer) OneWireConfig(context.Context, *OneWireConfigRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OneWireConfig not implemented")
+}
+func (UnimplementedFirmataServer) OneWireSearch(context.Context, *OneWireSearchRequest) (*OneWireAddresses, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OneWireSearch not implemented")
+}
+func (UnimplementedFirmataServer) OneWireCommand(context.Context, *OneWireCommandRequest) (*Data, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OneWireCommand not implemented")
+}
+func (UnimplementedFirmataServer) OneWireRelease(context.Context, *PinRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OneWireRelease not implemented")
+}
+func (UnimplementedFirmataServer) QueryFirmware(context.Context, *Empty) (*FirmwareInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryFirmware not implemented")
+}
+func (UnimplementedFirmataServer) QueryCapabilities(context.Context, *Empty) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryCapabilities not implemented")
+}
+func (UnimplementedFirmataServer) QueryAnalogMapping(context.Context, *Empty) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryAnalogMapping not implemented")
+}
+func (UnimplementedFirmataServer) Pins(context.Context, *Empty) (*PinList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Pins not implemented")
+}
+func (UnimplementedFirmataServer) Ping(context.Context, *Empty) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
+}
+func (UnimplementedFirmataServer) Flush(context.Context, *Empty) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Flush not implemented")
+}
+func (UnimplementedFirmataServer) Reports(*Empty, Firmata_ReportsServer) error {
+	return status.Errorf(codes.Unimplemented, "method Reports not implemented")
+}
+func (UnimplementedFirmataServer) mustEmbedUnimplementedFirmataServer() {}
+
+// UnsafeFirmataServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FirmataServer will
+// result in compilation errors.
+type UnsafeFirmataServer interface {
+	mustEmbedUnimplementedFirmataServer()
+}
+
+func RegisterFirmataServer(s grpc.ServiceRegistrar, srv FirmataServer) {
+	s.RegisterService(&Firmata_ServiceDesc, srv)
+}
+
+func _Firmata_SetPinMode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PinModeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).SetPinMode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_SetPinMode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).SetPinMode(ctx, req.(*PinModeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_SetPullup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).SetPullup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_SetPullup_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).SetPullup(ctx, req.(*PinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_DigitalWrite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DigitalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).DigitalWrite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_DigitalWrite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).DigitalWrite(ctx, req.(*DigitalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_AnalogWrite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AnalogRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).AnalogWrite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_AnalogWrite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).AnalogWrite(ctx, req.(*AnalogRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_EnableDigitalInput_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DigitalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).EnableDigitalInput(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_EnableDigitalInput_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).EnableDigitalInput(ctx, req.(*DigitalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_EnableAnalogInput_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DigitalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).EnableAnalogInput(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_EnableAnalogInput_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).EnableAnalogInput(ctx, req.(*DigitalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_SetAnalogSamplingInterval_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SamplingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).SetAnalogSamplingInterval(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_SetAnalogSamplingInterval_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).SetAnalogSamplingInterval(ctx, req.(*SamplingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_I2CConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(I2CConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).I2CConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_I2CConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).I2CConfig(ctx, req.(*I2CConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_I2CWrite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(I2CRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).I2CWrite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_I2CWrite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).I2CWrite(ctx, req.(*I2CRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_I2CRead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(I2CRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).I2CRead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_I2CRead_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).I2CRead(ctx, req.(*I2CRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_I2CReadContinuous_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(I2CRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FirmataServer).I2CReadContinuous(m, &firmataI2CReadContinuousServer{ServerStream: stream})
+}
+
+type Firmata_I2CReadContinuousServer interface {
+	Send(*I2CReply) error
+	grpc.ServerStream
+}
+
+type firmataI2CReadContinuousServer struct {
+	grpc.ServerStream
+}
+
+func (x *firmataI2CReadContinuousServer) Send(m *I2CReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Firmata_I2CStopReading_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(I2CRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).I2CStopReading(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_I2CStopReading_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).I2CStopReading(ctx, req.(*I2CRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_I2CBulkWrite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(I2CRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).I2CBulkWrite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_I2CBulkWrite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).I2CBulkWrite(ctx, req.(*I2CRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_I2CBulkRead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(I2CRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).I2CBulkRead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_I2CBulkRead_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).I2CBulkRead(ctx, req.(*I2CRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_OneWireConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OneWireConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).OneWireConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_OneWireConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).OneWireConfig(ctx, req.(*OneWireConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_OneWireSearch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OneWireSearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).OneWireSearch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_OneWireSearch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).OneWireSearch(ctx, req.(*OneWireSearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_OneWireCommand_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OneWireCommandRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).OneWireCommand(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_OneWireCommand_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).OneWireCommand(ctx, req.(*OneWireCommandRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_OneWireRelease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).OneWireRelease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_OneWireRelease_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).OneWireRelease(ctx, req.(*PinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_QueryFirmware_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).QueryFirmware(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_QueryFirmware_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).QueryFirmware(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_QueryCapabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).QueryCapabilities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_QueryCapabilities_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).QueryCapabilities(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_QueryAnalogMapping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).QueryAnalogMapping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_QueryAnalogMapping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).QueryAnalogMapping(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_Pins_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).Pins(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_Pins_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).Pins(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_Ping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).Ping(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_Flush_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).Flush(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_Flush_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).Flush(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_Reports_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FirmataServer).Reports(m, &firmataReportsServer{ServerStream: stream})
+}
+
+type Firmata_ReportsServer interface {
+	Send(*Report) error
+	grpc.ServerStream
+}
+
+type firmataReportsServer struct {
+	grpc.ServerStream
+}
+
+func (x *firmataReportsServer) Send(m *Report) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Firmata_ServiceDesc is the grpc.ServiceDesc for Firmata service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Firmata_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "firmata.Firmata",
+	HandlerType: (*FirmataServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SetPinMode",
+			Handler:    _Firmata_SetPinMode_Handler,
+		},
+		{
+			MethodName: "SetPullup",
+			Handler:    _Firmata_SetPullup_Handler,
+		},
+		{
+			MethodName: "DigitalWrite",
+			Handler:    _Firmata_DigitalWrite_Handler,
+		},
+		{
+			MethodName: "AnalogWrite",
+			Handler:    _Firmata_AnalogWrite_Handler,
+		},
+		{
+			MethodName: "EnableDigitalInput",
+			Handler:    _Firmata_EnableDigitalInput_Handler,
+		},
+		{
+			MethodName: "EnableAnalogInput",
+			Handler:    _Firmata_EnableAnalogInput_Handler,
+		},
+		{
+			MethodName: "SetAnalogSamplingInterval",
+			Handler:    _Firmata_SetAnalogSamplingInterval_Handler,
+		},
+		{
+			MethodName: "I2CConfig",
+			Handler:    _Firmata_I2CConfig_Handler,
+		},
+		{
+			MethodName: "I2CWrite",
+			Handler:    _Firmata_I2CWrite_Handler,
+		},
+		{
+			MethodName: "I2CRead",
+			Handler:    _Firmata_I2CRead_Handler,
+		},
+		{
+			MethodName: "I2CStopReading",
+			Handler:    _Firmata_I2CStopReading_Handler,
+		},
+		{
+			MethodName: "I2CBulkWrite",
+			Handler:    _Firmata_I2CBulkWrite_Handler,
+		},
+		{
+			MethodName: "I2CBulkRead",
+			Handler:    _Firmata_I2CBulkRead_Handler,
+		},
+		{
+			MethodName: "OneWireConfig",
+			Handler:    _Firmata_OneWireConfig_Handler,
+		},
+		{
+			MethodName: "OneWireSearch",
+			Handler:    _Firmata_OneWireSearch_Handler,
+		},
+		{
+			MethodName: "OneWireCommand",
+			Handler:    _Firmata_OneWireCommand_Handler,
+		},
+		{
+			MethodName: "OneWireRelease",
+			Handler:    _Firmata_OneWireRelease_Handler,
+		},
+		{
+			MethodName: "QueryFirmware",
+			Handler:    _Firmata_QueryFirmware_Handler,
+		},
+		{
+			MethodName: "QueryCapabilities",
+			Handler:    _Firmata_QueryCapabilities_Handler,
+		},
+		{
+			MethodName: "QueryAnalogMapping",
+			Handler:    _Firmata_QueryAnalogMapping_Handler,
+		},
+		{
+			MethodName: "Pins",
+			Handler:    _Firmata_Pins_Handler,
+		},
+		{
+			MethodName: "Ping",
+			Handler:    _Firmata_Ping_Handler,
+		},
+		{
+			MethodName: "Flush",
+			Handler:    _Firmata_Flush_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "I2CReadContinuous",
+			Handler:       _Firmata_I2CReadContinuous_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Reports",
+			Handler:       _Firmata_Reports_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "firmata.proto",
+}
@@ -0,0 +1,196 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package firmatagrpc serves a board over gRPC, so the machine it is
+// plugged into can share it with other processes and machines. The
+// service is defined in firmata.proto, for clients in other languages.
+//
+// The machine with the board runs a Server:
+//
+//	s := grpc.NewServer()
+//	firmatagrpc.RegisterFirmataServer(s, firmatagrpc.NewServer(client))
+//	s.Serve(lis)
+//
+// and Go programs elsewhere use a Client, which is a firmata.Firmata like
+// a local client:
+//
+//	conn, err := grpc.Dial("pi:7000", grpc.WithTransportCredentials(insecure.NewCredentials()))
+//	board, err := firmatagrpc.NewClient(ctx, conn)
+//	board.DigitalWrite(13, true)
+//
+// Errors from the board keep their firmata error, so errors.Is works on
+// either side of the link.
+package firmatagrpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative firmata.proto
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/buxtronix/go-firmata"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorCodes are the status codes the firmata errors are sent as.
+var errorCodes = []struct {
+	err  error
+	code codes.Code
+}{
+	{firmata.ErrInvalidPin, codes.NotFound},
+	{firmata.ErrUnsupportedPinMode, codes.InvalidArgument},
+	{firmata.ErrFeatureMissing, codes.FailedPrecondition},
+	{firmata.ErrTimeout, codes.DeadlineExceeded},
+	{firmata.ErrNotConnected, codes.Unavailable},
+	{firmata.ErrBadCrc, codes.DataLoss},
+}
+
+// toStatus returns err as a status error for its firmata error.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	for _, e := range errorCodes {
+		if errors.Is(err, e.err) {
+			return status.Error(e.code, err.Error())
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	if errors.Is(err, context.Canceled) {
+		return status.Error(codes.Canceled, err.Error())
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
+
+// remoteError is an error from the server, wrapping the firmata error of
+// its status code.
+type remoteError struct {
+	status *status.Status
+	err    error
+}
+
+func (e *remoteError) Error() string {
+	return e.status.Message()
+}
+
+func (e *remoteError) Unwrap() error {
+	return e.err
+}
+
+// GRPCStatus makes status.FromError return the status of the error.
+func (e *remoteError) GRPCStatus() *status.Status {
+	return e.status
+}
+
+// fromStatus returns a status error as the firmata error of its code.
+func fromStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	for _, e := range errorCodes {
+		if s.Code() == e.code {
+			return &remoteError{s, e.err}
+		}
+	}
+	switch s.Code() {
+	case codes.Canceled:
+		return &remoteError{s, context.Canceled}
+	}
+	return err
+}
+
+func pinInfoProto(p firmata.PinInfo) *PinInfo {
+	out := &PinInfo{
+		Number:        uint32(p.Number),
+		Label:         p.Label,
+		AnalogChannel: int32(p.AnalogChannel),
+		Mode:          uint32(p.Mode),
+		ModeSet:       p.ModeSet,
+		Value:         int32(p.Value),
+		HasValue:      p.HasValue,
+	}
+	for _, m := range p.Modes {
+		out.Modes = append(out.Modes, &PinMode{Mode: uint32(m), Resolution: int32(p.Resolutions[m])})
+	}
+	return out
+}
+
+func pinInfo(p *PinInfo) firmata.PinInfo {
+	out := firmata.PinInfo{
+		Number:        byte(p.Number),
+		Label:         p.Label,
+		AnalogChannel: int(p.AnalogChannel),
+		Resolutions:   make(map[firmata.PinMode]int, len(p.Modes)),
+		Mode:          firmata.PinMode(p.Mode),
+		ModeSet:       p.ModeSet,
+		Value:         int(p.Value),
+		HasValue:      p.HasValue,
+	}
+	for _, m := range p.Modes {
+		out.Modes = append(out.Modes, firmata.PinMode(m.Mode))
+		out.Resolutions[firmata.PinMode(m.Mode)] = int(m.Resolution)
+	}
+	return out
+}
+
+func bulkOptionsProto(o *firmata.I2CBulkOptions) *I2CBulkOptions {
+	if o == nil {
+		return nil
+	}
+	return &I2CBulkOptions{
+		ChunkSize:     int32(o.ChunkSize),
+		FixedRegister: o.FixedRegister,
+		RegisterWidth: int32(o.RegisterWidth),
+		PageSize:      int32(o.PageSize),
+		WriteDelayNs:  int64(o.WriteDelay),
+	}
+}
+
+func bulkOptions(o *I2CBulkOptions) *firmata.I2CBulkOptions {
+	if o == nil {
+		return nil
+	}
+	return &firmata.I2CBulkOptions{
+		ChunkSize:     int(o.ChunkSize),
+		FixedRegister: o.FixedRegister,
+		RegisterWidth: int(o.RegisterWidth),
+		PageSize:      int(o.PageSize),
+		WriteDelay:    time.Duration(o.WriteDelayNs),
+	}
+}
+
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromUnixNano(ns int64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
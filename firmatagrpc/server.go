@@ -0,0 +1,234 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmatagrpc
+
+import (
+	"context"
+
+	"github.com/buxtronix/go-firmata"
+)
+
+// reportBuffer is the number of reports buffered for each Reports stream
+// before the oldest are dropped.
+const reportBuffer = 64
+
+// Server is a FirmataServer serving the board of a client.
+type Server struct {
+	UnimplementedFirmataServer
+	client *firmata.FirmataClient
+}
+
+// NewServer creates a server for the board client is connected to.
+func NewServer(client *firmata.FirmataClient) *Server {
+	return &Server{client: client}
+}
+
+func (s *Server) SetPinMode(ctx context.Context, req *PinModeRequest) (*Empty, error) {
+	return &Empty{}, toStatus(s.client.SetPinMode(byte(req.Pin), firmata.PinMode(req.Mode)))
+}
+
+func (s *Server) SetPullup(ctx context.Context, req *PinRequest) (*Empty, error) {
+	return &Empty{}, toStatus(s.client.SetPullup(byte(req.Pin)))
+}
+
+func (s *Server) DigitalWrite(ctx context.Context, req *DigitalRequest) (*Empty, error) {
+	return &Empty{}, toStatus(s.client.DigitalWrite(uint(req.Pin), req.Value))
+}
+
+func (s *Server) AnalogWrite(ctx context.Context, req *AnalogRequest) (*Empty, error) {
+	return &Empty{}, toStatus(s.client.AnalogWrite(uint(req.Pin), byte(req.Value)))
+}
+
+func (s *Server) EnableDigitalInput(ctx context.Context, req *DigitalRequest) (*Empty, error) {
+	return &Empty{}, toStatus(s.client.EnableDigitalInput(uint(req.Pin), req.Value))
+}
+
+func (s *Server) EnableAnalogInput(ctx context.Context, req *DigitalRequest) (*Empty, error) {
+	return &Empty{}, toStatus(s.client.EnableAnalogInput(uint(req.Pin), req.Value))
+}
+
+func (s *Server) SetAnalogSamplingInterval(ctx context.Context, req *SamplingRequest) (*Empty, error) {
+	return &Empty{}, toStatus(s.client.SetAnalogSamplingInterval(byte(req.Ms)))
+}
+
+func (s *Server) I2CConfig(ctx context.Context, req *I2CConfigRequest) (*Empty, error) {
+	return &Empty{}, toStatus(s.client.I2CConfig(int(req.Delay)))
+}
+
+func (s *Server) I2CWrite(ctx context.Context, req *I2CRequest) (*Empty, error) {
+	return &Empty{}, toStatus(s.client.I2CWrite(byte(req.Address), req.Data...))
+}
+
+func (s *Server) I2CRead(ctx context.Context, req *I2CRequest) (*Data, error) {
+	data, err := s.client.I2CReadContext(ctx, byte(req.Address), int(req.Register), int(req.Count))
+	return &Data{Data: data}, toStatus(err)
+}
+
+// I2CReadContinuous streams a continuous read. If the call is cancelled,
+// every continuous read of the device is stopped.
+func (s *Server) I2CReadContinuous(req *I2CRequest, stream Firmata_I2CReadContinuousServer) error {
+	address := byte(req.Address)
+	ch, err := s.client.I2CReadContinuous(address, int(req.Register), int(req.Count))
+	if err != nil {
+		return toStatus(err)
+	}
+	for {
+		select {
+		case r, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			err := stream.Send(&I2CReply{
+				Address:      uint32(r.Address),
+				Register:     int32(r.Register),
+				Data:         r.Data,
+				TimeUnixNano: unixNano(r.Time),
+			})
+			if err != nil {
+				s.client.I2CStopReading(address)
+				return err
+			}
+		case <-stream.Context().Done():
+			s.client.I2CStopReading(address)
+			return toStatus(stream.Context().Err())
+		}
+	}
+}
+
+func (s *Server) I2CStopReading(ctx context.Context, req *I2CRequest) (*Empty, error) {
+	return &Empty{}, toStatus(s.client.I2CStopReading(byte(req.Address)))
+}
+
+func (s *Server) I2CBulkWrite(ctx context.Context, req *I2CRequest) (*Empty, error) {
+	return &Empty{}, toStatus(s.client.I2CBulkWrite(ctx, byte(req.Address), int(req.Register), req.Data, bulkOptions(req.Bulk)))
+}
+
+func (s *Server) I2CBulkRead(ctx context.Context, req *I2CRequest) (*Data, error) {
+	data, err := s.client.I2CBulkRead(ctx, byte(req.Address), int(req.Register), int(req.Count), bulkOptions(req.Bulk))
+	return &Data{Data: data}, toStatus(err)
+}
+
+func (s *Server) OneWireConfig(ctx context.Context, req *OneWireConfigRequest) (*Empty, error) {
+	return &Empty{}, toStatus(s.client.OneWireConfig(byte(req.Pin), byte(req.PowerMode)))
+}
+
+func (s *Server) OneWireSearch(ctx context.Context, req *OneWireSearchRequest) (*OneWireAddresses, error) {
+	addresses, err := s.client.OneWireSearchContext(ctx, byte(req.Pin), firmata.OneWireSubCommand(req.SearchMode))
+	out := &OneWireAddresses{}
+	for _, a := range addresses {
+		out.Addresses = append(out.Addresses, a)
+	}
+	return out, toStatus(err)
+}
+
+func (s *Server) OneWireCommand(ctx context.Context, req *OneWireCommandRequest) (*Data, error) {
+	data, err := s.client.OneWireCommandContext(ctx, byte(req.Pin), firmata.OneWireRequest{
+		Command:       firmata.OneWireSubCommand(req.Command),
+		Address:       req.Address,
+		ReadCount:     req.ReadCount,
+		CorrelationId: req.CorrelationId,
+		DelayMs:       req.DelayMs,
+		Data:          req.Data,
+	})
+	return &Data{Data: data}, toStatus(err)
+}
+
+func (s *Server) OneWireRelease(ctx context.Context, req *PinRequest) (*Empty, error) {
+	return &Empty{}, toStatus(s.client.OneWireRelease(byte(req.Pin)))
+}
+
+func (s *Server) QueryFirmware(ctx context.Context, _ *Empty) (*FirmwareInfo, error) {
+	f, err := s.client.QueryFirmware(ctx)
+	return &FirmwareInfo{Name: f.Name, Major: int32(f.Major), Minor: int32(f.Minor)}, toStatus(err)
+}
+
+func (s *Server) QueryCapabilities(ctx context.Context, _ *Empty) (*Empty, error) {
+	return &Empty{}, toStatus(s.client.QueryCapabilities(ctx))
+}
+
+func (s *Server) QueryAnalogMapping(ctx context.Context, _ *Empty) (*Empty, error) {
+	return &Empty{}, toStatus(s.client.QueryAnalogMapping(ctx))
+}
+
+func (s *Server) Pins(ctx context.Context, _ *Empty) (*PinList, error) {
+	out := &PinList{}
+	for _, p := range s.client.Pins() {
+		out.Pins = append(out.Pins, pinInfoProto(p))
+	}
+	return out, nil
+}
+
+func (s *Server) Ping(ctx context.Context, _ *Empty) (*Empty, error) {
+	_, err := s.client.Ping(ctx)
+	return &Empty{}, toStatus(err)
+}
+
+func (s *Server) Flush(ctx context.Context, _ *Empty) (*Empty, error) {
+	return &Empty{}, toStatus(s.client.Flush())
+}
+
+// Reports streams the inputs of the board. Reports are dropped, oldest
+// first, if the stream falls behind.
+func (s *Server) Reports(_ *Empty, stream Firmata_ReportsServer) error {
+	digital := s.client.SubscribeDigital(reportBuffer, firmata.DropOldest)
+	defer digital.Close()
+	analog := s.client.SubscribeAnalog(reportBuffer, firmata.DropOldest)
+	defer analog.Close()
+
+	for _, p := range s.client.Pins() {
+		n := uint(p.Number)
+		if v, ok := s.client.GetDigital(n); ok {
+			r := &Report{Pin: uint32(n)}
+			if v {
+				r.Value = 1
+			}
+			if err := stream.Send(r); err != nil {
+				return err
+			}
+		}
+		if p.AnalogChannel < 0 {
+			continue
+		}
+		if v, ok := s.client.GetAnalog(n); ok {
+			if err := stream.Send(&Report{Pin: uint32(n), Analog: true, Value: int32(v)}); err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		var r *Report
+		select {
+		case e, ok := <-digital.C:
+			if !ok {
+				return toStatus(firmata.ErrNotConnected)
+			}
+			r = &Report{Pin: uint32(e.Pin), TimeUnixNano: unixNano(e.Time)}
+			if e.Value {
+				r.Value = 1
+			}
+		case e, ok := <-analog.C:
+			if !ok {
+				return toStatus(firmata.ErrNotConnected)
+			}
+			r = &Report{Pin: uint32(e.Pin), Analog: true, Value: int32(e.Value), TimeUnixNano: unixNano(e.Time)}
+		case <-stream.Context().Done():
+			return toStatus(stream.Context().Err())
+		}
+		if err := stream.Send(r); err != nil {
+			return err
+		}
+	}
+}
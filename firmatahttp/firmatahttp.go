@@ -0,0 +1,470 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package firmatahttp serves a board over HTTP, as a JSON REST API:
+//
+//	http.Handle("/board/", http.StripPrefix("/board", firmatahttp.NewHandler(client)))
+//	http.ListenAndServe(":8080", nil)
+//
+//	curl localhost:8080/board/pins
+//	curl -X PUT -d '{"value":true}' localhost:8080/board/pins/13/digital
+//
+// Pins are named by number or by any name firmata.PinNumber accepts. The
+// endpoints are:
+//
+//	GET  /pins                      every pin, as for firmata.Pins
+//	GET  /pins/<pin>                one pin
+//	PUT  /pins/<pin>/mode           {"mode": "output"}, as for firmata.ParsePinMode
+//	GET  /pins/<pin>/digital        {"value": true}, the last reported level
+//	PUT  /pins/<pin>/digital        {"value": true}, to drive an output
+//	GET  /pins/<pin>/analog         {"value": 512}, the last reported reading
+//	PUT  /pins/<pin>/pwm            {"value": 128}, a PWM duty
+//	PUT  /pins/<pin>/servo          {"value": 90}, a servo angle
+//	POST /i2c/<address>/read        {"register": 208, "count": 1}, giving {"data": [96]}
+//	POST /i2c/<address>/write       {"data": [244, 39]}
+//	GET  /onewire/<pin>/devices     the addresses found by a search, in hex
+//	POST /onewire/<pin>/command     {"reset": true, "address": "28ff...", "write": [190], "read": 9}
+//
+// I2C addresses may be decimal or 0x prefixed hex, and I2C and 1-Wire
+// must be configured with I2CConfig and OneWireConfig first. Failures are
+// returned as {"error": "..."} with a 4xx or 5xx status.
+package firmatahttp
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/buxtronix/go-firmata"
+)
+
+// Handler is an http.Handler serving the REST API of a board.
+type Handler struct {
+	client *firmata.FirmataClient
+	// ReadOnly rejects every request which would change the board, with
+	// 403 Forbidden.
+	ReadOnly bool
+}
+
+// NewHandler creates a handler for the board client is connected to.
+func NewHandler(client *firmata.FirmataClient) *Handler {
+	return &Handler{client: client}
+}
+
+// Pin is the JSON of a pin.
+type Pin struct {
+	Number        byte           `json:"number"`
+	Label         string         `json:"label"`
+	AnalogChannel int            `json:"analogChannel"`
+	Modes         []string       `json:"modes"`
+	Resolutions   map[string]int `json:"resolutions"`
+	Mode          string         `json:"mode,omitempty"`
+	Value         *int           `json:"value,omitempty"`
+}
+
+// OneWireCommand is the JSON of a 1-Wire command. Address selects a
+// device, or Skip every device, after the bus is reset if Reset is set.
+// Write is then written and Read bytes read, after DelayMs if it is set.
+type OneWireCommand struct {
+	Reset   bool   `json:"reset"`
+	Skip    bool   `json:"skip"`
+	Address string `json:"address"`
+	Write   []int  `json:"write"`
+	Read    int    `json:"read"`
+	DelayMs int    `json:"delayMs"`
+}
+
+// statusError is an error with the HTTP status to return for it.
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &statusError{http.StatusBadRequest, fmt.Errorf(format, args...)}
+}
+
+func notFound(format string, args ...interface{}) error {
+	return &statusError{http.StatusNotFound, fmt.Errorf(format, args...)}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	v, err := h.serve(r)
+	if err != nil {
+		writeJSON(w, status(err), map[string]string{"error": err.Error()})
+		return
+	}
+	if v == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+// serve runs a request, returning the value to reply with.
+func (h *Handler) serve(r *http.Request) (interface{}, error) {
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	method := r.Method
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	if method != http.MethodGet && h.ReadOnly {
+		return nil, &statusError{http.StatusForbidden, errors.New("the board is read only")}
+	}
+	route := method + " " + path[0]
+	if len(path) > 2 {
+		route += "/*/" + strings.Join(path[2:], "/")
+	} else if len(path) == 2 {
+		route += "/*"
+	}
+	switch route {
+	case "GET pins":
+		pins := h.client.Pins()
+		out := make([]Pin, len(pins))
+		for i, p := range pins {
+			out[i] = pinJSON(p)
+		}
+		return out, nil
+	case "GET pins/*":
+		n, err := h.pin(path[1])
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range h.client.Pins() {
+			if p.Number == n {
+				return pinJSON(p), nil
+			}
+		}
+		return nil, notFound("No pin %v on the board", n)
+	case "PUT pins/*/mode":
+		return h.setMode(r, path[1])
+	case "GET pins/*/digital":
+		n, err := h.pin(path[1])
+		if err != nil {
+			return nil, err
+		}
+		v, err := h.client.DigitalRead(uint(n))
+		return value{v}, err
+	case "PUT pins/*/digital":
+		return h.write(r, path[1], func(pin *firmata.Pin, v json.RawMessage) error {
+			var level bool
+			if err := json.Unmarshal(v, &level); err != nil {
+				return badRequest("Bad level %s", v)
+			}
+			return pin.Write(level)
+		})
+	case "GET pins/*/analog":
+		n, err := h.pin(path[1])
+		if err != nil {
+			return nil, err
+		}
+		v, err := h.client.AnalogRead(uint(n))
+		return value{v}, err
+	case "PUT pins/*/pwm":
+		return h.write(r, path[1], func(pin *firmata.Pin, v json.RawMessage) error {
+			duty, err := byteValue(v)
+			if err != nil {
+				return err
+			}
+			return pin.Pwm(duty)
+		})
+	case "PUT pins/*/servo":
+		return h.write(r, path[1], func(pin *firmata.Pin, v json.RawMessage) error {
+			angle, err := byteValue(v)
+			if err != nil {
+				return err
+			}
+			return pin.Servo(angle)
+		})
+	case "POST i2c/*/read":
+		return h.i2cRead(r, path[1])
+	case "POST i2c/*/write":
+		return h.i2cWrite(r, path[1])
+	case "GET onewire/*/devices":
+		return h.oneWireDevices(r.Context(), path[1])
+	case "POST onewire/*/command":
+		return h.oneWireCommand(r, path[1])
+	}
+	return nil, notFound("No endpoint %s %s", r.Method, r.URL.Path)
+}
+
+// value is the JSON of a pin value.
+type value struct {
+	Value interface{} `json:"value"`
+}
+
+func pinJSON(p firmata.PinInfo) Pin {
+	out := Pin{
+		Number:        p.Number,
+		Label:         p.Label,
+		AnalogChannel: p.AnalogChannel,
+		Modes:         make([]string, len(p.Modes)),
+		Resolutions:   make(map[string]int, len(p.Resolutions)),
+	}
+	for i, m := range p.Modes {
+		out.Modes[i] = m.Name()
+	}
+	for m, res := range p.Resolutions {
+		out.Resolutions[m.Name()] = res
+	}
+	if p.ModeSet {
+		out.Mode = p.Mode.Name()
+	}
+	if p.HasValue {
+		v := p.Value
+		out.Value = &v
+	}
+	return out
+}
+
+// pin resolves the name of a pin in a path.
+func (h *Handler) pin(name string) (byte, error) {
+	n, err := h.client.PinNumber(name)
+	if err != nil {
+		return 0, &statusError{http.StatusNotFound, err}
+	}
+	return n, nil
+}
+
+func (h *Handler) setMode(r *http.Request, name string) (interface{}, error) {
+	n, err := h.pin(name)
+	if err != nil {
+		return nil, err
+	}
+	var body struct {
+		Mode string `json:"mode"`
+	}
+	if err := decode(r, &body); err != nil {
+		return nil, err
+	}
+	mode, err := firmata.ParsePinMode(body.Mode)
+	if err != nil {
+		return nil, &statusError{http.StatusBadRequest, err}
+	}
+	return nil, h.client.Pin(n).SetMode(mode)
+}
+
+// write decodes a {"value": ...} body and passes the value to fn to write
+// to the pin.
+func (h *Handler) write(r *http.Request, name string, fn func(*firmata.Pin, json.RawMessage) error) (interface{}, error) {
+	n, err := h.pin(name)
+	if err != nil {
+		return nil, err
+	}
+	var body struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := decode(r, &body); err != nil {
+		return nil, err
+	}
+	if body.Value == nil {
+		return nil, badRequest("No value")
+	}
+	return nil, fn(h.client.Pin(n), body.Value)
+}
+
+func (h *Handler) i2cRead(r *http.Request, addr string) (interface{}, error) {
+	a, err := i2cAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+	body := struct {
+		Register *int `json:"register"`
+		Count    int  `json:"count"`
+	}{}
+	if err := decode(r, &body); err != nil {
+		return nil, err
+	}
+	register := firmata.I2CNoRegister
+	if body.Register != nil {
+		register = *body.Register
+	}
+	if body.Count <= 0 {
+		return nil, badRequest("Bad count %v", body.Count)
+	}
+	data, err := h.client.I2CReadContext(r.Context(), a, register, body.Count)
+	if err != nil {
+		return nil, err
+	}
+	return dataJSON{ints(data)}, nil
+}
+
+func (h *Handler) i2cWrite(r *http.Request, addr string) (interface{}, error) {
+	a, err := i2cAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+	var body dataJSON
+	if err := decode(r, &body); err != nil {
+		return nil, err
+	}
+	data, err := toBytes(body.Data)
+	if err != nil {
+		return nil, err
+	}
+	return nil, h.client.I2CWrite(a, data...)
+}
+
+func (h *Handler) oneWireDevices(ctx context.Context, name string) (interface{}, error) {
+	n, err := h.pin(name)
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := h.client.OneWireSearchContext(ctx, n, firmata.OneWireSearch)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, len(addresses))
+	for i, a := range addresses {
+		out[i] = hex.EncodeToString(a)
+	}
+	return out, nil
+}
+
+func (h *Handler) oneWireCommand(r *http.Request, name string) (interface{}, error) {
+	n, err := h.pin(name)
+	if err != nil {
+		return nil, err
+	}
+	var body OneWireCommand
+	if err := decode(r, &body); err != nil {
+		return nil, err
+	}
+	var req firmata.OneWireRequest
+	if body.Reset {
+		req.Command |= firmata.OW_RESET
+	}
+	if body.Skip {
+		req.Command |= firmata.OW_SKIP
+	}
+	if body.Address != "" {
+		a, err := hex.DecodeString(body.Address)
+		if err != nil || len(a) != 8 {
+			return nil, badRequest("Bad 1-Wire address %q", body.Address)
+		}
+		req.Command |= firmata.OW_SELECT
+		req.Address = a
+	}
+	if len(body.Write) > 0 {
+		if req.Data, err = toBytes(body.Write); err != nil {
+			return nil, err
+		}
+		req.Command |= firmata.OW_WRITE
+	}
+	if body.DelayMs > 0 {
+		req.Command |= firmata.OW_DELAY
+		req.DelayMs = int32(body.DelayMs)
+	}
+	if body.Read > 0 {
+		req.Command |= firmata.OW_READ
+		req.ReadCount = int32(body.Read)
+	}
+	if req.Command == 0 {
+		return nil, badRequest("Empty 1-Wire command")
+	}
+	data, err := h.client.OneWireCommandContext(r.Context(), n, req)
+	if err != nil {
+		return nil, err
+	}
+	if body.Read == 0 {
+		return nil, nil
+	}
+	return dataJSON{ints(data)}, nil
+}
+
+// dataJSON is the JSON of data to or from a device. Bytes are numbers,
+// rather than the base64 encoding/json gives []byte.
+type dataJSON struct {
+	Data []int `json:"data"`
+}
+
+func ints(data []byte) []int {
+	out := make([]int, len(data))
+	for i, b := range data {
+		out[i] = int(b)
+	}
+	return out
+}
+
+func toBytes(data []int) ([]byte, error) {
+	out := make([]byte, len(data))
+	for i, v := range data {
+		if v < 0 || v > 0xff {
+			return nil, badRequest("Bad byte %v", v)
+		}
+		out[i] = byte(v)
+	}
+	return out, nil
+}
+
+func byteValue(v json.RawMessage) (byte, error) {
+	var n int
+	if err := json.Unmarshal(v, &n); err != nil || n < 0 || n > 0xff {
+		return 0, badRequest("Bad value %s", v)
+	}
+	return byte(n), nil
+}
+
+func i2cAddress(s string) (byte, error) {
+	a, err := strconv.ParseUint(s, 0, 7)
+	if err != nil {
+		return 0, badRequest("Bad I2C address %q", s)
+	}
+	return byte(a), nil
+}
+
+// decode decodes the JSON body of r into v.
+func decode(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return badRequest("Bad request body: %v", err)
+	}
+	return nil
+}
+
+// status returns the HTTP status for err.
+func status(err error) int {
+	var se *statusError
+	switch {
+	case errors.As(err, &se):
+		return se.status
+	case errors.Is(err, firmata.ErrInvalidPin),
+		errors.Is(err, firmata.ErrUnsupportedPinMode),
+		errors.Is(err, firmata.ErrFeatureMissing):
+		return http.StatusBadRequest
+	case errors.Is(err, firmata.ErrTimeout),
+		errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, firmata.ErrNotConnected):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, firmata.ErrBadCrc):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
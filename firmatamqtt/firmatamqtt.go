@@ -0,0 +1,358 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package firmatamqtt bridges a board to MQTT, publishing its inputs and
+// connection state and taking commands to write its pins:
+//
+//	opts := mqtt.NewClientOptions().AddBroker("tcp://broker:1883")
+//	opts.SetWill(firmatamqtt.StateTopic("greenhouse"), "offline", 1, true)
+//	mq := mqtt.NewClient(opts)
+//	mq.Connect().Wait()
+//	bridge := firmatamqtt.NewBridge(client, mq, &firmatamqtt.Config{
+//		Prefix:  "greenhouse",
+//		Digital: []byte{2},
+//		Analog:  []byte{14},
+//	})
+//	err := bridge.Start()
+//
+// Pins are named in topics by their label, or their number if they have
+// none, and commands accept any name firmata.PinNumber does. Under the
+// prefix, the bridge publishes:
+//
+//	state                 "online" or "offline", retained
+//	pin/<pin>/digital     "1" or "0" when a digital input changes, or an
+//	                      output is set
+//	pin/<pin>/analog      each analog input reading
+//	sensor/<name>         readings passed to Publish, as JSON
+//	error                 commands which failed
+//
+// and subscribes to:
+//
+//	pin/<pin>/set         "1", "on", "true" or "high" to drive an output high,
+//	                      "0", "off", "false" or "low" to drive it low
+//	pin/<pin>/pwm         a PWM duty, 0 to 255
+//	pin/<pin>/servo       a servo angle, 0 to 180
+//	pin/<pin>/mode        a pin mode, as for firmata.ParsePinMode
+//
+// Analog inputs report every sampling interval, so use
+// SetAnalogChangeOnly or SetAnalogReportRate to publish less often.
+//
+// Set Config.HomeAssistant to have the pins and sensors appear in Home
+// Assistant as switches, binary sensors and sensors, through its MQTT
+// discovery.
+package firmatamqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/buxtronix/go-firmata"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// DefaultPrefix is the topic prefix used if Config.Prefix is empty.
+const DefaultPrefix = "firmata"
+
+// StateTopic returns the state topic of a bridge with prefix, to set as
+// the will of the MQTT client so the board is shown offline if the
+// bridge dies.
+func StateTopic(prefix string) string {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return prefix + "/state"
+}
+
+// Config configures a Bridge.
+type Config struct {
+	// Prefix is the root of the topics, DefaultPrefix if empty.
+	Prefix string
+	// Digital and Analog are the input pins to publish. Start enables
+	// reporting for them.
+	Digital, Analog []byte
+	// Commandable are the pins commands may change. If nil, commands
+	// may change any pin.
+	Commandable []byte
+	// QoS is the MQTT quality of service of publishes and subscriptions.
+	QoS byte
+	// Timeout is how long to wait for the broker to acknowledge
+	// subscriptions and publishes, 5 seconds by default.
+	Timeout time.Duration
+	// HomeAssistant, if set, is announced to Home Assistant by Start.
+	HomeAssistant *Discovery
+}
+
+// Bridge connects a board to an MQTT broker.
+type Bridge struct {
+	client *firmata.FirmataClient
+	mq     mqtt.Client
+	config Config
+
+	mu       sync.Mutex
+	started  bool
+	digital  *firmata.DigitalSubscription
+	analog   *firmata.AnalogSubscription
+	removeCb func()
+	wg       sync.WaitGroup
+}
+
+// NewBridge creates a bridge between client and the broker mq is
+// connected to. A nil config uses the defaults, publishing no inputs.
+func NewBridge(client *firmata.FirmataClient, mq mqtt.Client, config *Config) *Bridge {
+	b := &Bridge{client: client, mq: mq}
+	if config != nil {
+		b.config = *config
+	}
+	if b.config.Prefix == "" {
+		b.config.Prefix = DefaultPrefix
+	}
+	if b.config.Timeout <= 0 {
+		b.config.Timeout = 5 * time.Second
+	}
+	return b
+}
+
+// commands are the command topics, under pin/<pin>/.
+var commands = []string{"set", "pwm", "servo", "mode"}
+
+// Start enables reporting of the inputs, subscribes to the command topics,
+// announces the board to Home Assistant if configured and publishes it as
+// online.
+func (b *Bridge) Start() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.started {
+		return errors.New("firmatamqtt: bridge already started")
+	}
+	for _, pin := range b.config.Digital {
+		if err := b.client.EnableDigitalInput(uint(pin), true); err != nil {
+			return err
+		}
+	}
+	for _, pin := range b.config.Analog {
+		if err := b.client.EnableAnalogInput(uint(pin), true); err != nil {
+			return err
+		}
+	}
+	for _, cmd := range commands {
+		topic := b.config.Prefix + "/pin/+/" + cmd
+		if err := b.wait(b.mq.Subscribe(topic, b.config.QoS, b.command)); err != nil {
+			return fmt.Errorf("firmatamqtt: subscribing to %s: %w", topic, err)
+		}
+	}
+
+	if len(b.config.Digital) > 0 {
+		b.digital = b.client.SubscribeDigital(10, firmata.DropOldest, b.config.Digital...)
+		b.wg.Add(1)
+		go func(s *firmata.DigitalSubscription) {
+			defer b.wg.Done()
+			for e := range s.C {
+				v := "0"
+				if e.Value {
+					v = "1"
+				}
+				b.publish(b.pinTopic(e.Pin, "digital"), false, v)
+			}
+		}(b.digital)
+	}
+	if len(b.config.Analog) > 0 {
+		b.analog = b.client.SubscribeAnalog(10, firmata.DropOldest, b.config.Analog...)
+		b.wg.Add(1)
+		go func(s *firmata.AnalogSubscription) {
+			defer b.wg.Done()
+			for e := range s.C {
+				b.publish(b.pinTopic(e.Pin, "analog"), false, strconv.Itoa(e.Value))
+			}
+		}(b.analog)
+	}
+	b.removeCb = b.client.OnConnectionChange(func(connected bool) {
+		b.publishState(connected)
+	})
+	b.started = true
+	if d := b.config.HomeAssistant; d != nil {
+		if err := b.Announce(d); err != nil {
+			return err
+		}
+	}
+	return b.publishState(true)
+}
+
+// Stop unsubscribes from the command topics, stops publishing and
+// publishes the board as offline. Reporting of the inputs is left on.
+func (b *Bridge) Stop() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.started {
+		return nil
+	}
+	b.started = false
+	b.removeCb()
+	if b.digital != nil {
+		b.digital.Close()
+	}
+	if b.analog != nil {
+		b.analog.Close()
+	}
+	b.wg.Wait()
+	var topics []string
+	for _, cmd := range commands {
+		topics = append(topics, b.config.Prefix+"/pin/+/"+cmd)
+	}
+	err := b.wait(b.mq.Unsubscribe(topics...))
+	if serr := b.publishState(false); err == nil {
+		err = serr
+	}
+	return err
+}
+
+// Publish publishes v, encoded as JSON, to the topic of sensor name.
+func (b *Bridge) Publish(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.publish(b.config.Prefix+"/sensor/"+name, false, data)
+}
+
+// temperatureReading is the JSON of a temperature reading.
+type temperatureReading struct {
+	Celsius *float32  `json:"celsius,omitempty"`
+	Error   string    `json:"error,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// PublishTemperatures publishes the readings of a TemperatureMonitor,
+// until readings is closed, under sensor/<address>, with the device
+// address in hex.
+func (b *Bridge) PublishTemperatures(readings <-chan firmata.TemperatureReading) {
+	go func() {
+		for r := range readings {
+			t := temperatureReading{Time: r.Time}
+			if r.Err != nil {
+				t.Error = r.Err.Error()
+			} else {
+				c := r.Temperature.Celsius()
+				t.Celsius = &c
+			}
+			b.Publish(fmt.Sprintf("%x", []byte(r.Device.Address)), t)
+		}
+	}()
+}
+
+// command runs a message received on a command topic.
+func (b *Bridge) command(_ mqtt.Client, msg mqtt.Message) {
+	if err := b.run(msg.Topic(), strings.TrimSpace(string(msg.Payload()))); err != nil {
+		b.client.Log.Warn("MQTT command %s: %s", msg.Topic(), err.Error())
+		b.publish(b.config.Prefix+"/error", false, fmt.Sprintf("%s: %s", msg.Topic(), err.Error()))
+	}
+}
+
+// run runs the command of topic.
+func (b *Bridge) run(topic, payload string) error {
+	parts := strings.Split(strings.TrimPrefix(topic, b.config.Prefix+"/pin/"), "/")
+	if len(parts) != 2 {
+		return fmt.Errorf("bad command topic")
+	}
+	n, err := b.client.PinNumber(parts[0])
+	if err != nil {
+		return err
+	}
+	if !b.commandable(n) {
+		return fmt.Errorf("pin %v does not take commands", n)
+	}
+	pin := b.client.Pin(n)
+	switch parts[1] {
+	case "set":
+		var v bool
+		switch strings.ToLower(payload) {
+		case "1", "on", "true", "high":
+			v = true
+		case "0", "off", "false", "low":
+		default:
+			return fmt.Errorf("bad level %q", payload)
+		}
+		if err := pin.Write(v); err != nil {
+			return err
+		}
+		state := "0"
+		if v {
+			state = "1"
+		}
+		return b.publish(b.pinTopic(n, "digital"), false, state)
+	case "pwm":
+		duty, err := strconv.ParseUint(payload, 10, 8)
+		if err != nil {
+			return fmt.Errorf("bad duty %q", payload)
+		}
+		return pin.Pwm(byte(duty))
+	case "servo":
+		angle, err := strconv.ParseUint(payload, 10, 8)
+		if err != nil {
+			return fmt.Errorf("bad angle %q", payload)
+		}
+		return pin.Servo(byte(angle))
+	case "mode":
+		mode, err := firmata.ParsePinMode(payload)
+		if err != nil {
+			return err
+		}
+		return pin.SetMode(mode)
+	}
+	return fmt.Errorf("unknown command %q", parts[1])
+}
+
+// commandable returns true if commands may change pin.
+func (b *Bridge) commandable(pin byte) bool {
+	if b.config.Commandable == nil {
+		return true
+	}
+	for _, p := range b.config.Commandable {
+		if p == pin {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Bridge) pinTopic(pin byte, kind string) string {
+	return b.config.Prefix + "/pin/" + b.client.PinLabel(pin) + "/" + kind
+}
+
+func (b *Bridge) publishState(online bool) error {
+	state := "offline"
+	if online {
+		state = "online"
+	}
+	return b.publish(StateTopic(b.config.Prefix), true, state)
+}
+
+func (b *Bridge) publish(topic string, retain bool, payload interface{}) error {
+	if err := b.wait(b.mq.Publish(topic, b.config.QoS, retain, payload)); err != nil {
+		return fmt.Errorf("firmatamqtt: publishing to %s: %w", topic, err)
+	}
+	return nil
+}
+
+// wait waits for the broker to acknowledge t.
+func (b *Bridge) wait(t mqtt.Token) error {
+	if !t.WaitTimeout(b.config.Timeout) {
+		return errors.New("timed out")
+	}
+	return t.Error()
+}
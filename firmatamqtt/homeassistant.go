@@ -0,0 +1,292 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmatamqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/buxtronix/go-firmata"
+)
+
+// Home Assistant entity components.
+const (
+	Switch       = "switch"
+	BinarySensor = "binary_sensor"
+	Sensor       = "sensor"
+)
+
+// DefaultDiscoveryPrefix is the Home Assistant discovery prefix used if
+// Discovery.Prefix is empty.
+const DefaultDiscoveryPrefix = "homeassistant"
+
+// Discovery announces the board to Home Assistant with MQTT discovery, so
+// its pins and sensors appear without configuring them there:
+//
+//	bridge := firmatamqtt.NewBridge(client, mq, &firmatamqtt.Config{
+//		Prefix:      "greenhouse",
+//		Digital:     []byte{2},
+//		Commandable: []byte{13},
+//		HomeAssistant: &firmatamqtt.Discovery{
+//			Device: firmatamqtt.Device{Name: "Greenhouse"},
+//			Entities: []firmatamqtt.Entity{
+//				{Component: firmatamqtt.Switch, Pin: "13", Name: "Fan"},
+//				{Component: firmatamqtt.BinarySensor, Pin: "2", Name: "Door", DeviceClass: "door"},
+//				firmatamqtt.TemperatureEntity(address, "Soil"),
+//			},
+//		},
+//	})
+//
+// Entities are shown available while the bridge's state is online.
+type Discovery struct {
+	// Prefix is the discovery prefix Home Assistant is configured with,
+	// DefaultDiscoveryPrefix if empty.
+	Prefix string
+	// NodeID identifies the board to Home Assistant, and prefixes the
+	// unique IDs of its entities. If empty, it is made from the bridge
+	// prefix.
+	NodeID string
+	// Device describes the board.
+	Device Device
+	// Entities are the entities of the board. If nil, there is a binary
+	// sensor for each digital input of the bridge, a sensor for each
+	// analog input, and a switch for each pin in Commandable.
+	Entities []Entity
+}
+
+// Device describes the board to Home Assistant. The model and software
+// version default to the firmware name and version.
+type Device struct {
+	Name, Manufacturer, Model, SwVersion string
+}
+
+// Entity is a Home Assistant entity of the board.
+type Entity struct {
+	// Component is Switch, BinarySensor or Sensor.
+	Component string
+	// Pin is the pin of a switch, a binary sensor or an analog sensor, by
+	// any name firmata.PinNumber accepts.
+	Pin string
+	// Sensor is the name readings of a sensor are given to Publish under,
+	// and Field the field of the readings holding its value. They are used
+	// instead of Pin if set.
+	Sensor, Field string
+	// Name is the name shown in Home Assistant, the pin label or sensor
+	// name if empty.
+	Name string
+	// DeviceClass, Unit and StateClass are the Home Assistant
+	// device_class, unit_of_measurement and state_class.
+	DeviceClass, Unit, StateClass string
+	// ValueTemplate converts the payload to the state, instead of the
+	// template for Field.
+	ValueTemplate string
+}
+
+// TemperatureEntity returns a sensor for the readings of the device at
+// address given to PublishTemperatures.
+func TemperatureEntity(address firmata.OneWireAddress, name string) Entity {
+	return Entity{
+		Component:   Sensor,
+		Sensor:      fmt.Sprintf("%x", []byte(address)),
+		Field:       "celsius",
+		Name:        name,
+		DeviceClass: "temperature",
+		Unit:        "°C",
+		StateClass:  "measurement",
+	}
+}
+
+// discoveryDevice is the device of a discovery payload.
+type discoveryDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name,omitempty"`
+	Manufacturer string   `json:"manufacturer,omitempty"`
+	Model        string   `json:"model,omitempty"`
+	SwVersion    string   `json:"sw_version,omitempty"`
+}
+
+// discoveryConfig is a discovery payload.
+type discoveryConfig struct {
+	Name                string          `json:"name"`
+	UniqueID            string          `json:"unique_id"`
+	ObjectID            string          `json:"object_id"`
+	Device              discoveryDevice `json:"device"`
+	AvailabilityTopic   string          `json:"availability_topic"`
+	PayloadAvailable    string          `json:"payload_available"`
+	PayloadNotAvailable string          `json:"payload_not_available"`
+	StateTopic          string          `json:"state_topic"`
+	CommandTopic        string          `json:"command_topic,omitempty"`
+	PayloadOn           string          `json:"payload_on,omitempty"`
+	PayloadOff          string          `json:"payload_off,omitempty"`
+	ValueTemplate       string          `json:"value_template,omitempty"`
+	DeviceClass         string          `json:"device_class,omitempty"`
+	Unit                string          `json:"unit_of_measurement,omitempty"`
+	StateClass          string          `json:"state_class,omitempty"`
+}
+
+// Announce publishes, retained, the discovery payloads of d. Start calls
+// it for Config.HomeAssistant.
+func (b *Bridge) Announce(d *Discovery) error {
+	configs, err := b.discovery(d)
+	if err != nil {
+		return err
+	}
+	for topic, c := range configs {
+		data, err := json.Marshal(c)
+		if err != nil {
+			return err
+		}
+		if err := b.publish(topic, true, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Unannounce removes the entities of d from Home Assistant.
+func (b *Bridge) Unannounce(d *Discovery) error {
+	configs, err := b.discovery(d)
+	if err != nil {
+		return err
+	}
+	for topic := range configs {
+		if err := b.publish(topic, true, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// discovery returns the discovery payloads of d, by topic.
+func (b *Bridge) discovery(d *Discovery) (map[string]*discoveryConfig, error) {
+	prefix := d.Prefix
+	if prefix == "" {
+		prefix = DefaultDiscoveryPrefix
+	}
+	node := d.NodeID
+	if node == "" {
+		node = b.config.Prefix
+	}
+	node = objectID(node)
+
+	f := b.client.Firmware()
+	device := discoveryDevice{
+		Identifiers:  []string{node},
+		Name:         d.Device.Name,
+		Manufacturer: d.Device.Manufacturer,
+		Model:        d.Device.Model,
+		SwVersion:    d.Device.SwVersion,
+	}
+	if device.Name == "" {
+		device.Name = b.config.Prefix
+	}
+	if device.Model == "" {
+		device.Model = f.Name
+	}
+	if device.SwVersion == "" && f.Name != "" {
+		device.SwVersion = fmt.Sprintf("%d.%d", f.Major, f.Minor)
+	}
+
+	entities := d.Entities
+	if entities == nil {
+		entities = b.defaultEntities()
+	}
+	configs := make(map[string]*discoveryConfig, len(entities))
+	for _, e := range entities {
+		c := &discoveryConfig{
+			Name:                e.Name,
+			Device:              device,
+			AvailabilityTopic:   StateTopic(b.config.Prefix),
+			PayloadAvailable:    "online",
+			PayloadNotAvailable: "offline",
+			ValueTemplate:       e.ValueTemplate,
+			DeviceClass:         e.DeviceClass,
+			Unit:                e.Unit,
+			StateClass:          e.StateClass,
+		}
+		var id string
+		if e.Sensor != "" {
+			if e.Component != Sensor && e.Component != BinarySensor {
+				return nil, fmt.Errorf("firmatamqtt: %s entity for sensor %q", e.Component, e.Sensor)
+			}
+			id = e.Sensor
+			if e.Field != "" {
+				id += "_" + e.Field
+				if c.ValueTemplate == "" {
+					c.ValueTemplate = "{{ value_json." + e.Field + " }}"
+				}
+			}
+			c.StateTopic = b.config.Prefix + "/sensor/" + e.Sensor
+			if c.Name == "" {
+				c.Name = e.Sensor
+			}
+		} else {
+			n, err := b.client.PinNumber(e.Pin)
+			if err != nil {
+				return nil, err
+			}
+			id = "pin" + strconv.Itoa(int(n))
+			if c.Name == "" {
+				c.Name = b.client.PinLabel(n)
+			}
+			switch e.Component {
+			case Switch:
+				c.CommandTopic = b.pinTopic(n, "set")
+				c.StateTopic = b.pinTopic(n, "digital")
+				c.PayloadOn, c.PayloadOff = "1", "0"
+			case BinarySensor:
+				c.StateTopic = b.pinTopic(n, "digital")
+				c.PayloadOn, c.PayloadOff = "1", "0"
+			case Sensor:
+				c.StateTopic = b.pinTopic(n, "analog")
+			default:
+				return nil, fmt.Errorf("firmatamqtt: unknown Home Assistant component %q", e.Component)
+			}
+		}
+		c.ObjectID = node + "_" + objectID(id)
+		c.UniqueID = c.ObjectID
+		configs[prefix+"/"+e.Component+"/"+node+"/"+objectID(id)+"/config"] = c
+	}
+	return configs, nil
+}
+
+// defaultEntities returns the entities of the bridge's pins.
+func (b *Bridge) defaultEntities() []Entity {
+	var entities []Entity
+	for _, pin := range b.config.Digital {
+		entities = append(entities, Entity{Component: BinarySensor, Pin: strconv.Itoa(int(pin))})
+	}
+	for _, pin := range b.config.Analog {
+		entities = append(entities, Entity{Component: Sensor, Pin: strconv.Itoa(int(pin)), StateClass: "measurement"})
+	}
+	for _, pin := range b.config.Commandable {
+		entities = append(entities, Entity{Component: Switch, Pin: strconv.Itoa(int(pin))})
+	}
+	return entities
+}
+
+// objectID makes s usable in a discovery topic and as an entity ID,
+// replacing anything but letters, digits, _ and - with _.
+func objectID(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		}
+		return '_'
+	}, s)
+}
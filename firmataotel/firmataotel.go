@@ -0,0 +1,75 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package firmataotel traces firmata requests, such as I2C reads and
+// OneWire commands, as OpenTelemetry spans:
+//
+//	client.SetRequestTracer(firmataotel.NewTracer(nil, "greenhouse"))
+//
+// Pass the request context to the Context variants of the client methods,
+// such as I2CReadContext, to make the spans children of the caller's span.
+package firmataotel
+
+import (
+	"context"
+
+	"github.com/buxtronix/go-firmata"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentation is the name spans are created under.
+const instrumentation = "github.com/buxtronix/go-firmata"
+
+// Tracer is a firmata.RequestTracer creating a span for each request.
+type Tracer struct {
+	tracer trace.Tracer
+	board  string
+}
+
+// NewTracer creates a tracer using tp, or the global tracer provider if it
+// is nil. Spans are labelled with board.
+func NewTracer(tp trace.TracerProvider, board string) *Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return &Tracer{tracer: tp.Tracer(instrumentation), board: board}
+}
+
+// StartRequest implements firmata.RequestTracer.
+func (t *Tracer) StartRequest(ctx context.Context, r firmata.RequestInfo) (context.Context, func(int, error)) {
+	attrs := []attribute.KeyValue{
+		attribute.String("firmata.board", t.board),
+		attribute.String("firmata.command", r.Command.String()),
+		attribute.Int("firmata.request_size", r.Size),
+	}
+	if r.Pin >= 0 {
+		attrs = append(attrs, attribute.Int("firmata.pin", r.Pin))
+	}
+	ctx, span := t.tracer.Start(ctx, "firmata "+r.Op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+	return ctx, func(replySize int, err error) {
+		span.SetAttributes(attribute.Int("firmata.reply_size", replySize))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		} else {
+			span.SetStatus(codes.Ok, "")
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package firmataperiph adapts the pins and I2C bus of a board to the
+// periph.io interfaces, so periph device drivers can be used over a
+// Firmata link rather than local hardware:
+//
+//	client.I2CConfig(0)
+//	dev, err := bmxx80.NewI2C(firmataperiph.NewI2C(client), 0x76, &bmxx80.DefaultOpts)
+//
+//	led := firmataperiph.NewPin(client, 13)
+//	led.Out(gpio.High)
+package firmataperiph
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/buxtronix/go-firmata"
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/i2c"
+	"periph.io/x/conn/v3/physic"
+)
+
+// Pin is a pin of the board as a gpio.PinIO. Like firmata.Pin, it sets
+// the pin mode each method needs.
+type Pin struct {
+	client *firmata.FirmataClient
+	pin    *firmata.Pin
+
+	mu    sync.Mutex
+	edges *firmata.EdgeWatch
+}
+
+var _ gpio.PinIO = (*Pin)(nil)
+
+// NewPin returns pin n of the board client is connected to.
+func NewPin(client *firmata.FirmataClient, n byte) *Pin {
+	return &Pin{client: client, pin: client.Pin(n)}
+}
+
+func (p *Pin) String() string {
+	return p.pin.String()
+}
+
+// Name returns the label of the pin, or its number if it has none.
+func (p *Pin) Name() string {
+	return p.pin.Label()
+}
+
+// Number returns the pin number.
+func (p *Pin) Number() int {
+	return int(p.pin.Number())
+}
+
+// Function returns the mode of the pin, or "" if it has not been set.
+func (p *Pin) Function() string {
+	if m, ok := p.pin.Mode(); ok {
+		return m.String()
+	}
+	return ""
+}
+
+// Halt stops watching for edges.
+func (p *Pin) Halt() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopEdges()
+	return nil
+}
+
+// In sets the pin to an input and enables reporting of it. Firmata has no
+// pull downs, so PullDown is an error. If edge is not gpio.NoEdge, edges
+// are watched for WaitForEdge.
+func (p *Pin) In(pull gpio.Pull, edge gpio.Edge) error {
+	n := p.pin.Number()
+	switch pull {
+	case gpio.PullDown:
+		return fmt.Errorf("firmataperiph: pin %v has no pull down", n)
+	case gpio.PullUp:
+		if err := p.client.SetPullup(n); err != nil {
+			return err
+		}
+	case gpio.PullNoChange:
+		if m, ok := p.pin.Mode(); !ok || (m != firmata.Input && m != firmata.Pullup) {
+			if err := p.pin.SetMode(firmata.Input); err != nil {
+				return err
+			}
+		}
+	default:
+		if err := p.pin.SetMode(firmata.Input); err != nil {
+			return err
+		}
+	}
+	if err := p.client.EnableDigitalInput(uint(n), true); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopEdges()
+	var e firmata.Edge
+	switch edge {
+	case gpio.RisingEdge:
+		e = firmata.Rising
+	case gpio.FallingEdge:
+		e = firmata.Falling
+	case gpio.BothEdges:
+		e = firmata.Both
+	default:
+		return nil
+	}
+	w, err := p.client.WatchEdge(n, e, 0)
+	if err != nil {
+		return err
+	}
+	p.edges = w
+	return nil
+}
+
+// Read returns the last reported level of the pin.
+func (p *Pin) Read() gpio.Level {
+	v, _ := p.client.GetDigital(uint(p.pin.Number()))
+	return gpio.Level(v)
+}
+
+// WaitForEdge waits up to timeout for an edge selected by In, or forever
+// if timeout is -1. It returns false if there was none, or edges are not
+// being watched.
+func (p *Pin) WaitForEdge(timeout time.Duration) bool {
+	p.mu.Lock()
+	w := p.edges
+	p.mu.Unlock()
+	if w == nil {
+		return false
+	}
+	var expired <-chan time.Time
+	if timeout >= 0 {
+		t := p.client.Clock().NewTimer(timeout)
+		defer t.Stop()
+		expired = t.C()
+	}
+	select {
+	case _, ok := <-w.C:
+		return ok
+	case <-expired:
+		return false
+	}
+}
+
+// Pull returns the pull of the pin, or gpio.PullNoChange if it is not an
+// input.
+func (p *Pin) Pull() gpio.Pull {
+	switch m, _ := p.pin.Mode(); m {
+	case firmata.Pullup:
+		return gpio.PullUp
+	case firmata.Input:
+		return gpio.Float
+	}
+	return gpio.PullNoChange
+}
+
+// DefaultPull returns gpio.Float, as pins start without pull ups.
+func (p *Pin) DefaultPull() gpio.Pull {
+	return gpio.Float
+}
+
+// Out sets the pin to an output and drives it to l.
+func (p *Pin) Out(l gpio.Level) error {
+	p.Halt()
+	return p.pin.Write(bool(l))
+}
+
+// PWM sets the pin to PWM and writes duty. Firmata cannot set the PWM
+// frequency, so f is ignored and the firmware's is used.
+func (p *Pin) PWM(duty gpio.Duty, f physic.Frequency) error {
+	p.Halt()
+	if duty < 0 {
+		duty = 0
+	}
+	if duty > gpio.DutyMax {
+		duty = gpio.DutyMax
+	}
+	return p.pin.Pwm(byte((int64(duty)*255 + int64(gpio.DutyMax)/2) / int64(gpio.DutyMax)))
+}
+
+// stopEdges stops the edge watch, with mu held.
+func (p *Pin) stopEdges() {
+	if p.edges != nil {
+		p.edges.Stop()
+		p.edges = nil
+	}
+}
+
+// I2C is the I2C bus of the board as an i2c.Bus. I2C must be enabled
+// with I2CConfig before it is used.
+type I2C struct {
+	bus firmata.I2CBus
+}
+
+var _ i2c.Bus = (*I2C)(nil)
+
+// NewI2C returns the I2C bus of bus, usually a *firmata.FirmataClient.
+func NewI2C(bus firmata.I2CBus) *I2C {
+	return &I2C{bus: bus}
+}
+
+func (b *I2C) String() string {
+	return "firmata-i2c"
+}
+
+// Tx writes w to the device at addr and then reads r from it. A single
+// byte w is sent as the register of the read, in one request. Firmata
+// has no repeated start for longer writes, so they are sent as a write
+// followed by a read, which suits devices keeping their register pointer
+// between transfers.
+func (b *I2C) Tx(addr uint16, w, r []byte) error {
+	if addr > 0x7f {
+		return fmt.Errorf("firmataperiph: I2C address 0x%x is not 7 bit", addr)
+	}
+	a := byte(addr)
+	if len(r) == 0 {
+		return b.bus.I2CWrite(a, w...)
+	}
+	register := firmata.I2CNoRegister
+	switch len(w) {
+	case 0:
+	case 1:
+		register = int(w[0])
+	default:
+		if err := b.bus.I2CWrite(a, w...); err != nil {
+			return err
+		}
+	}
+	data, err := b.bus.I2CRead(a, register, len(r))
+	if err != nil {
+		return err
+	}
+	if len(data) < len(r) {
+		return fmt.Errorf("firmataperiph: short I2C read from 0x%x: got %d bytes, want %d", addr, len(data), len(r))
+	}
+	copy(r, data)
+	return nil
+}
+
+// SetSpeed returns an error, as the firmware sets the bus speed.
+func (b *I2C) SetSpeed(f physic.Frequency) error {
+	return errors.New("firmataperiph: the I2C speed is set by the firmware")
+}
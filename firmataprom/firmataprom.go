@@ -0,0 +1,135 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package firmataprom exports firmata client metrics to Prometheus.
+//
+// One Metrics is registered for the process, and each client is given its
+// own sink, labelled with a board name:
+//
+//	m := firmataprom.NewMetrics("firmata")
+//	prometheus.MustRegister(m)
+//	client.SetMetrics(m.Sink("greenhouse"))
+package firmataprom
+
+import (
+	"errors"
+	"time"
+
+	"github.com/buxtronix/go-firmata"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics holds the Prometheus metrics for any number of boards. It is a
+// prometheus.Collector.
+type Metrics struct {
+	messages *prometheus.CounterVec
+	bytes    *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	queues   *prometheus.GaugeVec
+	latency  *prometheus.HistogramVec
+}
+
+// NewMetrics creates the metrics, with names in namespace.
+func NewMetrics(namespace string) *Metrics {
+	return &Metrics{
+		messages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_total",
+			Help:      "Frames sent to and received from the board, by command.",
+		}, []string{"board", "direction", "command"}),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "bytes_total",
+			Help:      "Bytes sent to and received from the board.",
+		}, []string{"board", "direction"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "protocol_errors_total",
+			Help:      "Traffic from the board which could not be handled, by type.",
+		}, []string{"board", "type"}),
+		queues: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "queue_depth",
+			Help:      "Items waiting in client queues.",
+		}, []string{"board", "queue"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Time taken by requests to the board, by request and outcome.",
+			// 1ms to about 4s.
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 13),
+		}, []string{"board", "op", "outcome"}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.messages.Describe(ch)
+	m.bytes.Describe(ch)
+	m.errors.Describe(ch)
+	m.queues.Describe(ch)
+	m.latency.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.messages.Collect(ch)
+	m.bytes.Collect(ch)
+	m.errors.Collect(ch)
+	m.queues.Collect(ch)
+	m.latency.Collect(ch)
+}
+
+// Sink returns a firmata.MetricsSink recording the metrics of one board.
+func (m *Metrics) Sink(board string) firmata.MetricsSink {
+	return &sink{m, board}
+}
+
+type sink struct {
+	m     *Metrics
+	board string
+}
+
+func (s *sink) MessageSent(command string, size int) {
+	s.m.messages.WithLabelValues(s.board, "sent", command).Inc()
+	s.m.bytes.WithLabelValues(s.board, "sent").Add(float64(size))
+}
+
+func (s *sink) MessageReceived(command string, size int) {
+	s.m.messages.WithLabelValues(s.board, "received", command).Inc()
+	s.m.bytes.WithLabelValues(s.board, "received").Add(float64(size))
+}
+
+func (s *sink) ProtocolError(t firmata.ProtocolErrorType) {
+	s.m.errors.WithLabelValues(s.board, t.String()).Inc()
+}
+
+func (s *sink) QueueDepth(queue string, depth int) {
+	s.m.queues.WithLabelValues(s.board, queue).Set(float64(depth))
+}
+
+func (s *sink) RequestDone(op string, d time.Duration, err error) {
+	s.m.latency.WithLabelValues(s.board, op, outcome(err)).Observe(d.Seconds())
+}
+
+// outcome labels a request result, keeping the label values few.
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, firmata.ErrTimeout):
+		return "timeout"
+	}
+	return "error"
+}
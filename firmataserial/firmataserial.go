@@ -0,0 +1,46 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package firmataserial opens boards with go.bug.st/serial, which unlike
+// the default backend can set DTR and RTS, so boards can be reset, or
+// kept from resetting, when their port is opened:
+//
+//	client, err := firmata.Open("/dev/ttyUSB0", 115200,
+//		firmata.WithSerialBackend(firmataserial.Backend{}),
+//		firmata.WithSerialReset(firmata.ResetRTS, 2*time.Second))
+package firmataserial
+
+import (
+	"github.com/buxtronix/go-firmata"
+	"go.bug.st/serial"
+)
+
+// Backend is a firmata.SerialBackend opening ports with go.bug.st/serial.
+type Backend struct{}
+
+var _ firmata.SerialBackend = Backend{}
+
+// Open opens dev with 8 data bits, no parity and one stop bit.
+func (Backend) Open(dev string, mode firmata.SerialMode) (firmata.SerialConn, error) {
+	m := &serial.Mode{BaudRate: mode.Baud}
+	if mode.NoReset {
+		m.InitialStatusBits = &serial.ModemOutputBits{}
+	}
+	return serial.Open(dev, m)
+}
+
+// Ports returns the serial ports found on the system.
+func Ports() ([]string, error) {
+	return serial.GetPortsList()
+}
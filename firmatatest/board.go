@@ -0,0 +1,507 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package firmatatest provides a simulated Firmata board, for testing
+// code using the firmata client without hardware.
+//
+// The board talks the protocol over an in-memory connection. It answers
+// the version, firmware, capability and analog mapping queries, keeps the
+// pin modes and outputs written by the client, and reports inputs set by
+// the test:
+//
+//	b := firmatatest.NewUno()
+//	defer b.Close()
+//	client, err := b.Connect()
+//	...
+//	client.DigitalWrite(13, true)
+//	if !b.Digital(13) { ... }
+package firmatatest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/buxtronix/go-firmata"
+	"github.com/buxtronix/go-firmata/firmataenc"
+)
+
+// PinConfig describes a pin of a simulated board.
+type PinConfig struct {
+	// Modes are the modes the pin supports, with their resolution in bits.
+	Modes map[firmata.PinMode]int
+	// AnalogChannel is the analog input channel of the pin, or -1 if it
+	// has none.
+	AnalogChannel int
+}
+
+// SysExHandler handles the payload of a SysEx message, returning the
+// frames to send in reply, such as those built by package firmataenc.
+type SysExHandler func(data []byte) (replies [][]byte)
+
+// Board is a simulated Firmata board. Its methods are safe to call from
+// multiple goroutines.
+type Board struct {
+	// Name and Major and Minor are the firmware name and version reported.
+	Name         string
+	Major, Minor byte
+
+	pins []PinConfig
+	conn *conn
+
+	mu               sync.Mutex
+	modes            map[byte]firmata.PinMode
+	outputs          [16]byte
+	analogOutputs    map[byte]int
+	inputs           [16]byte
+	analogInputs     map[byte]int
+	digitalReports   [16]bool
+	analogReports    map[byte]bool
+	samplingInterval int
+	handlers         map[firmata.SysExCommand]SysExHandler
+	oneWire          map[byte]*OneWireBus
+	wires            map[byte][]byte
+	received         []firmata.Message
+}
+
+// NewBoard creates a board with pins, and starts it.
+func NewBoard(pins []PinConfig) *Board {
+	b := &Board{
+		Name:             "SimulatedFirmata",
+		Major:            firmata.ProtocolMajorVersion,
+		Minor:            firmata.ProtocolMinorVersion,
+		pins:             pins,
+		conn:             &conn{in: newPipe(), out: newPipe()},
+		samplingInterval: 19,
+	}
+	b.reset()
+	go b.run()
+	return b
+}
+
+// NewUno creates a board with the pins of an Arduino Uno.
+func NewUno() *Board {
+	return NewBoard(UnoPins())
+}
+
+// UnoPins returns the pins of an Arduino Uno: 14 digital pins, PWM on 3, 5,
+// 6, 9, 10 and 11, and 6 analog inputs on 14 to 19, with I2C on 18 and 19.
+func UnoPins() []PinConfig {
+	pins := make([]PinConfig, 20)
+	for i := range pins {
+		modes := map[firmata.PinMode]int{
+			firmata.Input:  1,
+			firmata.Output: 1,
+			firmata.Pullup: 1,
+			firmata.Servo:  14,
+		}
+		switch i {
+		case 3, 5, 6, 9, 10, 11:
+			modes[firmata.PWM] = 8
+		case 18, 19:
+			modes[firmata.I2C] = 1
+		}
+		channel := -1
+		if i >= 14 {
+			modes[firmata.Analog] = 10
+			channel = i - 14
+		}
+		pins[i] = PinConfig{Modes: modes, AnalogChannel: channel}
+	}
+	return pins
+}
+
+// Pins returns the number of pins of the board.
+func (b *Board) Pins() int {
+	return len(b.pins)
+}
+
+// Conn returns the client end of the connection to the board.
+func (b *Board) Conn() io.ReadWriteCloser {
+	return b.conn
+}
+
+// Connect creates a client connected to the board.
+func (b *Board) Connect(opts ...firmata.Option) (*firmata.FirmataClient, error) {
+	return firmata.NewClient(b.conn, opts...)
+}
+
+// Close disconnects the board, as if it had been unplugged.
+func (b *Board) Close() error {
+	return b.conn.Close()
+}
+
+// HandleSysEx sets the handler for SysEx messages of type cmd, such as
+// I2CRequest, to simulate devices attached to the board. It replaces any
+// handling the board has for cmd.
+func (b *Board) HandleSysEx(cmd firmata.SysExCommand, h SysExHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.handlers == nil {
+		b.handlers = make(map[firmata.SysExCommand]SysExHandler)
+	}
+	b.handlers[cmd] = h
+}
+
+// Received returns the messages received from the client since the last
+// call.
+func (b *Board) Received() []firmata.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	m := b.received
+	b.received = nil
+	return m
+}
+
+// Mode returns the mode of pin, and false if it has not been set since the
+// board was reset.
+func (b *Board) Mode(pin byte) (firmata.PinMode, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	m, ok := b.modes[pin]
+	return m, ok
+}
+
+// Digital returns the output level last written to pin.
+func (b *Board) Digital(pin byte) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.outputs[(pin/8)&0x0F]&(1<<(pin%8)) != 0
+}
+
+// Analog returns the value last written to pin by an analog (PWM or servo)
+// write.
+func (b *Board) Analog(pin byte) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.analogOutputs[pin]
+}
+
+// SamplingInterval returns the analog sampling interval set by the client,
+// in milliseconds.
+func (b *Board) SamplingInterval() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.samplingInterval
+}
+
+// SetDigital sets the input level of pin, reporting its port if the client
+// has enabled reporting for it.
+func (b *Board) SetDigital(pin byte, high bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.setDigital(pin, high)
+}
+
+// setDigital sets the input level of pin, with mu held.
+func (b *Board) setDigital(pin byte, high bool) {
+	port := (pin / 8) & 0x0F
+	if high {
+		b.inputs[port] |= 1 << (pin % 8)
+	} else {
+		b.inputs[port] &^= 1 << (pin % 8)
+	}
+	if b.digitalReports[port] {
+		b.reportDigital(port)
+	}
+}
+
+// SetAnalog sets the input value of pin, reporting it if the client has
+// enabled reporting for the pin. A real board reports every sampling
+// interval; the simulator reports each value as it is set, so tests are
+// repeatable.
+func (b *Board) SetAnalog(pin byte, value int) error {
+	if int(pin) >= len(b.pins) || b.pins[pin].AnalogChannel < 0 {
+		return fmt.Errorf("pin %v is not an analog input", pin)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.setAnalog(pin, value)
+	return nil
+}
+
+// setAnalog sets the input value of an analog pin, with mu held.
+func (b *Board) setAnalog(pin byte, value int) {
+	b.analogInputs[pin] = value
+	if ch := byte(b.pins[pin].AnalogChannel); b.analogReports[ch] {
+		b.reportAnalog(ch, value)
+	}
+}
+
+// Wire connects output to input, as if the pins were wired together, so
+// round trips through the board can be tested. While output is in Output
+// mode its level drives input, and while it is in PWM mode its duty cycle
+// sets the reading of input if that is an analog pin, as through a filter.
+func (b *Board) Wire(output, input byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.wires == nil {
+		b.wires = make(map[byte][]byte)
+	}
+	b.wires[output] = append(b.wires[output], input)
+}
+
+// drive sets the inputs wired to output to level, as a fraction of full
+// scale, with mu held.
+func (b *Board) drive(output byte, level float64) {
+	for _, in := range b.wires[output] {
+		b.setDigital(in, level >= 0.5)
+		if r, ok := b.supports(in, firmata.Analog); ok {
+			b.setAnalog(in, int(math.Round(level*float64(int(1)<<uint(r)-1))))
+		}
+	}
+}
+
+// driveAnalog drives the inputs wired to a PWM output with value, with mu
+// held.
+func (b *Board) driveAnalog(output byte, value int) {
+	if r, ok := b.supports(output, firmata.PWM); ok && b.modes[output] == firmata.PWM {
+		b.drive(output, float64(value)/float64(int(1)<<uint(r)-1))
+	}
+}
+
+// Step is a change of an input in a script.
+type Step struct {
+	// After is how long to wait after the previous step.
+	After time.Duration
+	// Pin is the pin to change.
+	Pin byte
+	// Analog is set to change an analog input, rather than a digital one.
+	Analog bool
+	// Value is the new value. For digital inputs, any non-zero value is
+	// high.
+	Value int
+}
+
+// Play runs the steps of script in order, returning when they are done or
+// ctx is.
+func (b *Board) Play(ctx context.Context, script []Step) error {
+	for _, s := range script {
+		if s.After > 0 {
+			t := time.NewTimer(s.After)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+				return ctx.Err()
+			}
+		}
+		if s.Analog {
+			if err := b.SetAnalog(s.Pin, s.Value); err != nil {
+				return err
+			}
+		} else {
+			b.SetDigital(s.Pin, s.Value != 0)
+		}
+	}
+	return nil
+}
+
+// reset returns the board to its power on state, with mu held or before
+// the board is started.
+func (b *Board) reset() {
+	b.modes = make(map[byte]firmata.PinMode)
+	b.outputs = [16]byte{}
+	b.analogOutputs = make(map[byte]int)
+	b.digitalReports = [16]bool{}
+	b.analogReports = make(map[byte]bool)
+	if b.analogInputs == nil {
+		b.analogInputs = make(map[byte]int)
+	}
+}
+
+// run handles messages from the client until the connection is closed.
+func (b *Board) run() {
+	buf := make([]byte, 256)
+	d := firmata.NewDecoder(firmata.Sent)
+	for {
+		n, err := b.conn.out.Read(buf)
+		for _, m := range d.Feed(buf[:n]) {
+			if m.Err == nil {
+				b.handle(m)
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// handle handles a message from the client.
+func (b *Board) handle(m firmata.Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.received = append(b.received, m)
+	data := m.Data()
+	switch m.Command() {
+	case firmata.SystemReset:
+		b.reset()
+		b.reportVersion()
+		b.reportFirmware()
+	case firmata.ReportVersion:
+		b.reportVersion()
+	case firmata.SetPinMode:
+		if _, ok := b.supports(data[0], firmata.PinMode(data[1])); ok {
+			b.modes[data[0]] = firmata.PinMode(data[1])
+		}
+	case firmata.DigitalMessage:
+		port := m.Channel()
+		old := b.outputs[port]
+		b.outputs[port] = data[0] | data[1]<<7
+		for i := byte(0); i < 8; i++ {
+			pin, bit := port*8+i, byte(1)<<i
+			if (old^b.outputs[port])&bit != 0 && b.modes[pin] == firmata.Output {
+				b.drive(pin, float64(b.outputs[port]&bit>>i))
+			}
+		}
+	case firmata.AnalogMessage:
+		b.analogOutputs[m.Channel()] = int(data[0]) | int(data[1])<<7
+		b.driveAnalog(m.Channel(), b.analogOutputs[m.Channel()])
+	case firmata.EnableDigitalInput:
+		port := m.Channel()
+		b.digitalReports[port] = data[0] != 0
+		if b.digitalReports[port] {
+			b.reportDigital(port)
+		}
+	case firmata.EnableAnalogInput:
+		ch := m.Channel()
+		b.analogReports[ch] = data[0] != 0
+		if b.analogReports[ch] {
+			for pin, p := range b.pins {
+				if p.AnalogChannel == int(ch) {
+					b.reportAnalog(ch, b.analogInputs[byte(pin)])
+				}
+			}
+		}
+	case firmata.StartSysEx:
+		if len(m.Frame) >= 3 {
+			b.handleSysEx(m.SysEx(), data)
+		}
+	}
+}
+
+// handleSysEx handles a SysEx message from the client, with mu held.
+func (b *Board) handleSysEx(cmd firmata.SysExCommand, data []byte) {
+	if h := b.handlers[cmd]; h != nil {
+		// Handlers may call back into the board.
+		b.mu.Unlock()
+		replies := h(data)
+		b.mu.Lock()
+		for _, r := range replies {
+			b.send(r)
+		}
+		return
+	}
+	switch cmd {
+	case firmata.ReportFirmware:
+		b.reportFirmware()
+	case firmata.CapabilityQuery:
+		b.send(capabilities(b.pins))
+	case firmata.AnalogMappingQuery:
+		b.send(analogMapping(b.pins))
+	case firmata.SamplingInterval:
+		if len(data) >= 2 {
+			b.samplingInterval = int(data[0]) | int(data[1])<<7
+		}
+	case firmata.ExtendedAnalog:
+		if len(data) >= 2 {
+			value := 0
+			for i, v := range data[1:] {
+				value |= int(v) << (7 * uint(i))
+			}
+			b.analogOutputs[data[0]] = value
+			b.driveAnalog(data[0], value)
+		}
+	case firmata.PinStateQuery:
+		if len(data) < 1 {
+			return
+		}
+		pin := data[0]
+		mode, ok := b.modes[pin]
+		if !ok && int(pin) >= len(b.pins) {
+			mode = 0x7F
+		}
+		var state int
+		switch mode {
+		case firmata.Output:
+			if b.outputs[(pin/8)&0x0F]&(1<<(pin%8)) != 0 {
+				state = 1
+			}
+		case firmata.Input, firmata.Pullup:
+			if b.inputs[(pin/8)&0x0F]&(1<<(pin%8)) != 0 {
+				state = 1
+			}
+		case firmata.Analog:
+			state = b.analogInputs[pin]
+		default:
+			state = b.analogOutputs[pin]
+		}
+		b.send(firmataenc.PinState(pin, mode, state))
+	}
+}
+
+// supports returns the resolution of mode on pin, and whether the pin
+// supports it.
+func (b *Board) supports(pin byte, mode firmata.PinMode) (int, bool) {
+	if int(pin) >= len(b.pins) {
+		return 0, false
+	}
+	r, ok := b.pins[pin].Modes[mode]
+	return r, ok
+}
+
+func (b *Board) reportVersion() {
+	b.send(firmataenc.Version(firmata.ProtocolMajorVersion, firmata.ProtocolMinorVersion))
+}
+
+func (b *Board) reportFirmware() {
+	b.send(firmataenc.Firmware(b.Name, b.Major, b.Minor))
+}
+
+// reportDigital reports the input levels of port, with mu held.
+func (b *Board) reportDigital(port byte) {
+	b.send(firmataenc.DigitalMessage(port, b.inputs[port]))
+}
+
+// reportAnalog reports the value of analog channel ch, with mu held.
+func (b *Board) reportAnalog(ch byte, value int) {
+	b.send(firmataenc.AnalogMessage(ch, value))
+}
+
+// send writes a message to the client. Writes only fail once the
+// connection is closed, when there is no one to tell.
+func (b *Board) send(frame []byte) {
+	b.conn.in.Write(frame)
+}
+
+// capabilities returns the CapabilityResponse for pins.
+func capabilities(pins []PinConfig) []byte {
+	c := make([]firmataenc.Capability, len(pins))
+	for i, p := range pins {
+		c[i] = p.Modes
+	}
+	return firmataenc.Capabilities(c)
+}
+
+// analogMapping returns the AnalogMappingResponse for pins.
+func analogMapping(pins []PinConfig) []byte {
+	channels := make([]int, len(pins))
+	for i, p := range pins {
+		channels[i] = p.AnalogChannel
+	}
+	return firmataenc.AnalogMapping(channels)
+}
@@ -0,0 +1,208 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmatatest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/buxtronix/go-firmata"
+)
+
+// Clock is a firmata.Clock whose time only moves when the test advances
+// it, so timeouts, debouncing and schedulers can be tested without
+// sleeping:
+//
+//	clock := firmatatest.NewClock(time.Time{})
+//	client, err := b.Connect(firmata.WithClock(clock))
+//	client.SetDebounce(2, 50*time.Millisecond)
+//	b.SetDigital(2, true)
+//	clock.BlockUntil(1)
+//	clock.Advance(50 * time.Millisecond)
+//
+// Timers fire, and tickers tick, as Advance passes their times, in order.
+type Clock struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*clockTimer
+}
+
+// NewClock creates a clock starting at start.
+func NewClock(start time.Time) *Clock {
+	c := &Clock{now: start}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns the time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer creates a timer firing after d of the clock's time.
+func (c *Clock) NewTimer(d time.Duration) firmata.Timer {
+	return c.add(&clockTimer{clock: c, ch: make(chan time.Time, 1)}, d)
+}
+
+// AfterFunc calls f in its own goroutine after d of the clock's time.
+func (c *Clock) AfterFunc(d time.Duration, f func()) firmata.Timer {
+	return c.add(&clockTimer{clock: c, f: f}, d)
+}
+
+// NewTicker creates a ticker ticking every d of the clock's time. As with
+// time.Ticker, ticks are dropped if the receiver falls behind.
+func (c *Clock) NewTicker(d time.Duration) firmata.Ticker {
+	if d <= 0 {
+		panic("firmatatest: non-positive interval for NewTicker")
+	}
+	return clockTicker{c.add(&clockTimer{clock: c, ch: make(chan time.Time, 1), period: d}, d)}
+}
+
+// Advance moves the clock forward by d, firing the timers and tickers due
+// on the way in order. Ticks carry the time they were due.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	end := c.now.Add(d)
+	for {
+		next := -1
+		for i, t := range c.timers {
+			if !t.when.After(end) && (next < 0 || t.when.Before(c.timers[next].when)) {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		t := c.timers[next]
+		if t.when.After(c.now) {
+			c.now = t.when
+		}
+		c.fire(t)
+	}
+	c.now = end
+}
+
+// Timers returns the number of timers and tickers waiting to fire.
+func (c *Clock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// BlockUntil waits until at least n timers and tickers are waiting to
+// fire, so a test can let the code under test start waiting before it
+// advances the clock.
+func (c *Clock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+// add schedules t to fire after d.
+func (c *Clock) add(t *clockTimer, d time.Duration) *clockTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.schedule(t, d)
+	return t
+}
+
+// schedule schedules t to fire after d, with mu held. Timers already due
+// fire at once.
+func (c *Clock) schedule(t *clockTimer, d time.Duration) {
+	t.when = c.now.Add(d)
+	if d <= 0 {
+		c.send(t)
+		return
+	}
+	c.timers = append(c.timers, t)
+	c.cond.Broadcast()
+}
+
+// fire fires a pending timer, rescheduling it if it is a ticker, with mu
+// held.
+func (c *Clock) fire(t *clockTimer) {
+	c.send(t)
+	if t.period > 0 {
+		t.when = t.when.Add(t.period)
+		return
+	}
+	c.remove(t)
+}
+
+// send delivers the tick of t, with mu held.
+func (c *Clock) send(t *clockTimer) {
+	if t.f != nil {
+		go t.f()
+		return
+	}
+	select {
+	case t.ch <- c.now:
+	default:
+	}
+}
+
+// remove unschedules t, returning false if it was not pending, with mu
+// held.
+func (c *Clock) remove(t *clockTimer) bool {
+	for i, p := range c.timers {
+		if p == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// clockTimer is a timer or ticker of a Clock.
+type clockTimer struct {
+	clock  *Clock
+	when   time.Time
+	period time.Duration
+	ch     chan time.Time
+	f      func()
+}
+
+func (t *clockTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *clockTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.remove(t)
+}
+
+func (t *clockTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.clock.remove(t)
+	t.clock.schedule(t, d)
+	return active
+}
+
+// clockTicker is a ticker of a Clock.
+type clockTicker struct {
+	*clockTimer
+}
+
+func (t clockTicker) Stop() {
+	t.clockTimer.Stop()
+}
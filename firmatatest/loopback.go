@@ -0,0 +1,252 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmatatest
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/buxtronix/go-firmata"
+	"github.com/buxtronix/go-firmata/firmataenc"
+)
+
+// Exchange is a step of a Loopback script: bytes the client must send,
+// and the reply to them.
+type Exchange struct {
+	// Expect is the bytes the client must send next. If it is empty,
+	// Respond is sent as soon as the exchange before is done.
+	Expect []byte
+	// Respond is sent to the client once Expect has been received.
+	Respond []byte
+	// Delay is how long to wait before responding.
+	Delay time.Duration
+}
+
+// Loopback is a connection which checks what the client sends against a
+// script of exchanges, failing the test on anything else, and answers
+// with the scripted replies. It pins down exactly what goes on the wire:
+//
+//	l := firmatatest.NewLoopback(t, firmatatest.Handshake(pins)...)
+//	client, err := firmata.NewClient(l)
+//	l.Expect(firmatatest.Exchange{Expect: []byte{0x91, 0x20, 0x00}})
+//	client.DigitalWrite(13, true)
+//	l.Wait(time.Second)
+//
+// The client may split or join its writes, so Expect is matched against
+// the byte stream rather than single writes. Anything still expected when
+// the test ends fails it.
+type Loopback struct {
+	t    testing.TB
+	in   *pipe
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	script  []Exchange
+	got     []byte
+	replies []reply
+	failed  bool
+	ended   bool
+}
+
+type reply struct {
+	at   time.Time
+	data []byte
+}
+
+// NewLoopback creates a connection running script, and more exchanges
+// added with Expect.
+func NewLoopback(t testing.TB, script ...Exchange) *Loopback {
+	l := &Loopback{t: t, in: newPipe()}
+	l.cond = sync.NewCond(&l.mu)
+	l.Expect(script...)
+	t.Cleanup(l.end)
+	go l.respond()
+	return l
+}
+
+// Expect adds exchanges to the end of the script.
+func (l *Loopback) Expect(e ...Exchange) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.script = append(l.script, e...)
+	l.advance()
+}
+
+// Wait waits up to timeout for the script to be run and the replies sent,
+// failing the test if it is not. It must be called from the test
+// goroutine.
+func (l *Loopback) Wait(timeout time.Duration) {
+	l.t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		l.mu.Lock()
+		left, replies := len(l.script), len(l.replies)
+		l.mu.Unlock()
+		if left == 0 && replies == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			l.t.Errorf("loopback: %d exchanges and %d replies left after %v", left, replies, timeout)
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (l *Loopback) Read(b []byte) (int, error) {
+	return l.in.Read(b)
+}
+
+// Write checks b against the script. Bytes which do not match fail the
+// test and the write.
+func (l *Loopback) Write(b []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ended {
+		return 0, fmt.Errorf("loopback: test has ended")
+	}
+	if l.failed {
+		return 0, fmt.Errorf("loopback: earlier traffic did not match")
+	}
+	l.got = append(l.got, b...)
+	for len(l.got) > 0 && !l.failed {
+		if len(l.script) == 0 {
+			l.fail("unexpected % x", l.got)
+			break
+		}
+		want := l.script[0].Expect
+		n := len(want)
+		if n > len(l.got) {
+			n = len(l.got)
+		}
+		if !bytes.Equal(l.got[:n], want[:n]) {
+			l.fail("sent % x, expected % x", l.got, want)
+			break
+		}
+		if n < len(want) {
+			// The rest is still to be sent.
+			break
+		}
+		l.got = l.got[n:]
+		l.queue(l.script[0])
+		l.script = l.script[1:]
+		l.advance()
+	}
+	if l.failed {
+		return 0, fmt.Errorf("loopback: traffic did not match")
+	}
+	return len(b), nil
+}
+
+// Close closes the connection. The script is left to be checked when the
+// test ends.
+func (l *Loopback) Close() error {
+	return l.in.Close()
+}
+
+// advance sends the replies of exchanges which expect nothing, with mu
+// held.
+func (l *Loopback) advance() {
+	for len(l.script) > 0 && len(l.script[0].Expect) == 0 {
+		l.queue(l.script[0])
+		l.script = l.script[1:]
+	}
+}
+
+// queue schedules the reply of an exchange, with mu held.
+func (l *Loopback) queue(e Exchange) {
+	if len(e.Respond) == 0 {
+		return
+	}
+	l.replies = append(l.replies, reply{at: time.Now().Add(e.Delay), data: e.Respond})
+	l.cond.Broadcast()
+}
+
+// respond sends the replies in order, each no earlier than it is due.
+func (l *Loopback) respond() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for {
+		for len(l.replies) == 0 && !l.ended {
+			l.cond.Wait()
+		}
+		if l.ended {
+			return
+		}
+		r := l.replies[0]
+		l.mu.Unlock()
+		time.Sleep(time.Until(r.at))
+		l.in.Write(r.data)
+		l.mu.Lock()
+		l.replies = l.replies[1:]
+	}
+}
+
+// fail fails the test, with mu held.
+func (l *Loopback) fail(format string, args ...interface{}) {
+	l.failed = true
+	l.t.Errorf("loopback: "+format, args...)
+}
+
+// end is run when the test ends, failing it if the script was not run.
+func (l *Loopback) end() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.failed && len(l.script) > 0 {
+		l.t.Errorf("loopback: test ended with %d exchanges left, next expecting % x", len(l.script), l.script[0].Expect)
+	}
+	l.ended = true
+	l.cond.Broadcast()
+	l.in.Close()
+}
+
+// Handshake returns the exchanges of a client connecting to a board with
+// pins, for the start of a Loopback script.
+func Handshake(pins []PinConfig) []Exchange {
+	version := firmataenc.Version(firmata.ProtocolMajorVersion, firmata.ProtocolMinorVersion)
+	return []Exchange{
+		{
+			Expect:  firmataenc.SystemReset(),
+			Respond: append(version, firmataenc.Firmware("SimulatedFirmata", firmata.ProtocolMajorVersion, firmata.ProtocolMinorVersion)...),
+		},
+		{Expect: firmataenc.QueryAnalogMapping(), Respond: analogMapping(pins)},
+		{Expect: firmataenc.QueryCapabilities(), Respond: capabilities(pins)},
+	}
+}
+
+// Shutdown returns the exchanges of closing a client connected to a board
+// with pins, which has no safe states set: reporting is stopped for every
+// port and analog channel.
+func Shutdown(pins []PinConfig) []Exchange {
+	var e []Exchange
+	for port := 0; port < (len(pins)+7)/8 && port < 16; port++ {
+		e = append(e, Exchange{Expect: firmataenc.ReportDigital(byte(port), false)})
+	}
+	var channels [16]bool
+	for _, p := range pins {
+		if p.AnalogChannel >= 0 && p.AnalogChannel < 16 {
+			channels[p.AnalogChannel] = true
+		}
+	}
+	for ch := range channels {
+		if channels[ch] {
+			e = append(e, Exchange{Expect: firmataenc.ReportAnalog(byte(ch), false)})
+		}
+	}
+	return e
+}
@@ -0,0 +1,468 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmatatest
+
+import (
+	"bytes"
+	"math"
+	"sync"
+
+	"github.com/buxtronix/go-firmata"
+	"github.com/buxtronix/go-firmata/firmataenc"
+)
+
+// OneWireDevice is a device on a simulated OneWire bus.
+type OneWireDevice interface {
+	// Address returns the ROM address of the device.
+	Address() firmata.OneWireAddress
+	// Reset is called when the bus is reset.
+	Reset()
+	// Transfer is called, while the device is selected, with the bytes
+	// written by a request, and returns the n bytes read after them.
+	Transfer(write []byte, n int) []byte
+}
+
+// OneWireAlarm is implemented by devices which answer alarm searches.
+type OneWireAlarm interface {
+	// Alarm returns true if the device is in an alarm state.
+	Alarm() bool
+}
+
+// OneWireBus is a simulated OneWire bus on a pin of a Board. Devices can
+// be attached and detached at any time.
+type OneWireBus struct {
+	// Pin is the pin the bus is on.
+	Pin byte
+
+	mu       sync.Mutex
+	devices  []OneWireDevice
+	selected []OneWireDevice
+	config   bool
+	power    byte
+	corrupt  int
+}
+
+// OneWireBus returns the OneWire bus on pin, creating it on first use. The
+// board answers OneWire requests for the pin once the client has
+// configured it.
+func (b *Board) OneWireBus(pin byte) *OneWireBus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.oneWire == nil {
+		b.oneWire = make(map[byte]*OneWireBus)
+		if b.handlers == nil {
+			b.handlers = make(map[firmata.SysExCommand]SysExHandler)
+		}
+		b.handlers[firmata.SysExOneWire] = b.handleOneWire
+	}
+	if b.oneWire[pin] == nil {
+		b.oneWire[pin] = &OneWireBus{Pin: pin}
+	}
+	return b.oneWire[pin]
+}
+
+// Attach connects devices to the bus.
+func (o *OneWireBus) Attach(devices ...OneWireDevice) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.devices = append(o.devices, devices...)
+}
+
+// Detach disconnects the device at address, as if it had been unplugged,
+// returning false if there is none.
+func (o *OneWireBus) Detach(address firmata.OneWireAddress) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i, d := range o.devices {
+		if bytes.Equal(d.Address(), address) {
+			o.devices = append(o.devices[:i], o.devices[i+1:]...)
+			o.selected = nil
+			return true
+		}
+	}
+	return false
+}
+
+// Corrupt flips a bit in the last byte of each of the next n reads, which
+// for most devices is a CRC.
+func (o *OneWireBus) Corrupt(n int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.corrupt = n
+}
+
+// Power returns the power mode the client configured the bus with, and
+// false if it has not been configured.
+func (o *OneWireBus) Power() (byte, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.power, o.config
+}
+
+// handleOneWire handles the OneWire SysEx messages.
+func (b *Board) handleOneWire(data []byte) [][]byte {
+	if len(data) < 2 {
+		return nil
+	}
+	b.mu.Lock()
+	o := b.oneWire[data[1]]
+	b.mu.Unlock()
+	if o == nil {
+		return nil
+	}
+	return o.handle(firmata.OneWireSubCommand(data[0]), data[2:])
+}
+
+// handle runs a request on the bus, returning the replies.
+func (o *OneWireBus) handle(cmd firmata.OneWireSubCommand, data []byte) [][]byte {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	switch cmd {
+	case firmata.OneWireConfig:
+		o.config = true
+		if len(data) > 0 {
+			o.power = data[0]
+		}
+		return nil
+	case firmata.OneWireSearch, firmata.OneWireSearchAlarms:
+		if !o.config {
+			return nil
+		}
+		var found []firmata.OneWireAddress
+		for _, d := range o.devices {
+			if a, ok := d.(OneWireAlarm); cmd == firmata.OneWireSearch || ok && a.Alarm() {
+				found = append(found, d.Address())
+			}
+		}
+		return [][]byte{firmataenc.OneWireSearchReply(o.Pin, cmd, found)}
+	}
+	if !o.config || cmd&^(firmata.OW_RESET|firmata.OW_SKIP|firmata.OW_SELECT|firmata.OW_READ|firmata.OW_DELAY|firmata.OW_WRITE) != 0 {
+		return nil
+	}
+
+	// The fields present follow the order of OneWireRequest.Encode.
+	d := firmata.Decode7BitMulti(data)
+	field := func(n int) []byte {
+		if n > len(d) {
+			n = len(d)
+		}
+		f := d[:n]
+		d = d[n:]
+		return f
+	}
+	var address firmata.OneWireAddress
+	var read, correlation int
+	if cmd&firmata.OW_SELECT != 0 {
+		address = field(8)
+	}
+	if cmd&firmata.OW_READ != 0 {
+		f := make([]byte, 4)
+		copy(f, field(4))
+		read = int(f[0]) | int(f[1])<<8
+		correlation = int(f[2]) | int(f[3])<<8
+	}
+	if cmd&firmata.OW_DELAY != 0 {
+		// The simulator does not wait.
+		field(4)
+	}
+	var write []byte
+	if cmd&firmata.OW_WRITE != 0 {
+		write = d
+	}
+
+	if cmd&firmata.OW_RESET != 0 {
+		o.selected = nil
+		for _, dev := range o.devices {
+			dev.Reset()
+		}
+	}
+	if cmd&firmata.OW_SKIP != 0 {
+		o.selected = append([]OneWireDevice(nil), o.devices...)
+	}
+	if cmd&firmata.OW_SELECT != 0 {
+		o.selected = nil
+		for _, dev := range o.devices {
+			if bytes.Equal(dev.Address(), address) {
+				o.selected = []OneWireDevice{dev}
+			}
+		}
+	}
+	if cmd&(firmata.OW_WRITE|firmata.OW_READ) == 0 {
+		return nil
+	}
+	// An idle bus reads as ones, and devices answering together pull it
+	// low wherever any of them does.
+	result := bytes.Repeat([]byte{0xff}, read)
+	for _, dev := range o.selected {
+		r := dev.Transfer(write, read)
+		for i := range result {
+			if i < len(r) {
+				result[i] &= r[i]
+			}
+		}
+	}
+	if cmd&firmata.OW_READ == 0 {
+		return nil
+	}
+	if o.corrupt > 0 && len(result) > 0 {
+		o.corrupt--
+		result[len(result)-1] ^= 0x01
+	}
+	return [][]byte{firmataenc.OneWireReadReply(o.Pin, correlation, result)}
+}
+
+// oneWireAddress returns the address of a device of family with serial
+// number serial, with its CRC.
+func oneWireAddress(family byte, serial uint64) firmata.OneWireAddress {
+	a := firmata.OneWireAddress{family}
+	for i := 0; i < 6; i++ {
+		a = append(a, byte(serial>>(8*uint(i))))
+	}
+	return append(a, firmata.OneWireCrc8(a))
+}
+
+// readBytes returns the first n bytes of data, padded with ones as an idle
+// bus reads.
+func readBytes(data []byte, n int) []byte {
+	r := bytes.Repeat([]byte{0xff}, n)
+	copy(r, data)
+	return r
+}
+
+// DS18B20 is a simulated DS18B20 temperature sensor.
+type DS18B20 struct {
+	address firmata.OneWireAddress
+
+	mu          sync.Mutex
+	temperature float64
+	parasite    bool
+	badCrc      bool
+	scratch     [9]byte
+	eeprom      [3]byte
+}
+
+// NewDS18B20 creates a DS18B20 with serial number serial, at 25°C and 12
+// bit resolution. Like a real one, it reads 85°C until the first
+// conversion.
+func NewDS18B20(serial uint64) *DS18B20 {
+	d := &DS18B20{
+		address:     oneWireAddress(0x28, serial),
+		temperature: 25,
+		eeprom:      [3]byte{0x4b, 0x46, 0x7f},
+	}
+	d.scratch = [9]byte{0x50, 0x05, 0x4b, 0x46, 0x7f, 0xff, 0x0c, 0x10}
+	return d
+}
+
+// Address returns the ROM address of the sensor.
+func (d *DS18B20) Address() firmata.OneWireAddress {
+	return d.address
+}
+
+// SetTemperature sets the temperature, in °C, the next conversion reads.
+func (d *DS18B20) SetTemperature(c float64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.temperature = c
+}
+
+// SetParasite sets whether the sensor reports being parasite powered.
+func (d *DS18B20) SetParasite(on bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.parasite = on
+}
+
+// SetCrcError makes the sensor send a bad CRC with its scratchpad.
+func (d *DS18B20) SetCrcError(on bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.badCrc = on
+}
+
+// Alarm returns true if the last conversion is outside the TL to TH
+// range.
+func (d *DS18B20) Alarm() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	t := int8(int16(uint16(d.scratch[0])|uint16(d.scratch[1])<<8) >> 4)
+	return t >= int8(d.scratch[2]) || t <= int8(d.scratch[3])
+}
+
+// Reset does nothing, as the sensor keeps its scratchpad across resets.
+func (d *DS18B20) Reset() {}
+
+// Transfer runs a function command.
+func (d *DS18B20) Transfer(write []byte, n int) []byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(write) == 0 {
+		// Reading after a conversion reports it done.
+		return readBytes(nil, n)
+	}
+	switch write[0] {
+	case 0x44: // Convert T
+		res := uint((d.scratch[4]>>5)&0x3) + 9
+		raw := int16(math.Round(d.temperature * 16))
+		raw &^= int16(1<<(12-res)) - 1
+		d.scratch[0], d.scratch[1] = byte(raw), byte(uint16(raw)>>8)
+	case 0xbe: // Read scratchpad
+		d.scratch[8] = firmata.OneWireCrc8(d.scratch[:8])
+		if d.badCrc {
+			d.scratch[8] ^= 0xff
+		}
+		return readBytes(d.scratch[:], n)
+	case 0x4e: // Write scratchpad
+		copy(d.scratch[2:5], write[1:])
+		d.scratch[4] = d.scratch[4]&0x60 | 0x1f
+	case 0x48: // Copy scratchpad
+		copy(d.eeprom[:], d.scratch[2:5])
+	case 0xb8: // Recall EEPROM
+		copy(d.scratch[2:5], d.eeprom[:])
+	case 0xb4: // Read power supply
+		if d.parasite {
+			return readBytes([]byte{0x00}, n)
+		}
+	}
+	return readBytes(nil, n)
+}
+
+// DS243x is a simulated DS2431 or DS2433 EEPROM.
+type DS243x struct {
+	address firmata.OneWireAddress
+	row     int
+
+	mu      sync.Mutex
+	memory  []byte
+	scratch []byte
+	ta      int
+	es      byte
+	copied  bool
+	badCrc  bool
+}
+
+// NewDS2431 creates a 1Kbit DS2431 with serial number serial, and memory
+// erased to ones.
+func NewDS2431(serial uint64) *DS243x {
+	return newDS243x(firmata.Ds2431Family, serial, 128, 8)
+}
+
+// NewDS2433 creates a 4Kbit DS2433 with serial number serial, and memory
+// erased to ones.
+func NewDS2433(serial uint64) *DS243x {
+	return newDS243x(firmata.Ds2433Family, serial, 512, 32)
+}
+
+func newDS243x(family byte, serial uint64, size, row int) *DS243x {
+	return &DS243x{
+		address: oneWireAddress(family, serial),
+		row:     row,
+		memory:  bytes.Repeat([]byte{0xff}, size),
+		scratch: bytes.Repeat([]byte{0xff}, row),
+	}
+}
+
+// Address returns the ROM address of the EEPROM.
+func (d *DS243x) Address() firmata.OneWireAddress {
+	return d.address
+}
+
+// Memory returns a copy of the memory.
+func (d *DS243x) Memory() []byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]byte(nil), d.memory...)
+}
+
+// SetMemory sets the memory from offset.
+func (d *DS243x) SetMemory(offset int, data []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	copy(d.memory[offset:], data)
+}
+
+// SetCrcError makes the EEPROM send bad CRCs with its scratchpad.
+func (d *DS243x) SetCrcError(on bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.badCrc = on
+}
+
+// Reset ends a copy in progress.
+func (d *DS243x) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.copied = false
+}
+
+// Transfer runs a memory function command.
+func (d *DS243x) Transfer(write []byte, n int) []byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(write) == 0 {
+		if d.copied {
+			// A finished copy reads as alternating ones and zeros.
+			return readBytes(bytes.Repeat([]byte{0xaa}, n), n)
+		}
+		return readBytes(nil, n)
+	}
+	if len(write) < 3 && write[0] != 0xaa {
+		return readBytes(nil, n)
+	}
+	switch write[0] {
+	case 0x0f: // Write scratchpad
+		d.ta = int(write[1]) | int(write[2])<<8
+		data := write[3:]
+		start := d.ta % d.row
+		copy(d.scratch[start:], data)
+		end := start + len(data) - 1
+		if end >= d.row {
+			end = d.row - 1
+		}
+		d.es = byte(end)
+		if start != 0 || len(data) != d.row {
+			// Partial flag.
+			d.es |= 0x20
+		}
+		return readBytes(d.crc(write), n)
+	case 0xaa: // Read scratchpad
+		r := append([]byte{byte(d.ta), byte(d.ta >> 8), d.es}, d.scratch...)
+		return readBytes(append(r, d.crc(append([]byte{0xaa}, r...))...), n)
+	case 0x55: // Copy scratchpad
+		if len(write) >= 4 && int(write[1])|int(write[2])<<8 == d.ta && write[3] == d.es && d.es&0x20 == 0 {
+			offset := d.ta &^ (d.row - 1)
+			if offset+d.row <= len(d.memory) {
+				copy(d.memory[offset:], d.scratch)
+				d.es |= 0x80
+				d.copied = true
+			}
+		}
+	case 0xf0: // Read memory
+		offset := int(write[1]) | int(write[2])<<8
+		if offset < len(d.memory) {
+			return readBytes(d.memory[offset:], n)
+		}
+	}
+	return readBytes(nil, n)
+}
+
+// crc returns the inverted CRC16 the EEPROM sends after data.
+func (d *DS243x) crc(data []byte) []byte {
+	c := ^firmata.OneWireCrc16(data)
+	if d.badCrc {
+		c ^= 0xffff
+	}
+	return []byte{byte(c), byte(c >> 8)}
+}
@@ -0,0 +1,90 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmatatest
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// pipe is one direction of an in-memory connection. Unlike io.Pipe,
+// writes never wait for a reader, so the board and client cannot hold
+// each other up.
+type pipe struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	buf    bytes.Buffer
+	closed bool
+}
+
+func newPipe() *pipe {
+	p := &pipe{}
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+// Read waits for data, returning io.EOF once the pipe is closed and
+// drained.
+func (p *pipe) Read(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for p.buf.Len() == 0 && !p.closed {
+		p.cond.Wait()
+	}
+	if p.buf.Len() == 0 {
+		return 0, io.EOF
+	}
+	return p.buf.Read(b)
+}
+
+func (p *pipe) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return 0, io.ErrClosedPipe
+	}
+	p.buf.Write(b)
+	p.cond.Broadcast()
+	return len(b), nil
+}
+
+func (p *pipe) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	p.cond.Broadcast()
+	return nil
+}
+
+// conn is the client end of a board connection.
+type conn struct {
+	in  *pipe
+	out *pipe
+}
+
+func (c *conn) Read(b []byte) (int, error) {
+	return c.in.Read(b)
+}
+
+func (c *conn) Write(b []byte) (int, error) {
+	return c.out.Write(b)
+}
+
+// Close closes both directions, as unplugging the board would.
+func (c *conn) Close() error {
+	c.out.Close()
+	return c.in.Close()
+}
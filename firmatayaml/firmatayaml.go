@@ -0,0 +1,100 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package firmatayaml reads firmata board configs written as YAML, with
+// the field names of the JSON form:
+//
+//	board: Uno
+//	pins:
+//	  D13: {label: led, mode: output, value: 1}
+//	  D2: {label: door, mode: pullup, report: true}
+//	  A0:
+//	    mode: analog
+//	    report: true
+//	    calibration: {type: voltage, bits: 10, vref: 5}
+//	drivers:
+//	  - name: outside
+//	    type: bme280
+//	    settings: {address: 0x77}
+//
+// It is kept apart from the firmata package so programs which only use
+// JSON do not depend on a YAML parser.
+package firmatayaml
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/buxtronix/go-firmata"
+	"gopkg.in/yaml.v3"
+)
+
+// Load reads and checks a BoardConfig from a file, as YAML if its name
+// ends in .yaml or .yml, and as JSON otherwise.
+func Load(path string) (*firmata.BoardConfig, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+	default:
+		return firmata.LoadBoardConfig(path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Unmarshal(data)
+}
+
+// Unmarshal decodes and checks a BoardConfig written as YAML. As with
+// firmata.UnmarshalBoardConfig, unknown fields are errors.
+func Unmarshal(data []byte) (*firmata.BoardConfig, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("firmatayaml: bad board config: %w", err)
+	}
+	if v == nil {
+		// An empty document is an empty config.
+		v = map[string]interface{}{}
+	}
+	data, err := json.Marshal(jsonValue(v))
+	if err != nil {
+		return nil, fmt.Errorf("firmatayaml: bad board config: %w", err)
+	}
+	return firmata.UnmarshalBoardConfig(data)
+}
+
+// jsonValue converts a decoded YAML value to one encoding/json can encode,
+// giving mappings with keys which are not strings, such as pin numbers,
+// string keys.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = jsonValue(e)
+		}
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = jsonValue(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range v {
+			v[i] = jsonValue(e)
+		}
+	}
+	return v
+}
@@ -0,0 +1,113 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"context"
+)
+
+// Future is the result of a request running in the background. Requests
+// on different buses run concurrently; those on the same bus take turns.
+type Future[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// Waiter is satisfied by any Future, for WaitAll.
+type Waiter interface {
+	Done() <-chan struct{}
+	Err() error
+}
+
+// async runs f in the background, returning its Future.
+func async[T any](f func() (T, error)) *Future[T] {
+	fut := &Future[T]{done: make(chan struct{})}
+	go func() {
+		defer close(fut.done)
+		fut.value, fut.err = f()
+	}()
+	return fut
+}
+
+// Done is closed when the request completes.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Result waits for the request to complete and returns its result.
+func (f *Future[T]) Result() (T, error) {
+	<-f.done
+	return f.value, f.err
+}
+
+// Err waits for the request to complete and returns its error.
+func (f *Future[T]) Err() error {
+	<-f.done
+	return f.err
+}
+
+// WaitAll waits for every future to complete, or ctx to be done, and
+// returns the first error of those futures, in order.
+func WaitAll(ctx context.Context, futures ...Waiter) error {
+	for _, f := range futures {
+		select {
+		case <-f.Done():
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	for _, f := range futures {
+		if err := f.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// I2CReadAsync is I2CReadContext, run in the background.
+func (c *FirmataClient) I2CReadAsync(ctx context.Context, address byte, register int, count int) *Future[[]byte] {
+	return async(func() ([]byte, error) { return c.I2CReadContext(ctx, address, register, count) })
+}
+
+// SPIReadWriteAsync is SPIReadWriteContext, run in the background.
+func (c *FirmataClient) SPIReadWriteAsync(ctx context.Context, csPin byte, data []byte) *Future[[]byte] {
+	return async(func() ([]byte, error) { return c.SPIReadWriteContext(ctx, csPin, data) })
+}
+
+// OneWireSearchAsync is OneWireSearchContext, run in the background.
+func (c *FirmataClient) OneWireSearchAsync(ctx context.Context, csPin byte, owSearchMode OneWireSubCommand) *Future[[]OneWireAddress] {
+	return async(func() ([]OneWireAddress, error) { return c.OneWireSearchContext(ctx, csPin, owSearchMode) })
+}
+
+// OneWireCommandAsync is OneWireCommandContext, run in the background.
+func (c *FirmataClient) OneWireCommandAsync(ctx context.Context, csPin byte, request OneWireRequest) *Future[[]byte] {
+	return async(func() ([]byte, error) { return c.OneWireCommandContext(ctx, csPin, request) })
+}
+
+// QueryFirmwareAsync is QueryFirmware, run in the background.
+func (c *FirmataClient) QueryFirmwareAsync(ctx context.Context) *Future[FirmwareInfo] {
+	return async(func() (FirmwareInfo, error) { return c.QueryFirmware(ctx) })
+}
+
+// QueryCapabilitiesAsync is QueryCapabilities, run in the background.
+func (c *FirmataClient) QueryCapabilitiesAsync(ctx context.Context) *Future[struct{}] {
+	return async(func() (struct{}, error) { return struct{}{}, c.QueryCapabilities(ctx) })
+}
+
+// QueryAnalogMappingAsync is QueryAnalogMapping, run in the background.
+func (c *FirmataClient) QueryAnalogMappingAsync(ctx context.Context) *Future[struct{}] {
+	return async(func() (struct{}, error) { return struct{}{}, c.QueryAnalogMapping(ctx) })
+}
@@ -0,0 +1,213 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// Hd44780Address is the usual I2C address of a PCF8574 LCD backpack.
+	// PCF8574A based backpacks are at 0x3f.
+	Hd44780Address = 0x27
+
+	// PCF8574 output bits.
+	lcdRs        = 0x01
+	lcdEnable    = 0x04
+	lcdBacklight = 0x08
+
+	lcdClear        = 0x01
+	lcdHome         = 0x02
+	lcdEntryMode    = 0x04
+	lcdDisplayCtl   = 0x08
+	lcdFunctionSet  = 0x20
+	lcdSetCgramAddr = 0x40
+	lcdSetDdramAddr = 0x80
+
+	lcdDisplayOn = 0x04
+	lcdCursorOn  = 0x02
+	lcdBlinkOn   = 0x01
+
+	// lcdChunk is the most PCF8574 output bytes queued in one I2C write.
+	// Each LCD byte takes four.
+	lcdChunk = 16
+)
+
+// Hd44780 is a HD44780 compatible character LCD on a PCF8574 I2C backpack,
+// driven in 4 bit mode. Each write to the PCF8574 takes longer than the
+// LCD enable pulse and command times, so nibbles are queued back to back
+// in one I2C write; only clear and home need an explicit wait.
+type Hd44780 struct {
+	// The client.
+	Client *FirmataClient
+	// The I2C address of the backpack.
+	Address byte
+	// Cols and Rows are the display size in characters.
+	Cols int
+	Rows int
+
+	backlight byte
+	control   byte
+}
+
+// Init resets the LCD into 4 bit mode, clears it and turns on the display
+// and backlight.
+func (d *Hd44780) Init() error {
+	if d.Rows < 1 || d.Rows > 4 {
+		return fmt.Errorf("unsupported number of rows %v", d.Rows)
+	}
+	d.backlight = lcdBacklight
+	d.control = lcdDisplayOn
+	d.Client.sleep(50 * time.Millisecond)
+
+	// Synchronise to 8 bit mode whatever state the LCD was left in, then
+	// switch to 4 bit mode.
+	for _, wait := range []time.Duration{4500 * time.Microsecond, 150 * time.Microsecond, 150 * time.Microsecond} {
+		if err := d.Client.I2CWrite(d.Address, d.nibble(0x30, 0)...); err != nil {
+			return err
+		}
+		d.Client.sleep(wait)
+	}
+	if err := d.Client.I2CWrite(d.Address, d.nibble(0x20, 0)...); err != nil {
+		return err
+	}
+
+	lines := byte(0x08)
+	if d.Rows == 1 {
+		lines = 0
+	}
+	if err := d.command(lcdFunctionSet | lines); err != nil {
+		return err
+	}
+	if err := d.command(lcdDisplayCtl | d.control); err != nil {
+		return err
+	}
+	if err := d.command(lcdEntryMode | 0x02); err != nil {
+		return err
+	}
+	return d.Clear()
+}
+
+// Clear clears the display and returns the cursor home.
+func (d *Hd44780) Clear() error {
+	err := d.command(lcdClear)
+	d.Client.sleep(2 * time.Millisecond)
+	return err
+}
+
+// Home returns the cursor to the top left.
+func (d *Hd44780) Home() error {
+	err := d.command(lcdHome)
+	d.Client.sleep(2 * time.Millisecond)
+	return err
+}
+
+// SetCursor moves the cursor to the given column and row, from 0.
+func (d *Hd44780) SetCursor(col, row int) error {
+	if row < 0 || row >= d.Rows || col < 0 || col >= d.Cols {
+		return fmt.Errorf("cursor position %v,%v is outside the display", col, row)
+	}
+	offsets := []int{0x00, 0x40, 0x14, 0x54}
+	return d.command(lcdSetDdramAddr | byte(col+offsets[row]))
+}
+
+// Backlight turns the backlight on or off.
+func (d *Hd44780) Backlight(on bool) error {
+	if on {
+		d.backlight = lcdBacklight
+	} else {
+		d.backlight = 0
+	}
+	return d.Client.I2CWrite(d.Address, d.backlight)
+}
+
+// Display turns the display on or off, without losing its contents.
+func (d *Hd44780) Display(on bool) error {
+	return d.setControl(lcdDisplayOn, on)
+}
+
+// Cursor shows or hides the underline cursor.
+func (d *Hd44780) Cursor(on bool) error {
+	return d.setControl(lcdCursorOn, on)
+}
+
+// Blink turns the blinking block cursor on or off.
+func (d *Hd44780) Blink(on bool) error {
+	return d.setControl(lcdBlinkOn, on)
+}
+
+// CreateChar defines custom character location, from 0 to 7, from rows
+// of 5 pixels. The character is printed as the byte value of location.
+func (d *Hd44780) CreateChar(location byte, pattern [8]byte) error {
+	if location > 7 {
+		return fmt.Errorf("invalid custom character location %v", location)
+	}
+	if err := d.command(lcdSetCgramAddr | location<<3); err != nil {
+		return err
+	}
+	return d.write(pattern[:], lcdRs)
+}
+
+// Print writes text at the cursor.
+func (d *Hd44780) Print(text string) error {
+	return d.write([]byte(text), lcdRs)
+}
+
+// Printf writes formatted text at the cursor.
+func (d *Hd44780) Printf(format string, a ...interface{}) error {
+	return d.Print(fmt.Sprintf(format, a...))
+}
+
+func (d *Hd44780) setControl(bit byte, on bool) error {
+	if on {
+		d.control |= bit
+	} else {
+		d.control &^= bit
+	}
+	return d.command(lcdDisplayCtl | d.control)
+}
+
+func (d *Hd44780) command(cmd byte) error {
+	return d.write([]byte{cmd}, 0)
+}
+
+// write sends bytes to the LCD as queued nibbles, with mode lcdRs for
+// data or 0 for commands.
+func (d *Hd44780) write(data []byte, mode byte) error {
+	var queue []byte
+	for _, b := range data {
+		queue = append(queue, d.nibble(b&0xf0, mode)...)
+		queue = append(queue, d.nibble(b<<4, mode)...)
+	}
+	for len(queue) > 0 {
+		n := len(queue)
+		if n > lcdChunk {
+			n = lcdChunk
+		}
+		if err := d.Client.I2CWrite(d.Address, queue[:n]...); err != nil {
+			return err
+		}
+		queue = queue[n:]
+	}
+	return nil
+}
+
+// nibble returns the PCF8574 outputs to clock the high nibble of b into
+// the LCD.
+func (d *Hd44780) nibble(b byte, mode byte) []byte {
+	v := b&0xf0 | mode | d.backlight
+	return []byte{v | lcdEnable, v}
+}
@@ -0,0 +1,188 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// Sht31Address is the default I2C address of an SHT31. The alternate
+	// address is 0x45.
+	Sht31Address = 0x44
+	// Htu21dAddress is the I2C address of an HTU21D.
+	Htu21dAddress = 0x40
+
+	htu21dReadTemp     = 0xf3
+	htu21dReadHumidity = 0xf5
+	htu21dWriteUser    = 0xe6
+	htu21dReadUser     = 0xe7
+	htu21dReset        = 0xfe
+	htu21dHeater       = 0x04
+)
+
+// Sht31 is a Sensirion SHT31 humidity and temperature sensor.
+type Sht31 struct {
+	// The client.
+	Client *FirmataClient
+	// The I2C address of the device.
+	Address byte
+	// Unit is the unit of readings, or UnitDefault for the client's.
+	Unit TemperatureUnit
+}
+
+// Read takes a single high repeatability measurement, returning the
+// relative humidity in percent and the temperature.
+func (d *Sht31) Read() (humidity float64, temperature Temperature, err error) {
+	if err = d.Client.I2CWrite(d.Address, 0x24, 0x00); err != nil {
+		return
+	}
+	d.Client.sleep(16 * time.Millisecond)
+	data, err := d.Client.I2CRead(d.Address, I2CNoRegister, 6)
+	if err != nil {
+		return
+	}
+	if len(data) < 6 {
+		err = fmt.Errorf("short measurement read")
+		return
+	}
+	if err = sensirionCheckCrc(d.Client, data[0:3], 0xff); err != nil {
+		return
+	}
+	if err = sensirionCheckCrc(d.Client, data[3:6], 0xff); err != nil {
+		return
+	}
+	rawT := float64(uint16(data[0])<<8 | uint16(data[1]))
+	rawH := float64(uint16(data[3])<<8 | uint16(data[4]))
+	temperature = NewTemperature(float32(-45+175*rawT/65535), temperatureUnit(d.Client, d.Unit))
+	humidity = 100 * rawH / 65535
+	return
+}
+
+// Heater turns the internal heater, used to drive off condensation, on or
+// off.
+func (d *Sht31) Heater(on bool) error {
+	if on {
+		return d.Client.I2CWrite(d.Address, 0x30, 0x6d)
+	}
+	return d.Client.I2CWrite(d.Address, 0x30, 0x66)
+}
+
+// Reset soft resets the sensor.
+func (d *Sht31) Reset() error {
+	err := d.Client.I2CWrite(d.Address, 0x30, 0xa2)
+	d.Client.sleep(2 * time.Millisecond)
+	return err
+}
+
+// Htu21d is a TE Connectivity HTU21D (or Sensirion SHT21) humidity and
+// temperature sensor.
+type Htu21d struct {
+	// The client.
+	Client *FirmataClient
+	// The I2C address of the device.
+	Address byte
+	// Unit is the unit of readings, or UnitDefault for the client's.
+	Unit TemperatureUnit
+}
+
+// Read measures the relative humidity in percent and the temperature.
+func (d *Htu21d) Read() (humidity float64, temperature Temperature, err error) {
+	rawT, err := d.measure(htu21dReadTemp, 50*time.Millisecond)
+	if err != nil {
+		return
+	}
+	rawH, err := d.measure(htu21dReadHumidity, 16*time.Millisecond)
+	if err != nil {
+		return
+	}
+	temperature = NewTemperature(float32(-46.85+175.72*rawT/65536), temperatureUnit(d.Client, d.Unit))
+	humidity = -6 + 125*rawH/65536
+	switch {
+	case humidity > 100:
+		humidity = 100
+	case humidity < 0:
+		humidity = 0
+	}
+	return
+}
+
+// Heater turns the internal heater on or off.
+func (d *Htu21d) Heater(on bool) error {
+	user, err := d.Client.I2CRead(d.Address, htu21dReadUser, 1)
+	if err != nil {
+		return err
+	}
+	if len(user) < 1 {
+		return fmt.Errorf("short user register read")
+	}
+	v := user[0] &^ htu21dHeater
+	if on {
+		v |= htu21dHeater
+	}
+	return d.Client.I2CWrite(d.Address, htu21dWriteUser, v)
+}
+
+// Reset soft resets the sensor.
+func (d *Htu21d) Reset() error {
+	err := d.Client.I2CWrite(d.Address, htu21dReset)
+	d.Client.sleep(15 * time.Millisecond)
+	return err
+}
+
+// measure starts a no-hold-master measurement, waits for it and reads the
+// result with its status bits cleared.
+func (d *Htu21d) measure(cmd byte, wait time.Duration) (float64, error) {
+	if err := d.Client.I2CWrite(d.Address, cmd); err != nil {
+		return 0, err
+	}
+	d.Client.sleep(wait)
+	data, err := d.Client.I2CRead(d.Address, I2CNoRegister, 3)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) < 3 {
+		return 0, fmt.Errorf("short measurement read")
+	}
+	if err := sensirionCheckCrc(d.Client, data, 0x00); err != nil {
+		return 0, err
+	}
+	return float64(uint16(data[0])<<8 | uint16(data[1])&0xfffc), nil
+}
+
+// sensirionCheckCrc verifies the CRC-8 (polynomial 0x31) following a 16
+// bit measurement word. The SHT31 starts the CRC at 0xff, the HTU21D at 0.
+func sensirionCheckCrc(client *FirmataClient, data []byte, init byte) error {
+	crc := sensirionCrc8(data[:2], init)
+	if crc != data[2] {
+		return client.crcError(uint16(data[2]), uint16(crc), data[:2])
+	}
+	return nil
+}
+
+func sensirionCrc8(data []byte, crc byte) byte {
+	for _, b := range data {
+		crc ^= b
+		for i := 8; i > 0; i-- {
+			if crc&0x80 > 0 {
+				crc = crc<<1 ^ 0x31
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,177 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// i2cMaxWrite is the most bytes, register included, sent in one I2C
+	// write. Each byte takes two in the SysEx message, which the firmware
+	// limits to 64 bytes, and the Wire library buffers 32.
+	i2cMaxWrite = 30
+	// i2cMaxRead is the most bytes read in one I2C request, the size of
+	// the Wire library buffer.
+	i2cMaxRead = 32
+	// i2cSyncEvery is the number of write chunks sent before waiting for
+	// the board to catch up.
+	i2cSyncEvery = 4
+)
+
+// I2CBulkOptions controls how I2CBulkWrite and I2CBulkRead split a
+// transfer into requests. The zero value suits a device with 8 bit
+// registers which advance as they are written or read.
+type I2CBulkOptions struct {
+	// ChunkSize is the most data bytes in each request. Zero uses the
+	// most the firmware can take.
+	ChunkSize int
+	// FixedRegister sends every chunk to the same register, for devices
+	// which take a stream through one register, such as a display data
+	// register, instead of advancing the register by the chunk offset.
+	FixedRegister bool
+	// RegisterWidth is the size of the register address in bytes: 1 if
+	// zero, or 2 for devices such as EEPROMs with 16 bit memory addresses,
+	// which are sent high byte first.
+	RegisterWidth int
+	// PageSize stops write chunks crossing a page boundary, for EEPROMs
+	// which wrap writes within a page. Zero has no pages.
+	PageSize int
+	// WriteDelay is the time to wait after each write chunk, such as an
+	// EEPROM write cycle.
+	WriteDelay time.Duration
+}
+
+func (o *I2CBulkOptions) width() int {
+	if o.RegisterWidth == 2 {
+		return 2
+	}
+	return 1
+}
+
+// registerBytes returns the address of register, offset by the position of
+// a chunk if the register advances.
+func (o *I2CBulkOptions) registerBytes(register int, offset int) []byte {
+	if !o.FixedRegister {
+		register += offset
+	}
+	if o.width() == 2 {
+		return []byte{byte(register >> 8), byte(register)}
+	}
+	return []byte{byte(register)}
+}
+
+// I2CBulkWrite writes data to an I2C device from register onwards, split
+// into requests the firmware can take. Every few chunks it waits until the
+// board has handled those before, so a long transfer does not overrun the
+// board. With nil options the defaults are used.
+func (c *FirmataClient) I2CBulkWrite(ctx context.Context, address byte, register int, data []byte, o *I2CBulkOptions) error {
+	if o == nil {
+		o = &I2CBulkOptions{}
+	}
+	size := i2cMaxWrite - o.width()
+	if o.ChunkSize > 0 && o.ChunkSize < size {
+		size = o.ChunkSize
+	}
+	c.i2cBulk.Lock()
+	defer c.i2cBulk.Unlock()
+
+	for offset, chunks := 0, 0; offset < len(data); chunks++ {
+		n := len(data) - offset
+		if n > size {
+			n = size
+		}
+		if o.PageSize > 0 && !o.FixedRegister {
+			if left := o.PageSize - (register+offset)%o.PageSize; n > left {
+				n = left
+			}
+		}
+		if chunks > 0 && chunks%i2cSyncEvery == 0 {
+			if err := c.i2cSync(ctx); err != nil {
+				return err
+			}
+		}
+		chunk := append(o.registerBytes(register, offset), data[offset:offset+n]...)
+		if err := c.i2cWrite(address, bulkPriority, chunk); err != nil {
+			return fmt.Errorf("I2C bulk write at offset %v: %w", offset, err)
+		}
+		if o.WriteDelay > 0 {
+			if err := c.i2cSync(ctx); err != nil {
+				return err
+			}
+			select {
+			case <-c.after(o.WriteDelay):
+			case <-ctx.Done():
+				return requestError(ctx, "I2C bulk write")
+			}
+		}
+		offset += n
+	}
+	return nil
+}
+
+// I2CBulkRead reads count bytes from an I2C device from register onwards,
+// as a series of reads the firmware can take, and returns them joined.
+// With nil options the defaults are used.
+func (c *FirmataClient) I2CBulkRead(ctx context.Context, address byte, register int, count int, o *I2CBulkOptions) ([]byte, error) {
+	if o == nil {
+		o = &I2CBulkOptions{}
+	}
+	size := i2cMaxRead
+	if o.ChunkSize > 0 && o.ChunkSize < size {
+		size = o.ChunkSize
+	}
+	c.i2cBulk.Lock()
+	defer c.i2cBulk.Unlock()
+
+	data := make([]byte, 0, count)
+	for len(data) < count {
+		n := count - len(data)
+		if n > size {
+			n = size
+		}
+		var chunk []byte
+		var err error
+		reg := o.registerBytes(register, len(data))
+		if o.width() == 2 {
+			// The firmware sends only one register byte, so set the
+			// address with a write and read from there.
+			if err = c.I2CWrite(address, reg...); err == nil {
+				chunk, err = c.I2CReadContext(ctx, address, I2CNoRegister, n)
+			}
+		} else {
+			chunk, err = c.I2CReadContext(ctx, address, int(reg[0]), n)
+		}
+		if err != nil {
+			return data, fmt.Errorf("I2C bulk read at offset %v: %w", len(data), err)
+		}
+		if len(chunk) < n {
+			return data, fmt.Errorf("I2C bulk read at offset %v: short reply of %v bytes, wanted %v", len(data), len(chunk), n)
+		}
+		data = append(data, chunk[:n]...)
+	}
+	return data, nil
+}
+
+// i2cSync waits for the board to handle the writes sent so far. The board
+// handles messages in order, so its reply to a ping comes after them.
+func (c *FirmataClient) i2cSync(ctx context.Context) error {
+	if _, err := c.Ping(ctx); err != nil {
+		return fmt.Errorf("I2C bulk write: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type I2CMode byte
+
+// I2CNoRegister is passed as the register to read from a device without
+// first writing a register address to it.
+const I2CNoRegister = -1
+
+// I2CResponse is data read from an I2C device.
+type I2CResponse struct {
+	// Address is the device address.
+	Address byte
+	// Register is the register the data was read from.
+	Register int
+	// Data is the data read.
+	Data []byte
+	// Time is when the reply was received.
+	Time time.Time
+}
+
+// Enable I2C, with delay microseconds between a register write and the
+// following read for devices which need it.
+func (c *FirmataClient) I2CConfig(delay int) (err error) {
+	c.i2cMu.Lock()
+	if c.i2cChan == nil {
+		c.i2cChan = make(chan I2CResponse, 1)
+	}
+	c.i2cMu.Unlock()
+	err = c.sendSysEx(I2CConfig, byte(delay&0x7f), byte((delay>>7)&0x7f))
+	if err == nil {
+		c.recordConfig(func(cfg *boardConfig) { cfg.i2cDelay = &delay })
+	}
+	return
+}
+
+// Write data to an I2C device
+func (c *FirmataClient) I2CWrite(address byte, data ...byte) (err error) {
+	return c.i2cWrite(address, normalPriority, data)
+}
+
+func (c *FirmataClient) i2cWrite(address byte, prio writePriority, data []byte) error {
+	data7Bit := []byte{address & 0x7f, byte(I2CModeWrite)}
+	for _, b := range data {
+		data7Bit = append(data7Bit, to7Bit(b)...)
+	}
+	return c.sendSysExFrame(I2CRequest, false, prio, data7Bit)
+}
+
+// Read count bytes from an I2C device, starting at register
+func (c *FirmataClient) I2CRead(address byte, register int, count int) (dataOut []byte, err error) {
+	return c.I2CReadContext(context.Background(), address, register, count)
+}
+
+// I2CReadContext is I2CRead, giving up if ctx is done or the request
+// times out before the device replies.
+func (c *FirmataClient) I2CReadContext(ctx context.Context, address byte, register int, count int) (data []byte, err error) {
+	ctx, done := c.startRequest(ctx, RequestInfo{Op: "I2C read", Command: I2CRequest, Pin: int(address), Size: count})
+	defer func() { done(len(data), err) }()
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+	c.i2cReq.Lock()
+	defer c.i2cReq.Unlock()
+	c.i2cMu.Lock()
+	ch := c.i2cChan
+	if ch != nil {
+		c.i2cPending = &i2cQuery{address, register}
+	}
+	c.i2cMu.Unlock()
+	if ch == nil {
+		return nil, fmt.Errorf("%w: I2C not configured", ErrFeatureMissing)
+	}
+	defer func() {
+		c.i2cMu.Lock()
+		c.i2cPending = nil
+		c.i2cMu.Unlock()
+	}()
+	// Drop any reply which arrived after an earlier read gave up.
+	select {
+	case <-ch:
+	default:
+	}
+	if err := c.sendRequest(I2CRequest, i2cReadRequest(address, I2CModeRead, register, count)...); err != nil {
+		return nil, err
+	}
+	select {
+	case reply := <-ch:
+		return reply.Data, nil
+	case <-ctx.Done():
+		return nil, requestError(ctx, "I2C read")
+	case <-c.done:
+		return nil, closedError("I2C read")
+	}
+}
+
+// Continuously read count bytes from an I2C device, starting at register,
+// once every sampling interval. Replies are sent on the returned channel
+// until I2CStopReading is called for the device.
+func (c *FirmataClient) I2CReadContinuous(address byte, register int, count int) (<-chan I2CResponse, error) {
+	l := &i2cListener{
+		ch:   make(chan I2CResponse, c.Queue(QueueI2C).Size),
+		done: make(chan bool),
+	}
+	c.i2cMu.Lock()
+	if c.i2cListeners == nil {
+		c.i2cListeners = make(map[i2cQuery]*i2cListener)
+	}
+	c.i2cListeners[i2cQuery{address, register}] = l
+	c.i2cMu.Unlock()
+
+	err := c.sendRequest(I2CRequest, i2cReadRequest(address, I2CModeReadContinuous, register, count)...)
+	if err != nil {
+		c.i2cRemoveListeners(address)
+		return nil, err
+	}
+	return l.ch, nil
+}
+
+// Stop all continuous reads from an I2C device, closing their channels
+func (c *FirmataClient) I2CStopReading(address byte) (err error) {
+	err = c.sendSysEx(I2CRequest, address&0x7f, byte(I2CModeStopReading))
+	c.i2cRemoveListeners(address)
+	return
+}
+
+// i2cQuery identifies a read, by device and register.
+type i2cQuery struct {
+	address  byte
+	register int
+}
+
+// matches returns whether reply is for the read q. The register the board
+// reports for a read without one depends on the firmware, so such a read
+// matches any reply from the device.
+func (q i2cQuery) matches(reply I2CResponse) bool {
+	return q.address == reply.Address && (q.register == I2CNoRegister || q.register == reply.Register)
+}
+
+// i2cListener is a continuous read, fed by the reader.
+type i2cListener struct {
+	ch     chan I2CResponse
+	mu     sync.Mutex
+	closed bool
+	done   chan bool
+	once   sync.Once
+}
+
+// send delivers reply, returning false if it overflowed the buffer.
+func (l *i2cListener) send(c *FirmataClient, reply I2CResponse) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return true
+	}
+	return deliverPolicy(c, QueueI2C, c.queues[QueueI2C].Policy, l.done, l.ch, reply)
+}
+
+func (l *i2cListener) stop() {
+	// Release a blocked send first, as it holds the lock.
+	l.once.Do(func() { close(l.done) })
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.closed {
+		l.closed = true
+		close(l.ch)
+	}
+}
+
+func (c *FirmataClient) i2cRemoveListeners(address byte) {
+	c.i2cStopListeners(func(q i2cQuery) bool { return q.address == address })
+}
+
+// i2cStopListeners removes and closes the continuous reads match selects.
+func (c *FirmataClient) i2cStopListeners(match func(i2cQuery) bool) {
+	var stopped []*i2cListener
+	c.i2cMu.Lock()
+	for q, l := range c.i2cListeners {
+		if match(q) {
+			delete(c.i2cListeners, q)
+			stopped = append(stopped, l)
+		}
+	}
+	c.i2cMu.Unlock()
+	for _, l := range stopped {
+		l.stop()
+	}
+}
+
+// i2cReadRequest builds the 7 bit payload of an I2C read request.
+func i2cReadRequest(address byte, mode I2CMode, register int, count int) []byte {
+	data7Bit := []byte{address & 0x7f, byte(mode)}
+	if register != I2CNoRegister {
+		data7Bit = append(data7Bit, byte(register&0x7f), byte((register>>7)&0x7f))
+	}
+	return append(data7Bit, byte(count&0x7f), byte((count>>7)&0x7f))
+}
+
+func (c *FirmataClient) parseI2CResponse(data7bit []byte) {
+	if len(data7bit) < 4 {
+		c.Log.Debug("Discarding short I2C reply")
+		return
+	}
+	reply := I2CResponse{
+		Address:  from7Bit(data7bit[0], data7bit[1]),
+		Register: int(data7bit[2]&0x7f) | int(data7bit[3]&0x7f)<<7,
+		Data:     make([]byte, 0, (len(data7bit)-4)/2),
+		Time:     c.received,
+	}
+	for i := 4; i+1 < len(data7bit); i = i + 2 {
+		reply.Data = append(reply.Data, from7Bit(data7bit[i], data7bit[i+1]))
+	}
+	c.i2cMu.Lock()
+	// A reply goes to a continuous read of the register, and to I2CRead if
+	// it is waiting for it, so reads of a device being streamed still get
+	// their reply.
+	listener, streamed := c.i2cListeners[i2cQuery{reply.Address, reply.Register}]
+	if !streamed {
+		listener, streamed = c.i2cListeners[i2cQuery{reply.Address, I2CNoRegister}]
+	}
+	pending := c.i2cPending != nil && c.i2cPending.matches(reply)
+	ch := c.i2cChan
+	c.i2cMu.Unlock()
+	// The listener is sent to without i2cMu, so a full buffer does not
+	// hold up I2CRead and I2CStopReading.
+	if streamed {
+		if !listener.send(c, reply) {
+			c.Log.Warn("I2C data buffer overflow for device 0x%x. No listener?", reply.Address)
+		}
+		if !pending {
+			return
+		}
+	}
+	if ch == nil {
+		c.Log.Debug("Discarding I2C reply, I2C not configured")
+		return
+	}
+	if streamed {
+		reply.Data = append([]byte(nil), reply.Data...)
+	}
+	select {
+	case ch <- reply:
+	default:
+		c.Log.Warn("Discarding I2C reply from device 0x%x, no pending read", reply.Address)
+	}
+}
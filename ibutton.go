@@ -0,0 +1,106 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"time"
+)
+
+// Ds1990Family is the family code of a DS1990A iButton.
+const Ds1990Family = 0x01
+
+// IButtonEvent reports an iButton being presented to or removed from the
+// reader.
+type IButtonEvent struct {
+	// Address is the ROM address of the iButton.
+	Address OneWireAddress
+	// Present is true when the key was presented and false when removed.
+	Present bool
+}
+
+// IButtonReader polls a OneWire bus for DS1990A iButtons.
+type IButtonReader struct {
+	// The client.
+	Client *FirmataClient
+	// The pin the bus is on.
+	Pin byte
+	// Interval is the time between bus searches.
+	Interval time.Duration
+	// Misses is the number of searches in a row a key must be missing
+	// from before it is reported removed, 3 if zero, so a key which
+	// loses contact for a moment is not removed and presented again.
+	Misses int
+
+	events  chan IButtonEvent
+	poller  *poller
+	present *presence
+}
+
+// NewIButtonReader creates a reader which searches the bus on pin every
+// interval. The pin must already be configured with OneWireConfig.
+func NewIButtonReader(client *FirmataClient, pin byte, interval time.Duration) *IButtonReader {
+	return &IButtonReader{
+		Client:   client,
+		Pin:      pin,
+		Interval: interval,
+		events:   make(chan IButtonEvent, 10),
+		poller:   newPoller(),
+		present:  newPresence(),
+	}
+}
+
+// Start begins polling the bus and returns the channel of key events.
+func (r *IButtonReader) Start() <-chan IButtonEvent {
+	go r.poll()
+	return r.events
+}
+
+// Stop stops polling the bus and closes the event channel. It does not
+// block, so may be called from the loop reading the events.
+func (r *IButtonReader) Stop() {
+	r.poller.Stop()
+}
+
+func (r *IButtonReader) poll() {
+	defer close(r.events)
+	r.poller.every(r.Client.Clock(), r.Interval, r.scan)
+}
+
+// scan runs a single search and emits events for keys which have appeared,
+// or have been missing for Misses searches.
+func (r *IButtonReader) scan() {
+	devices, err := r.Client.oneWireDevices(r.Pin)
+	if err != nil {
+		r.Client.Log.Warn("iButton search: %s", err.Error())
+		return
+	}
+	seen := make(map[string]OneWireAddress)
+	for k, a := range devices {
+		if a[0] == Ds1990Family {
+			seen[k] = a
+		}
+	}
+	added, removed := r.present.update(seen, r.Misses)
+	for _, a := range added {
+		if !pollSend(r.poller, r.events, IButtonEvent{Address: a, Present: true}) {
+			return
+		}
+	}
+	for _, a := range removed {
+		if !pollSend(r.poller, r.events, IButtonEvent{Address: a, Present: false}) {
+			return
+		}
+	}
+}
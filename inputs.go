@@ -0,0 +1,168 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"time"
+)
+
+// Read the last reported value of a digital input pin. Reporting must be
+// enabled for the pin with EnableDigitalInput.
+func (c *FirmataClient) DigitalRead(pin uint) (bool, error) {
+	if pin >= uint(len(c.capabilities())) || pin/8 >= uint(len(c.digitalInputs)) {
+		return false, fmt.Errorf("%w %v", ErrInvalidPin, pin)
+	}
+	c.inputMu.Lock()
+	defer c.inputMu.Unlock()
+	return c.digitalInputs[pin/8]&(1<<(pin%8)) > 0, nil
+}
+
+// Read the last reported value of an analog input pin. Reporting must be
+// enabled for the pin with EnableAnalogInput.
+func (c *FirmataClient) AnalogRead(pin uint) (int, error) {
+	pinChannels, _ := c.analogChannels()
+	if _, ok := pinChannels[int(pin)]; !ok {
+		return 0, fmt.Errorf("%w: pin %v is not an analog pin", ErrUnsupportedPinMode, pin)
+	}
+	c.inputMu.Lock()
+	defer c.inputMu.Unlock()
+	return c.analogInputs[int(pin)], nil
+}
+
+// GetDigital returns the cached value of a digital input pin, without
+// waiting. The second result is false if the board has not yet reported
+// the pin, or it is not a pin of the board.
+func (c *FirmataClient) GetDigital(pin uint) (value bool, ok bool) {
+	if pin/8 >= uint(len(c.digitalInputs)) {
+		return false, false
+	}
+	c.inputMu.Lock()
+	defer c.inputMu.Unlock()
+	if !c.portReported[pin/8] {
+		return false, false
+	}
+	return c.digitalInputs[pin/8]&(1<<(pin%8)) > 0, true
+}
+
+// GetAnalog returns the cached reading of an analog input pin, without
+// waiting. The second result is false if the board has not yet reported
+// the pin.
+func (c *FirmataClient) GetAnalog(pin uint) (value int, ok bool) {
+	c.inputMu.Lock()
+	defer c.inputMu.Unlock()
+	value, ok = c.analogInputs[int(pin)]
+	return
+}
+
+// Set an input pin to use its internal pullup resistor. Firmware without
+// the Pullup pin mode is sent the older equivalent, a high write to an
+// input pin.
+func (c *FirmataClient) SetPullup(pin byte) error {
+	if c.supports(pin, Pullup) {
+		return c.SetPinMode(pin, Pullup)
+	}
+	if err := c.SetPinMode(pin, Input); err != nil {
+		return err
+	}
+	return c.DigitalWrite(uint(pin), true)
+}
+
+// recordValue caches a reported pin value and passes it to the listeners.
+// It returns false if the value is held back by an analog filter, and so
+// should not be passed on to the WithValues channel either.
+func (c *FirmataClient) recordValue(v FirmataValue) bool {
+	c.inputMu.Lock()
+	defer c.inputMu.Unlock()
+	if v.IsAnalog() {
+		pin, val, _ := v.GetAnalogValue()
+		if c.analogInputs == nil {
+			c.analogInputs = make(map[int]int)
+		}
+		old, ok := c.analogInputs[pin]
+		c.analogInputs[pin] = val
+		if a := c.captures[byte(pin)]; a != nil {
+			a.add(val, v.received)
+		}
+		pass, previous := c.filterAnalog(byte(pin), val, old, v.received)
+		if !pass {
+			return false
+		}
+		if !ok || previous != val {
+			c.analogChanged(byte(pin), val)
+		}
+		c.publishAnalog(byte(pin), val, previous, v.received)
+	} else if port := int(v.valueType & 0x0F); port < len(c.digitalInputs) {
+		c.recordDigital(byte(port), byte(v.value), v.received)
+	}
+	for ch := range c.valueListeners {
+		if !deliver(c, QueueListeners, ch, v) {
+			c.Log.Warn("Pin value buffer overflow. No listener?")
+		}
+	}
+	return true
+}
+
+// recordDigital caches a digital port report. Pins with a debounce time
+// are held back until they have been stable for it. inputMu is held.
+func (c *FirmataClient) recordDigital(port byte, raw byte, t time.Time) {
+	prevRaw := c.digitalRaw[port]
+	c.digitalRaw[port] = raw
+	c.portReported[port] = true
+	held := c.debounceMask(port)
+	c.commitDigital(port, (c.digitalInputs[port]^raw)&^held, raw, t)
+	for bit := byte(0); bit < 8; bit++ {
+		if held&(1<<bit) > 0 {
+			c.debounceBit(port, bit, (raw^prevRaw)&(1<<bit) > 0, t)
+		}
+	}
+}
+
+// commitDigital updates the pins of port in mask to their value in raw,
+// and notifies subscribers of those which changed. inputMu is held.
+func (c *FirmataClient) commitDigital(port byte, mask byte, raw byte, t time.Time) {
+	if mask == 0 {
+		return
+	}
+	state := c.digitalInputs[port]&^mask | raw&mask
+	if changed := c.digitalInputs[port] ^ state; changed != 0 {
+		c.digitalChanged(port, changed, state)
+		c.publishDigital(port, changed, state, t)
+	}
+	c.digitalInputs[port] = state
+}
+
+// addValueListener returns a channel which receives every reported pin
+// value, independent of the channel given to WithValues.
+func (c *FirmataClient) addValueListener() chan FirmataValue {
+	ch := make(chan FirmataValue, c.Queue(QueueListeners).Size)
+	c.inputMu.Lock()
+	defer c.inputMu.Unlock()
+	if c.valueListeners == nil {
+		c.valueListeners = make(map[chan FirmataValue]bool)
+	}
+	c.valueListeners[ch] = true
+	return ch
+}
+
+// removeValueListener stops and closes a channel from addValueListener.
+func (c *FirmataClient) removeValueListener(ch chan FirmataValue) {
+	c.inputMu.Lock()
+	defer c.inputMu.Unlock()
+	if c.valueListeners[ch] {
+		delete(c.valueListeners, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"context"
+	"time"
+)
+
+// PinController is pin configuration and digital and analog I/O.
+type PinController interface {
+	SetPinMode(pin byte, mode PinMode) error
+	SetPullup(pin byte) error
+	DigitalWrite(pin uint, val bool) error
+	DigitalRead(pin uint) (bool, error)
+	AnalogWrite(pin uint, pinData byte) error
+	AnalogRead(pin uint) (int, error)
+	EnableDigitalInput(pin uint, val bool) error
+	EnableAnalogInput(pin uint, val bool) error
+	GetDigital(pin uint) (value bool, ok bool)
+	GetAnalog(pin uint) (value int, ok bool)
+	SetAnalogSamplingInterval(ms byte) error
+}
+
+// DigitalPin is a single digital pin, of the board or of an expander such
+// as an Mcp23017. *Pin and *Mcp23017Pin implement it.
+type DigitalPin interface {
+	Number() byte
+	Modes() []PinMode
+	Mode() (PinMode, bool)
+	SetMode(mode PinMode) error
+	High() error
+	Low() error
+	Write(val bool) error
+	Read() (int, error)
+	Watch() (<-chan int, error)
+	StopWatching()
+	Label() string
+	String() string
+}
+
+var _ DigitalPin = (*Pin)(nil)
+
+// I2CBus is the I2C bus of the board.
+type I2CBus interface {
+	I2CConfig(delay int) error
+	I2CWrite(address byte, data ...byte) error
+	I2CRead(address byte, register int, count int) ([]byte, error)
+	I2CReadContext(ctx context.Context, address byte, register int, count int) ([]byte, error)
+	I2CReadContinuous(address byte, register int, count int) (<-chan I2CResponse, error)
+	I2CStopReading(address byte) error
+	I2CBulkWrite(ctx context.Context, address byte, register int, data []byte, o *I2CBulkOptions) error
+	I2CBulkRead(ctx context.Context, address byte, register int, count int, o *I2CBulkOptions) ([]byte, error)
+}
+
+// OneWireBus is the OneWire buses of the board, one per pin.
+type OneWireBus interface {
+	OneWireConfig(csPin byte, owPowerMode byte) error
+	OneWireSearch(csPin byte, owSearchMode OneWireSubCommand) ([]OneWireAddress, error)
+	OneWireSearchContext(ctx context.Context, csPin byte, owSearchMode OneWireSubCommand) ([]OneWireAddress, error)
+	OneWireCommand(csPin byte, request OneWireRequest) ([]byte, error)
+	OneWireCommandContext(ctx context.Context, csPin byte, request OneWireRequest) ([]byte, error)
+	OneWireRelease(csPin byte) error
+}
+
+// Querier is what the board reports about itself.
+type Querier interface {
+	Firmware() FirmwareInfo
+	QueryFirmware(ctx context.Context) (FirmwareInfo, error)
+	QueryCapabilities(ctx context.Context) error
+	QueryAnalogMapping(ctx context.Context) error
+	Pins() []PinInfo
+	Ping(ctx context.Context) (time.Duration, error)
+}
+
+// Firmata is the client surface most application code uses, so it can be
+// given a mock board in tests. *FirmataClient implements it; code needing
+// the rest of the client, such as the drivers, takes a *FirmataClient.
+type Firmata interface {
+	PinController
+	I2CBus
+	OneWireBus
+	Querier
+
+	Flush() error
+	CloseContext(ctx context.Context) error
+}
+
+var _ Firmata = (*FirmataClient)(nil)
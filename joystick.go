@@ -0,0 +1,195 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"math"
+)
+
+// JoystickAxis is the calibration of one joystick axis, in raw analog
+// readings.
+type JoystickAxis struct {
+	Min, Center, Max int
+}
+
+// normalize maps a raw reading to -1..1, with readings within deadZone
+// of the center mapped to 0.
+func (a JoystickAxis) normalize(raw int, deadZone float64) float64 {
+	var v float64
+	if raw >= a.Center {
+		if a.Max > a.Center {
+			v = float64(raw-a.Center) / float64(a.Max-a.Center)
+		}
+	} else if a.Center > a.Min {
+		v = float64(raw-a.Center) / float64(a.Center-a.Min)
+	}
+	v = math.Max(-1, math.Min(1, v))
+	if math.Abs(v) <= deadZone {
+		return 0
+	}
+	// Rescale so that output starts from 0 at the edge of the dead zone.
+	if v > 0 {
+		return (v - deadZone) / (1 - deadZone)
+	}
+	return (v + deadZone) / (1 - deadZone)
+}
+
+// JoystickEvent reports the position of a Joystick.
+type JoystickEvent struct {
+	// X and Y are the position, from -1 to 1 with 0 at the center.
+	X, Y float64
+	// Pressed is the state of the button, if there is one.
+	Pressed bool
+}
+
+// Joystick is a two axis analog joystick, with an optional push button.
+type Joystick struct {
+	// The client.
+	Client *FirmataClient
+	// XPin and YPin are the analog pins of the axes.
+	XPin, YPin byte
+	// Button is the joystick button, or nil if there isn't one.
+	Button *Button
+	// X and Y are the axis calibrations. They default to a centered 10
+	// bit range.
+	X, Y JoystickAxis
+	// DeadZone is the distance from the center, from 0 to 1, which is
+	// treated as centered.
+	DeadZone float64
+	// Threshold is how far the position must move on either axis before a
+	// new event is sent.
+	Threshold float64
+
+	events chan JoystickEvent
+	poller *poller
+}
+
+// NewJoystick creates a joystick on analog pins x and y, with a dead zone
+// of 0.1 and a threshold of 0.05. The button may be nil.
+func NewJoystick(client *FirmataClient, x, y byte, button *Button) *Joystick {
+	return &Joystick{
+		Client:    client,
+		XPin:      x,
+		YPin:      y,
+		Button:    button,
+		X:         JoystickAxis{0, 512, 1023},
+		Y:         JoystickAxis{0, 512, 1023},
+		DeadZone:  0.1,
+		Threshold: 0.05,
+	}
+}
+
+// Calibrate sets the center of each axis to its current reading. The
+// joystick should be at rest.
+func (j *Joystick) Calibrate() error {
+	x, err := j.Client.AnalogRead(uint(j.XPin))
+	if err != nil {
+		return err
+	}
+	y, err := j.Client.AnalogRead(uint(j.YPin))
+	if err != nil {
+		return err
+	}
+	j.X.Center, j.Y.Center = x, y
+	return nil
+}
+
+// Start enables reporting of the axes, and the button if there is one,
+// and returns the channel of position events.
+func (j *Joystick) Start() (<-chan JoystickEvent, error) {
+	for _, p := range []byte{j.XPin, j.YPin} {
+		if err := j.Client.SetPinMode(p, Analog); err != nil {
+			return nil, err
+		}
+		if err := j.Client.EnableAnalogInput(uint(p), true); err != nil {
+			return nil, err
+		}
+	}
+	var buttons <-chan ButtonEvent
+	if j.Button != nil {
+		var err error
+		if buttons, err = j.Button.Start(); err != nil {
+			return nil, err
+		}
+	}
+	j.events = make(chan JoystickEvent, 10)
+	j.poller = newPoller()
+	go j.watch(j.Client.addValueListener(), buttons, j.events, j.poller)
+	return j.events, nil
+}
+
+// Stop stops watching the joystick and closes the event channel. It does
+// not block, so may be called from the loop reading the events.
+func (j *Joystick) Stop() {
+	j.poller.Stop()
+}
+
+func (j *Joystick) watch(values chan FirmataValue, buttons <-chan ButtonEvent, events chan JoystickEvent, p *poller) {
+	defer close(events)
+	defer j.Client.removeValueListener(values)
+	if j.Button != nil {
+		defer j.Button.Stop()
+	}
+
+	// last starts off range so the first reading is always sent.
+	last := JoystickEvent{X: 2, Y: 2}
+	pos := last
+	for {
+		select {
+		case v, ok := <-values:
+			if !ok {
+				return
+			}
+			pin, raw, err := v.GetAnalogValue()
+			if err != nil {
+				continue
+			}
+			switch pin {
+			case int(j.XPin):
+				pos.X = j.X.normalize(raw, j.DeadZone)
+			case int(j.YPin):
+				pos.Y = j.Y.normalize(raw, j.DeadZone)
+			default:
+				continue
+			}
+			if math.Abs(pos.X-last.X) < j.Threshold && math.Abs(pos.Y-last.Y) < j.Threshold {
+				continue
+			}
+		case e, ok := <-buttons:
+			if !ok {
+				buttons = nil
+				continue
+			}
+			switch e.Type {
+			case ButtonPress:
+				pos.Pressed = true
+			case ButtonRelease:
+				pos.Pressed = false
+			default:
+				continue
+			}
+		case <-p.stopped():
+			return
+		}
+		if pos.X > 1 || pos.Y > 1 {
+			// Wait for both axes to be reported.
+			continue
+		}
+		last = pos
+		if !pollSend(p, events, pos) {
+			return
+		}
+	}
+}
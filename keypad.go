@@ -0,0 +1,217 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"time"
+)
+
+// Keypad4x4 is the layout of a common 4x4 membrane keypad.
+var Keypad4x4 = [][]rune{
+	{'1', '2', '3', 'A'},
+	{'4', '5', '6', 'B'},
+	{'7', '8', '9', 'C'},
+	{'*', '0', '#', 'D'},
+}
+
+// Keypad4x3 is the layout of a common 4x3 telephone keypad.
+var Keypad4x3 = [][]rune{
+	{'1', '2', '3'},
+	{'4', '5', '6'},
+	{'7', '8', '9'},
+	{'*', '0', '#'},
+}
+
+// KeyEvent reports a key on a Keypad being pressed or released.
+type KeyEvent struct {
+	// Key is the key from the keypad layout.
+	Key rune
+	// Row and Col locate the key in the matrix.
+	Row, Col int
+	// Pressed is true when the key was pressed and false when released.
+	Pressed bool
+}
+
+// Keypad scans a matrix keypad. Rows are driven low one at a time and
+// the columns, held high by pullups, are read for pressed keys.
+//
+// Each key is tracked separately so any number may be held at once. Keys
+// which form the corners of a rectangle with three other pressed keys
+// cannot be told apart from a phantom press on a diode-less matrix, so
+// they keep their previous state until the ambiguity clears.
+type Keypad struct {
+	// The client.
+	Client *FirmataClient
+	// RowPins and ColPins are the pins the matrix is wired to.
+	RowPins []byte
+	ColPins []byte
+	// Keys is the layout, indexed by row then column.
+	Keys [][]rune
+	// Interval is the time between scans.
+	Interval time.Duration
+	// Settle is how long to wait after driving a row before reading the
+	// columns, allowing for the firmware to report the change.
+	Settle time.Duration
+	// Debounce is the number of consecutive scans a key must differ from
+	// its current state before the change is reported.
+	Debounce int
+
+	events  chan KeyEvent
+	poller  *poller
+	pressed [][]bool
+	counts  [][]int
+}
+
+// NewKeypad creates a keypad on the row and column pins, scanned every
+// interval, with layout keys.
+func NewKeypad(client *FirmataClient, rows, cols []byte, keys [][]rune, interval time.Duration) *Keypad {
+	return &Keypad{
+		Client:   client,
+		RowPins:  rows,
+		ColPins:  cols,
+		Keys:     keys,
+		Interval: interval,
+		Settle:   10 * time.Millisecond,
+		Debounce: 2,
+	}
+}
+
+// Start configures the pins and begins scanning, returning the channel of
+// key events.
+func (k *Keypad) Start() (<-chan KeyEvent, error) {
+	if len(k.Keys) != len(k.RowPins) {
+		return nil, fmt.Errorf("keypad layout has %v rows, expected %v", len(k.Keys), len(k.RowPins))
+	}
+	for _, row := range k.Keys {
+		if len(row) != len(k.ColPins) {
+			return nil, fmt.Errorf("keypad layout has %v columns, expected %v", len(row), len(k.ColPins))
+		}
+	}
+	for _, p := range k.RowPins {
+		if err := k.Client.SetPinMode(p, Output); err != nil {
+			return nil, err
+		}
+		if err := k.Client.DigitalWrite(uint(p), true); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range k.ColPins {
+		if err := k.Client.SetPullup(p); err != nil {
+			return nil, err
+		}
+		if err := k.Client.EnableDigitalInput(uint(p), true); err != nil {
+			return nil, err
+		}
+	}
+	k.pressed = make([][]bool, len(k.RowPins))
+	k.counts = make([][]int, len(k.RowPins))
+	for r := range k.RowPins {
+		k.pressed[r] = make([]bool, len(k.ColPins))
+		k.counts[r] = make([]int, len(k.ColPins))
+	}
+	k.events = make(chan KeyEvent, 10)
+	k.poller = newPoller()
+	go k.poll()
+	return k.events, nil
+}
+
+// Stop stops scanning and closes the event channel. It does not block, so
+// may be called from the loop reading the events.
+func (k *Keypad) Stop() {
+	k.poller.Stop()
+}
+
+func (k *Keypad) poll() {
+	defer close(k.events)
+	k.poller.every(k.Client.Clock(), k.Interval, func() {
+		if scan, err := k.scan(); err != nil {
+			k.Client.Log.Warn("Keypad scan: %s", err.Error())
+		} else {
+			k.update(scan)
+		}
+	})
+}
+
+// scan drives each row in turn and returns the raw matrix state.
+func (k *Keypad) scan() ([][]bool, error) {
+	scan := make([][]bool, len(k.RowPins))
+	for r, rp := range k.RowPins {
+		if err := k.Client.DigitalWrite(uint(rp), false); err != nil {
+			return nil, err
+		}
+		k.Client.sleep(k.Settle)
+		scan[r] = make([]bool, len(k.ColPins))
+		for c, cp := range k.ColPins {
+			high, err := k.Client.DigitalRead(uint(cp))
+			if err != nil {
+				return nil, err
+			}
+			scan[r][c] = !high
+		}
+		if err := k.Client.DigitalWrite(uint(rp), true); err != nil {
+			return nil, err
+		}
+	}
+	return scan, nil
+}
+
+// update debounces a scan against the current state and emits events for
+// keys which have changed.
+func (k *Keypad) update(scan [][]bool) {
+	ghost := ghostedKeys(scan)
+	for r := range scan {
+		for c := range scan[r] {
+			if ghost[r][c] || scan[r][c] == k.pressed[r][c] {
+				k.counts[r][c] = 0
+				continue
+			}
+			k.counts[r][c]++
+			if k.counts[r][c] < k.Debounce {
+				continue
+			}
+			k.counts[r][c] = 0
+			k.pressed[r][c] = scan[r][c]
+			if !pollSend(k.poller, k.events, KeyEvent{Key: k.Keys[r][c], Row: r, Col: c, Pressed: scan[r][c]}) {
+				return
+			}
+		}
+	}
+}
+
+// ghostedKeys marks the keys of a scan which are at the corners of a
+// rectangle of pressed keys.
+func ghostedKeys(scan [][]bool) [][]bool {
+	ghost := make([][]bool, len(scan))
+	for r := range scan {
+		ghost[r] = make([]bool, len(scan[r]))
+	}
+	for r1 := range scan {
+		for r2 := r1 + 1; r2 < len(scan); r2++ {
+			for c1 := range scan[r1] {
+				if !scan[r1][c1] || !scan[r2][c1] {
+					continue
+				}
+				for c2 := c1 + 1; c2 < len(scan[r1]); c2++ {
+					if scan[r1][c2] && scan[r2][c2] {
+						ghost[r1][c1], ghost[r1][c2] = true, true
+						ghost[r2][c1], ghost[r2][c2] = true, true
+					}
+				}
+			}
+		}
+	}
+	return ghost
+}
@@ -0,0 +1,89 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// LabelPin gives pin an application defined name, such as "pump_relay".
+// The label is used to describe the pin in events, errors and logs, and
+// PinNumber and NamedPin resolve it, as do the pin names of a BoardConfig
+// and the firmata command. Each method of the client which takes a pin
+// number has a counterpart on Pin, so look the pin up once by label and
+// use it:
+//
+//	pump, err := client.NamedPin("pump_relay")
+//	...
+//	pump.SetSafeState(false)
+//	err = pump.High()
+//
+// Methods which take the pin of a bus, such as OneWireConfig, take
+// PinNumber of the label.
+//
+// A pin has at most one label, and labelling it again replaces the old
+// one.
+func (c *FirmataClient) LabelPin(label string, pin byte) error {
+	if label == "" {
+		return fmt.Errorf("Empty pin label")
+	}
+	if _, err := strconv.ParseUint(label, 10, 8); err == nil {
+		return fmt.Errorf("Pin label %q is a pin number", label)
+	}
+	c.modeMu.Lock()
+	defer c.modeMu.Unlock()
+	if p, ok := c.labelPins[label]; ok && p != pin {
+		return fmt.Errorf("Pin label %q is already used by pin %v", label, p)
+	}
+	if c.labelPins == nil {
+		c.labelPins = make(map[string]byte)
+		c.pinLabels = make(map[byte]string)
+	}
+	if old, ok := c.pinLabels[pin]; ok {
+		delete(c.labelPins, old)
+	}
+	c.labelPins[label] = pin
+	c.pinLabels[pin] = label
+	return nil
+}
+
+// UnlabelPin removes the label of pin.
+func (c *FirmataClient) UnlabelPin(pin byte) {
+	c.modeMu.Lock()
+	defer c.modeMu.Unlock()
+	if label, ok := c.pinLabels[pin]; ok {
+		delete(c.labelPins, label)
+		delete(c.pinLabels, pin)
+	}
+}
+
+// PinLabel returns the label of pin, or its number if it has none.
+func (c *FirmataClient) PinLabel(pin byte) string {
+	c.modeMu.Lock()
+	defer c.modeMu.Unlock()
+	if label, ok := c.pinLabels[pin]; ok {
+		return label
+	}
+	return strconv.Itoa(int(pin))
+}
+
+// labelledPin returns the pin with label, if there is one.
+func (c *FirmataClient) labelledPin(label string) (byte, bool) {
+	c.modeMu.Lock()
+	defer c.modeMu.Unlock()
+	pin, ok := c.labelPins[label]
+	return pin, ok
+}
@@ -0,0 +1,103 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+)
+
+// Max31850Family is the family code of the MAX31850 and MAX31851.
+const Max31850Family = 0x3b
+
+// Max31850 is a Maxim MAX31850 or MAX31851 thermocouple to 1-Wire converter.
+type Max31850 struct {
+	// The client.
+	Client *FirmataClient
+	// The pin the bus is on.
+	Pin byte
+	// The address of the device on the bus.
+	Address OneWireAddress
+	// scratch is the raw register data.
+	scratch []byte
+	// Latest thermocouple temperature reading.
+	Temperature Temperature
+	// Latest cold junction (device) temperature reading.
+	ColdJunction Temperature
+	// Unit is the unit of the readings, or UnitDefault for the client's.
+	Unit TemperatureUnit
+	// Fault is set if any fault was detected in the latest reading.
+	Fault bool
+	// OpenCircuit is set if the thermocouple is not connected.
+	OpenCircuit bool
+	// ShortGnd is set if the thermocouple is shorted to ground.
+	ShortGnd bool
+	// ShortVdd is set if the thermocouple is shorted to VDD.
+	ShortVdd bool
+}
+
+// ConvertT initiates a temperature conversion.
+func (d *Max31850) ConvertT() error {
+	req := OneWireRequest{
+		Command: OW_RESET | OW_SELECT | OW_WRITE,
+		Address: d.Address,
+		Data:    []byte{0x44},
+	}
+	_, err := d.Client.OneWireCommand(d.Pin, req)
+	return err
+}
+
+// ReadScratchPad reads the device scratchpad and decodes the temperatures
+// and fault bits.
+func (d *Max31850) ReadScratchPad() error {
+	req := OneWireRequest{
+		Command:       OW_RESET | OW_SELECT | OW_WRITE | OW_READ,
+		Address:       d.Address,
+		ReadCount:     9,
+		CorrelationId: 0x1234,
+		Data:          []byte{0xbe},
+	}
+	scratch, err := d.Client.OneWireCommand(d.Pin, req)
+	if err != nil {
+		return err
+	}
+	if len(scratch) < 11 {
+		return fmt.Errorf("short scratchpad read, got %d bytes", len(scratch)-2)
+	}
+	d.scratch = scratch[2:11]
+	crc := d.scratch[8]
+	c := OneWireCrc8(d.scratch[:8])
+	if c != crc {
+		return d.Client.crcError(uint16(crc), uint16(c), d.scratch[:8])
+	}
+	d.parseScratchPad()
+	return nil
+}
+
+// parseScratchPad decodes the raw scratchpad data.
+func (d *Max31850) parseScratchPad() {
+	unit := temperatureUnit(d.Client, d.Unit)
+
+	// Thermocouple temperature is signed 14 bits in 0.25C steps.
+	tc := int16(uint16(d.scratch[0]) | uint16(d.scratch[1])<<8)
+	d.Temperature = NewTemperature(float32(tc>>2)/4, unit)
+	d.Fault = d.scratch[0]&0x1 > 0
+
+	// Cold junction temperature is signed 12 bits in 0.0625C steps.
+	cj := int16(uint16(d.scratch[2]) | uint16(d.scratch[3])<<8)
+	d.ColdJunction = NewTemperature(float32(cj>>4)/16, unit)
+	d.OpenCircuit = d.scratch[2]&0x1 > 0
+	d.ShortGnd = d.scratch[2]&0x2 > 0
+	d.ShortVdd = d.scratch[2]&0x4 > 0
+}
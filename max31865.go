@@ -0,0 +1,168 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	max31865Config = 0x00
+	max31865Rtd    = 0x01
+	max31865Fault  = 0x07
+	max31865Write  = 0x80
+
+	max31865Vbias      = 0x80
+	max31865Auto       = 0x40
+	max31865ThreeWire  = 0x10
+	max31865FaultClear = 0x02
+	max31865Filter50Hz = 0x01
+
+	// Callendar-Van Dusen coefficients for IEC 60751 platinum RTDs.
+	cvdA = 3.9083e-3
+	cvdB = -5.775e-7
+)
+
+// max31865Faults describes each bit of the fault status register.
+var max31865Faults = []struct {
+	bit  byte
+	desc string
+}{
+	{0x80, "RTD above high threshold"},
+	{0x40, "RTD below low threshold"},
+	{0x20, "REFIN- above 0.85 x VBIAS"},
+	{0x10, "REFIN- below 0.85 x VBIAS, FORCE- open"},
+	{0x08, "RTDIN- below 0.85 x VBIAS, FORCE- open"},
+	{0x04, "over or under voltage"},
+}
+
+// Max31865 is a Maxim MAX31865 RTD to digital converter on SPI.
+type Max31865 struct {
+	// The client.
+	Client *FirmataClient
+	// CsPin is the chip select pin of the device.
+	CsPin byte
+	// Nominal is the RTD resistance at 0C, 100 for a PT100 or 1000 for a
+	// PT1000.
+	Nominal float64
+	// Reference is the value of the reference resistor, typically 430 for
+	// a PT100 or 4300 for a PT1000.
+	Reference float64
+	// ThreeWire is set for a 3 wire RTD, otherwise 2 or 4 wire is assumed.
+	ThreeWire bool
+	// Filter50Hz selects 50Hz mains rejection instead of 60Hz.
+	Filter50Hz bool
+	// Unit is the unit of readings, or UnitDefault for the client's.
+	Unit TemperatureUnit
+}
+
+// Init enables SPI for the device and starts automatic conversions.
+func (d *Max31865) Init() error {
+	if err := d.Client.SPIConfig(d.CsPin, SPI_MODE1); err != nil {
+		return err
+	}
+	return d.writeConfig(max31865FaultClear)
+}
+
+// Resistance returns the measured RTD resistance in ohms.
+func (d *Max31865) Resistance() (float64, error) {
+	data, err := d.read(max31865Rtd, 2)
+	if err != nil {
+		return 0, err
+	}
+	if data[1]&0x01 > 0 {
+		return 0, d.faultError()
+	}
+	raw := uint16(data[0])<<7 | uint16(data[1])>>1
+	return float64(raw) * d.Reference / 32768, nil
+}
+
+// Read returns the RTD temperature, converted with the Callendar-Van
+// Dusen equation.
+func (d *Max31865) Read() (Temperature, error) {
+	r, err := d.Resistance()
+	if err != nil {
+		return Temperature{}, err
+	}
+	return NewTemperature(float32(rtdTemperature(r, d.Nominal)), temperatureUnit(d.Client, d.Unit)), nil
+}
+
+// Faults returns the fault status register.
+func (d *Max31865) Faults() (byte, error) {
+	data, err := d.read(max31865Fault, 1)
+	if err != nil {
+		return 0, err
+	}
+	return data[0], nil
+}
+
+// ClearFaults clears the fault status register.
+func (d *Max31865) ClearFaults() error {
+	return d.writeConfig(max31865FaultClear)
+}
+
+// faultError reads the fault status and describes it.
+func (d *Max31865) faultError() error {
+	status, err := d.Faults()
+	if err != nil {
+		return err
+	}
+	var faults []string
+	for _, f := range max31865Faults {
+		if status&f.bit > 0 {
+			faults = append(faults, f.desc)
+		}
+	}
+	return fmt.Errorf("RTD fault 0x%x: %s", status, strings.Join(faults, ", "))
+}
+
+func (d *Max31865) writeConfig(extra byte) error {
+	config := byte(max31865Vbias | max31865Auto)
+	if d.ThreeWire {
+		config |= max31865ThreeWire
+	}
+	if d.Filter50Hz {
+		config |= max31865Filter50Hz
+	}
+	_, err := d.Client.SPIReadWrite(d.CsPin, []byte{max31865Write | max31865Config, config | extra})
+	return err
+}
+
+// read reads count registers starting at reg.
+func (d *Max31865) read(reg byte, count int) ([]byte, error) {
+	data, err := d.Client.SPIReadWrite(d.CsPin, append([]byte{reg}, make([]byte, count)...))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < count+1 {
+		return nil, fmt.Errorf("short read of register 0x%x", reg)
+	}
+	return data[1 : count+1], nil
+}
+
+// rtdTemperature converts a platinum RTD resistance to degrees C. Above
+// 0C the Callendar-Van Dusen quadratic is solved directly; below it a
+// polynomial fit of the full equation is used.
+func rtdTemperature(r, nominal float64) float64 {
+	t := (-cvdA + math.Sqrt(cvdA*cvdA-4*cvdB*(1-r/nominal))) / (2 * cvdB)
+	if t >= 0 {
+		return t
+	}
+	rt := r / nominal * 100
+	return -242.02 + 2.2228*rt + 2.5859e-3*rt*rt - 4.8260e-6*rt*rt*rt -
+		2.8183e-8*rt*rt*rt*rt + 1.5243e-10*rt*rt*rt*rt*rt
+}
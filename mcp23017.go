@@ -0,0 +1,424 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// Mcp23017Address is the default I2C address of an MCP23017, with
+	// A0-A2 tied low.
+	Mcp23017Address = 0x20
+
+	// Register addresses for port A with IOCON.BANK clear. Port B is at
+	// the following address, so 16 bit accesses cover both ports.
+	mcp23017Iodir   = 0x00
+	mcp23017Gpinten = 0x04
+	mcp23017Iocon   = 0x0a
+	mcp23017Gppu    = 0x0c
+	mcp23017Intf    = 0x0e
+	mcp23017Intcap  = 0x10
+	mcp23017Gpio    = 0x12
+	mcp23017Olat    = 0x14
+
+	// mcp23017Mirror joins the INTA and INTB outputs.
+	mcp23017Mirror = 0x40
+
+	// mcp23017PollInterval is how often a watched expander pin is read
+	// unless PollInterval is set.
+	mcp23017PollInterval = 50 * time.Millisecond
+)
+
+// Mcp23017Event reports a pin change detected by the interrupt logic.
+type Mcp23017Event struct {
+	// Pin is the expander pin, from 0 (GPA0) to 15 (GPB7).
+	Pin int
+	// Value is the pin level captured at the interrupt.
+	Value bool
+}
+
+// Mcp23017 is a Microchip MCP23017 16 bit I2C GPIO expander. Pins 0-7 are
+// GPA0-7 and 8-15 are GPB0-7. Pin returns a pin of it which can be used
+// as a native pin is.
+type Mcp23017 struct {
+	// The client.
+	Client *FirmataClient
+	// The I2C address of the device.
+	Address byte
+	// PollInterval is how often a watched pin is read, 50ms if zero. The
+	// expander does not report changes, so pins are polled.
+	PollInterval time.Duration
+
+	mu      sync.Mutex
+	iodir   uint16
+	olat    uint16
+	gppu    uint16
+	gpinten uint16
+	modeSet uint16
+	poller  *poller
+}
+
+// Init resets all pins to inputs without pullups and interrupts, with the
+// INTA and INTB outputs mirrored so either can be wired to a native pin.
+func (d *Mcp23017) Init() error {
+	if err := d.Client.I2CWrite(d.Address, mcp23017Iocon, mcp23017Mirror); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.iodir, d.olat, d.gppu, d.gpinten, d.modeSet = 0xffff, 0, 0, 0, 0
+	// The registers are written in a fixed order, directions first so no
+	// pin drives an output while the rest are reset.
+	for _, w := range []struct {
+		reg byte
+		v   uint16
+	}{
+		{mcp23017Iodir, d.iodir},
+		{mcp23017Olat, d.olat},
+		{mcp23017Gppu, d.gppu},
+		{mcp23017Gpinten, d.gpinten},
+	} {
+		if err := d.write16(w.reg, w.v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sets the Pin mode of an expander pin, which must be Input, Pullup or
+// Output. Pullup is an input with the internal 100k pullup, and Input
+// turns the pullup off.
+func (d *Mcp23017) SetPinMode(pin byte, mode PinMode) error {
+	if err := checkMcp23017Pin(uint(pin)); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	bit := uint16(1) << pin
+	iodir, gppu := d.iodir, d.gppu
+	switch mode {
+	case Input:
+		iodir |= bit
+		gppu &^= bit
+	case Pullup:
+		iodir |= bit
+		gppu |= bit
+	case Output:
+		iodir &^= bit
+	default:
+		return fmt.Errorf("%w: %v by expander pin %v", ErrUnsupportedPinMode, mode, pin)
+	}
+	if gppu != d.gppu {
+		if err := d.write16(mcp23017Gppu, gppu); err != nil {
+			return err
+		}
+		d.gppu = gppu
+	}
+	if err := d.write16(mcp23017Iodir, iodir); err != nil {
+		return err
+	}
+	d.iodir = iodir
+	d.modeSet |= bit
+	return nil
+}
+
+// mode returns the mode expander pin was last set to, and false if it has
+// not been set since Init.
+func (d *Mcp23017) mode(pin byte) (PinMode, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	bit := uint16(1) << pin
+	switch {
+	case d.modeSet&bit == 0:
+		return 0, false
+	case d.iodir&bit == 0:
+		return Output, true
+	case d.gppu&bit != 0:
+		return Pullup, true
+	}
+	return Input, true
+}
+
+// Set the value of an expander pin
+func (d *Mcp23017) DigitalWrite(pin uint, val bool) error {
+	if err := checkMcp23017Pin(pin); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if val {
+		d.olat |= 1 << pin
+	} else {
+		d.olat &^= 1 << pin
+	}
+	return d.write16(mcp23017Olat, d.olat)
+}
+
+// Read the value of an expander pin
+func (d *Mcp23017) DigitalRead(pin uint) (bool, error) {
+	if err := checkMcp23017Pin(pin); err != nil {
+		return false, err
+	}
+	v, err := d.ReadAll()
+	return v&(1<<pin) > 0, err
+}
+
+// ReadAll reads the level of all 16 pins as a bitmask.
+func (d *Mcp23017) ReadAll() (uint16, error) {
+	return d.read16(mcp23017Gpio)
+}
+
+// Enable or disable the internal 100k pullup on an expander pin
+func (d *Mcp23017) SetPullup(pin uint, on bool) error {
+	if err := checkMcp23017Pin(pin); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if on {
+		d.gppu |= 1 << pin
+	} else {
+		d.gppu &^= 1 << pin
+	}
+	return d.write16(mcp23017Gppu, d.gppu)
+}
+
+// Enable or disable interrupt-on-change for an expander pin
+func (d *Mcp23017) EnableInterrupt(pin uint, on bool) error {
+	if err := checkMcp23017Pin(pin); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if on {
+		d.gpinten |= 1 << pin
+	} else {
+		d.gpinten &^= 1 << pin
+	}
+	return d.write16(mcp23017Gpinten, d.gpinten)
+}
+
+// HandleInterrupt reads the interrupt flag and capture registers, which
+// also clears the interrupt, and returns an event for each flagged pin.
+// Call it when the native pin wired to INTA or INTB goes low.
+func (d *Mcp23017) HandleInterrupt() ([]Mcp23017Event, error) {
+	data, err := d.Client.I2CRead(d.Address, mcp23017Intf, 4)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 4 {
+		return nil, fmt.Errorf("short interrupt register read")
+	}
+	flags := uint16(data[0]) | uint16(data[1])<<8
+	capture := uint16(data[2]) | uint16(data[3])<<8
+	var events []Mcp23017Event
+	for pin := uint(0); pin < 16; pin++ {
+		if flags&(1<<pin) > 0 {
+			events = append(events, Mcp23017Event{Pin: int(pin), Value: capture&(1<<pin) > 0})
+		}
+	}
+	return events, nil
+}
+
+// PollInterrupts calls HandleInterrupt every interval and sends the
+// events on the returned channel, for when INTA and INTB are not wired to
+// the board. Polling continues until StopPolling is called.
+func (d *Mcp23017) PollInterrupts(interval time.Duration) <-chan Mcp23017Event {
+	events := make(chan Mcp23017Event, 16)
+	p := newPoller()
+	d.mu.Lock()
+	d.poller.Stop()
+	d.poller = p
+	d.mu.Unlock()
+	go func(p *poller) {
+		defer close(events)
+		p.every(d.Client.Clock(), interval, func() {
+			changes, err := d.HandleInterrupt()
+			if err != nil {
+				d.Client.Log.Warn("MCP23017 interrupt poll: %s", err.Error())
+				return
+			}
+			for _, e := range changes {
+				if !pollSend(p, events, e) {
+					return
+				}
+			}
+		})
+	}(p)
+	return events
+}
+
+// StopPolling stops the polling started by PollInterrupts, closing the
+// channel. It does not block, so may be called from the loop reading the
+// events.
+func (d *Mcp23017) StopPolling() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.poller.Stop()
+}
+
+func (d *Mcp23017) write16(reg byte, v uint16) error {
+	return d.Client.I2CWrite(d.Address, reg, byte(v), byte(v>>8))
+}
+
+func (d *Mcp23017) read16(reg byte) (uint16, error) {
+	data, err := d.Client.I2CRead(d.Address, int(reg), 2)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) < 2 {
+		return 0, fmt.Errorf("short read of register 0x%x", reg)
+	}
+	return uint16(data[0]) | uint16(data[1])<<8, nil
+}
+
+func checkMcp23017Pin(pin uint) error {
+	if pin > 15 {
+		return fmt.Errorf("%w %v on expander", ErrInvalidPin, pin)
+	}
+	return nil
+}
+
+// Mcp23017Pin is a pin of an Mcp23017, with the API of a native Pin. Like
+// Pin, each method sets the mode it needs.
+type Mcp23017Pin struct {
+	device *Mcp23017
+	number byte
+	watch  *poller
+}
+
+var _ DigitalPin = (*Mcp23017Pin)(nil)
+
+// Pin returns expander pin n, from 0 (GPA0) to 15 (GPB7).
+func (d *Mcp23017) Pin(n byte) *Mcp23017Pin {
+	return &Mcp23017Pin{device: d, number: n}
+}
+
+// Number returns the expander pin number.
+func (p *Mcp23017Pin) Number() byte {
+	return p.number
+}
+
+// Modes returns the modes of an expander pin.
+func (p *Mcp23017Pin) Modes() []PinMode {
+	return []PinMode{Input, Output, Pullup}
+}
+
+// Mode returns the mode the pin was last set to. The second result is
+// false if the mode has not been set since Init.
+func (p *Mcp23017Pin) Mode() (PinMode, bool) {
+	return p.device.mode(p.number)
+}
+
+// SetMode sets the pin mode.
+func (p *Mcp23017Pin) SetMode(mode PinMode) error {
+	return p.device.SetPinMode(p.number, mode)
+}
+
+// High sets the pin to an output and drives it high.
+func (p *Mcp23017Pin) High() error {
+	return p.Write(true)
+}
+
+// Low sets the pin to an output and drives it low.
+func (p *Mcp23017Pin) Low() error {
+	return p.Write(false)
+}
+
+// Write sets the pin to an output and drives it to val.
+func (p *Mcp23017Pin) Write(val bool) error {
+	if m, ok := p.Mode(); !ok || m != Output {
+		if err := p.SetMode(Output); err != nil {
+			return err
+		}
+	}
+	return p.device.DigitalWrite(uint(p.number), val)
+}
+
+// Read reads the level of the pin, 0 or 1. A pin which has not been set to
+// an input mode is set to Input.
+func (p *Mcp23017Pin) Read() (int, error) {
+	if err := p.ensureInput(); err != nil {
+		return 0, err
+	}
+	high, err := p.device.DigitalRead(uint(p.number))
+	if high {
+		return 1, err
+	}
+	return 0, err
+}
+
+// Watch reads the pin every PollInterval of the expander, and sends each
+// new level on the returned channel until StopWatching is called or the
+// pin is watched again. The pin is set to Input unless it is already an
+// input.
+func (p *Mcp23017Pin) Watch() (<-chan int, error) {
+	if err := p.ensureInput(); err != nil {
+		return nil, err
+	}
+	interval := p.device.PollInterval
+	if interval <= 0 {
+		interval = mcp23017PollInterval
+	}
+	out := make(chan int, 10)
+	w := newPoller()
+	p.device.mu.Lock()
+	p.watch.Stop()
+	p.watch = w
+	p.device.mu.Unlock()
+	go func(w *poller) {
+		defer close(out)
+		last := -1
+		w.every(p.device.Client.Clock(), interval, func() {
+			all, err := p.device.ReadAll()
+			if err != nil {
+				p.device.Client.Log.Warn("%v: %s", p, err.Error())
+				return
+			}
+			val := int(all>>p.number) & 1
+			if val != last && pollSend(w, out, val) {
+				last = val
+			}
+		})
+	}(w)
+	return out, nil
+}
+
+// StopWatching stops the values started by Watch, closing the channel. It
+// does not block, so may be called from the loop reading the values.
+func (p *Mcp23017Pin) StopWatching() {
+	p.device.mu.Lock()
+	defer p.device.mu.Unlock()
+	p.watch.Stop()
+}
+
+// Label returns the name of the pin, such as GPA0.
+func (p *Mcp23017Pin) Label() string {
+	return fmt.Sprintf("GP%c%d", 'A'+p.number/8, p.number%8)
+}
+
+func (p *Mcp23017Pin) String() string {
+	return fmt.Sprintf("MCP23017 0x%02x pin %v", p.device.Address, p.Label())
+}
+
+func (p *Mcp23017Pin) ensureInput() error {
+	if m, ok := p.Mode(); ok && (m == Input || m == Pullup) {
+		return nil
+	}
+	return p.SetMode(Input)
+}
@@ -0,0 +1,100 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// Mcp4725Address is the I2C address of an MCP4725A0 with A0 low.
+	Mcp4725Address = 0x60
+
+	mcp4725WriteDacEeprom = 0x60
+	mcp4725Ready          = 0x80
+)
+
+// Mcp4725 is a Microchip MCP4725 12 bit DAC.
+type Mcp4725 struct {
+	// The client.
+	Client *FirmataClient
+	// The I2C address of the device.
+	Address byte
+	// Vdd is the supply voltage, which is the full scale output. It
+	// defaults to 5V.
+	Vdd float64
+}
+
+// SetValue sets the output to value, from 0 to 4095, using the two byte
+// fast write command.
+func (d *Mcp4725) SetValue(value uint16) error {
+	if value > 4095 {
+		return fmt.Errorf("DAC value %v is out of range", value)
+	}
+	return d.Client.I2CWrite(d.Address, byte(value>>8), byte(value))
+}
+
+// SetVoltage sets the output to the nearest value to volts.
+func (d *Mcp4725) SetVoltage(volts float64) error {
+	vdd := d.Vdd
+	if vdd == 0 {
+		vdd = 5
+	}
+	if volts < 0 || volts > vdd {
+		return fmt.Errorf("voltage %v is outside 0-%vV", volts, vdd)
+	}
+	return d.SetValue(uint16(volts/vdd*4095 + 0.5))
+}
+
+// SaveValue sets the output to value and stores it in EEPROM as the
+// power-on output, waiting for the EEPROM write to complete.
+func (d *Mcp4725) SaveValue(value uint16) error {
+	if value > 4095 {
+		return fmt.Errorf("DAC value %v is out of range", value)
+	}
+	if err := d.Client.I2CWrite(d.Address, mcp4725WriteDacEeprom, byte(value>>4), byte(value<<4)); err != nil {
+		return err
+	}
+	deadline := d.Client.now().Add(100 * time.Millisecond)
+	for {
+		d.Client.sleep(10 * time.Millisecond)
+		data, err := d.Client.I2CRead(d.Address, I2CNoRegister, 1)
+		if err != nil {
+			return err
+		}
+		if len(data) > 0 && data[0]&mcp4725Ready > 0 {
+			return nil
+		}
+		if d.Client.now().After(deadline) {
+			return &TimeoutError{Op: "MCP4725 EEPROM write"}
+		}
+	}
+}
+
+// Read returns the current output value and the value stored in EEPROM.
+func (d *Mcp4725) Read() (value uint16, saved uint16, err error) {
+	data, err := d.Client.I2CRead(d.Address, I2CNoRegister, 5)
+	if err != nil {
+		return
+	}
+	if len(data) < 5 {
+		err = fmt.Errorf("short read")
+		return
+	}
+	value = uint16(data[1])<<4 | uint16(data[2])>>4
+	saved = uint16(data[3]&0x0f)<<8 | uint16(data[4])
+	return
+}
@@ -0,0 +1,82 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"strings"
+	"time"
+)
+
+// MetricsSink receives measurements of client activity. Methods are called
+// from the reader and from requesting goroutines, so must be safe for
+// concurrent use, and should be quick.
+//
+// Command names are those of the command, or SysEx command, without the
+// byte value, such as "DigitalMessage" or "SysEx I2CReply", so have a small
+// fixed set of values.
+type MetricsSink interface {
+	// MessageSent counts a frame of size bytes sent to the board.
+	MessageSent(command string, size int)
+	// MessageReceived counts a frame of size bytes received from the board.
+	MessageReceived(command string, size int)
+	// ProtocolError counts a ProtocolError, by its type.
+	ProtocolError(t ProtocolErrorType)
+	// QueueDepth reports the number of items waiting in a client queue:
+	// "writes", "values", "callbacks" or "errors".
+	QueueDepth(queue string, depth int)
+	// RequestDone reports the time taken by a request to the board, such as
+	// "I2C read", and the error, nil if it succeeded.
+	RequestDone(op string, d time.Duration, err error)
+}
+
+// SetMetrics sends measurements of the client to sink, until called with
+// nil.
+func (c *FirmataClient) SetMetrics(sink MetricsSink) {
+	c.metricsMu.Lock()
+	defer c.metricsMu.Unlock()
+	c.metrics = sink
+}
+
+func (c *FirmataClient) metricsSink() MetricsSink {
+	c.metricsMu.Lock()
+	defer c.metricsMu.Unlock()
+	return c.metrics
+}
+
+// countFrame counts a frame sent or received.
+func (c *FirmataClient) countFrame(d TraceDirection, data []byte) {
+	m := c.metricsSink()
+	if m == nil {
+		return
+	}
+	f := TraceFrame{Direction: d, Data: data}
+	name := f.Command()
+	if i := strings.Index(name, " (0x"); i >= 0 {
+		name = name[:i]
+	}
+	if d == Sent {
+		m.MessageSent(name, len(data))
+	} else {
+		m.MessageReceived(name, len(data))
+	}
+}
+
+// queueDepth reports the depth of a client queue.
+func (c *FirmataClient) queueDepth(queue string, depth int) {
+	c.debug.queueDepth(queue, depth)
+	if m := c.metricsSink(); m != nil {
+		m.QueueDepth(queue, depth)
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AccelRange is the full scale range of the Mpu6050 accelerometer.
+type AccelRange byte
+
+// GyroRange is the full scale range of the Mpu6050 gyroscope.
+type GyroRange byte
+
+const (
+	// Mpu6050Address is the default I2C address of an MPU6050. The
+	// alternate address is 0x69.
+	Mpu6050Address = 0x68
+
+	Accel2G  AccelRange = 0x00
+	Accel4G  AccelRange = 0x01
+	Accel8G  AccelRange = 0x02
+	Accel16G AccelRange = 0x03
+
+	Gyro250  GyroRange = 0x00 // degrees per second
+	Gyro500  GyroRange = 0x01
+	Gyro1000 GyroRange = 0x02
+	Gyro2000 GyroRange = 0x03
+
+	mpu6050GyroConfig  = 0x1b
+	mpu6050AccelConfig = 0x1c
+	mpu6050Data        = 0x3b
+	mpu6050PwrMgmt1    = 0x6b
+	mpu6050WhoAmI      = 0x75
+
+	// mpu6050DataSize is the size of the accelerometer, temperature and
+	// gyroscope data registers.
+	mpu6050DataSize = 14
+)
+
+// Mpu6050Reading is a reading from a Mpu6050.
+type Mpu6050Reading struct {
+	// Time is when the reading was received.
+	Time time.Time
+	// Accel is the X, Y and Z acceleration in g.
+	Accel [3]float64
+	// Gyro is the X, Y and Z rotation rate in degrees per second.
+	Gyro [3]float64
+	// Temperature is the die temperature.
+	Temperature Temperature
+}
+
+// Mpu6050 is an InvenSense MPU6050 accelerometer and gyroscope.
+type Mpu6050 struct {
+	// The client.
+	Client *FirmataClient
+	// The I2C address of the device.
+	Address byte
+	// Unit is the unit of readings, or UnitDefault for the client's.
+	Unit TemperatureUnit
+
+	mu         sync.Mutex
+	stream     *poller
+	accelRange AccelRange
+	gyroRange  GyroRange
+}
+
+// Init checks the device identity and wakes it from sleep, using the X
+// gyro as the clock source, with the given ranges.
+func (d *Mpu6050) Init(accel AccelRange, gyro GyroRange) error {
+	id, err := d.Client.I2CRead(d.Address, mpu6050WhoAmI, 1)
+	if err != nil {
+		return err
+	}
+	if len(id) < 1 || id[0]&0x7e != 0x68 {
+		return fmt.Errorf("unexpected WHO_AM_I response 0x%x", id)
+	}
+	if err := d.Client.I2CWrite(d.Address, mpu6050PwrMgmt1, 0x01); err != nil {
+		return err
+	}
+	if err := d.SetAccelRange(accel); err != nil {
+		return err
+	}
+	return d.SetGyroRange(gyro)
+}
+
+// SetAccelRange sets the accelerometer full scale range.
+func (d *Mpu6050) SetAccelRange(r AccelRange) error {
+	if r > Accel16G {
+		return fmt.Errorf("invalid accelerometer range 0x%x", byte(r))
+	}
+	if err := d.Client.I2CWrite(d.Address, mpu6050AccelConfig, byte(r)<<3); err != nil {
+		return err
+	}
+	d.accelRange = r
+	return nil
+}
+
+// SetGyroRange sets the gyroscope full scale range.
+func (d *Mpu6050) SetGyroRange(r GyroRange) error {
+	if r > Gyro2000 {
+		return fmt.Errorf("invalid gyroscope range 0x%x", byte(r))
+	}
+	if err := d.Client.I2CWrite(d.Address, mpu6050GyroConfig, byte(r)<<3); err != nil {
+		return err
+	}
+	d.gyroRange = r
+	return nil
+}
+
+// Read returns the latest reading.
+func (d *Mpu6050) Read() (Mpu6050Reading, error) {
+	data, err := d.Client.I2CRead(d.Address, mpu6050Data, mpu6050DataSize)
+	if err != nil {
+		return Mpu6050Reading{}, err
+	}
+	if len(data) < mpu6050DataSize {
+		return Mpu6050Reading{}, fmt.Errorf("short read: got %d bytes, want %d", len(data), mpu6050DataSize)
+	}
+	return d.parse(data, d.Client.now()), nil
+}
+
+// Stream delivers a reading every sampling interval, set with
+// SetAnalogSamplingInterval, until StopStream is called.
+func (d *Mpu6050) Stream() (<-chan Mpu6050Reading, error) {
+	replies, err := d.Client.I2CReadContinuous(d.Address, mpu6050Data, mpu6050DataSize)
+	if err != nil {
+		return nil, err
+	}
+	readings := make(chan Mpu6050Reading, 10)
+	p := newPoller()
+	d.mu.Lock()
+	d.stream.Stop()
+	d.stream = p
+	d.mu.Unlock()
+	go func() {
+		defer close(readings)
+		for {
+			select {
+			case r, ok := <-replies:
+				if !ok {
+					return
+				}
+				if len(r.Data) < mpu6050DataSize {
+					continue
+				}
+				if !pollSend(p, readings, d.parse(r.Data, r.Time)) {
+					return
+				}
+			case <-p.stopped():
+				return
+			}
+		}
+	}()
+	return readings, nil
+}
+
+// StopStream stops the readings started by Stream, closing the channel.
+func (d *Mpu6050) StopStream() error {
+	d.mu.Lock()
+	d.stream.Stop()
+	d.mu.Unlock()
+	return d.Client.I2CStopReading(d.Address)
+}
+
+// parse scales the raw big endian register data, read at t.
+func (d *Mpu6050) parse(data []byte, t time.Time) Mpu6050Reading {
+	s16 := func(i int) float64 { return float64(int16(uint16(data[i])<<8 | uint16(data[i+1]))) }
+	accelScale := 16384 / float64(int(1)<<d.accelRange)
+	gyroScale := 131 / float64(int(1)<<d.gyroRange)
+
+	r := Mpu6050Reading{Time: t}
+	for i := 0; i < 3; i++ {
+		r.Accel[i] = s16(2*i) / accelScale
+		r.Gyro[i] = s16(8+2*i) / gyroScale
+	}
+	r.Temperature = NewTemperature(float32(s16(6)/340+36.53), temperatureUnit(d.Client, d.Unit))
+	return r
+}
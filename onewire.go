@@ -1,7 +1,10 @@
 package firmata
 
 import (
+	"context"
 	"fmt"
+	"sync"
+	"time"
 )
 
 // OneWireSubCommand is the command to send to the device.
@@ -10,6 +13,12 @@ type OneWireSubCommand byte
 // OneWireAddress is a ROM address.
 type OneWireAddress []byte
 
+// Valid reports whether the address is 8 bytes long and its final byte
+// matches the CRC of the family code and serial number.
+func (a OneWireAddress) Valid() bool {
+	return len(a) == 8 && OneWireCrc8(a[:7]) == a[7]
+}
+
 // A OneWireRequest is a Firmata OneWire request.
 type OneWireRequest struct {
 	// Command is the command to send to the Firmata firmware.
@@ -67,21 +76,87 @@ func OneWireCrc8(data []byte) byte {
 	return crc
 }
 
+// OneWireCrc16 calculates the 16 bit CRC of the data. Devices send the
+// inverse of this value, so invert it before comparing.
+func OneWireCrc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 8; i > 0; i-- {
+			if crc&0x1 > 0 {
+				crc = crc>>1 ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
+
 // OneWireConfig configures a pin as a OneWire interface.
 func (c *FirmataClient) OneWireConfig(csPin byte, owPowerMode byte) (err error) {
 	csPinBytes := to7Bit(csPin)
 	powerModeBytes := to7Bit(owPowerMode)
-	c.owChan = make(chan []byte)
+	c.busMu.Lock()
+	if c.owPins == nil {
+		c.owPins = make(map[byte]*oneWirePin)
+	}
+	if c.owPins[csPin] == nil {
+		c.owPins[csPin] = &oneWirePin{replies: make(chan []byte, 1)}
+	}
+	c.busMu.Unlock()
 
 	err = c.sendSysEx(SysExOneWire, byte(OneWireConfig),
 		csPinBytes[0], powerModeBytes[0])
+	if err == nil {
+		c.recordConfig(func(cfg *boardConfig) { cfg.oneWire[csPin] = owPowerMode })
+	}
 	return
 }
 
+// OneWireRelease returns a OneWire pin to digital input mode, so it can be
+// reused without reconnecting.
+func (c *FirmataClient) OneWireRelease(csPin byte) error {
+	c.busMu.Lock()
+	if c.owPins[csPin] == nil {
+		c.busMu.Unlock()
+		return fmt.Errorf("%w: pin %v is not configured for OneWire", ErrFeatureMissing, csPin)
+	}
+	delete(c.owPins, csPin)
+	c.busMu.Unlock()
+	c.recordConfig(func(cfg *boardConfig) { delete(cfg.oneWire, csPin) })
+	return c.sendCommand([]byte{byte(SetPinMode), csPin & 0x7F, byte(Input)})
+}
+
 // OneWireSearch initiates a search on the OneWire bus.
 func (c *FirmataClient) OneWireSearch(csPin byte, owSearchMode OneWireSubCommand) (addresses []OneWireAddress, err error) {
-	err = c.sendSysEx(SysExOneWire, byte(owSearchMode), csPin)
-	dataOut := <-c.owChan
+	return c.OneWireSearchContext(context.Background(), csPin, owSearchMode)
+}
+
+// OneWireSearchContext is OneWireSearch, giving up if ctx is done or the
+// request times out before the search completes.
+func (c *FirmataClient) OneWireSearchContext(ctx context.Context, csPin byte, owSearchMode OneWireSubCommand) (addresses []OneWireAddress, err error) {
+	ctx, done := c.startRequest(ctx, RequestInfo{Op: "OneWire search", Command: SysExOneWire, Pin: int(csPin)})
+	defer func() { done(len(addresses), err) }()
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+	bus, err := c.owBus(csPin)
+	if err != nil {
+		return nil, err
+	}
+	ch := bus.lock()
+	defer bus.req.Unlock()
+	if err = c.sendRequest(SysExOneWire, byte(owSearchMode), csPin); err != nil {
+		return nil, err
+	}
+	var dataOut []byte
+	select {
+	case dataOut = <-ch:
+	case <-ctx.Done():
+		return nil, requestError(ctx, "OneWire search")
+	case <-c.done:
+		return nil, closedError("OneWire search")
+	}
 	t := make(OneWireAddress, 0)
 	for i, d := range dataOut {
 		t = append(t, d)
@@ -93,27 +168,161 @@ func (c *FirmataClient) OneWireSearch(csPin byte, owSearchMode OneWireSubCommand
 	return
 }
 
+// oneWireDevices searches the bus on pin, returning the addresses with
+// good CRCs, keyed by address, for tracking with a presence.
+func (c *FirmataClient) oneWireDevices(pin byte) (map[string]OneWireAddress, error) {
+	addresses, err := c.OneWireSearch(pin, OneWireSearch)
+	if err != nil {
+		return nil, err
+	}
+	devices := make(map[string]OneWireAddress)
+	for _, a := range addresses {
+		if !a.Valid() {
+			c.Log.Debug("Discarding address with bad crc 0x%x on pin %v", []byte(a), pin)
+			continue
+		}
+		devices[string(a)] = a
+	}
+	return devices, nil
+}
+
+// defaultMisses is the number of searches in a row a device must be
+// missing from to be taken as removed, as a search can miss a device
+// which is still there, such as an iButton with a poor contact.
+const defaultMisses = 3
+
+// presence tracks the devices on a bus through repeated searches.
+type presence struct {
+	devices map[string]OneWireAddress
+	misses  map[string]int
+}
+
+func newPresence() *presence {
+	return &presence{
+		devices: make(map[string]OneWireAddress),
+		misses:  make(map[string]int),
+	}
+}
+
+// update records the devices found by a search, returning those which are
+// new, and those which have now been missing from limit searches in a row,
+// or defaultMisses if limit is not positive.
+func (p *presence) update(seen map[string]OneWireAddress, limit int) (added, removed []OneWireAddress) {
+	if limit <= 0 {
+		limit = defaultMisses
+	}
+	for k, a := range seen {
+		delete(p.misses, k)
+		if _, ok := p.devices[k]; !ok {
+			p.devices[k] = a
+			added = append(added, a)
+		}
+	}
+	for k, a := range p.devices {
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		if p.misses[k]++; p.misses[k] >= limit {
+			delete(p.devices, k)
+			delete(p.misses, k)
+			removed = append(removed, a)
+		}
+	}
+	return added, removed
+}
+
 // OneWireCommand initiates a command on the OneWire bus.
 func (c *FirmataClient) OneWireCommand(csPin byte, request OneWireRequest) ([]byte, error) {
-	var dataOut []byte
+	return c.OneWireCommandContext(context.Background(), csPin, request)
+}
+
+// OneWireCommandContext is OneWireCommand, giving up if ctx is done or the
+// request times out before the reply to a read arrives.
+func (c *FirmataClient) OneWireCommandContext(ctx context.Context, csPin byte, request OneWireRequest) (dataOut []byte, err error) {
+	ctx, done := c.startRequest(ctx, RequestInfo{Op: "OneWire command", Command: SysExOneWire, Pin: int(csPin), Size: len(request.Data)})
+	defer func() { done(len(dataOut), err) }()
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+	bus, err := c.owBus(csPin)
+	if err != nil {
+		return nil, err
+	}
+	ch := bus.lock()
+	defer bus.req.Unlock()
 	var d []byte
 	d = append(d, byte(request.Command))
 	d = append(d, csPin)
 	d = append(d, request.Encode()...)
-	err := c.sendSysEx(SysExOneWire, d...)
-	if err != nil {
+	send := c.sendSysEx
+	if request.Command&OW_READ != 0 {
+		send = c.sendRequest
+	}
+	if err := send(SysExOneWire, d...); err != nil {
 		return nil, err
 	}
-	if request.Command&0x8 > 0 {
-		dataOut = <-c.owChan
+	if request.Command&OW_READ == 0 {
+		return nil, nil
+	}
+	select {
+	case dataOut := <-ch:
+		return dataOut, nil
+	case <-ctx.Done():
+		return nil, requestError(ctx, "OneWire command")
+	case <-c.done:
+		return nil, closedError("OneWire command")
+	}
+}
+
+// oneWirePin is a pin configured as a OneWire bus. Each bus has its own
+// replies, so requests to different buses can run concurrently.
+type oneWirePin struct {
+	replies chan []byte
+	// req allows one request awaiting a reply on the bus.
+	req sync.Mutex
+}
+
+// lock takes the bus for a request and returns its reply channel, after
+// dropping any reply which arrived after an earlier request gave up.
+func (b *oneWirePin) lock() chan []byte {
+	b.req.Lock()
+	select {
+	case <-b.replies:
+	default:
 	}
-	return dataOut, nil
+	return b.replies
+}
+
+// owBus returns the OneWire bus on csPin.
+func (c *FirmataClient) owBus(csPin byte) (*oneWirePin, error) {
+	c.busMu.Lock()
+	defer c.busMu.Unlock()
+	if b := c.owPins[csPin]; b != nil {
+		return b, nil
+	}
+	return nil, fmt.Errorf("%w: pin %v is not configured for OneWire", ErrFeatureMissing, csPin)
 }
 
 // parseOWResponse handles a OneWire SysEx response packet.
 func (c *FirmataClient) parseOWResponse(data7bit []byte) {
-	data := From7BitMulti(data7bit)
-	c.owChan <- data
+	if len(data7bit) < 2 {
+		c.protocolError(MalformedMessage, fmt.Errorf("short OneWire reply"), data7bit, c.received)
+		return
+	}
+	// The reply starts with the subcommand and the pin of the bus.
+	pin := data7bit[1]
+	c.busMu.Lock()
+	bus := c.owPins[pin]
+	c.busMu.Unlock()
+	if bus == nil {
+		c.Log.Debug("Discarding OneWire response, pin %v not configured for OneWire", pin)
+		return
+	}
+	data := Decode7BitMulti(data7bit[2:])
+	select {
+	case bus.replies <- data:
+	default:
+		c.Log.Warn("Discarding OneWire response on pin %v, no pending request", pin)
+	}
 }
 
 // Ds18x20 is a Maxim DS1820 or DS18B20 device.
@@ -127,16 +336,24 @@ type Ds18x20 struct {
 	// scratch is the raw register data.
 	scratch []byte
 	// Latest temperature reading.
-	Temperature float32
+	Temperature Temperature
+	// Unit is the unit of Temperature, or UnitDefault for the client's.
+	Unit TemperatureUnit
 	// The TH register.
 	RegisterTh byte
 	// The TL register.
 	RegisterTl byte
 	// The config register.
 	ConfigRegister byte
+	// Parasite is set if ReadPowerSupply found the device parasite powered.
+	Parasite bool
 }
 
-// ConvertT initiates a temperature conversion.
+// ConvertT initiates a temperature conversion. Despite its name, all
+// addresses the conversion to this device, and without it the conversion
+// is sent with SKIP ROM to every device on the bus. Parasite powered
+// devices hold the bus for the full conversion time, so nothing else is
+// sent until it completes.
 func (d *Ds18x20) ConvertT(all bool) error {
 	var req OneWireRequest
 	req.Data = []byte{0x44}
@@ -146,6 +363,10 @@ func (d *Ds18x20) ConvertT(all bool) error {
 	} else {
 		req.Command = OW_RESET | OW_SKIP | OW_WRITE
 	}
+	if d.Parasite {
+		req.Command |= OW_DELAY
+		req.DelayMs = int32(d.ConversionTime() / time.Millisecond)
+	}
 
 	_, err := d.Client.OneWireCommand(d.Pin, req)
 	return err
@@ -153,6 +374,10 @@ func (d *Ds18x20) ConvertT(all bool) error {
 
 // ReadScratchPad reads the device scratchpad.
 func (d *Ds18x20) ReadScratchPad() error {
+	return d.readScratchPad(context.Background())
+}
+
+func (d *Ds18x20) readScratchPad(ctx context.Context) error {
 	req := OneWireRequest{
 		Command:       OW_RESET | OW_SELECT | OW_WRITE | OW_READ,
 		Address:       d.Address,
@@ -160,7 +385,7 @@ func (d *Ds18x20) ReadScratchPad() error {
 		CorrelationId: 0x1234,
 		Data:          []byte{0xbe},
 	}
-	scratch, err := d.Client.OneWireCommand(d.Pin, req)
+	scratch, err := d.Client.OneWireCommandContext(ctx, d.Pin, req)
 	if err != nil {
 		return err
 	}
@@ -168,7 +393,7 @@ func (d *Ds18x20) ReadScratchPad() error {
 	crc := d.scratch[len(d.scratch)-1]
 	c := OneWireCrc8(d.scratch[:len(d.scratch)-1])
 	if c != crc {
-		return fmt.Errorf("crc mismatch! Received 0x%x, calculated 0x%x! [0x%x]", crc, c, d.scratch)
+		return d.Client.crcError(uint16(crc), uint16(c), d.scratch[:len(d.scratch)-1])
 	}
 	d.parseTemperature()
 	d.ConfigRegister = d.scratch[4]
@@ -177,29 +402,182 @@ func (d *Ds18x20) ReadScratchPad() error {
 	return nil
 }
 
-// Resolution sets the thermometer resolution, in bits.
+// ReadTemperature starts a conversion, waits for it to complete and then
+// reads the scratchpad, returning the temperature.
+func (d *Ds18x20) ReadTemperature(ctx context.Context) (Temperature, error) {
+	if err := d.ConvertT(true); err != nil {
+		return Temperature{}, err
+	}
+	// A parasite powered device has the board hold the bus for the
+	// conversion, so the read waits behind it on the board.
+	if !d.Parasite {
+		select {
+		case <-d.Client.after(d.ConversionTime()):
+		case <-ctx.Done():
+			return Temperature{}, requestError(ctx, "temperature conversion")
+		}
+	}
+	if err := d.readScratchPad(ctx); err != nil {
+		return Temperature{}, err
+	}
+	return d.Temperature, nil
+}
+
+// ReadPowerSupply checks whether the device is parasite powered. If it is,
+// the bus is reconfigured to drive the strong pullup after writes, which
+// the device needs to complete conversions and EEPROM copies.
+func (d *Ds18x20) ReadPowerSupply() error {
+	req := OneWireRequest{
+		Command:       OW_RESET | OW_SELECT | OW_WRITE | OW_READ,
+		Address:       d.Address,
+		ReadCount:     1,
+		CorrelationId: 0x1234,
+		Data:          []byte{0xb4},
+	}
+	resp, err := d.Client.OneWireCommand(d.Pin, req)
+	if err != nil {
+		return err
+	}
+	if len(resp) < 3 {
+		return fmt.Errorf("short power supply read")
+	}
+	// Parasite powered devices pull the first read slot low.
+	d.Parasite = resp[2]&0x1 == 0
+	if d.Parasite {
+		return d.Client.OneWireConfig(d.Pin, OneWirePowerParasitic)
+	}
+	return nil
+}
+
+// ConversionTime returns the maximum temperature conversion time at the
+// current resolution, or at 12 bits if the scratchpad has not been read.
+func (d *Ds18x20) ConversionTime() time.Duration {
+	if d.Address[0] == 0x10 || d.scratch == nil {
+		return 750 * time.Millisecond
+	}
+	return 750 * time.Millisecond >> (12 - d.GetResolution())
+}
+
+// Resolution sets the thermometer resolution, in bits. The alarm
+// thresholds are rewritten with their current values.
 func (d *Ds18x20) Resolution(r byte) error {
 	if r < 9 || r > 12 {
 		return fmt.Errorf("resolution must be between 9 and 12!")
 	}
+	return d.writeScratchPad(d.RegisterTh, d.RegisterTl, (r-9)<<5|0x1f)
+}
+
+// SetAlarms sets the low and high alarm thresholds, in whole degrees C.
+// The config register is rewritten with its current value, so the
+// scratchpad is read first if it has not been already.
+func (d *Ds18x20) SetAlarms(low, high int8) error {
+	if low > high {
+		return fmt.Errorf("low alarm %v is above high alarm %v!", low, high)
+	}
+	if d.scratch == nil {
+		if err := d.ReadScratchPad(); err != nil {
+			return err
+		}
+	}
+	return d.writeScratchPad(byte(high), byte(low), d.ConfigRegister)
+}
+
+// writeScratchPad writes the TH, TL and config registers. The DS18S20 has
+// no config register, so only the alarm thresholds are sent to it.
+func (d *Ds18x20) writeScratchPad(th, tl, config byte) error {
 	req := OneWireRequest{
 		Command:       OW_RESET | OW_SELECT | OW_WRITE,
 		Address:       d.Address,
 		CorrelationId: 0x1234,
-		Data:          []byte{0x4e, 0x0, 0x0},
+		Data:          []byte{0x4e, th, tl},
+	}
+	if d.Address[0] != 0x10 {
+		req.Data = append(req.Data, config)
+	}
+	if _, err := d.Client.OneWireCommand(d.Pin, req); err != nil {
+		return err
+	}
+	d.RegisterTh = th
+	d.RegisterTl = tl
+	d.ConfigRegister = config
+	return nil
+}
+
+// CopyScratchpad saves the TH, TL and config registers to the device
+// EEPROM, so they survive a power cycle.
+func (d *Ds18x20) CopyScratchpad() error {
+	req := OneWireRequest{
+		Command: OW_RESET | OW_SELECT | OW_WRITE | OW_DELAY,
+		Address: d.Address,
+		DelayMs: 10,
+		Data:    []byte{0x48},
 	}
-	r = (r - 9) << 6
-	req.Data = append(req.Data, r)
 	_, err := d.Client.OneWireCommand(d.Pin, req)
 	return err
 }
 
+// RecallEEPROM reloads the TH, TL and config registers from the device
+// EEPROM, then reads the scratchpad to refresh them.
+func (d *Ds18x20) RecallEEPROM() error {
+	req := OneWireRequest{
+		Command:       OW_RESET | OW_SELECT | OW_WRITE | OW_READ,
+		Address:       d.Address,
+		ReadCount:     1,
+		CorrelationId: 0x1234,
+		Data:          []byte{0xb8},
+	}
+	resp, err := d.Client.OneWireCommand(d.Pin, req)
+	if err != nil {
+		return err
+	}
+	// The device holds read slots low until the recall is complete.
+	if len(resp) < 3 || resp[2]&0x80 == 0 {
+		return fmt.Errorf("EEPROM recall did not complete")
+	}
+	return d.ReadScratchPad()
+}
+
+// Ds18x20AlarmSearch starts a conversion on every Ds18x20 on the bus and,
+// once it completes, searches for devices whose reading is outside the
+// thresholds set with SetAlarms. The conversion is waited for as long as
+// the slowest of devices needs at its resolution, with the board holding
+// the bus if any is parasite powered. Without devices, the wait is the
+// 12 bit conversion time, held by the board.
+func Ds18x20AlarmSearch(client *FirmataClient, pin byte, devices ...*Ds18x20) ([]OneWireAddress, error) {
+	wait, parasite := 750*time.Millisecond, len(devices) == 0
+	if len(devices) > 0 {
+		wait = 0
+	}
+	for _, d := range devices {
+		if t := d.ConversionTime(); t > wait {
+			wait = t
+		}
+		parasite = parasite || d.Parasite
+	}
+	req := OneWireRequest{
+		Command: OW_RESET | OW_SKIP | OW_WRITE,
+		Data:    []byte{0x44},
+	}
+	if parasite {
+		req.Command |= OW_DELAY
+		req.DelayMs = int32(wait / time.Millisecond)
+	}
+	if _, err := client.OneWireCommand(pin, req); err != nil {
+		return nil, err
+	}
+	if !parasite {
+		client.sleep(wait)
+	}
+	return client.OneWireSearch(pin, OneWireSearchAlarms)
+}
+
 // parseTemperature parses the raw temperature data.
 func (d *Ds18x20) parseTemperature() {
+	var t float32
 	raw := uint16(d.scratch[0]) | uint16(d.scratch[1])<<8
 	if d.Address[0] == 0x10 {
 		raw = raw << 3
-		d.Temperature = float32(raw&0xFFF0 + 12 - uint16(d.scratch[6]))
+		t = float32(int16(raw&0xFFF0 + 12 - uint16(d.scratch[6])))
 	} else {
 		// Zero out bits that are undefined at lower resolutions.
 		switch d.GetResolution() {
@@ -210,9 +588,9 @@ func (d *Ds18x20) parseTemperature() {
 		case 11:
 			raw &= 0xfffe
 		}
-		d.Temperature = float32(raw)
+		t = float32(int16(raw))
 	}
-	d.Temperature /= 16
+	d.Temperature = NewTemperature(t/16, temperatureUnit(d.Client, d.Unit))
 }
 
 // GetResolution gets the device temperature resolution in bits.
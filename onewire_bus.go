@@ -0,0 +1,149 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BusEventType is the kind of change seen on a OneWire bus.
+type BusEventType byte
+
+const (
+	DeviceAdded BusEventType = iota
+	DeviceRemoved
+)
+
+func (t BusEventType) String() string {
+	switch t {
+	case DeviceAdded:
+		return "DeviceAdded"
+	case DeviceRemoved:
+		return "DeviceRemoved"
+	}
+	return fmt.Sprintf("Unknown bus event (0x%x)", byte(t))
+}
+
+// BusEvent reports a device appearing on or disappearing from a bus.
+type BusEvent struct {
+	// Type is the kind of change.
+	Type BusEventType
+	// Pin is the pin of the bus the device is on.
+	Pin byte
+	// Address is the ROM address of the device.
+	Address OneWireAddress
+}
+
+// BusManager periodically searches one or more OneWire buses and reports
+// devices being plugged and unplugged.
+type BusManager struct {
+	// The client.
+	Client *FirmataClient
+	// Pins are the pins of the buses to search.
+	Pins []byte
+	// Interval is the time between bus searches.
+	Interval time.Duration
+	// Misses is the number of searches in a row a device must be missing
+	// from before it is reported removed, 3 if zero.
+	Misses int
+
+	events  chan BusEvent
+	poller  *poller
+	mu      sync.Mutex
+	devices map[byte]*presence
+}
+
+// NewBusManager creates a manager which searches each of pins every
+// interval. The pins must already be configured with OneWireConfig.
+func NewBusManager(client *FirmataClient, interval time.Duration, pins ...byte) *BusManager {
+	return &BusManager{
+		Client:   client,
+		Pins:     pins,
+		Interval: interval,
+		events:   make(chan BusEvent, 10),
+		poller:   newPoller(),
+		devices:  make(map[byte]*presence),
+	}
+}
+
+// Start begins searching the buses and returns the channel of bus events.
+// Devices present at the first search are reported as added.
+func (m *BusManager) Start() <-chan BusEvent {
+	go m.poll()
+	return m.events
+}
+
+// Stop stops searching and closes the event channel. It does not block,
+// so may be called from the loop reading the events.
+func (m *BusManager) Stop() {
+	m.poller.Stop()
+}
+
+// Devices returns the addresses of the devices on pin: those reported
+// added and not yet removed.
+func (m *BusManager) Devices(pin byte) []OneWireAddress {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var addresses []OneWireAddress
+	p := m.devices[pin]
+	if p == nil {
+		return nil
+	}
+	for _, a := range p.devices {
+		addresses = append(addresses, a)
+	}
+	return addresses
+}
+
+func (m *BusManager) poll() {
+	defer close(m.events)
+	m.poller.every(m.Client.Clock(), m.Interval, func() {
+		for _, pin := range m.Pins {
+			m.scan(pin)
+		}
+	})
+}
+
+// scan searches the bus on pin and emits events for devices which have
+// appeared, or have been missing for Misses searches.
+func (m *BusManager) scan(pin byte) {
+	seen, err := m.Client.oneWireDevices(pin)
+	if err != nil {
+		m.Client.Log.Warn("OneWire search on pin %v: %s", pin, err.Error())
+		return
+	}
+
+	m.mu.Lock()
+	p := m.devices[pin]
+	if p == nil {
+		p = newPresence()
+		m.devices[pin] = p
+	}
+	added, removed := p.update(seen, m.Misses)
+	m.mu.Unlock()
+
+	for _, a := range added {
+		if !pollSend(m.poller, m.events, BusEvent{Type: DeviceAdded, Pin: pin, Address: a}) {
+			return
+		}
+	}
+	for _, a := range removed {
+		if !pollSend(m.poller, m.events, BusEvent{Type: DeviceRemoved, Pin: pin, Address: a}) {
+			return
+		}
+	}
+}
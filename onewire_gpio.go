@@ -0,0 +1,163 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+)
+
+const (
+	// Family codes of the supported switches.
+	Ds2413Family = 0x3a
+	Ds2408Family = 0x29
+
+	owGpioAccessRead   = 0xf5
+	owGpioAccessWrite  = 0x5a
+	owGpioReadRegister = 0xf0
+	owGpioConfirm      = 0xaa
+
+	// ds2408PioLogicState is the address of the DS2408 pin state register,
+	// which is followed by the output latch register.
+	ds2408PioLogicState = 0x88
+)
+
+// OneWireGpio is a Maxim DS2413 (2 channel) or DS2408 (8 channel)
+// addressable switch.
+type OneWireGpio struct {
+	// The client.
+	Client *FirmataClient
+	// The pin the bus is on.
+	Pin byte
+	// The address of the device on the bus.
+	Address OneWireAddress
+}
+
+// OneWireSwitch is a single PIO channel of a OneWireGpio.
+type OneWireSwitch struct {
+	gpio    *OneWireGpio
+	channel uint
+}
+
+// Channels returns the number of PIO channels on the device.
+func (g *OneWireGpio) Channels() int {
+	if g.Address[0] == Ds2408Family {
+		return 8
+	}
+	return 2
+}
+
+// Switch returns the PIO channel numbered n, counting from 0.
+func (g *OneWireGpio) Switch(n int) (*OneWireSwitch, error) {
+	if n < 0 || n >= g.Channels() {
+		return nil, fmt.Errorf("invalid channel %v, device has %v channels", n, g.Channels())
+	}
+	return &OneWireSwitch{gpio: g, channel: uint(n)}, nil
+}
+
+// ReadPins returns the sensed logic level of each channel as a bitmask.
+func (g *OneWireGpio) ReadPins() (byte, error) {
+	pins, _, err := g.readState()
+	return pins, err
+}
+
+// ReadLatches returns the output latch state of each channel as a bitmask.
+func (g *OneWireGpio) ReadLatches() (byte, error) {
+	_, latches, err := g.readState()
+	return latches, err
+}
+
+// WriteLatches sets the output latch of each channel from a bitmask. A set
+// bit turns the output transistor off, letting the pin float high.
+func (g *OneWireGpio) WriteLatches(latches byte) error {
+	v := latches
+	if g.Address[0] != Ds2408Family {
+		v = 0xfc | latches&0x3
+	}
+	req := OneWireRequest{
+		Command:       OW_RESET | OW_SELECT | OW_WRITE | OW_READ,
+		Address:       g.Address,
+		ReadCount:     2,
+		CorrelationId: 0x1234,
+		Data:          []byte{owGpioAccessWrite, v, ^v},
+	}
+	resp, err := g.Client.OneWireCommand(g.Pin, req)
+	if err != nil {
+		return err
+	}
+	if len(resp) < 4 || resp[2] != owGpioConfirm {
+		return fmt.Errorf("PIO write of 0x%x was not confirmed", latches)
+	}
+	return nil
+}
+
+// readState reads both the pin levels and output latches.
+func (g *OneWireGpio) readState() (pins byte, latches byte, err error) {
+	req := OneWireRequest{
+		Command:       OW_RESET | OW_SELECT | OW_WRITE | OW_READ,
+		Address:       g.Address,
+		CorrelationId: 0x1234,
+	}
+	if g.Address[0] == Ds2408Family {
+		req.ReadCount = 2
+		req.Data = []byte{owGpioReadRegister, ds2408PioLogicState, 0x00}
+	} else {
+		req.ReadCount = 1
+		req.Data = []byte{owGpioAccessRead}
+	}
+	resp, err := g.Client.OneWireCommand(g.Pin, req)
+	if err != nil {
+		return
+	}
+	if len(resp) < int(req.ReadCount)+2 {
+		err = fmt.Errorf("short PIO read response")
+		return
+	}
+	if g.Address[0] == Ds2408Family {
+		pins, latches = resp[2], resp[3]
+		return
+	}
+	s := resp[2]
+	if s>>4 != ^s&0xf {
+		err = fmt.Errorf("PIO status 0x%x failed its complement check", s)
+		return
+	}
+	pins = s&0x1 | (s>>1)&0x2
+	latches = (s>>1)&0x1 | (s>>2)&0x2
+	return
+}
+
+// Get returns the sensed logic level of the channel.
+func (s *OneWireSwitch) Get() (bool, error) {
+	pins, err := s.gpio.ReadPins()
+	if err != nil {
+		return false, err
+	}
+	return pins&(1<<s.channel) > 0, nil
+}
+
+// Set sets the output latch of the channel, leaving other channels as they
+// are. Setting it high turns the output transistor off.
+func (s *OneWireSwitch) Set(high bool) error {
+	latches, err := s.gpio.ReadLatches()
+	if err != nil {
+		return err
+	}
+	if high {
+		latches |= 1 << s.channel
+	} else {
+		latches &= ^(1 << s.channel)
+	}
+	return s.gpio.WriteLatches(latches)
+}
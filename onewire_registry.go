@@ -0,0 +1,92 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"sync"
+)
+
+// OneWireDriver constructs a typed driver for the device at address.
+type OneWireDriver func(client *FirmataClient, pin byte, address OneWireAddress) interface{}
+
+var (
+	oneWireDriversMu sync.RWMutex
+	oneWireDrivers   = map[byte]OneWireDriver{
+		0x10:           newDs18x20,
+		0x22:           newDs18x20,
+		0x28:           newDs18x20,
+		Ds2431Family:   newDs243x,
+		Ds2433Family:   newDs243x,
+		Ds2413Family:   newOneWireGpio,
+		Ds2408Family:   newOneWireGpio,
+		Max31850Family: newMax31850,
+	}
+)
+
+// RegisterOneWireDriver sets the driver used for devices with the given
+// family code, replacing any existing one.
+func RegisterOneWireDriver(family byte, driver OneWireDriver) {
+	oneWireDriversMu.Lock()
+	defer oneWireDriversMu.Unlock()
+	oneWireDrivers[family] = driver
+}
+
+// NewOneWireDevice returns the registered driver for the device at
+// address, or the address itself if its family has no driver.
+func NewOneWireDevice(client *FirmataClient, pin byte, address OneWireAddress) interface{} {
+	oneWireDriversMu.RLock()
+	driver := oneWireDrivers[address[0]]
+	oneWireDriversMu.RUnlock()
+	if driver == nil {
+		return address
+	}
+	return driver(client, pin, address)
+}
+
+// OneWireDevices searches the bus on pin and returns a driver for each
+// device found, such as *Ds18x20 or *Ds243x. Devices of unknown family
+// are returned as their OneWireAddress, and addresses with a bad crc are
+// skipped.
+func (c *FirmataClient) OneWireDevices(pin byte) ([]interface{}, error) {
+	addresses, err := c.OneWireSearch(pin, OneWireSearch)
+	if err != nil {
+		return nil, err
+	}
+	var devices []interface{}
+	for _, a := range addresses {
+		if !a.Valid() {
+			c.Log.Debug("Discarding address with bad crc 0x%x on pin %v", []byte(a), pin)
+			continue
+		}
+		devices = append(devices, NewOneWireDevice(c, pin, a))
+	}
+	return devices, nil
+}
+
+func newDs18x20(client *FirmataClient, pin byte, address OneWireAddress) interface{} {
+	return &Ds18x20{Client: client, Pin: pin, Address: address}
+}
+
+func newDs243x(client *FirmataClient, pin byte, address OneWireAddress) interface{} {
+	return &Ds243x{Client: client, Pin: pin, Address: address}
+}
+
+func newOneWireGpio(client *FirmataClient, pin byte, address OneWireAddress) interface{} {
+	return &OneWireGpio{Client: client, Pin: pin, Address: address}
+}
+
+func newMax31850(client *FirmataClient, pin byte, address OneWireAddress) interface{} {
+	return &Max31850{Client: client, Pin: pin, Address: address}
+}
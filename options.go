@@ -0,0 +1,133 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"io"
+	"time"
+
+	"code.google.com/p/log4go"
+)
+
+// Option configures a client created by NewClient.
+type Option func(*options)
+
+type options struct {
+	connectTimeout   time.Duration
+	resetAfter       time.Duration
+	timeout          time.Duration
+	logger           *log4go.Logger
+	board            *Board
+	values           chan FirmataValue
+	samplingInterval byte
+	dial             func() (io.ReadWriteCloser, error)
+	redialWait       time.Duration
+	metrics          MetricsSink
+	tracer           RequestTracer
+	trace            io.Writer
+	writeRate        int
+	writeBurst       int
+	writeBuffering   bool
+	queues           map[string]QueueConfig
+	sysexCopy        bool
+	clock            Clock
+	serialBackend    SerialBackend
+	serialReset      SerialReset
+	resetWait        time.Duration
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		connectTimeout: 30 * time.Second,
+		resetAfter:     15 * time.Second,
+		clock:          SystemClock,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithTimeout sets the default time to wait for the board to reply to a
+// request, as for SetTimeout.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// WithConnectTimeout sets how long NewClient waits for the board to
+// report its firmware and capabilities, 30 seconds by default. The board
+// is reset again every resetAfter, 15 seconds by default, while waiting.
+func WithConnectTimeout(d time.Duration, resetAfter time.Duration) Option {
+	return func(o *options) {
+		o.connectTimeout = d
+		if resetAfter > 0 {
+			o.resetAfter = resetAfter
+		}
+	}
+}
+
+// WithLogger sets the logger, rather than logging to stdout.
+func WithLogger(l *log4go.Logger) Option {
+	return func(o *options) { o.logger = l }
+}
+
+// WithBoardProfile sets the board profile, rather than detecting it from
+// the firmware.
+func WithBoardProfile(b *Board) Option {
+	return func(o *options) { o.board = b }
+}
+
+// WithValues sends every reported pin value on ch, to be read with
+// GetValues. The channel must be read, or the reader is held up, unless
+// QueueValues is set to drop values with WithQueue. The client closes it
+// when it is closed.
+func WithValues(ch chan FirmataValue) Option {
+	return func(o *options) { o.values = ch }
+}
+
+// WithSamplingInterval sets the analog sampling interval, in milliseconds,
+// once connected.
+func WithSamplingInterval(ms byte) Option {
+	return func(o *options) { o.samplingInterval = ms }
+}
+
+// WithReconnect reconnects using dial when the connection to the board is
+// lost, trying every wait until it succeeds or the client is closed.
+// Helpers with a safe state apply it when the connection is lost, and the
+// board is reset on reconnection, so pins must be set up again, for
+// example by Restore of a Snapshot taken once they were set up.
+func WithReconnect(dial func() (io.ReadWriteCloser, error), wait time.Duration) Option {
+	return func(o *options) {
+		o.dial = dial
+		o.redialWait = wait
+	}
+}
+
+// WithMetrics sends client measurements to sink, as for SetMetrics.
+func WithMetrics(sink MetricsSink) Option {
+	return func(o *options) { o.metrics = sink }
+}
+
+// WithRequestTracer traces requests with t, as for SetRequestTracer. The
+// connection handshake is traced as the request "connect".
+func WithRequestTracer(t RequestTracer) Option {
+	return func(o *options) { o.tracer = t }
+}
+
+// WithTrace writes every frame to w, as for SetTrace, from the start of
+// the connection.
+func WithTrace(w io.Writer) Option {
+	return func(o *options) { o.trace = w }
+}
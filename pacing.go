@@ -0,0 +1,96 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"time"
+)
+
+// DefaultWriteBurst is the burst used when SetWriteRate is given none: the
+// size of the serial receive buffer on 8 bit AVR boards.
+const DefaultWriteBurst = 64
+
+// SetWriteRate limits writes to the board to bytesPerSecond on average,
+// with up to burst bytes sent at once, so a board which handles commands
+// slower than they arrive does not overrun its serial buffer and drop
+// them. Larger frames, such as a Batch, are sent burst bytes at a time.
+// A rate of zero, the default, turns pacing off.
+func (c *FirmataClient) SetWriteRate(bytesPerSecond int, burst int) {
+	if burst <= 0 {
+		burst = DefaultWriteBurst
+	}
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	c.writeRate = bytesPerSecond
+	c.writeBurst = burst
+}
+
+// WithWriteRate limits writes to the board, as for SetWriteRate.
+func WithWriteRate(bytesPerSecond int, burst int) Option {
+	return func(o *options) {
+		o.writeRate = bytesPerSecond
+		o.writeBurst = burst
+	}
+}
+
+// pacer is a token bucket of bytes, used only by the writer.
+type pacer struct {
+	tokens float64
+	last   time.Time
+}
+
+// paceWrite writes data, waiting for the write rate to allow it. It returns
+// ErrNotConnected if the client is closed while waiting.
+func (c *FirmataClient) paceWrite(p *pacer, data []byte) error {
+	c.stateMu.RLock()
+	rate, burst := c.writeRate, c.writeBurst
+	c.stateMu.RUnlock()
+	if rate <= 0 {
+		_, err := c.transport().Write(data)
+		return err
+	}
+	for len(data) > 0 {
+		n := len(data)
+		if n > burst {
+			n = burst
+		}
+		now := c.now()
+		if p.last.IsZero() {
+			p.tokens = float64(burst)
+		} else {
+			p.tokens += now.Sub(p.last).Seconds() * float64(rate)
+			if p.tokens > float64(burst) {
+				p.tokens = float64(burst)
+			}
+		}
+		p.last = now
+		if short := float64(n) - p.tokens; short > 0 {
+			wait := time.Duration(short / float64(rate) * float64(time.Second))
+			select {
+			case <-c.after(wait):
+			case <-c.done:
+				return ErrNotConnected
+			}
+			p.tokens += wait.Seconds() * float64(rate)
+			p.last = c.now()
+		}
+		p.tokens -= float64(n)
+		if _, err := c.transport().Write(data[:n]); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Pattern is a repeating sequence of alternating on and off times,
+// starting with on.
+type Pattern []time.Duration
+
+const sosDot = 200 * time.Millisecond
+
+var (
+	// PatternHeartbeat is a double flash once a second.
+	PatternHeartbeat = Pattern{100 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond, 700 * time.Millisecond}
+	// PatternSOS is SOS in morse code.
+	PatternSOS = Pattern{
+		sosDot, sosDot, sosDot, sosDot, sosDot, 3 * sosDot,
+		3 * sosDot, sosDot, 3 * sosDot, sosDot, 3 * sosDot, 3 * sosDot,
+		sosDot, sosDot, sosDot, sosDot, sosDot, 7 * sosDot,
+	}
+)
+
+// PatternBlink returns a pattern which is on and off for period each.
+func PatternBlink(period time.Duration) Pattern {
+	return Pattern{period, period}
+}
+
+// patternState is a pattern playing on a pin.
+type patternState struct {
+	pattern Pattern
+	step    int
+	next    time.Time
+}
+
+// PatternPlayer plays patterns on digital outputs. All pins are driven
+// from a single ticker, so pattern times are rounded to its resolution.
+type PatternPlayer struct {
+	// The client.
+	Client *FirmataClient
+	// Resolution is the ticker interval.
+	Resolution time.Duration
+
+	mu      sync.Mutex
+	playing map[byte]*patternState
+	stop    chan bool
+}
+
+// NewPatternPlayer creates a player with a ticker every resolution. The
+// player is stopped when the client is closed.
+func NewPatternPlayer(client *FirmataClient, resolution time.Duration) *PatternPlayer {
+	p := &PatternPlayer{
+		Client:     client,
+		Resolution: resolution,
+		playing:    make(map[byte]*patternState),
+	}
+	client.onClose(p.Stop)
+	return p
+}
+
+// Play starts pattern on each of pins, replacing any pattern they were
+// playing. The pins are set to outputs.
+func (p *PatternPlayer) Play(pattern Pattern, pins ...byte) error {
+	if len(pattern) == 0 || len(pattern)%2 != 0 {
+		return fmt.Errorf("Pattern must have an even number of steps, has %v", len(pattern))
+	}
+	for _, pin := range pins {
+		if err := p.Client.Pin(pin).ensureMode(Output); err != nil {
+			return err
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.Client.now()
+	for _, pin := range pins {
+		// Start each pin one step before the pattern, so the next tick
+		// turns it on.
+		p.playing[pin] = &patternState{pattern: pattern, step: -1, next: now}
+	}
+	if p.stop == nil {
+		p.stop = make(chan bool)
+		go p.run(p.stop)
+	}
+	return nil
+}
+
+// StopPins stops the patterns on pins and turns them off.
+func (p *PatternPlayer) StopPins(pins ...byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, pin := range pins {
+		if _, ok := p.playing[pin]; ok {
+			delete(p.playing, pin)
+			p.write(pin, false)
+		}
+	}
+}
+
+// Stop stops every pattern, turning the pins off, and stops the ticker.
+func (p *PatternPlayer) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for pin := range p.playing {
+		p.write(pin, false)
+	}
+	p.playing = make(map[byte]*patternState)
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+}
+
+func (p *PatternPlayer) run(stop chan bool) {
+	t := p.Client.Clock().NewTicker(p.Resolution)
+	defer t.Stop()
+	for {
+		select {
+		case now := <-t.C():
+			p.tick(now)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// tick advances every pattern which is due.
+func (p *PatternPlayer) tick(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for pin, s := range p.playing {
+		if now.Before(s.next) {
+			continue
+		}
+		s.step = (s.step + 1) % len(s.pattern)
+		s.next = s.next.Add(s.pattern[s.step])
+		if s.next.Before(now) {
+			// Fell behind, such as after a stall. Restart timing from now.
+			s.next = now.Add(s.pattern[s.step])
+		}
+		p.write(pin, s.step%2 == 0)
+	}
+}
+
+func (p *PatternPlayer) write(pin byte, on bool) {
+	if err := p.Client.DigitalWrite(uint(pin), on); err != nil {
+		p.Client.Log.Warn("Pattern on pin %v: %s", p.Client.PinLabel(pin), err.Error())
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// Pca9685Address is the default I2C address of a PCA9685.
+	Pca9685Address = 0x40
+
+	pca9685Mode1    = 0x00
+	pca9685Mode2    = 0x01
+	pca9685Led0     = 0x06
+	pca9685PreScale = 0xfe
+
+	pca9685Restart = 0x80
+	pca9685AutoInc = 0x20
+	pca9685Sleep   = 0x10
+	pca9685OutDrv  = 0x04
+
+	pca9685Clock = 25000000
+)
+
+// Pca9685 is an NXP PCA9685 16 channel, 12 bit PWM controller.
+type Pca9685 struct {
+	// The client.
+	Client *FirmataClient
+	// The I2C address of the device.
+	Address byte
+	// MinPulse and MaxPulse are the servo pulse widths for 0 and 180
+	// degrees. They default to 1ms and 2ms.
+	MinPulse time.Duration
+	MaxPulse time.Duration
+	// frequency is the PWM frequency in Hz.
+	frequency float64
+}
+
+// Init wakes the device with totem pole outputs and register auto
+// increment enabled, then sets the PWM frequency in Hz.
+func (d *Pca9685) Init(frequency float64) error {
+	if err := d.Client.I2CWrite(d.Address, pca9685Mode2, pca9685OutDrv); err != nil {
+		return err
+	}
+	if err := d.Client.I2CWrite(d.Address, pca9685Mode1, pca9685AutoInc); err != nil {
+		return err
+	}
+	return d.SetFrequency(frequency)
+}
+
+// SetFrequency sets the PWM frequency of all channels, between 24 and
+// 1526Hz.
+func (d *Pca9685) SetFrequency(frequency float64) error {
+	prescale := math.Floor(pca9685Clock/(4096*frequency)+0.5) - 1
+	if prescale < 3 || prescale > 255 {
+		return fmt.Errorf("frequency %vHz is out of range", frequency)
+	}
+	mode1, err := d.Client.I2CRead(d.Address, pca9685Mode1, 1)
+	if err != nil {
+		return err
+	}
+	if len(mode1) < 1 {
+		return fmt.Errorf("short MODE1 read")
+	}
+	// The prescaler can only be set while the oscillator is off.
+	old := mode1[0] &^ pca9685Restart
+	if err := d.Client.I2CWrite(d.Address, pca9685Mode1, old|pca9685Sleep); err != nil {
+		return err
+	}
+	if err := d.Client.I2CWrite(d.Address, pca9685PreScale, byte(prescale)); err != nil {
+		return err
+	}
+	if err := d.Client.I2CWrite(d.Address, pca9685Mode1, old&^pca9685Sleep); err != nil {
+		return err
+	}
+	d.Client.sleep(500 * time.Microsecond)
+	if err := d.Client.I2CWrite(d.Address, pca9685Mode1, old&^pca9685Sleep|pca9685Restart); err != nil {
+		return err
+	}
+	d.frequency = pca9685Clock / (4096 * (prescale + 1))
+	return nil
+}
+
+// Frequency returns the actual PWM frequency set by SetFrequency.
+func (d *Pca9685) Frequency() float64 {
+	return d.frequency
+}
+
+// SetPwm sets the counts, from 0 to 4095, at which the channel output
+// turns on and off in each PWM cycle.
+func (d *Pca9685) SetPwm(channel int, on, off uint16) error {
+	if channel < 0 || channel > 15 {
+		return fmt.Errorf("invalid channel %v", channel)
+	}
+	reg := byte(pca9685Led0 + 4*channel)
+	return d.Client.I2CWrite(d.Address, reg,
+		byte(on), byte(on>>8)&0x1f, byte(off), byte(off>>8)&0x1f)
+}
+
+// SetDuty sets the channel duty cycle, from 0 to 1.
+func (d *Pca9685) SetDuty(channel int, duty float64) error {
+	switch {
+	case duty <= 0:
+		// Full off is bit 4 of the OFF high byte.
+		return d.SetPwm(channel, 0, 0x1000)
+	case duty >= 1:
+		return d.SetPwm(channel, 0x1000, 0)
+	}
+	return d.SetPwm(channel, 0, uint16(duty*4095+0.5))
+}
+
+// SetPulse sets the channel to output pulses of the given width.
+func (d *Pca9685) SetPulse(channel int, width time.Duration) error {
+	if d.frequency == 0 {
+		return fmt.Errorf("frequency not set, call Init first")
+	}
+	period := time.Duration(float64(time.Second) / d.frequency)
+	return d.SetDuty(channel, float64(width)/float64(period))
+}
+
+// SetServoAngle sets a servo on the channel to angle, from 0 to 180
+// degrees.
+func (d *Pca9685) SetServoAngle(channel int, angle float64) error {
+	if angle < 0 || angle > 180 {
+		return fmt.Errorf("servo angle %v is out of range", angle)
+	}
+	min, max := d.MinPulse, d.MaxPulse
+	if min == 0 {
+		min = time.Millisecond
+	}
+	if max == 0 {
+		max = 2 * time.Millisecond
+	}
+	width := min + time.Duration(float64(max-min)*angle/180)
+	return d.SetPulse(channel, width)
+}
@@ -0,0 +1,370 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"time"
+)
+
+// Pin is a single pin of the board. Each method sets the pin mode it
+// needs, if the pin is not already in that mode, so a pin can be used
+// without calling SetPinMode first.
+type Pin struct {
+	client *FirmataClient
+	number byte
+	watch  *poller
+}
+
+// Pin returns the pin numbered n.
+func (c *FirmataClient) Pin(n byte) *Pin {
+	return &Pin{client: c, number: n}
+}
+
+// Number returns the pin number.
+func (p *Pin) Number() byte {
+	return p.number
+}
+
+// Modes returns the modes supported by the pin.
+func (p *Pin) Modes() []PinMode {
+	var modes []PinMode
+	if caps := p.client.capabilities(); int(p.number) < len(caps) {
+		for m := range caps[p.number] {
+			modes = append(modes, m)
+		}
+	}
+	return modes
+}
+
+// Mode returns the mode the pin was last set to. The second result is
+// false if the mode has not been set since the client connected.
+func (p *Pin) Mode() (PinMode, bool) {
+	p.client.modeMu.Lock()
+	defer p.client.modeMu.Unlock()
+	m, ok := p.client.currentModes[p.number]
+	return m, ok
+}
+
+// SetMode sets the pin mode.
+func (p *Pin) SetMode(mode PinMode) error {
+	return p.client.SetPinMode(p.number, mode)
+}
+
+// High sets the pin to an output and drives it high.
+func (p *Pin) High() error {
+	return p.Write(true)
+}
+
+// Low sets the pin to an output and drives it low.
+func (p *Pin) Low() error {
+	return p.Write(false)
+}
+
+// Write sets the pin to an output and drives it to val.
+func (p *Pin) Write(val bool) error {
+	if err := p.ensureMode(Output); err != nil {
+		return err
+	}
+	return p.client.DigitalWrite(uint(p.number), val)
+}
+
+// Read returns the last reported value of the pin: the analog reading of
+// a pin in Analog mode, or 0 or 1 otherwise. A pin which has not been set
+// to an input mode is set to Input, and reporting is enabled.
+func (p *Pin) Read() (int, error) {
+	m, ok := p.Mode()
+	switch {
+	case ok && m == Analog:
+		return p.client.AnalogRead(uint(p.number))
+	case !ok || (m != Input && m != Pullup):
+		if err := p.SetMode(Input); err != nil {
+			return 0, err
+		}
+		if err := p.client.EnableDigitalInput(uint(p.number), true); err != nil {
+			return 0, err
+		}
+	}
+	high, err := p.client.DigitalRead(uint(p.number))
+	if high {
+		return 1, err
+	}
+	return 0, err
+}
+
+// Pwm sets the pin to PWM and writes duty, from 0 (off) to 255 (on).
+func (p *Pin) Pwm(duty byte) error {
+	if err := p.ensureMode(PWM); err != nil {
+		return err
+	}
+	return p.client.AnalogWrite(uint(p.number), duty)
+}
+
+// Servo sets the pin to Servo and moves the servo to angle degrees.
+func (p *Pin) Servo(angle byte) error {
+	if angle > 180 {
+		return fmt.Errorf("Servo angle %v out of range", angle)
+	}
+	if err := p.ensureMode(Servo); err != nil {
+		return err
+	}
+	return p.client.AnalogWrite(uint(p.number), angle)
+}
+
+// Watch enables reporting of the pin and sends each reported value on the
+// returned channel, until StopWatching is called or the pin is watched
+// again. Values are as for Read.
+// The pin is set to Input unless it is already an input or analog pin.
+func (p *Pin) Watch() (<-chan int, error) {
+	m, ok := p.Mode()
+	analog := ok && m == Analog
+	if !analog && (!ok || (m != Input && m != Pullup)) {
+		if err := p.SetMode(Input); err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	if analog {
+		err = p.client.EnableAnalogInput(uint(p.number), true)
+	} else {
+		err = p.client.EnableDigitalInput(uint(p.number), true)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := p.client.addValueListener()
+	out := make(chan int, 10)
+	p.watch.Stop()
+	p.watch = newPoller()
+	go func(w *poller) {
+		defer close(out)
+		defer p.client.removeValueListener(values)
+		last := -1
+		for {
+			select {
+			case v, ok := <-values:
+				if !ok {
+					return
+				}
+				val, ok := p.valueOf(v)
+				if !ok || val == last {
+					continue
+				}
+				last = val
+				if !pollSend(w, out, val) {
+					return
+				}
+			case <-w.stopped():
+				return
+			}
+		}
+	}(p.watch)
+	return out, nil
+}
+
+// StopWatching stops the values started by Watch, closing the channel. It
+// does not block, so may be called from the loop reading the values.
+func (p *Pin) StopWatching() {
+	p.watch.Stop()
+}
+
+// Label returns the label of the pin, or its number if it has none.
+func (p *Pin) Label() string {
+	return p.client.PinLabel(p.number)
+}
+
+func (p *Pin) String() string {
+	return fmt.Sprintf("Pin %v", p.Label())
+}
+
+// valueOf extracts the value of the pin from a report, if it has one.
+func (p *Pin) valueOf(v FirmataValue) (int, bool) {
+	if v.IsAnalog() {
+		pin, val, _ := v.GetAnalogValue()
+		return val, pin == int(p.number)
+	}
+	if int(v.valueType&0x0F) != int(p.number/8) {
+		return 0, false
+	}
+	return (v.value >> (p.number % 8)) & 1, true
+}
+
+func (p *Pin) ensureMode(mode PinMode) error {
+	if m, ok := p.Mode(); ok && m == mode {
+		return nil
+	}
+	return p.SetMode(mode)
+}
+
+// The methods below are those of the client which take a pin, for the
+// pin, so a pin looked up once by label with NamedPin can be used
+// throughout. Unlike the methods above they do not set the pin mode,
+// except where the client method does.
+
+// EnableDigitalInput turns reporting of the pin's port on or off.
+func (p *Pin) EnableDigitalInput(val bool) error {
+	return p.client.EnableDigitalInput(uint(p.number), val)
+}
+
+// EnableAnalogInput turns reporting of the pin on or off.
+func (p *Pin) EnableAnalogInput(val bool) error {
+	return p.client.EnableAnalogInput(uint(p.number), val)
+}
+
+// DigitalRead returns the last reported level of the pin.
+func (p *Pin) DigitalRead() (bool, error) {
+	return p.client.DigitalRead(uint(p.number))
+}
+
+// AnalogRead returns the last reported reading of the pin.
+func (p *Pin) AnalogRead() (int, error) {
+	return p.client.AnalogRead(uint(p.number))
+}
+
+// AnalogReadScaled returns the last reported reading of the pin, converted
+// by its calibration.
+func (p *Pin) AnalogReadScaled() (float64, error) {
+	return p.client.AnalogReadScaled(uint(p.number))
+}
+
+// GetDigital returns the cached level of the pin, without waiting. The
+// second result is false if the board has not yet reported it.
+func (p *Pin) GetDigital() (value bool, ok bool) {
+	return p.client.GetDigital(uint(p.number))
+}
+
+// GetAnalog returns the cached reading of the pin, without waiting.
+func (p *Pin) GetAnalog() (value int, ok bool) {
+	return p.client.GetAnalog(uint(p.number))
+}
+
+// SetAnalogChangeOnly passes on only readings of the pin which differ by
+// at least minDelta, as for the client method.
+func (p *Pin) SetAnalogChangeOnly(minDelta int) {
+	p.client.SetAnalogChangeOnly(p.number, minDelta)
+}
+
+// AnalogChangeOnly returns the minimum change set with
+// SetAnalogChangeOnly.
+func (p *Pin) AnalogChangeOnly() int {
+	return p.client.AnalogChangeOnly(p.number)
+}
+
+// SetAnalogReportRate passes on at most one reading of the pin every
+// interval, as for the client method.
+func (p *Pin) SetAnalogReportRate(interval time.Duration) error {
+	return p.client.SetAnalogReportRate(p.number, interval)
+}
+
+// AnalogReportRate returns the interval set with SetAnalogReportRate.
+func (p *Pin) AnalogReportRate() time.Duration {
+	return p.client.AnalogReportRate(p.number)
+}
+
+// SetCalibration attaches a transfer function to the pin, or removes it
+// if fn is nil.
+func (p *Pin) SetCalibration(fn AnalogTransfer) {
+	p.client.SetCalibration(p.number, fn)
+}
+
+// SetDebounce ignores changes of the pin which last less than d.
+func (p *Pin) SetDebounce(d time.Duration) {
+	p.client.SetDebounce(p.number, d)
+}
+
+// Debounce returns the debounce time of the pin.
+func (p *Pin) Debounce() time.Duration {
+	return p.client.Debounce(p.number)
+}
+
+// OnDigitalChange calls fn whenever the reported level of the pin
+// changes.
+func (p *Pin) OnDigitalChange(fn func(value bool)) (remove func()) {
+	return p.client.OnDigitalChange(p.number, fn)
+}
+
+// OnAnalogChange calls fn whenever the reading of the pin changes.
+func (p *Pin) OnAnalogChange(fn func(value int)) (remove func()) {
+	return p.client.OnAnalogChange(p.number, fn)
+}
+
+// WatchEdge sends the transitions of the pin matching edge.
+func (p *Pin) WatchEdge(edge Edge, minInterval time.Duration) (*EdgeWatch, error) {
+	return p.client.WatchEdge(p.number, edge, minInterval)
+}
+
+// WatchThreshold watches the scaled reading of the pin cross high and
+// low.
+func (p *Pin) WatchThreshold(high, low float64) (*ThresholdWatch, error) {
+	return p.client.WatchThreshold(p.number, high, low)
+}
+
+// StartCapture captures readings of the pin into a ring of frames.
+func (p *Pin) StartCapture(frameSize int, frames int) (*AnalogCapture, error) {
+	return p.client.StartCapture(p.number, frameSize, frames)
+}
+
+// SetSafeState sets the level the pin is driven to when the client is
+// closed.
+func (p *Pin) SetSafeState(high bool) {
+	p.client.SetSafeState(p.number, high)
+}
+
+// ClearSafeState leaves the pin as it is when the client is closed.
+func (p *Pin) ClearSafeState() {
+	p.client.ClearSafeState(p.number)
+}
+
+// Pulse drives the pin to level for d, and then back.
+func (p *Pin) Pulse(level bool, d time.Duration) error {
+	return p.client.Pulse(p.number, level, d)
+}
+
+// ServoConfig sets the pulse widths of a servo on the pin.
+func (p *Pin) ServoConfig(minPulse int16, maxPulse int16) error {
+	return p.client.ServoConfig(p.number, minPulse, maxPulse)
+}
+
+// Fade changes the PWM duty of the pin from from to to over d.
+func (p *Pin) Fade(from, to byte, d time.Duration) (*Effect, error) {
+	return p.client.Fade(p.number, from, to, d)
+}
+
+// Breathe varies the PWM duty of the pin between min and max every
+// period.
+func (p *Pin) Breathe(min, max byte, period time.Duration) (*Effect, error) {
+	return p.client.Breathe(p.number, min, max, period)
+}
+
+// SoftPwm starts software PWM on the pin.
+func (p *Pin) SoftPwm(frequency float64, duty float64) (*SoftPwm, error) {
+	return p.client.SoftPwm(p.number, frequency, duty)
+}
+
+// Led returns the LED on the pin.
+func (p *Pin) Led() *Led {
+	return p.client.Led(p.number)
+}
+
+// Button returns the push button on the pin.
+func (p *Pin) Button() *PushButton {
+	return p.client.Button(p.number)
+}
+
+// Sensor returns the analog sensor on the pin.
+func (p *Pin) Sensor() *Sensor {
+	return p.client.Sensor(p.number)
+}
@@ -0,0 +1,202 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Ping measures the round trip time to the board, from sending a protocol
+// version query to receiving the reply.
+func (c *FirmataClient) Ping(ctx context.Context) (rtt time.Duration, err error) {
+	ctx, done := c.startRequest(ctx, RequestInfo{Op: "ping", Pin: -1})
+	defer func() { done(0, err) }()
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	reply := make(chan time.Time, 1)
+	c.versionMu.Lock()
+	c.versionWaiters = append(c.versionWaiters, reply)
+	c.versionMu.Unlock()
+	defer c.removeVersionWaiter(reply)
+
+	sent := c.now()
+	if err = c.sendCommand([]byte{byte(ReportVersion)}); err != nil {
+		return 0, err
+	}
+	if err = c.Flush(); err != nil {
+		return 0, err
+	}
+	select {
+	case received := <-reply:
+		return received.Sub(sent), nil
+	case <-ctx.Done():
+		return 0, requestError(ctx, "ping")
+	case <-c.done:
+		return 0, closedError("ping")
+	}
+}
+
+// versionReported passes the time of a protocol version report to the
+// waiting pings.
+func (c *FirmataClient) versionReported(t time.Time) {
+	c.versionMu.Lock()
+	defer c.versionMu.Unlock()
+	for _, ch := range c.versionWaiters {
+		select {
+		case ch <- t:
+		default:
+		}
+	}
+	c.versionWaiters = nil
+}
+
+func (c *FirmataClient) removeVersionWaiter(ch chan time.Time) {
+	c.versionMu.Lock()
+	defer c.versionMu.Unlock()
+	for i, w := range c.versionWaiters {
+		if w == ch {
+			c.versionWaiters = append(c.versionWaiters[:i], c.versionWaiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// LatencyStats summarises the round trip times measured by a
+// LatencyMonitor.
+type LatencyStats struct {
+	// Sent is the number of pings sent, and Lost those which had no reply
+	// within the timeout.
+	Sent, Lost int
+	// Last, Min, Max and Mean are the round trip times of the replies.
+	Last, Min, Max, Mean time.Duration
+	// Jitter is the mean difference between successive round trip times.
+	Jitter time.Duration
+}
+
+// LatencyMonitor pings the board periodically, to watch the health of the
+// link.
+type LatencyMonitor struct {
+	// The client.
+	Client *FirmataClient
+	// Interval is the time between pings.
+	Interval time.Duration
+	// Timeout is the time to wait for each reply before counting the ping
+	// as lost. Zero uses the client timeout.
+	Timeout time.Duration
+
+	mu     sync.Mutex
+	stats  LatencyStats
+	total  time.Duration
+	jitter time.Duration
+	stop   chan bool
+}
+
+// NewLatencyMonitor creates a monitor pinging every interval. It is
+// stopped when the client is closed.
+func NewLatencyMonitor(client *FirmataClient, interval time.Duration, timeout time.Duration) *LatencyMonitor {
+	m := &LatencyMonitor{
+		Client:   client,
+		Interval: interval,
+		Timeout:  timeout,
+	}
+	client.onClose(m.Stop)
+	return m
+}
+
+// Start begins pinging the board.
+func (m *LatencyMonitor) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stop == nil {
+		m.stop = make(chan bool)
+		go m.run(m.stop)
+	}
+}
+
+// Stop stops pinging the board. The stats are kept.
+func (m *LatencyMonitor) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stop != nil {
+		close(m.stop)
+		m.stop = nil
+	}
+}
+
+// Stats returns the stats of the pings so far.
+func (m *LatencyMonitor) Stats() LatencyStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+// Reset clears the stats.
+func (m *LatencyMonitor) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats = LatencyStats{}
+	m.total, m.jitter = 0, 0
+}
+
+func (m *LatencyMonitor) run(stop chan bool) {
+	t := m.Client.Clock().NewTicker(m.Interval)
+	defer t.Stop()
+	for {
+		ctx, cancel := context.Background(), context.CancelFunc(func() {})
+		if m.Timeout > 0 {
+			ctx, cancel = m.Client.withTimeout(ctx, m.Timeout)
+		}
+		rtt, err := m.Client.Ping(ctx)
+		cancel()
+		m.record(rtt, err)
+		select {
+		case <-t.C():
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (m *LatencyMonitor) record(rtt time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := &m.stats
+	s.Sent++
+	if err != nil {
+		s.Lost++
+		return
+	}
+	replies := s.Sent - s.Lost
+	if replies > 1 {
+		diff := rtt - s.Last
+		if diff < 0 {
+			diff = -diff
+		}
+		m.jitter += diff
+		s.Jitter = m.jitter / time.Duration(replies-1)
+	}
+	if replies == 1 || rtt < s.Min {
+		s.Min = rtt
+	}
+	if rtt > s.Max {
+		s.Max = rtt
+	}
+	s.Last = rtt
+	m.total += rtt
+	s.Mean = m.total / time.Duration(replies)
+}
@@ -0,0 +1,163 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PinInfo describes a pin of the board, as known to the client.
+type PinInfo struct {
+	// Number is the pin number, and Label its label, or its number if it
+	// has none.
+	Number byte
+	Label  string
+	// AnalogChannel is the analog input channel of the pin, or -1 if it
+	// is not an analog pin.
+	AnalogChannel int
+	// Modes are the modes the pin supports, in order, and Resolutions
+	// the resolution in bits the board reports for each of them.
+	Modes       []PinMode
+	Resolutions map[PinMode]int
+	// Mode is the mode the pin was last set to, if ModeSet is true.
+	Mode    PinMode
+	ModeSet bool
+	// Value is the last known value of the pin: the reading of an analog
+	// input, the output of a PWM or servo pin, or 0 or 1 for a digital
+	// input or output. HasValue is false if no value is known yet.
+	Value    int
+	HasValue bool
+}
+
+// Supports returns true if the pin supports mode.
+func (p PinInfo) Supports(mode PinMode) bool {
+	_, ok := p.Resolutions[mode]
+	return ok
+}
+
+// Pins describes every pin the board reported, in pin number order.
+func (c *FirmataClient) Pins() []PinInfo {
+	caps := c.capabilities()
+	pinChannels, _ := c.analogChannels()
+
+	c.modeMu.Lock()
+	modes := make(map[byte]PinMode, len(c.currentModes))
+	for pin, m := range c.currentModes {
+		modes[pin] = m
+	}
+	c.modeMu.Unlock()
+
+	c.configMu.Lock()
+	analogOutputs := make(map[byte]byte, len(c.config.analogOutputs))
+	for pin, v := range c.config.analogOutputs {
+		analogOutputs[pin] = v
+	}
+	c.configMu.Unlock()
+
+	c.outputMu.Lock()
+	outputs := c.digitalPinState
+	c.outputMu.Unlock()
+
+	c.inputMu.Lock()
+	inputs, reported := c.digitalInputs, c.portReported
+	analogInputs := make(map[int]int, len(c.analogInputs))
+	for pin, v := range c.analogInputs {
+		analogInputs[pin] = v
+	}
+	c.inputMu.Unlock()
+
+	pins := make([]PinInfo, len(caps))
+	for n, pinCaps := range caps {
+		pin := byte(n)
+		p := PinInfo{
+			Number:        pin,
+			Label:         c.PinLabel(pin),
+			AnalogChannel: -1,
+			Resolutions:   make(map[PinMode]int, len(pinCaps)),
+		}
+		if ch, ok := pinChannels[n]; ok {
+			p.AnalogChannel = int(ch)
+		}
+		for m, res := range pinCaps {
+			p.Modes = append(p.Modes, m)
+			r, _ := res.(byte)
+			p.Resolutions[m] = int(r)
+		}
+		sort.Slice(p.Modes, func(i, j int) bool { return p.Modes[i] < p.Modes[j] })
+		p.Mode, p.ModeSet = modes[pin]
+
+		port, bit := n/8, byte(1)<<(n%8)
+		switch {
+		case !p.ModeSet:
+		case p.Mode == Analog:
+			p.Value, p.HasValue = analogInputs[n]
+		case p.Mode == PWM || p.Mode == Servo:
+			var v byte
+			v, p.HasValue = analogOutputs[pin]
+			p.Value = int(v)
+		case p.Mode == Output && port < len(outputs):
+			p.HasValue = true
+			if outputs[port]&bit != 0 {
+				p.Value = 1
+			}
+		case (p.Mode == Input || p.Mode == Pullup) && port < len(inputs):
+			p.HasValue = reported[port]
+			if p.HasValue && inputs[port]&bit != 0 {
+				p.Value = 1
+			}
+		}
+		pins[n] = p
+	}
+	return pins
+}
+
+// pinModeNames are the mode names accepted by ParsePinMode.
+var pinModeNames = map[string]PinMode{
+	"input":  Input,
+	"output": Output,
+	"analog": Analog,
+	"pwm":    PWM,
+	"servo":  Servo,
+	"shift":  Shift,
+	"i2c":    I2C,
+	"spi":    SPI,
+	"pullup": Pullup,
+}
+
+// ParsePinMode parses a pin mode name, such as "output" or "PWM", in any
+// case, or a mode number.
+func ParsePinMode(s string) (PinMode, error) {
+	if m, ok := pinModeNames[strings.ToLower(s)]; ok {
+		return m, nil
+	}
+	if n, err := strconv.ParseUint(s, 0, 7); err == nil {
+		return PinMode(n), nil
+	}
+	return 0, fmt.Errorf("Unknown pin mode %q", s)
+}
+
+// Name returns the name ParsePinMode accepts for m, or its number if it
+// has no name.
+func (m PinMode) Name() string {
+	for name, mode := range pinModeNames {
+		if mode == m {
+			return name
+		}
+	}
+	return strconv.Itoa(int(m))
+}
@@ -0,0 +1,83 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"sync"
+	"time"
+)
+
+// poller is the goroutine of a reader, watch or device which polls and
+// sends what it finds on a channel until it is stopped. Stopping never
+// blocks: it may be done any number of times, before the goroutine is
+// started, and from the goroutine reading the channel, as a blocked send
+// gives up once the poller is stopped. A nil poller is one never started,
+// and stopping it does nothing.
+type poller struct {
+	stop chan bool
+	once sync.Once
+}
+
+func newPoller() *poller {
+	return &poller{stop: make(chan bool)}
+}
+
+// Stop stops the poller, without waiting for it.
+func (p *poller) Stop() {
+	if p != nil {
+		p.once.Do(func() { close(p.stop) })
+	}
+}
+
+// stopped is closed once the poller is stopped.
+func (p *poller) stopped() <-chan bool {
+	return p.stop
+}
+
+// every calls f straight away and then every interval until the poller is
+// stopped, or back to back if interval is not positive.
+func (p *poller) every(clock Clock, interval time.Duration, f func()) {
+	if interval <= 0 {
+		for {
+			select {
+			case <-p.stop:
+				return
+			default:
+			}
+			f()
+		}
+	}
+	t := clock.NewTicker(interval)
+	defer t.Stop()
+	for {
+		f()
+		select {
+		case <-t.C():
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// pollSend sends v on ch, returning false without sending if p is
+// stopped first.
+func pollSend[T any](p *poller, ch chan<- T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	case <-p.stop:
+		return false
+	}
+}
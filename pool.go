@@ -0,0 +1,53 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"sync"
+)
+
+// maxPooledBuffer is the largest buffer kept for reuse, so one long
+// message does not hold on to its memory.
+const maxPooledBuffer = 4096
+
+// bufferPool holds message buffers for reuse, for outgoing SysEx
+// messages and the payloads passed to OnSysex callbacks.
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 64)
+		return &b
+	},
+}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *[]byte {
+	b := bufferPool.Get().(*[]byte)
+	*b = (*b)[:0]
+	return b
+}
+
+// putBuffer returns a buffer to the pool. It must not be used after.
+func putBuffer(b *[]byte) {
+	if cap(*b) <= maxPooledBuffer {
+		bufferPool.Put(b)
+	}
+}
+
+// WithSysexCopy gives each OnSysex callback a copy of the message which
+// it may keep, instead of a pooled buffer which is only valid until the
+// callback returns.
+func WithSysexCopy() Option {
+	return func(o *options) { o.sysexCopy = true }
+}
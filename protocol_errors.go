@@ -0,0 +1,135 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"time"
+)
+
+// protocolErrorBuffer is the default number of protocol errors held for a
+// slow reader of Errors before the oldest are dropped.
+const protocolErrorBuffer = 50
+
+// ProtocolErrorType is the kind of problem a ProtocolError reports.
+type ProtocolErrorType byte
+
+const (
+	// UnexpectedCommand is a command byte the client does not handle, or
+	// a data byte where a command was expected.
+	UnexpectedCommand ProtocolErrorType = iota
+	// UnexpectedSysEx is a SysEx message of a type the client does not
+	// handle.
+	UnexpectedSysEx
+	// MalformedMessage is a message with missing or invalid fields.
+	MalformedMessage
+	// ShortRead is a message cut off by a read error.
+	ShortRead
+	// CrcMismatch is device data which failed its CRC check.
+	CrcMismatch
+)
+
+func (t ProtocolErrorType) String() string {
+	switch t {
+	case UnexpectedCommand:
+		return "UnexpectedCommand"
+	case UnexpectedSysEx:
+		return "UnexpectedSysEx"
+	case MalformedMessage:
+		return "MalformedMessage"
+	case ShortRead:
+		return "ShortRead"
+	case CrcMismatch:
+		return "CrcMismatch"
+	}
+	return fmt.Sprintf("Unknown protocol error (0x%x)", byte(t))
+}
+
+// ProtocolError describes traffic from the board which could not be
+// handled.
+type ProtocolError struct {
+	// Type is the kind of problem.
+	Type ProtocolErrorType
+	// Err describes the problem.
+	Err error
+	// Data is the bytes involved, for context.
+	Data []byte
+	// Time is when the traffic was received.
+	Time time.Time
+}
+
+func (e *ProtocolError) Error() string {
+	return fmt.Sprintf("%v: %s [% x]", e.Type, e.Err.Error(), e.Data)
+}
+
+// Unwrap returns Err.
+func (e *ProtocolError) Unwrap() error {
+	return e.Err
+}
+
+// Errors returns a channel of protocol errors seen by the client. Errors
+// are dropped, oldest first, if the channel is not read, unless QueueErrors
+// is set otherwise with WithQueue. It is closed when the client is closed.
+func (c *FirmataClient) Errors() <-chan *ProtocolError {
+	c.errorMu.Lock()
+	defer c.errorMu.Unlock()
+	if c.errors == nil {
+		c.errors = make(chan *ProtocolError, c.Queue(QueueErrors).Size)
+		if c.closed() {
+			close(c.errors)
+		}
+	}
+	return c.errors
+}
+
+// protocolError logs a problem with traffic received at the given time and
+// passes it to the Errors channel, if there is one.
+func (c *FirmataClient) protocolError(t ProtocolErrorType, err error, data []byte, at time.Time) {
+	e := &ProtocolError{
+		Type: t,
+		Err:  err,
+		Data: append([]byte(nil), data...),
+		Time: at,
+	}
+	c.Log.Debug("Protocol error: %s", e.Error())
+	c.debug.protocolError(e)
+	if m := c.metricsSink(); m != nil {
+		m.ProtocolError(t)
+	}
+	c.errorMu.Lock()
+	defer c.errorMu.Unlock()
+	if c.errors == nil || c.closed() {
+		return
+	}
+	deliver(c, QueueErrors, c.errors, e)
+}
+
+// crcError returns a CrcError for device data, also reporting it as a
+// protocol error. It is called by device drivers rather than the reader,
+// so the error is timed when it is found.
+func (c *FirmataClient) crcError(received, calculated uint16, data []byte) error {
+	err := &CrcError{Received: received, Calculated: calculated, Data: data}
+	c.protocolError(CrcMismatch, err, data, c.now())
+	return err
+}
+
+// stopErrors closes the Errors channel.
+func (c *FirmataClient) stopErrors() {
+	c.errorMu.Lock()
+	defer c.errorMu.Unlock()
+	if c.errors != nil {
+		close(c.errors)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"time"
+)
+
+// pulse is a pin asserted by Pulse, waiting to be released.
+type pulse struct {
+	timer Timer
+	level bool
+}
+
+// Pulse sets pin to an output, drives it to level and returns. The pin is
+// driven back to the opposite level after d by a timer owned by the
+// client, so the release happens even if the caller goes away. It is also
+// released early if the client is closed or the connection lost. A pulse
+// on a pin which is already pulsing replaces the old one, restarting the
+// timer.
+func (c *FirmataClient) Pulse(pin byte, level bool, d time.Duration) error {
+	if err := c.Pin(pin).ensureMode(Output); err != nil {
+		return err
+	}
+	c.pulseMu.Lock()
+	defer c.pulseMu.Unlock()
+	if p, ok := c.pulses[pin]; ok {
+		p.timer.Stop()
+		delete(c.pulses, pin)
+	}
+	if err := c.DigitalWrite(uint(pin), level); err != nil {
+		return err
+	}
+	if c.pulses == nil {
+		c.pulses = make(map[byte]*pulse)
+		c.onClose(c.releasePulses)
+	}
+	p := &pulse{level: level}
+	p.timer = c.Clock().AfterFunc(d, func() {
+		c.pulseMu.Lock()
+		defer c.pulseMu.Unlock()
+		if c.pulses[pin] != p {
+			return
+		}
+		delete(c.pulses, pin)
+		c.releasePulse(pin, p)
+	})
+	c.pulses[pin] = p
+	return nil
+}
+
+// releasePulses releases every pending pulse, when the client is closed.
+func (c *FirmataClient) releasePulses() {
+	c.pulseMu.Lock()
+	defer c.pulseMu.Unlock()
+	for pin, p := range c.pulses {
+		p.timer.Stop()
+		c.releasePulse(pin, p)
+	}
+	c.pulses = nil
+}
+
+// releasePulse ends a pulse. pulseMu is held.
+func (c *FirmataClient) releasePulse(pin byte, p *pulse) {
+	if err := c.DigitalWrite(uint(pin), !p.level); err != nil {
+		c.Log.Critical("Unable to release pulse on pin %v: %s", c.PinLabel(pin), err.Error())
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"context"
+)
+
+// FirmwareInfo is the firmware reported by the board.
+type FirmwareInfo struct {
+	// Name is the firmware name, usually its source file.
+	Name string
+	// Major and Minor are the firmware version.
+	Major, Minor int
+}
+
+// Firmware returns the firmware last reported by the board.
+func (c *FirmataClient) Firmware() FirmwareInfo {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	f := FirmwareInfo{Name: c.firmwareName}
+	if len(c.firmwareVersion) == 2 {
+		f.Major, f.Minor = c.firmwareVersion[0], c.firmwareVersion[1]
+	}
+	return f
+}
+
+// QueryFirmware asks the board for its firmware name and version.
+func (c *FirmataClient) QueryFirmware(ctx context.Context) (FirmwareInfo, error) {
+	c.stateMu.RLock()
+	reports := c.firmwareReports
+	c.stateMu.RUnlock()
+	err := c.query(ctx, "firmware query", ReportFirmware, func() bool {
+		c.stateMu.RLock()
+		defer c.stateMu.RUnlock()
+		return c.firmwareReports != reports
+	})
+	if err != nil {
+		return FirmwareInfo{}, err
+	}
+	return c.Firmware(), nil
+}
+
+// QueryCapabilities asks the board again for the modes its pins support,
+// waiting for the reply.
+func (c *FirmataClient) QueryCapabilities(ctx context.Context) error {
+	c.stateMu.Lock()
+	c.capabilityDone = false
+	c.stateMu.Unlock()
+	return c.query(ctx, "capability query", CapabilityQuery, func() bool {
+		c.stateMu.RLock()
+		defer c.stateMu.RUnlock()
+		return c.capabilityDone
+	})
+}
+
+// QueryAnalogMapping asks the board again for its analog pin mapping,
+// waiting for the reply.
+func (c *FirmataClient) QueryAnalogMapping(ctx context.Context) error {
+	c.stateMu.Lock()
+	c.analogMappingDone = false
+	c.stateMu.Unlock()
+	return c.query(ctx, "analog mapping query", AnalogMappingQuery, func() bool {
+		c.stateMu.RLock()
+		defer c.stateMu.RUnlock()
+		return c.analogMappingDone
+	})
+}
+
+// query sends a SysEx query with no data, and waits for replied to return
+// true.
+func (c *FirmataClient) query(ctx context.Context, op string, cmd SysExCommand, replied func() bool) (err error) {
+	ctx, done := c.startRequest(ctx, RequestInfo{Op: op, Command: cmd, Pin: -1})
+	defer func() { done(0, err) }()
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+
+	if err = c.sendRequest(cmd); err != nil {
+		return err
+	}
+	for {
+		reported := c.stateReported()
+		if replied() {
+			return nil
+		}
+		select {
+		case <-reported:
+		case <-ctx.Done():
+			return requestError(ctx, op)
+		case <-c.done:
+			return closedError(op)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata_test
+
+// These tests use the client from many goroutines at once against a
+// simulated board. They check results where they can, but are mostly for
+// the race detector:
+//
+//	go test -race
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"code.google.com/p/log4go"
+	"github.com/buxtronix/go-firmata"
+	"github.com/buxtronix/go-firmata/firmataenc"
+	"github.com/buxtronix/go-firmata/firmatatest"
+)
+
+// connect connects a client to b which logs nothing, closing both when the
+// test ends.
+func connect(t *testing.T, b *firmatatest.Board, opts ...firmata.Option) *firmata.FirmataClient {
+	t.Helper()
+	l := make(log4go.Logger)
+	opts = append([]firmata.Option{firmata.WithLogger(&l), firmata.WithConnectTimeout(5*time.Second, 0)}, opts...)
+	c, err := b.Connect(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		c.Close()
+		b.Close()
+	})
+	return c
+}
+
+// streamAnalog changes the analog inputs of b until stop is closed, so the
+// reader is busy while a test runs.
+func streamAnalog(b *firmatatest.Board, stop chan bool, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for v := 0; ; v++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			for pin := byte(14); pin < 20; pin++ {
+				b.SetAnalog(pin, (v+int(pin))%1024)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+}
+
+// eventually fails the test unless ok returns true within a second.
+func eventually(t *testing.T, what string, ok func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !ok(); {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestConcurrentI2CStream(t *testing.T) {
+	b := firmatatest.NewUno()
+	// The device answers a read with its register, so replies can be
+	// matched to requests.
+	b.HandleSysEx(firmata.I2CRequest, func(data []byte) [][]byte {
+		if len(data) < 6 || firmata.I2CMode(data[1]) == firmata.I2CModeWrite {
+			return nil
+		}
+		register := int(data[2]) | int(data[3])<<7
+		return [][]byte{firmataenc.I2CReply(data[0], register, []byte{byte(register)})}
+	})
+	c := connect(t, b)
+	if err := c.I2CConfig(0); err != nil {
+		t.Fatal(err)
+	}
+	stream, err := c.I2CReadContinuous(0x40, 0x10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				register := 0x20 + g*8 + i%8
+				data, err := c.I2CRead(0x40, register, 1)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if len(data) != 1 || int(data[0]) != register {
+					t.Errorf("read of register 0x%x got % x", register, data)
+				}
+				c.I2CWrite(0x40, byte(register), 0)
+			}
+		}(g)
+	}
+	// A one-shot read of the streamed register still gets its reply.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			data, err := c.I2CRead(0x40, 0x10, 1)
+			if err != nil || len(data) != 1 || data[0] != 0x10 {
+				t.Errorf("read of streamed register got % x, %v", data, err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	select {
+	case r := <-stream:
+		if r.Register != 0x10 {
+			t.Errorf("stream got register 0x%x, want 0x10", r.Register)
+		}
+	case <-time.After(time.Second):
+		t.Error("no reply on the stream")
+	}
+	if err := c.I2CStopReading(0x40); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// A continuous read with a full Block buffer holds up the reader, but
+// not I2CStopReading, which releases it.
+func TestI2CStopBlockedStream(t *testing.T) {
+	b := firmatatest.NewUno()
+	b.HandleSysEx(firmata.I2CRequest, func(data []byte) [][]byte {
+		if len(data) < 6 || firmata.I2CMode(data[1]) == firmata.I2CModeWrite {
+			return nil
+		}
+		register := int(data[2]) | int(data[3])<<7
+		n := 1
+		if firmata.I2CMode(data[1]) == firmata.I2CModeReadContinuous {
+			n = 4
+		}
+		var replies [][]byte
+		for i := 0; i < n; i++ {
+			replies = append(replies, firmataenc.I2CReply(data[0], register, []byte{byte(i)}))
+		}
+		return replies
+	})
+	c := connect(t, b, firmata.WithQueue(firmata.QueueI2C, 1, firmata.Block))
+	if err := c.I2CConfig(0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.I2CReadContinuous(0x40, 0x10, 1); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	stopped := make(chan error)
+	go func() { stopped <- c.I2CStopReading(0x40) }()
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("I2CStopReading blocked by a full stream")
+	}
+	if _, err := c.I2CRead(0x41, 0x20, 1); err != nil {
+		t.Errorf("read after stopping the stream: %v", err)
+	}
+}
+
+func TestConcurrentOneWire(t *testing.T) {
+	b := firmatatest.NewUno()
+	good := firmatatest.NewDS18B20(1)
+	good.SetTemperature(21.5)
+	bad := firmatatest.NewDS18B20(2)
+	bad.SetCrcError(true)
+	b.OneWireBus(4).Attach(good, bad)
+	b.OneWireBus(5).Attach(firmatatest.NewDS18B20(3))
+	c := connect(t, b)
+	for _, pin := range []byte{4, 5} {
+		if err := c.OneWireConfig(pin, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for pin := uint(14); pin < 20; pin++ {
+		if err := c.EnableAnalogInput(pin, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	errs := c.Errors()
+	stop := make(chan bool)
+	var background sync.WaitGroup
+	streamAnalog(b, stop, &background)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		for {
+			select {
+			case <-errs:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	read := func(d *firmata.Ds18x20, wantErr bool) {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			err := d.ReadScratchPad()
+			var crc *firmata.CrcError
+			if wantErr && !errors.As(err, &crc) {
+				t.Errorf("read of bad device got %v, want a CrcError", err)
+				return
+			}
+			if !wantErr && err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}
+	wg.Add(3)
+	go read(&firmata.Ds18x20{Client: c, Pin: 4, Address: bad.Address()}, true)
+	go read(&firmata.Ds18x20{Client: c, Pin: 4, Address: good.Address()}, false)
+	go read(&firmata.Ds18x20{Client: c, Pin: 5, Address: firmatatest.NewDS18B20(3).Address()}, false)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 5; i++ {
+			addrs, err := c.OneWireSearch(4, firmata.OneWireSearch)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if len(addrs) != 2 {
+				t.Errorf("search found %v devices, want 2", len(addrs))
+			}
+		}
+	}()
+	wg.Wait()
+	close(stop)
+	background.Wait()
+}
+
+func TestConcurrentReconnect(t *testing.T) {
+	var mu sync.Mutex
+	boards := []*firmatatest.Board{firmatatest.NewUno()}
+	current := func() *firmatatest.Board {
+		mu.Lock()
+		defer mu.Unlock()
+		return boards[len(boards)-1]
+	}
+	dial := func() (io.ReadWriteCloser, error) {
+		b := firmatatest.NewUno()
+		mu.Lock()
+		boards = append(boards, b)
+		mu.Unlock()
+		return b.Conn(), nil
+	}
+	c := connect(t, boards[0], firmata.WithReconnect(dial, 10*time.Millisecond))
+	t.Cleanup(func() {
+		for _, b := range boards {
+			b.Close()
+		}
+	})
+	connected := make(chan bool, 10)
+	c.OnConnectionChange(func(up bool) { connected <- up })
+	relay, err := firmata.NewRelay(c, 8, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := relay.On(); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan bool)
+	var wg sync.WaitGroup
+	for pin := byte(2); pin < 6; pin++ {
+		wg.Add(1)
+		go func(pin byte) {
+			defer wg.Done()
+			for high := false; ; high = !high {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				// Writes fail while the link is down.
+				c.DigitalWrite(uint(pin), high)
+				c.DigitalRead(uint(pin))
+				time.Sleep(time.Millisecond)
+			}
+		}(pin)
+	}
+
+	for i := 0; i < 2; i++ {
+		time.Sleep(20 * time.Millisecond)
+		current().Close()
+		for _, want := range []bool{false, true} {
+			select {
+			case up := <-connected:
+				if up != want {
+					t.Fatalf("connection change %v, want %v", up, want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("no connection change to %v", want)
+			}
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	// The relay is active low, so its safe, de-energised state is high.
+	b := current()
+	eventually(t, "relay safe state", func() bool {
+		mode, _ := b.Mode(8)
+		return mode == firmata.Output && b.Digital(8)
+	})
+	if relay.State() {
+		t.Error("relay energised after reconnecting")
+	}
+	if err := relay.On(); err != nil {
+		t.Errorf("relay unusable after reconnecting: %v", err)
+	}
+}
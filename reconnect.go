@@ -0,0 +1,88 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"io"
+	"time"
+)
+
+// transport returns the current connection to the board.
+func (c *FirmataClient) transport() io.ReadWriteCloser {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	return c.conn
+}
+
+// readLoop runs the reader on conn, and on each new connection if the
+// client reconnects, until the client is closed or the connection is lost
+// for good.
+func (c *FirmataClient) readLoop(conn io.ReadWriteCloser) {
+	defer close(c.readerDone)
+	for {
+		err := c.replyReader(conn)
+		if c.closed() {
+			return
+		}
+		c.Log.Critical("Read: %s", err.Error())
+		c.runCloseHooks()
+		c.connectionChanged(false)
+		if c.dial == nil {
+			return
+		}
+		if conn = c.redial(); conn == nil {
+			return
+		}
+		c.connectionChanged(true)
+	}
+}
+
+// redial reconnects to the board and resets it, returning the new
+// connection, or nil if the client was closed first.
+func (c *FirmataClient) redial() io.ReadWriteCloser {
+	c.transport().Close()
+	wait := c.redialWait
+	if wait <= 0 {
+		wait = time.Second
+	}
+	for {
+		select {
+		case <-c.after(wait):
+		case <-c.done:
+			return nil
+		}
+		conn, err := c.dial()
+		if err != nil {
+			c.Log.Warn("Reconnect: %s", err.Error())
+			continue
+		}
+		c.connMu.Lock()
+		if c.closed() {
+			c.connMu.Unlock()
+			conn.Close()
+			return nil
+		}
+		c.conn = conn
+		c.connMu.Unlock()
+		c.stateMu.Lock()
+		c.analogMappingDone = false
+		c.capabilityDone = false
+		c.stateMu.Unlock()
+		c.Log.Info("Reconnected, resetting board")
+		c.sendCommand([]byte{byte(SystemReset)})
+		c.Flush()
+		return conn
+	}
+}
@@ -0,0 +1,324 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecordedChunk is the bytes passed in one read or write of a recorded
+// connection.
+type RecordedChunk struct {
+	// Offset is the time since the recording started.
+	Offset time.Duration
+	// Direction is whether the bytes were sent to or received from the
+	// board.
+	Direction TraceDirection
+	// Data is the bytes.
+	Data []byte
+}
+
+// String formats the chunk as a line of a recording: the offset in
+// microseconds, the direction, and the bytes in hex.
+func (r RecordedChunk) String() string {
+	return fmt.Sprintf("%d %v % x", r.Offset.Microseconds(), r.Direction, r.Data)
+}
+
+// Recorder is a connection to the board which writes everything sent and
+// received to a recording, to be played back with a Replay. For example,
+// to record a session in the field:
+//
+//	f, _ := os.Create("session.rec")
+//	client, err := firmata.NewClient(firmata.NewRecorder(conn, f))
+type Recorder struct {
+	conn  io.ReadWriteCloser
+	mu    sync.Mutex
+	w     io.Writer
+	start time.Time
+	err   error
+}
+
+// NewRecorder records the traffic of conn to w.
+func NewRecorder(conn io.ReadWriteCloser, w io.Writer) *Recorder {
+	return &Recorder{conn: conn, w: w, start: time.Now()}
+}
+
+func (r *Recorder) Read(b []byte) (int, error) {
+	n, err := r.conn.Read(b)
+	if n > 0 {
+		r.record(Received, b[:n])
+	}
+	return n, err
+}
+
+func (r *Recorder) Write(b []byte) (int, error) {
+	n, err := r.conn.Write(b)
+	if n > 0 {
+		r.record(Sent, b[:n])
+	}
+	return n, err
+}
+
+// Close closes the connection. The recording is left open.
+func (r *Recorder) Close() error {
+	return r.conn.Close()
+}
+
+// Err returns the first error writing the recording. Recording stops at
+// an error, but the connection carries on.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) record(d TraceDirection, data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	c := RecordedChunk{Offset: time.Since(r.start), Direction: d, Data: data}
+	_, r.err = fmt.Fprintln(r.w, c.String())
+}
+
+// ReadRecording reads a recording written by a Recorder. Blank lines and
+// lines starting with # are skipped, so recordings can be annotated.
+func ReadRecording(r io.Reader) ([]RecordedChunk, error) {
+	var chunks []RecordedChunk
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("recording line %d: too few fields", line)
+		}
+		us, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("recording line %d: bad offset: %w", line, err)
+		}
+		c := RecordedChunk{Offset: time.Duration(us) * time.Microsecond}
+		switch fields[1] {
+		case Sent.String():
+			c.Direction = Sent
+		case Received.String():
+			c.Direction = Received
+		default:
+			return nil, fmt.Errorf("recording line %d: bad direction %q", line, fields[1])
+		}
+		if c.Data, err = hex.DecodeString(strings.Join(fields[2:], "")); err != nil {
+			return nil, fmt.Errorf("recording line %d: bad data: %w", line, err)
+		}
+		chunks = append(chunks, c)
+	}
+	return chunks, s.Err()
+}
+
+// ReplayMismatch is the error from a Replay when the client sends
+// something other than what was recorded.
+type ReplayMismatch struct {
+	// Offset is the position in the sent bytes where they differ.
+	Offset int
+	// Want is the recorded bytes from Offset, and Got those sent.
+	Want, Got []byte
+}
+
+func (e *ReplayMismatch) Error() string {
+	return fmt.Sprintf("replay: sent % x at byte %d, recording has % x", e.Got, e.Offset, e.Want)
+}
+
+// Replay is a connection which plays back a recording to the client, as
+// if it were the board. Each chunk received from the board is played
+// once the client has sent everything recorded before it, so replies
+// follow the requests they answer. What the client sends is checked
+// against the recording, and the first difference is kept for Err, but
+// playback carries on.
+//
+// Once the recording is played, reads wait until the Replay is closed,
+// as a quiet board would.
+type Replay struct {
+	chunks []RecordedChunk
+	timing bool
+	done   chan bool
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	want    []byte
+	ends    []int
+	written int
+	next    int
+	pending []byte
+	last    time.Time
+	lastAt  time.Duration
+	err     error
+	closed  bool
+}
+
+// NewReplay creates a connection playing back chunks. With timing set,
+// received chunks are played with the gaps they were recorded with,
+// otherwise as soon as the client has sent what came before them.
+func NewReplay(chunks []RecordedChunk, timing bool) *Replay {
+	r := &Replay{chunks: chunks, timing: timing, done: make(chan bool), last: time.Now()}
+	r.cond = sync.NewCond(&r.mu)
+	for _, c := range chunks {
+		if c.Direction == Sent {
+			r.want = append(r.want, c.Data...)
+		}
+		r.ends = append(r.ends, len(r.want))
+	}
+	return r
+}
+
+func (r *Replay) Read(b []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for len(r.pending) == 0 {
+		if r.closed {
+			return 0, io.EOF
+		}
+		for r.next < len(r.chunks) && r.chunks[r.next].Direction == Sent {
+			r.next++
+		}
+		if r.next == len(r.chunks) || r.written < r.ends[r.next] {
+			r.cond.Wait()
+			continue
+		}
+		c := r.chunks[r.next]
+		if r.timing {
+			if wait := c.Offset - r.lastAt - time.Since(r.last); wait > 0 {
+				r.mu.Unlock()
+				t := time.NewTimer(wait)
+				select {
+				case <-t.C:
+				case <-r.done:
+					t.Stop()
+				}
+				r.mu.Lock()
+				continue
+			}
+		}
+		r.pending = c.Data
+		r.last, r.lastAt = time.Now(), c.Offset
+		r.next++
+		r.cond.Broadcast()
+	}
+	n := copy(b, r.pending)
+	r.pending = r.pending[n:]
+	r.cond.Broadcast()
+	return n, nil
+}
+
+func (r *Replay) Write(b []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if r.err == nil {
+		var want []byte
+		if r.written < len(r.want) {
+			want = r.want[r.written:]
+		}
+		if len(want) > len(b) {
+			want = want[:len(b)]
+		}
+		if !bytes.Equal(want, b) {
+			r.err = &ReplayMismatch{Offset: r.written, Want: append([]byte(nil), want...), Got: append([]byte(nil), b...)}
+		}
+	}
+	// The recorded gaps after a request are timed from when it is sent.
+	for i, c := range r.chunks {
+		if c.Direction == Sent && r.written < r.ends[i] && r.written+len(b) >= r.ends[i] {
+			r.last, r.lastAt = time.Now(), c.Offset
+		}
+	}
+	r.written += len(b)
+	r.cond.Broadcast()
+	return len(b), nil
+}
+
+// Close ends the playback. Reads return io.EOF.
+func (r *Replay) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.closed {
+		r.closed = true
+		close(r.done)
+		r.cond.Broadcast()
+	}
+	return nil
+}
+
+// Err returns the first difference between what the client sent and the
+// recording, as a *ReplayMismatch, or nil.
+func (r *Replay) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Wait waits until the whole recording has been played and read, and
+// everything recorded as sent has been sent, then returns Err. If ctx is
+// done first its error is returned.
+func (r *Replay) Wait(ctx context.Context) error {
+	stop := make(chan bool)
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			r.mu.Lock()
+			r.cond.Broadcast()
+			r.mu.Unlock()
+		case <-stop:
+		}
+	}()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for !r.finished() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		r.cond.Wait()
+	}
+	return r.err
+}
+
+// finished returns true once the recording is played out, with mu held.
+func (r *Replay) finished() bool {
+	if r.closed {
+		return true
+	}
+	if r.written < len(r.want) || len(r.pending) > 0 {
+		return false
+	}
+	for _, c := range r.chunks[r.next:] {
+		if c.Direction == Received {
+			return false
+		}
+	}
+	return true
+}
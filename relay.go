@@ -0,0 +1,147 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Relay is a relay on a digital output, which is returned to a safe state
+// when it is closed or the client is closed. A board loses its outputs
+// when the connection to it is lost and it is reset, so the relay is put
+// in its safe state again when a client made with WithReconnect
+// reconnects.
+type Relay struct {
+	// The client.
+	Client *FirmataClient
+	// The pin the relay is on.
+	Pin byte
+	// ActiveLow is set for relay modules which energise on a low output.
+	ActiveLow bool
+	// SafeState is the state the relay is put in on Close or reconnection.
+	// It is normally false, de-energised. The client is given the state by
+	// NewRelay, for when it is closed.
+	SafeState bool
+	// MinInterval is the shortest time allowed between switches, to
+	// protect the relay contacts and the load.
+	MinInterval time.Duration
+
+	mu       sync.Mutex
+	on       bool
+	switched time.Time
+	closed   bool
+	// unwatch removes the connection callback.
+	unwatch func()
+}
+
+// NewRelay creates a relay on pin and sets it to its safe state.
+func NewRelay(client *FirmataClient, pin byte, activeLow bool, safeState bool) (*Relay, error) {
+	r := &Relay{
+		Client:    client,
+		Pin:       pin,
+		ActiveLow: activeLow,
+		SafeState: safeState,
+	}
+	if err := client.SetPinMode(pin, Output); err != nil {
+		return nil, err
+	}
+	if err := r.write(safeState); err != nil {
+		return nil, err
+	}
+	client.SetSafeState(pin, safeState != activeLow)
+	r.unwatch = client.OnConnectionChange(r.reconnected)
+	return r, nil
+}
+
+// On energises the relay.
+func (r *Relay) On() error {
+	return r.Set(true)
+}
+
+// Off de-energises the relay.
+func (r *Relay) Off() error {
+	return r.Set(false)
+}
+
+// Set switches the relay on or off. An error is returned if the relay
+// last switched less than MinInterval ago.
+func (r *Relay) Set(on bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return fmt.Errorf("relay on pin %v is closed", r.Client.PinLabel(r.Pin))
+	}
+	if on == r.on {
+		return nil
+	}
+	if wait := r.MinInterval - r.Client.since(r.switched); wait > 0 {
+		return fmt.Errorf("relay on pin %v switched too recently, wait %v", r.Client.PinLabel(r.Pin), wait)
+	}
+	return r.write(on)
+}
+
+// State returns true if the relay is energised.
+func (r *Relay) State() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.on
+}
+
+// Close puts the relay in its safe state and prevents further switching.
+// The minimum interval is not applied.
+func (r *Relay) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	r.unwatch()
+	r.Client.ClearSafeState(r.Pin)
+	return r.write(r.SafeState)
+}
+
+// reconnected puts the relay back in its safe state once the client has
+// reconnected and reset the board. A lost connection cannot be written to,
+// so is left alone.
+func (r *Relay) reconnected(connected bool) {
+	if !connected {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	err := r.Client.SetPinMode(r.Pin, Output)
+	if err == nil {
+		err = r.write(r.SafeState)
+	}
+	if err != nil {
+		r.Client.Log.Critical("Unable to put relay on pin %v in safe state: %s", r.Client.PinLabel(r.Pin), err.Error())
+	}
+}
+
+// write sets the output, with mu held.
+func (r *Relay) write(on bool) error {
+	if err := r.Client.DigitalWrite(uint(r.Pin), on != r.ActiveLow); err != nil {
+		return err
+	}
+	r.on = on
+	r.switched = r.Client.now()
+	return nil
+}
@@ -15,14 +15,26 @@
 package firmata
 
 import (
-	"bufio"
 	"fmt"
+	"io"
+	"time"
 )
 
+// readBufferSize is the size of the read buffer, and the initial size of
+// the frame buffer.
+const readBufferSize = 4096
+
 type FirmataValue struct {
 	valueType            FirmataCommand
 	value                int
 	analogChannelPinsMap map[byte]int
+	received             time.Time
+}
+
+// Time returns when the value was received from the board. It has a
+// monotonic clock reading, so is safe to subtract from other receive times.
+func (v FirmataValue) Time() time.Time {
+	return v.received
 }
 
 func (v FirmataValue) IsAnalog() bool {
@@ -65,55 +77,72 @@ func (v FirmataValue) String() string {
 	}
 }
 
-func (c *FirmataClient) replyReader() {
-	r := bufio.NewReader(*c.conn)
-	//c.valueChan = make(chan FirmataValue)
+// replyReader handles messages from conn until a read fails, returning
+// the error. Frames are assembled by a decoder in a reused buffer, so
+// steady streams of reports do not allocate. The handlers must copy
+// anything they keep.
+func (c *FirmataClient) replyReader(conn io.Reader) error {
+	buf := make([]byte, readBufferSize)
 	var init bool
+	d := newDecoder(func(frame []byte) {
+		c.observeFrame(Received, c.received, frame)
+		if !init {
+			if FirmataCommand(frame[0]) != ReportVersion {
+				// Expected while the board resets, so not a protocol error.
+				c.Log.Debug("Discarding %v (not initialized)", FirmataCommand(frame[0]))
+				return
+			}
+			init = true
+		}
+		c.handleFrame(frame)
+	}, func(t ProtocolErrorType, err error, data []byte) {
+		c.observeFrame(Received, c.received, data)
+		if !init {
+			c.Log.Debug("Discarding bytes (not initialized): %s", err.Error())
+			return
+		}
+		c.protocolError(t, err, data, c.received)
+	})
 
 	for {
-		b, err := (r.ReadByte())
+		n, err := conn.Read(buf)
+		c.received = c.now()
+		d.write(buf[:n])
 		if err != nil {
-      c.Log.Critical("Read: %s", err.Error())
-			return
+			d.end(err)
+			return err
 		}
+	}
+}
 
-		cmd := FirmataCommand(b)
-    c.Log.Trace("Incoming cmd %v", cmd)
-		if !init {
-			if cmd != ReportVersion {
-				c.Log.Debug("Discarding unexpected command byte %0d (not initialized)\n", b)
-				continue
-			} else {
-				init = true
-			}
+// handleFrame handles a complete frame from the board.
+func (c *FirmataClient) handleFrame(frame []byte) {
+	cmd := FirmataCommand(frame[0])
+  c.Log.Trace("Incoming cmd %v", cmd)
+	switch {
+	case cmd == ReportVersion:
+		major, minor := frame[1], frame[2]
+		c.stateMu.Lock()
+		// Pings ask for the version repeatedly, so only log changes.
+		if len(c.protocolVersion) != 2 || c.protocolVersion[0] != major || c.protocolVersion[1] != minor {
+			c.protocolVersion = []byte{major, minor}
+			c.Log.Info("Protocol version: %d.%d", major, minor)
 		}
-		
-		switch {
-		case cmd == ReportVersion:
-			c.protocolVersion = make([]byte, 2)
-			c.protocolVersion[0], err = r.ReadByte()
-			c.protocolVersion[1], err = r.ReadByte()
-			c.Log.Info("Protocol version: %d.%d", c.protocolVersion[0], c.protocolVersion[1])
-		case cmd == StartSysEx:
-			var sysExData []byte
-			sysExData, err = r.ReadSlice(byte(EndSysEx))
-			if err == nil {
-				c.parseSysEx(sysExData[0 : len(sysExData)-1])
-			} else {
-        c.Log.Critical("parseSysEx: %s", err.Error())
-      }
-		case (cmd&DigitalMessage) > 0 || byte(cmd&AnalogMessage) > 0:
-			b1, _ := r.ReadByte()
-			b2, _ := r.ReadByte()
-			select {
-			case c.valueChan <- FirmataValue{cmd, int(from7Bit(b1, b2)), c.analogChannelPinsMap}:
-			}
-		default:
-			c.Log.Debug("Discarding unexpected command byte %0d\n", b)
-		}
-		if err != nil {
-			c.Log.Critical(err)
+		c.stateMu.Unlock()
+		c.versionReported(c.received)
+	case cmd == StartSysEx:
+		if len(frame) < 3 {
+			c.protocolError(MalformedMessage, fmt.Errorf("empty SysEx message"), frame, c.received)
 			return
 		}
+		c.parseSysEx(frame[1 : len(frame)-1])
+	default:
+		// A digital or analog message. Analog values are up to 14 bits, so
+		// don't truncate with from7Bit.
+		_, channelPins := c.analogChannels()
+		v := FirmataValue{cmd, int(frame[1]) | int(frame[2])<<7, channelPins, c.received}
+		if c.recordValue(v) && c.valueChan != nil && !deliver(c, QueueValues, c.valueChan, v) {
+			c.Log.Warn("Pin value channel full, dropped a value. No listener?")
+		}
 	}
 }
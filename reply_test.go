@@ -0,0 +1,121 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"io"
+	"testing"
+
+	"code.google.com/p/log4go"
+)
+
+// frameReader serves a version report and then frame n times, as many
+// whole frames to a read as fit, like a board streaming reports.
+type frameReader struct {
+	pending []byte
+	frame   []byte
+	n       int
+}
+
+func newFrameReader(frame []byte, n int) *frameReader {
+	return &frameReader{pending: []byte{byte(ReportVersion), 2, 5}, frame: frame, n: n}
+}
+
+func (r *frameReader) Read(p []byte) (int, error) {
+	if len(r.pending) > 0 {
+		n := copy(p, r.pending)
+		r.pending = r.pending[n:]
+		return n, nil
+	}
+	if r.n == 0 {
+		return 0, io.EOF
+	}
+	var n int
+	for r.n > 0 && n+len(r.frame) <= len(p) {
+		n += copy(p[n:], r.frame)
+		r.n--
+	}
+	if n == 0 {
+		// A frame larger than the buffer is split.
+		r.pending = append([]byte(nil), r.frame...)
+		r.n--
+		return r.Read(p)
+	}
+	return n, nil
+}
+
+// benchmarkClient returns a client with no connection, which logs
+// nothing, for running the reader on its own.
+func benchmarkClient() *FirmataClient {
+	l := make(log4go.Logger)
+	return &FirmataClient{
+		Log:        &l,
+		queues:     queueConfigs(&options{}),
+		done:       make(chan bool),
+		readerDone: make(chan bool),
+	}
+}
+
+func BenchmarkReplyReader(b *testing.B) {
+	for _, bm := range []struct {
+		name  string
+		frame []byte
+	}{
+		{"analog", []byte{byte(AnalogMessage) | 2, 0x7f, 0x03}},
+		{"digital", []byte{byte(DigitalMessage) | 1, 0x55, 0x01}},
+		{"sysex", []byte{byte(StartSysEx), byte(StringData), 'o', 0, 'k', 0, byte(EndSysEx)}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			c := benchmarkClient()
+			r := newFrameReader(bm.frame, b.N)
+			b.ReportAllocs()
+			b.SetBytes(int64(len(bm.frame)))
+			b.ResetTimer()
+			if err := c.replyReader(r); err != io.EOF {
+				b.Fatalf("replyReader returned %v, want EOF", err)
+			}
+		})
+	}
+}
+
+// SPI replies have SysEx command 0x80, which has the top bit set like a
+// command byte.
+func TestDecoderSysExCommandTopBit(t *testing.T) {
+	frame := []byte{byte(StartSysEx), byte(SysExSPI), byte(SPIComm), 1, 0x02, 0x7f, byte(EndSysEx)}
+	for _, dir := range []TraceDirection{Received, Sent} {
+		msgs := NewDecoder(dir).Feed(frame)
+		if len(msgs) != 1 || msgs[0].Err != nil {
+			t.Fatalf("%v: decoded %v, want one message", dir, msgs)
+		}
+		if got := msgs[0].SysEx(); got != SysExSPI {
+			t.Errorf("%v: SysEx() = %v, want %v", dir, got, SysExSPI)
+		}
+		if got, want := msgs[0].Data(), frame[2:len(frame)-1]; string(got) != string(want) {
+			t.Errorf("%v: Data() = % x, want % x", dir, got, want)
+		}
+	}
+}
+
+// A command byte straight after StartSysEx is still the SysEx command,
+// but one later in the frame cuts it off.
+func TestDecoderSysExCutOff(t *testing.T) {
+	msgs := NewDecoder(Received).Feed([]byte{byte(StartSysEx), byte(SysExSPI), 1, byte(ReportVersion), 2, 5})
+	if len(msgs) != 2 || msgs[0].Err == nil || msgs[0].Err.Type != MalformedMessage {
+		t.Fatalf("decoded %v, want a malformed message and a version report", msgs)
+	}
+	if msgs[1].Err != nil || msgs[1].Command() != ReportVersion {
+		t.Errorf("decoded %v, want a version report", msgs[1])
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"context"
+)
+
+// RequestInfo describes a request to the board which waits for a reply.
+type RequestInfo struct {
+	// Op names the request, such as "I2C read".
+	Op string
+	// Command is the SysEx command sent, or zero for a request which is
+	// not a SysEx message.
+	Command SysExCommand
+	// Pin is the pin, or for I2C the device address, or -1 if the request
+	// is not for one.
+	Pin int
+	// Size is the number of bytes requested or sent.
+	Size int
+}
+
+// RequestTracer follows requests to the board, for example as spans in a
+// distributed trace. StartRequest is called as a request begins, and may
+// return a derived context. The returned function is called once with the
+// outcome: the size of the reply, in bytes or for searches and queries in
+// items found, and the error, which is nil on success.
+type RequestTracer interface {
+	StartRequest(ctx context.Context, r RequestInfo) (context.Context, func(replySize int, err error))
+}
+
+// SetRequestTracer passes every request which waits for a reply to t,
+// until called with nil.
+func (c *FirmataClient) SetRequestTracer(t RequestTracer) {
+	c.metricsMu.Lock()
+	defer c.metricsMu.Unlock()
+	c.tracer = t
+}
+
+// startRequest begins tracing and timing a request. The returned function
+// must be called with the outcome.
+func (c *FirmataClient) startRequest(ctx context.Context, r RequestInfo) (context.Context, func(replySize int, err error)) {
+	start := c.now()
+	c.metricsMu.Lock()
+	t, m := c.tracer, c.metrics
+	c.metricsMu.Unlock()
+	var end func(int, error)
+	if t != nil {
+		ctx, end = t.StartRequest(ctx, r)
+	}
+	return ctx, func(replySize int, err error) {
+		c.debug.requestDone(r.Op, err, c.now())
+		if m != nil {
+			m.RequestDone(r.Op, c.since(start), err)
+		}
+		if end != nil {
+			end(replySize, err)
+		}
+	}
+}
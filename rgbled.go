@@ -0,0 +1,225 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"image/color"
+	"math"
+	"sync"
+	"time"
+)
+
+// rgbStep is the update interval of fades and blinks.
+const rgbStep = 20 * time.Millisecond
+
+// HSV is a colour in the hue, saturation, value model. H is in degrees,
+// S and V are from 0 to 1. It implements color.Color so can be passed to
+// RgbLed.SetColor.
+type HSV struct {
+	H, S, V float64
+}
+
+// RGBA implements color.Color.
+func (c HSV) RGBA() (r, g, b, a uint32) {
+	h := math.Mod(c.H, 360)
+	if h < 0 {
+		h += 360
+	}
+	chroma := c.V * c.S
+	x := chroma * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	var rf, gf, bf float64
+	switch {
+	case h < 60:
+		rf, gf = chroma, x
+	case h < 120:
+		rf, gf = x, chroma
+	case h < 180:
+		gf, bf = chroma, x
+	case h < 240:
+		gf, bf = x, chroma
+	case h < 300:
+		rf, bf = x, chroma
+	default:
+		rf, bf = chroma, x
+	}
+	m := c.V - chroma
+	scale := func(f float64) uint32 { return uint32((f+m)*0xffff + 0.5) }
+	return scale(rf), scale(gf), scale(bf), 0xffff
+}
+
+// RgbLed is an RGB LED driven by three PWM pins.
+type RgbLed struct {
+	// The client.
+	Client *FirmataClient
+	// The PWM pins for each colour.
+	RedPin, GreenPin, BluePin byte
+	// CommonAnode inverts the outputs, for LEDs wired to the positive rail.
+	CommonAnode bool
+	// Gamma is the exponent applied to each channel so that brightness
+	// appears linear. 1 disables correction.
+	Gamma float64
+
+	mu    sync.Mutex
+	color color.RGBA
+	stop  chan bool
+}
+
+// NewRgbLed creates an LED on the PWM pins, with a gamma of 2.2, and
+// turns it off.
+func NewRgbLed(client *FirmataClient, red, green, blue byte) (*RgbLed, error) {
+	l := &RgbLed{
+		Client:   client,
+		RedPin:   red,
+		GreenPin: green,
+		BluePin:  blue,
+		Gamma:    2.2,
+	}
+	for _, p := range []byte{red, green, blue} {
+		if err := client.SetPinMode(p, PWM); err != nil {
+			return nil, err
+		}
+	}
+	return l, l.SetColor(color.Black)
+}
+
+// Color returns the colour the LED is currently showing.
+func (l *RgbLed) Color() color.RGBA {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.color
+}
+
+// SetColor stops any fade or blink and sets the LED to c.
+func (l *RgbLed) SetColor(c color.Color) error {
+	l.Stop()
+	return l.write(color.RGBAModel.Convert(c).(color.RGBA))
+}
+
+// SetRGB sets the LED to the red, green and blue levels.
+func (l *RgbLed) SetRGB(r, g, b byte) error {
+	return l.SetColor(color.RGBA{r, g, b, 0xff})
+}
+
+// SetHSV sets the LED to a hue in degrees, and a saturation and value
+// from 0 to 1.
+func (l *RgbLed) SetHSV(h, s, v float64) error {
+	return l.SetColor(HSV{h, s, v})
+}
+
+// Off turns the LED off.
+func (l *RgbLed) Off() error {
+	return l.SetColor(color.Black)
+}
+
+// Fade changes the LED smoothly from its current colour to c over d. It
+// returns immediately, and the fade is cancelled by any later change.
+func (l *RgbLed) Fade(c color.Color, d time.Duration) {
+	from := l.Color()
+	to := color.RGBAModel.Convert(c).(color.RGBA)
+	start := l.Client.now()
+	l.animate(func(now time.Time) (color.RGBA, bool) {
+		f := float64(now.Sub(start)) / float64(d)
+		if f >= 1 {
+			return to, false
+		}
+		mix := func(a, b uint8) uint8 { return uint8(float64(a) + (float64(b)-float64(a))*f + 0.5) }
+		return color.RGBA{mix(from.R, to.R), mix(from.G, to.G), mix(from.B, to.B), 0xff}, true
+	})
+}
+
+// Blink switches the LED between c and off, spending period in each,
+// until cancelled by any later change. It returns immediately.
+func (l *RgbLed) Blink(c color.Color, period time.Duration) {
+	on := color.RGBAModel.Convert(c).(color.RGBA)
+	off := color.RGBA{A: 0xff}
+	start := l.Client.now()
+	l.animate(func(now time.Time) (color.RGBA, bool) {
+		if (now.Sub(start)/period)%2 == 0 {
+			return on, true
+		}
+		return off, true
+	})
+}
+
+// Stop cancels any fade or blink, leaving the LED at its current colour.
+func (l *RgbLed) Stop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stop != nil {
+		close(l.stop)
+		l.stop = nil
+	}
+}
+
+// animate replaces any running animation with one which sets the colour
+// returned by step every rgbStep, until step returns false.
+func (l *RgbLed) animate(step func(time.Time) (color.RGBA, bool)) {
+	l.Stop()
+	stop := make(chan bool)
+	l.mu.Lock()
+	l.stop = stop
+	l.mu.Unlock()
+
+	go func() {
+		t := l.Client.Clock().NewTicker(rgbStep)
+		defer t.Stop()
+		last := l.Color()
+		for {
+			c, more := step(l.Client.now())
+			if c != last {
+				if err := l.write(c); err != nil {
+					l.Client.Log.Warn("RGB LED write: %s", err.Error())
+				}
+				last = c
+			}
+			if !more {
+				return
+			}
+			select {
+			case <-t.C():
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// write sets the PWM outputs for c.
+func (l *RgbLed) write(c color.RGBA) error {
+	for _, ch := range []struct {
+		pin   byte
+		level uint8
+	}{{l.RedPin, c.R}, {l.GreenPin, c.G}, {l.BluePin, c.B}} {
+		if err := l.Client.AnalogWrite(uint(ch.pin), l.correct(ch.level)); err != nil {
+			return err
+		}
+	}
+	l.mu.Lock()
+	l.color = c
+	l.mu.Unlock()
+	return nil
+}
+
+// correct applies gamma correction and inversion to a channel level.
+func (l *RgbLed) correct(level uint8) byte {
+	out := level
+	if l.Gamma > 0 && l.Gamma != 1 {
+		out = uint8(math.Pow(float64(level)/255, l.Gamma)*255 + 0.5)
+	}
+	if l.CommonAnode {
+		out = 255 - out
+	}
+	return out
+}
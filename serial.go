@@ -0,0 +1,119 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !js
+
+package firmata
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/tarm/goserial"
+)
+
+// DefaultSerialBackend is the backend Open and SerialDialer use unless
+// given another with WithSerialBackend.
+var DefaultSerialBackend SerialBackend = TarmSerial{}
+
+// Open connects to a board on serial port dev, as for NewClient. The
+// port is opened and the board reset as set by WithSerialBackend and
+// WithSerialReset. It is not available in the browser, where
+// OpenWebSerial is used instead.
+func Open(dev string, baud int, opts ...Option) (*FirmataClient, error) {
+	conn, err := SerialDialer(dev, baud, opts...)()
+	if err != nil {
+		return nil, err
+	}
+	client, err := NewClient(conn, opts...)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	client.serialDev = dev
+	client.baud = baud
+	return client, nil
+}
+
+// SerialDialer returns a function opening serial port dev, for use with
+// WithReconnect. Of opts, only WithSerialBackend and WithSerialReset
+// apply.
+func SerialDialer(dev string, baud int, opts ...Option) func() (io.ReadWriteCloser, error) {
+	o := newOptions(opts)
+	return func() (io.ReadWriteCloser, error) {
+		return openSerial(dev, baud, o)
+	}
+}
+
+// openSerial opens and resets the board on dev as the options say.
+func openSerial(dev string, baud int, o *options) (SerialConn, error) {
+	backend := o.serialBackend
+	if backend == nil {
+		backend = DefaultSerialBackend
+	}
+	conn, err := backend.Open(dev, SerialMode{Baud: baud, NoReset: o.serialReset == ResetNone})
+	if err != nil {
+		return nil, err
+	}
+	switch o.serialReset {
+	case ResetNone:
+		return conn, nil
+	case ResetDTR:
+		err = pulseLine(conn.SetDTR, false)
+	case ResetRTS:
+		if err = conn.SetDTR(false); err == nil {
+			err = pulseLine(conn.SetRTS, true)
+		}
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("resetting board on %s: %w", dev, err)
+	}
+	wait := o.resetWait
+	if wait <= 0 {
+		wait = time.Second
+	}
+	time.Sleep(wait)
+	return conn, nil
+}
+
+// pulseLine sets a line to level for resetPulse, then back.
+func pulseLine(set func(bool) error, level bool) error {
+	if err := set(level); err != nil {
+		return err
+	}
+	time.Sleep(resetPulse)
+	return set(!level)
+}
+
+// TarmSerial is the SerialBackend of github.com/tarm/goserial. It cannot
+// set DTR or RTS, so only ResetOnOpen works with it.
+type TarmSerial struct{}
+
+// Open opens dev.
+func (TarmSerial) Open(dev string, mode SerialMode) (SerialConn, error) {
+	conn, err := serial.OpenPort(&serial.Config{Name: dev, Baud: mode.Baud})
+	if err != nil {
+		return nil, err
+	}
+	return tarmConn{conn}, nil
+}
+
+type tarmConn struct {
+	io.ReadWriteCloser
+}
+
+func (tarmConn) SetDTR(on bool) error { return ErrNoModemControl }
+func (tarmConn) SetRTS(on bool) error { return ErrNoModemControl }
@@ -0,0 +1,100 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNoModemControl is returned by a SerialConn which cannot set the DTR
+// or RTS line.
+var ErrNoModemControl = errors.New("serial port has no modem control")
+
+// SerialConn is an open serial port.
+type SerialConn interface {
+	io.ReadWriteCloser
+	// SetDTR and SetRTS assert or clear the DTR and RTS lines, returning
+	// ErrNoModemControl if the backend cannot.
+	SetDTR(on bool) error
+	SetRTS(on bool) error
+}
+
+// SerialMode is how a SerialBackend opens a port.
+type SerialMode struct {
+	Baud int
+	// NoReset opens the port with DTR and RTS clear, if the backend can,
+	// rather than asserting them as usual. Boards which reset when DTR is
+	// asserted then keep running.
+	NoReset bool
+}
+
+// SerialBackend opens serial ports, for Open and SerialDialer. Backends
+// for other serial libraries, or for ports which are not serial devices,
+// can be given with WithSerialBackend.
+type SerialBackend interface {
+	Open(dev string, mode SerialMode) (SerialConn, error)
+}
+
+// SerialBackendFunc adapts a function to a SerialBackend.
+type SerialBackendFunc func(dev string, mode SerialMode) (SerialConn, error)
+
+// Open calls f.
+func (f SerialBackendFunc) Open(dev string, mode SerialMode) (SerialConn, error) {
+	return f(dev, mode)
+}
+
+// SerialReset is how Open and SerialDialer reset the board after opening
+// its port.
+type SerialReset int
+
+const (
+	// ResetOnOpen relies on opening the port to reset the board, as
+	// asserting DTR does on most Arduinos, and waits for it to start.
+	ResetOnOpen SerialReset = iota
+	// ResetDTR clears and then asserts DTR, resetting boards wired like
+	// the Arduino Uno even if the port was already open, and waits for
+	// the board to start.
+	ResetDTR
+	// ResetRTS asserts and then clears RTS, with DTR clear, resetting
+	// boards whose enable line is driven by RTS, such as ESP32 and
+	// ESP8266 development boards, and waits for the board to start.
+	ResetRTS
+	// ResetNone opens the port without asserting DTR and RTS where the
+	// backend can, and does not wait, for a board which is kept running
+	// or does not reset.
+	ResetNone
+)
+
+// resetPulse is how long a reset line is held.
+const resetPulse = 100 * time.Millisecond
+
+// WithSerialBackend sets the backend Open and SerialDialer open the port
+// with, DefaultSerialBackend by default.
+func WithSerialBackend(b SerialBackend) Option {
+	return func(o *options) { o.serialBackend = b }
+}
+
+// WithSerialReset sets how Open and SerialDialer reset the board, and
+// how long they wait for it to start afterwards, one second by default.
+func WithSerialReset(r SerialReset, wait time.Duration) Option {
+	return func(o *options) {
+		o.serialReset = r
+		if wait > 0 {
+			o.resetWait = wait
+		}
+	}
+}
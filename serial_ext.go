@@ -22,7 +22,11 @@ func (c *FirmataClient) SerialConfig(port SerialPort, baud int, txPin byte, rxPi
 	baudBytes := intto7Bit(baud)
 	bufferSize := intto7Bit(1024)
 	termChar := to7Bit('\n')
-	c.serialChan = make(chan string, 10)
+	c.busMu.Lock()
+	if c.serialChan == nil {
+		c.serialChan = make(chan string, c.Queue(QueueSerial).Size)
+	}
+	c.busMu.Unlock()
 
 	err = c.sendSysEx(Serial, byte(SerialConfig)|byte(port),
 		baudBytes[0], baudBytes[1], baudBytes[2],
@@ -33,18 +37,25 @@ func (c *FirmataClient) SerialConfig(port SerialPort, baud int, txPin byte, rxPi
 
 // Get channel for incoming serial data
 func (c *FirmataClient) GetSerialData() <-chan string {
+	c.busMu.Lock()
+	defer c.busMu.Unlock()
 	return c.serialChan
 }
 
 func (c *FirmataClient) parseSerialResponse(data7bit []byte) {
 
-	data := make([]byte, 0)
-	for i := 1; i < len(data7bit); i = i + 2 {
+	data := make([]byte, 0, len(data7bit)/2)
+	for i := 1; i+1 < len(data7bit); i = i + 2 {
 		data = append(data, byte(from7Bit(data7bit[i], data7bit[i+1])))
 	}
-	select {
-	case c.serialChan <- string(data):
-	default:
+	c.busMu.Lock()
+	ch := c.serialChan
+	c.busMu.Unlock()
+	if ch == nil {
+		c.Log.Debug("Discarding serial data, serial not configured")
+		return
+	}
+	if !deliver(c, QueueSerial, ch, string(data)) {
 		c.Log.Critical("Serial data buffer overflow. No listener?")
 	}
 }
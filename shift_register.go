@@ -0,0 +1,141 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+)
+
+const (
+	shiftOut      = 0x01
+	shiftMsbFirst = 0x01
+)
+
+// ShiftRegister is a chain of 74HC595 shift registers, presented as 8
+// virtual output pins per register. Pin 0 is output Q0 of the register
+// nearest the board.
+//
+// Changes made with Set are held until Latch, so any number of outputs
+// can be updated in a single latch cycle. If the data pin supports the
+// Shift pin mode the data is sent with one SHIFT_DATA message, otherwise
+// it is bit-banged with digital writes.
+type ShiftRegister struct {
+	// The client.
+	Client *FirmataClient
+	// DataPin, ClockPin and LatchPin are wired to the register SER, SRCLK
+	// and RCLK inputs.
+	DataPin  byte
+	ClockPin byte
+	LatchPin byte
+	// Count is the number of registers in the chain.
+	Count int
+
+	state    []byte
+	useShift bool
+}
+
+// Init configures the pins and clears all outputs.
+func (s *ShiftRegister) Init() error {
+	if s.Count < 1 {
+		return fmt.Errorf("shift register count must be at least 1")
+	}
+	s.state = make([]byte, s.Count)
+	s.useShift = s.Client.supports(s.DataPin, Shift)
+
+	dataMode := Output
+	if s.useShift {
+		dataMode = Shift
+	}
+	if err := s.Client.SetPinMode(s.DataPin, dataMode); err != nil {
+		return err
+	}
+	if err := s.Client.SetPinMode(s.ClockPin, Output); err != nil {
+		return err
+	}
+	if err := s.Client.SetPinMode(s.LatchPin, Output); err != nil {
+		return err
+	}
+	return s.Latch()
+}
+
+// Pins returns the number of virtual output pins.
+func (s *ShiftRegister) Pins() int {
+	return 8 * s.Count
+}
+
+// Set changes the value of a virtual pin, to be sent on the next Latch.
+func (s *ShiftRegister) Set(pin int, val bool) error {
+	if pin < 0 || pin >= s.Pins() {
+		return fmt.Errorf("%w %v on shift register", ErrInvalidPin, pin)
+	}
+	if val {
+		s.state[pin/8] |= 1 << uint(pin%8)
+	} else {
+		s.state[pin/8] &^= 1 << uint(pin%8)
+	}
+	return nil
+}
+
+// Write sets the value of a virtual pin and latches it immediately.
+func (s *ShiftRegister) Write(pin int, val bool) error {
+	if err := s.Set(pin, val); err != nil {
+		return err
+	}
+	return s.Latch()
+}
+
+// Latch shifts out the state of every virtual pin and latches it to the
+// outputs.
+func (s *ShiftRegister) Latch() error {
+	if err := s.Client.DigitalWrite(uint(s.LatchPin), false); err != nil {
+		return err
+	}
+	// The last register in the chain is shifted out first.
+	var err error
+	if s.useShift {
+		err = s.shiftData()
+	} else {
+		err = s.bitBang()
+	}
+	if err != nil {
+		return err
+	}
+	return s.Client.DigitalWrite(uint(s.LatchPin), true)
+}
+
+func (s *ShiftRegister) shiftData() error {
+	data := []byte{shiftOut, s.DataPin, s.ClockPin, shiftMsbFirst}
+	for i := len(s.state) - 1; i >= 0; i-- {
+		data = append(data, to7Bit(s.state[i])...)
+	}
+	return s.Client.sendSysEx(ShiftData, data...)
+}
+
+func (s *ShiftRegister) bitBang() error {
+	for i := len(s.state) - 1; i >= 0; i-- {
+		for bit := 7; bit >= 0; bit-- {
+			if err := s.Client.DigitalWrite(uint(s.DataPin), s.state[i]&(1<<uint(bit)) > 0); err != nil {
+				return err
+			}
+			if err := s.Client.DigitalWrite(uint(s.ClockPin), true); err != nil {
+				return err
+			}
+			if err := s.Client.DigitalWrite(uint(s.ClockPin), false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Snapshot records how the board has been set up through the client, so
+// it can be set up again with Restore, for example after reconnecting or
+// on a replacement board. It can be encoded as JSON.
+type Snapshot struct {
+	// Modes is the mode of each pin which has been set.
+	Modes map[byte]PinMode `json:"modes,omitempty"`
+	// DigitalOutputs is the value of each pin in Output mode.
+	DigitalOutputs map[byte]bool `json:"digital_outputs,omitempty"`
+	// AnalogOutputs is the last value written to each PWM or servo pin.
+	AnalogOutputs map[byte]byte `json:"analog_outputs,omitempty"`
+	// DigitalReports is the ports with digital reporting enabled.
+	DigitalReports []byte `json:"digital_reports,omitempty"`
+	// AnalogReports is the pins with analog reporting enabled.
+	AnalogReports []byte `json:"analog_reports,omitempty"`
+	// I2CDelay is the I2C read delay, or nil if I2C is not configured.
+	I2CDelay *int `json:"i2c_delay,omitempty"`
+	// OneWire is the power mode of each OneWire pin.
+	OneWire map[byte]byte `json:"onewire,omitempty"`
+	// SPI is the mode of each SPI chip select pin.
+	SPI map[byte]byte `json:"spi,omitempty"`
+	// SamplingInterval is the analog sampling interval in milliseconds, or
+	// zero if it has not been set.
+	SamplingInterval byte `json:"sampling_interval,omitempty"`
+}
+
+// boardConfig is the setup recorded for Snapshot and BoardConfig, other
+// than pin modes and digital outputs which the client keeps anyway.
+type boardConfig struct {
+	digitalReports   map[byte]bool
+	analogReports    map[byte]bool
+	analogOutputs    map[byte]byte
+	i2cDelay         *int
+	oneWire          map[byte]byte
+	spi              map[byte]byte
+	samplingInterval byte
+	// userSampling is the sampling interval set with
+	// SetAnalogSamplingInterval, which report rates may only shorten.
+	userSampling byte
+	// calibrations are the calibrations set from a BoardConfig.
+	calibrations map[byte]*Calibration
+	// drivers are the drivers attached by AttachDriver, in order.
+	drivers []*attachedDriver
+}
+
+// Snapshot returns the current setup of the board.
+func (c *FirmataClient) Snapshot() *Snapshot {
+	s := &Snapshot{
+		Modes:          make(map[byte]PinMode),
+		DigitalOutputs: make(map[byte]bool),
+		AnalogOutputs:  make(map[byte]byte),
+		OneWire:        make(map[byte]byte),
+		SPI:            make(map[byte]byte),
+	}
+	c.modeMu.Lock()
+	for pin, mode := range c.currentModes {
+		s.Modes[pin] = mode
+	}
+	c.modeMu.Unlock()
+
+	c.outputMu.Lock()
+	for pin, mode := range s.Modes {
+		if mode == Output && int(pin/8) < len(c.digitalPinState) {
+			s.DigitalOutputs[pin] = c.digitalPinState[pin/8]&(1<<(pin%8)) > 0
+		}
+	}
+	c.outputMu.Unlock()
+
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
+	cfg := &c.config
+	for pin, v := range cfg.analogOutputs {
+		s.AnalogOutputs[pin] = v
+	}
+	s.DigitalReports = sortedPins(cfg.digitalReports)
+	s.AnalogReports = sortedPins(cfg.analogReports)
+	if cfg.i2cDelay != nil {
+		delay := *cfg.i2cDelay
+		s.I2CDelay = &delay
+	}
+	for pin, power := range cfg.oneWire {
+		s.OneWire[pin] = power
+	}
+	for pin, mode := range cfg.spi {
+		s.SPI[pin] = mode
+	}
+	s.SamplingInterval = cfg.samplingInterval
+	return s
+}
+
+// Restore sets up the board as recorded in s: pin modes, then outputs,
+// then I2C, OneWire and SPI, the sampling interval, and finally reporting.
+// It stops at the first error.
+func (c *FirmataClient) Restore(s *Snapshot) error {
+	for _, pin := range sortedModes(s.Modes) {
+		if err := c.SetPinMode(pin, s.Modes[pin]); err != nil {
+			return fmt.Errorf("restore mode of pin %v: %w", c.PinLabel(pin), err)
+		}
+	}
+	for pin, v := range s.DigitalOutputs {
+		if err := c.DigitalWrite(uint(pin), v); err != nil {
+			return fmt.Errorf("restore output of pin %v: %w", c.PinLabel(pin), err)
+		}
+	}
+	for pin, v := range s.AnalogOutputs {
+		if err := c.AnalogWrite(uint(pin), v); err != nil {
+			return fmt.Errorf("restore output of pin %v: %w", c.PinLabel(pin), err)
+		}
+	}
+	if s.I2CDelay != nil {
+		if err := c.I2CConfig(*s.I2CDelay); err != nil {
+			return fmt.Errorf("restore I2C: %w", err)
+		}
+	}
+	for pin, power := range s.OneWire {
+		if err := c.OneWireConfig(pin, power); err != nil {
+			return fmt.Errorf("restore OneWire on pin %v: %w", c.PinLabel(pin), err)
+		}
+	}
+	for pin, mode := range s.SPI {
+		if err := c.SPIConfig(pin, mode); err != nil {
+			return fmt.Errorf("restore SPI on pin %v: %w", c.PinLabel(pin), err)
+		}
+	}
+	if s.SamplingInterval > 0 {
+		if err := c.SetAnalogSamplingInterval(s.SamplingInterval); err != nil {
+			return fmt.Errorf("restore sampling interval: %w", err)
+		}
+	}
+	for _, port := range s.DigitalReports {
+		if err := c.EnableDigitalInput(uint(port)*8, true); err != nil {
+			return fmt.Errorf("restore reporting of port %v: %w", port, err)
+		}
+	}
+	for _, pin := range s.AnalogReports {
+		if err := c.EnableAnalogInput(uint(pin), true); err != nil {
+			return fmt.Errorf("restore reporting of pin %v: %w", c.PinLabel(pin), err)
+		}
+	}
+	return nil
+}
+
+// recordConfig updates the setup recorded for Snapshot and BoardConfig.
+func (c *FirmataClient) recordConfig(f func(cfg *boardConfig)) {
+	c.configMu.Lock()
+	defer c.configMu.Unlock()
+	cfg := &c.config
+	if cfg.digitalReports == nil {
+		cfg.digitalReports = make(map[byte]bool)
+		cfg.analogReports = make(map[byte]bool)
+		cfg.analogOutputs = make(map[byte]byte)
+		cfg.oneWire = make(map[byte]byte)
+		cfg.spi = make(map[byte]byte)
+		cfg.calibrations = make(map[byte]*Calibration)
+	}
+	f(cfg)
+}
+
+func sortedPins(set map[byte]bool) []byte {
+	var pins []byte
+	for p, on := range set {
+		if on {
+			pins = append(pins, p)
+		}
+	}
+	sort.Slice(pins, func(i, j int) bool { return pins[i] < pins[j] })
+	return pins
+}
+
+func sortedModes(modes map[byte]PinMode) []byte {
+	var pins []byte
+	for p := range modes {
+		pins = append(pins, p)
+	}
+	sort.Slice(pins, func(i, j int) bool { return pins[i] < pins[j] })
+	return pins
+}
@@ -0,0 +1,154 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SoftPwmMaxFrequency is the highest frequency accepted by SoftPwm.
+const SoftPwmMaxFrequency = 50
+
+// SoftPwm is PWM generated by the host, toggling a digital output with
+// individual writes. It suits slow loads such as heaters, fans and relays
+// which don't need a hardware PWM pin.
+//
+// Every edge is a message over the serial link, timed by the Go scheduler,
+// so edges jitter by several milliseconds and by more under load or on a
+// busy link. Frequencies are limited to SoftPwmMaxFrequency, and duties
+// giving pulses shorter than a few milliseconds will be inaccurate.
+type SoftPwm struct {
+	client *FirmataClient
+	pin    byte
+
+	mu        sync.Mutex
+	frequency float64
+	duty      float64
+	update    chan bool
+	stop      chan bool
+	done      chan bool
+	once      sync.Once
+}
+
+// SoftPwm starts software PWM on pin at frequency Hz and duty from 0 to 1.
+// The pin is driven low when the SoftPwm is stopped or the client closed.
+func (c *FirmataClient) SoftPwm(pin byte, frequency float64, duty float64) (*SoftPwm, error) {
+	if err := checkSoftPwm(frequency, duty); err != nil {
+		return nil, err
+	}
+	if err := c.SetPinMode(pin, Output); err != nil {
+		return nil, err
+	}
+	s := &SoftPwm{
+		client:    c,
+		pin:       pin,
+		frequency: frequency,
+		duty:      duty,
+		update:    make(chan bool, 1),
+		stop:      make(chan bool),
+		done:      make(chan bool),
+	}
+	c.onClose(s.Stop)
+	go s.run()
+	return s, nil
+}
+
+// SetDuty changes the duty, from 0 to 1, from the next cycle.
+func (s *SoftPwm) SetDuty(duty float64) error {
+	return s.set(s.Frequency(), duty)
+}
+
+// SetFrequency changes the frequency, from the next cycle.
+func (s *SoftPwm) SetFrequency(frequency float64) error {
+	s.mu.Lock()
+	duty := s.duty
+	s.mu.Unlock()
+	return s.set(frequency, duty)
+}
+
+// Frequency returns the frequency in Hz.
+func (s *SoftPwm) Frequency() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.frequency
+}
+
+// Stop stops the PWM and drives the pin low.
+func (s *SoftPwm) Stop() {
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+}
+
+func (s *SoftPwm) set(frequency, duty float64) error {
+	if err := checkSoftPwm(frequency, duty); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.frequency, s.duty = frequency, duty
+	s.mu.Unlock()
+	select {
+	case s.update <- true:
+	default:
+	}
+	return nil
+}
+
+func (s *SoftPwm) run() {
+	defer close(s.done)
+	defer func() {
+		if err := s.client.DigitalWrite(uint(s.pin), false); err != nil {
+			s.client.Log.Warn("Soft PWM on pin %v: %s", s.client.PinLabel(s.pin), err.Error())
+		}
+	}()
+cycle:
+	for {
+		s.mu.Lock()
+		period := time.Duration(float64(time.Second) / s.frequency)
+		on := time.Duration(float64(period) * s.duty)
+		s.mu.Unlock()
+
+		for _, phase := range []struct {
+			level bool
+			d     time.Duration
+		}{{true, on}, {false, period - on}} {
+			if phase.d <= 0 {
+				continue
+			}
+			if err := s.client.DigitalWrite(uint(s.pin), phase.level); err != nil {
+				s.client.Log.Warn("Soft PWM on pin %v: %s", s.client.PinLabel(s.pin), err.Error())
+			}
+			select {
+			case <-s.client.after(phase.d):
+			case <-s.update:
+				// Start a new cycle with the new settings.
+				continue cycle
+			case <-s.stop:
+				return
+			}
+		}
+	}
+}
+
+func checkSoftPwm(frequency, duty float64) error {
+	if frequency <= 0 || frequency > SoftPwmMaxFrequency {
+		return fmt.Errorf("Soft PWM frequency %v outside 0-%vHz", frequency, SoftPwmMaxFrequency)
+	}
+	if duty < 0 || duty > 1 {
+		return fmt.Errorf("Soft PWM duty %v outside 0-1", duty)
+	}
+	return nil
+}
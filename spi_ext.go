@@ -14,22 +14,56 @@
 
 package firmata
 
+import (
+	"context"
+	"fmt"
+)
+
 type SPISubCommand byte
 
 // Enable SPI communication for selected chip-select pin
 func (c *FirmataClient) SPIConfig(csPin byte, spiMode byte) (err error) {
 	csPinBytes := to7Bit(csPin)
 	spiModeBytes := to7Bit(spiMode)
-	c.spiChan = make(chan []byte)
+	c.busMu.Lock()
+	if c.spiChan == nil {
+		c.spiChan = make(chan []byte, 1)
+	}
+	c.busMu.Unlock()
 
 	err = c.sendSysEx(SysExSPI, byte(SPIConfig),
 		csPinBytes[0], csPinBytes[1],
 		spiModeBytes[0], spiModeBytes[1])
+	if err == nil {
+		c.recordConfig(func(cfg *boardConfig) { cfg.spi[csPin] = spiMode })
+	}
 	return
 }
 
 // Read and write data to SPI device
 func (c *FirmataClient) SPIReadWrite(csPin byte, data []byte) (dataOut []byte, err error) {
+	return c.SPIReadWriteContext(context.Background(), csPin, data)
+}
+
+// SPIReadWriteContext is SPIReadWrite, giving up if ctx is done or the
+// request times out before the device replies.
+func (c *FirmataClient) SPIReadWriteContext(ctx context.Context, csPin byte, data []byte) (dataOut []byte, err error) {
+	ctx, done := c.startRequest(ctx, RequestInfo{Op: "SPI transfer", Command: SysExSPI, Pin: int(csPin), Size: len(data)})
+	defer func() { done(len(dataOut), err) }()
+	ctx, cancel := c.requestContext(ctx)
+	defer cancel()
+	c.spiReq.Lock()
+	defer c.spiReq.Unlock()
+	c.busMu.Lock()
+	ch := c.spiChan
+	c.busMu.Unlock()
+	if ch == nil {
+		return nil, fmt.Errorf("%w: SPI not configured", ErrFeatureMissing)
+	}
+	select {
+	case <-ch:
+	default:
+	}
 	csPinBytes := to7Bit(csPin)
 	data7Bit := []byte{byte(SPIComm)}
 
@@ -39,17 +73,32 @@ func (c *FirmataClient) SPIReadWrite(csPin byte, data []byte) (dataOut []byte, e
 		data7Bit = append(data7Bit, bytes...)
 	}
 
-	err = c.sendSysEx(SysExSPI, data7Bit...)
-	dataOut = <- c.spiChan
+	if err = c.sendRequest(SysExSPI, data7Bit...); err != nil {
+		return
+	}
+	select {
+	case dataOut = <-ch:
+	case <-ctx.Done():
+		err = requestError(ctx, "SPI transfer")
+	case <-c.done:
+		err = closedError("SPI transfer")
+	}
 	return
 }
 
 func (c *FirmataClient) parseSPIResponse(data7bit []byte) {
-	data := make([]byte,0)
+	data := make([]byte, 0, len(data7bit)/2)
 	for i, _ := range data7bit {
-		if i >=3 && i%2 != 0 {
+		if i >=3 && i%2 != 0 && i+1 < len(data7bit) {
 			data = append(data, from7Bit(data7bit[i], data7bit[i+1]))
 		}
 	}
-	c.spiChan <- data
+	c.busMu.Lock()
+	ch := c.spiChan
+	c.busMu.Unlock()
+	select {
+	case ch <- data:
+	default:
+		c.Log.Warn("Discarding SPI reply, no pending transfer")
+	}
 }
@@ -0,0 +1,227 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	// Ssd1306Address is the default I2C address of an SSD1306.
+	Ssd1306Address = 0x3c
+
+	ssd1306Command = 0x00
+	ssd1306Data    = 0x40
+
+	// ssd1306Chunk is the most display data sent in one I2C write. Each
+	// byte takes two in the sysex message, and the firmware buffer and
+	// the Wire library both limit the message size.
+	ssd1306Chunk = 16
+)
+
+// ssd1306Bulk sends display data and commands through the control byte,
+// which is given as the register and does not advance.
+var ssd1306Bulk = &I2CBulkOptions{ChunkSize: ssd1306Chunk, FixedRegister: true}
+
+// Ssd1306 is a Solomon SSD1306 monochrome OLED display controller. Drawing
+// is done to a local framebuffer, which is sent to the display by Display.
+type Ssd1306 struct {
+	// The client.
+	Client *FirmataClient
+	// The I2C address of the device.
+	Address byte
+	// Width and Height are the display size in pixels. Height must be 32
+	// or 64.
+	Width  int
+	Height int
+
+	buffer []byte
+	dirty  []bool
+}
+
+// Init configures the display and clears it.
+func (d *Ssd1306) Init() error {
+	if d.Width <= 0 || d.Width > 128 || (d.Height != 32 && d.Height != 64) {
+		return fmt.Errorf("unsupported display size %vx%v", d.Width, d.Height)
+	}
+	d.buffer = make([]byte, d.Width*d.Height/8)
+	d.dirty = make([]bool, (len(d.buffer)+ssd1306Chunk-1)/ssd1306Chunk)
+
+	comPins := byte(0x12)
+	if d.Height == 32 {
+		comPins = 0x02
+	}
+	err := d.command(
+		0xae,       // display off
+		0xd5, 0x80, // clock divide
+		0xa8, byte(d.Height-1), // multiplex ratio
+		0xd3, 0x00, // display offset
+		0x40,       // start line 0
+		0x8d, 0x14, // charge pump on
+		0x20, 0x00, // horizontal addressing
+		0xa1, // segment remap
+		0xc8, // COM scan decrement
+		0xda, comPins,
+		0x81, 0xcf, // contrast
+		0xd9, 0xf1, // precharge
+		0xdb, 0x40, // VCOMH deselect level
+		0xa4, // display follows RAM
+		0xa6, // normal, not inverted
+	)
+	if err != nil {
+		return err
+	}
+	d.Clear()
+	if err := d.Display(); err != nil {
+		return err
+	}
+	return d.On(true)
+}
+
+// On turns the display on or off.
+func (d *Ssd1306) On(on bool) error {
+	if on {
+		return d.command(0xaf)
+	}
+	return d.command(0xae)
+}
+
+// Invert sets whether the display is shown inverted.
+func (d *Ssd1306) Invert(invert bool) error {
+	if invert {
+		return d.command(0xa7)
+	}
+	return d.command(0xa6)
+}
+
+// Contrast sets the display contrast.
+func (d *Ssd1306) Contrast(contrast byte) error {
+	return d.command(0x81, contrast)
+}
+
+// Clear clears the framebuffer.
+func (d *Ssd1306) Clear() {
+	for i := range d.buffer {
+		d.buffer[i] = 0
+	}
+	for i := range d.dirty {
+		d.dirty[i] = true
+	}
+}
+
+// SetPixel sets or clears a pixel in the framebuffer. Pixels outside the
+// display are ignored.
+func (d *Ssd1306) SetPixel(x, y int, on bool) {
+	if x < 0 || x >= d.Width || y < 0 || y >= d.Height {
+		return
+	}
+	i := y/8*d.Width + x
+	old := d.buffer[i]
+	if on {
+		d.buffer[i] |= 1 << uint(y%8)
+	} else {
+		d.buffer[i] &^= 1 << uint(y%8)
+	}
+	if d.buffer[i] != old {
+		d.dirty[i/ssd1306Chunk] = true
+	}
+}
+
+// Line draws a line between two points in the framebuffer.
+func (d *Ssd1306) Line(x0, y0, x1, y1 int, on bool) {
+	dx, sx := x1-x0, 1
+	if dx < 0 {
+		dx, sx = -dx, -1
+	}
+	dy, sy := y1-y0, 1
+	if dy < 0 {
+		dy, sy = -dy, -1
+	}
+	e := dx - dy
+	for {
+		d.SetPixel(x0, y0, on)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 > -dy {
+			e -= dy
+			x0 += sx
+		}
+		if e2 < dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// Text draws text in the framebuffer with its top left corner at x, y,
+// and returns the x position following it. Characters are 6 pixels wide,
+// including spacing, and 8 high. Characters outside printable ASCII are
+// drawn as '?'.
+func (d *Ssd1306) Text(x, y int, text string) int {
+	for _, r := range text {
+		if r < ' ' || r > '~' {
+			r = '?'
+		}
+		for col, bits := range font5x7[r-' '] {
+			for row := 0; row < 8; row++ {
+				d.SetPixel(x+col, y+row, bits&(1<<uint(row)) > 0)
+			}
+		}
+		for row := 0; row < 8; row++ {
+			d.SetPixel(x+5, y+row, false)
+		}
+		x += 6
+	}
+	return x
+}
+
+// Display sends the changed parts of the framebuffer to the display.
+func (d *Ssd1306) Display() error {
+	for chunk, dirty := range d.dirty {
+		if !dirty {
+			continue
+		}
+		start := chunk * ssd1306Chunk
+		end := start + ssd1306Chunk
+		if end > len(d.buffer) {
+			end = len(d.buffer)
+		}
+		// Chunks may span pages, so address each page row separately.
+		for start < end {
+			page, col := start/d.Width, start%d.Width
+			n := end - start
+			if col+n > d.Width {
+				n = d.Width - col
+			}
+			if err := d.command(0x21, byte(col), byte(col+n-1), 0x22, byte(page), byte(page)); err != nil {
+				return err
+			}
+			if err := d.Client.I2CBulkWrite(context.Background(), d.Address, ssd1306Data, d.buffer[start:start+n], ssd1306Bulk); err != nil {
+				return err
+			}
+			start += n
+		}
+		d.dirty[chunk] = false
+	}
+	return nil
+}
+
+// command sends a sequence of command bytes.
+func (d *Ssd1306) command(cmd ...byte) error {
+	return d.Client.I2CBulkWrite(context.Background(), d.Address, ssd1306Command, cmd, ssd1306Bulk)
+}
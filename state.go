@@ -0,0 +1,106 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"sort"
+)
+
+// The reader replaces, rather than modifies, the capability and analog
+// mapping reports, so the values returned here can be used after stateMu
+// is released. Callers must not modify them.
+
+// capabilities returns the modes, and their resolutions, supported by each
+// pin.
+func (c *FirmataClient) capabilities() []map[PinMode]interface{} {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	return c.pinModes
+}
+
+// supports returns true if the board reports pin supports mode.
+func (c *FirmataClient) supports(pin byte, mode PinMode) bool {
+	caps := c.capabilities()
+	return int(pin) < len(caps) && caps[pin][mode] != nil
+}
+
+// checkMode returns an error unless the board reports pin supports mode.
+func (c *FirmataClient) checkMode(pin byte, mode PinMode) error {
+	caps := c.capabilities()
+	if int(pin) >= len(caps) {
+		return fmt.Errorf("%w %v", ErrInvalidPin, pin)
+	}
+	if caps[pin][mode] != nil {
+		return nil
+	}
+	supported := make([]PinMode, 0, len(caps[pin]))
+	for m := range caps[pin] {
+		supported = append(supported, m)
+	}
+	sort.Slice(supported, func(i, j int) bool { return supported[i] < supported[j] })
+	return &PinModeError{Pin: pin, Label: c.PinLabel(pin), Mode: mode, Supported: supported}
+}
+
+// analogChannels returns the analog pin to channel mapping, both ways.
+func (c *FirmataClient) analogChannels() (pinChannels map[int]byte, channelPins map[byte]int) {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	return c.analogPinsChannelMap, c.analogChannelPinsMap
+}
+
+// firmware returns the firmware name reported by the board.
+func (c *FirmataClient) firmware() string {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	return c.firmwareName
+}
+
+// stateReported returns a channel which is closed when the board next
+// reports its firmware, capabilities or analog mapping, for waiting on
+// the state below without polling. It must be got before checking the
+// state, so a report in between is not missed.
+func (c *FirmataClient) stateReported() <-chan bool {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	if c.reported == nil {
+		c.reported = make(chan bool)
+	}
+	return c.reported
+}
+
+// wakeReported wakes the waiters of stateReported. stateMu must be held.
+func (c *FirmataClient) wakeReported() {
+	if c.reported != nil {
+		close(c.reported)
+		c.reported = nil
+	}
+}
+
+// initialised returns true once the board has reported its firmware,
+// capabilities and analog mapping.
+func (c *FirmataClient) initialised() bool {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	return c.ready && c.analogMappingDone && c.capabilityDone
+}
+
+// queried returns true once the capabilities and analog mapping have been
+// reported.
+func (c *FirmataClient) queried() bool {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	return c.analogMappingDone && c.capabilityDone
+}
@@ -26,18 +26,17 @@ func (c *FirmataClient) parseSysEx(data []byte) {
   c.Log.Trace("Processing sysex %v\n", cmd)
 	data = data[1:]
 	
-	bStr := ""
-	for _, b := range data {
-		bStr = bStr + fmt.Sprintf(" %#2x", b)
+	if c.logsTrace() {
+		c.Log.Trace("SysEx recv %v\n", hexDump(data))
 	}
-  c.Log.Trace("SysEx recv %v\n", bStr)
+	c.sysexReceived(cmd, data)
 	
 	switch {
 	case cmd == StringData:
 		c.Log.Info("String data: %v", string(data))
 	case cmd == CapabilityResponse:
 		dataBuf := bytes.NewBuffer(data)
-		c.pinModes = make([]map[PinMode]interface{}, 0)
+		capabilities := make([]map[PinMode]interface{}, 0)
 
 		pin := 0
 		var err error
@@ -52,58 +51,94 @@ func (c *FirmataClient) parseSysEx(data []byte) {
 			for i := 0; i < len(modes); i = i + 2 {
 				pinModes[PinMode(modes[i])] = modes[i+1]
 			}
-			c.pinModes = append(c.pinModes, pinModes)
+			capabilities = append(capabilities, pinModes)
 			pin = pin + 1
 		}
     c.Log.Debug("Total pins: %v\n", pin-1)
+		c.stateMu.Lock()
+		c.pinModes = capabilities
 		c.capabilityDone = true
+		c.wakeReported()
+		c.stateMu.Unlock()
 	case cmd == AnalogMappingResponse:
-		c.analogPinsChannelMap = make(map[int]byte)
-		c.analogChannelPinsMap = make(map[byte]int)
+		pinChannels := make(map[int]byte)
+		channelPins := make(map[byte]int)
 		for pin, channel := range data {
 			if channel != 127 {
-				c.analogPinsChannelMap[pin] = channel
-				c.analogChannelPinsMap[channel] = pin
+				pinChannels[pin] = channel
+				channelPins[channel] = pin
 			}
 		}
-		c.Log.Trace("pin -> channel: %v\n", c.analogPinsChannelMap)
+		c.Log.Trace("pin -> channel: %v\n", pinChannels)
+		c.stateMu.Lock()
+		c.analogPinsChannelMap = pinChannels
+		c.analogChannelPinsMap = channelPins
 		c.analogMappingDone = true
+		c.wakeReported()
+		c.stateMu.Unlock()
 	case cmd == ReportFirmware:
-		c.firmwareVersion = make([]int, 2)
-		c.firmwareVersion[0] = int(data[0])
-		c.firmwareVersion[1] = int(data[1])
+		if len(data) < 2 {
+			c.protocolError(MalformedMessage, fmt.Errorf("short firmware report"), data, c.received)
+			return
+		}
+		version := []int{int(data[0]), int(data[1])}
 		data = data[2:]
-		c.Log.Trace("in %v", data[2:])
-		c.firmwareName = multibyteString(data)
-		c.Log.Info("Firmware: %v [%v.%v]", c.firmwareName, c.firmwareVersion[0], c.firmwareVersion[1])
+		c.Log.Trace("in %v", data)
+		name := multibyteString(data)
+		c.Log.Info("Firmware: %v [%v.%v]", name, version[0], version[1])
+		c.stateMu.Lock()
+		c.firmwareVersion = version
+		c.firmwareName = name
+		c.firmwareReports++
 		c.ready = true
-		c.sendSysEx(AnalogMappingQuery)
-		c.sendSysEx(CapabilityQuery)
+		c.wakeReported()
+		c.stateMu.Unlock()
+		// The board is new or reset, so find out what it can do.
+		if !c.queried() {
+			c.sendSysEx(AnalogMappingQuery)
+			c.sendRequest(CapabilityQuery)
+		}
 	case cmd == Serial:
 		c.parseSerialResponse(data)
+	case cmd == I2CReply:
+		c.parseI2CResponse(data)
 	case cmd == SysExSPI:
 		c.parseSPIResponse(data)
 	case cmd == SysExOneWire:
 		c.parseOWResponse(data)
 	default:
 		c.Log.Debug("Discarding unexpected SysEx command %v", cmd)
+		c.protocolError(UnexpectedSysEx, fmt.Errorf("unexpected SysEx command %v", cmd), data, c.received)
 	}
 }
 
 func (c *FirmataClient) sendSysEx(cmd SysExCommand, data ...byte) (err error) {
-	var b bytes.Buffer
+	return c.sendSysExFrame(cmd, false, normalPriority, data)
+}
 
-	b.WriteByte(byte(StartSysEx))
-	b.WriteByte(byte(cmd))
-	b.Write(data)
-	b.WriteByte(byte(EndSysEx))
+// sendRequest sends a SysEx message which the board replies to. Buffered
+// frames are flushed with it, so the board sees the request straight away.
+func (c *FirmataClient) sendRequest(cmd SysExCommand, data ...byte) error {
+	return c.sendSysExFrame(cmd, true, controlPriority, data)
+}
+
+func (c *FirmataClient) sendSysExFrame(cmd SysExCommand, flush bool, prio writePriority, data []byte) (err error) {
+	if c.closed() {
+		return ErrNotConnected
+	}
+	// The writer copies the frame before writeFrame returns, so the
+	// buffer can go straight back to the pool.
+	b := getBuffer()
+	defer putBuffer(b)
+	*b = append(*b, byte(StartSysEx), byte(cmd))
+	*b = append(*b, data...)
+	*b = append(*b, byte(EndSysEx))
 
-	bStr := ""
-	for _, b := range b.Bytes() {
-		bStr = bStr + fmt.Sprintf(" %#2x", b)
+	if c.logsTrace() {
+		c.Log.Trace("SysEx send %v: %v\n", cmd, hexDump(*b))
 	}
-  c.Log.Trace("SysEx send %v: %v\n", cmd, bStr)
+	c.observeFrame(Sent, c.now(), *b)
 
-	_, err = b.WriteTo(*(c.conn))
+	err = c.writeFrame(*b, flush, prio)
 	return
 }
@@ -0,0 +1,108 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+)
+
+// TemperatureUnit is the unit a Temperature is presented in.
+type TemperatureUnit byte
+
+const (
+	// UnitDefault uses the client's unit, which is Celsius unless set
+	// with SetTemperatureUnit.
+	UnitDefault TemperatureUnit = iota
+	UnitCelsius
+	UnitFahrenheit
+	UnitKelvin
+)
+
+func (u TemperatureUnit) String() string {
+	switch u {
+	case UnitDefault, UnitCelsius:
+		return "°C"
+	case UnitFahrenheit:
+		return "°F"
+	case UnitKelvin:
+		return "K"
+	}
+	return fmt.Sprintf("Unknown unit (0x%x)", byte(u))
+}
+
+// Temperature is a temperature reading, presented in Unit.
+type Temperature struct {
+	celsius float32
+	// Unit is the unit used by Value and String.
+	Unit TemperatureUnit
+}
+
+// NewTemperature creates a Temperature from a value in degrees C.
+func NewTemperature(celsius float32, unit TemperatureUnit) Temperature {
+	return Temperature{celsius: celsius, Unit: unit}
+}
+
+// Celsius returns the temperature in degrees C.
+func (t Temperature) Celsius() float32 {
+	return t.celsius
+}
+
+// Fahrenheit returns the temperature in degrees F.
+func (t Temperature) Fahrenheit() float32 {
+	return t.celsius*9/5 + 32
+}
+
+// Kelvin returns the temperature in K.
+func (t Temperature) Kelvin() float32 {
+	return t.celsius + 273.15
+}
+
+// Value returns the temperature in its Unit.
+func (t Temperature) Value() float32 {
+	switch t.Unit {
+	case UnitFahrenheit:
+		return t.Fahrenheit()
+	case UnitKelvin:
+		return t.Kelvin()
+	}
+	return t.Celsius()
+}
+
+func (t Temperature) String() string {
+	return fmt.Sprintf("%.2f%v", t.Value(), t.Unit)
+}
+
+// SetTemperatureUnit sets the unit used by temperature drivers which have
+// no unit of their own.
+func (c *FirmataClient) SetTemperatureUnit(unit TemperatureUnit) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	c.temperatureUnit = unit
+}
+
+// TemperatureUnit returns the unit set with SetTemperatureUnit.
+func (c *FirmataClient) TemperatureUnit() TemperatureUnit {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	return c.temperatureUnit
+}
+
+// temperatureUnit resolves a device unit setting against the client's.
+func temperatureUnit(c *FirmataClient, unit TemperatureUnit) TemperatureUnit {
+	if unit == UnitDefault && c != nil {
+		return c.TemperatureUnit()
+	}
+	return unit
+}
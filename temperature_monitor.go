@@ -0,0 +1,160 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"sync"
+	"time"
+)
+
+// TemperatureReading is a single reading taken by a TemperatureMonitor.
+type TemperatureReading struct {
+	// Device is the device the reading is from.
+	Device *Ds18x20
+	// Time is when the scratchpad was read.
+	Time time.Time
+	// Temperature is the reading, valid if Err is nil.
+	Temperature Temperature
+	// Err is set if the device could not be read.
+	Err error
+}
+
+// TemperatureMonitor periodically reads a set of Ds18x20 devices, which
+// may be spread over several buses. Each bus is sent a single broadcast
+// conversion per cycle.
+type TemperatureMonitor struct {
+	// Interval is the time between the start of each reading cycle.
+	Interval time.Duration
+
+	readings chan TemperatureReading
+	poller   *poller
+	mu       sync.Mutex
+	devices  []*Ds18x20
+	errors   map[*Ds18x20]int
+}
+
+// owBus identifies a OneWire bus.
+type owBus struct {
+	client *FirmataClient
+	pin    byte
+}
+
+// NewTemperatureMonitor creates a monitor reading devices every interval.
+func NewTemperatureMonitor(interval time.Duration, devices ...*Ds18x20) *TemperatureMonitor {
+	return &TemperatureMonitor{
+		Interval: interval,
+		readings: make(chan TemperatureReading, 10),
+		poller:   newPoller(),
+		devices:  devices,
+		errors:   make(map[*Ds18x20]int),
+	}
+}
+
+// Add adds a device to the monitor, from the next reading cycle.
+func (m *TemperatureMonitor) Add(d *Ds18x20) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.devices = append(m.devices, d)
+}
+
+// Errors returns the number of failed readings of the device.
+func (m *TemperatureMonitor) Errors(d *Ds18x20) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.errors[d]
+}
+
+// Start begins reading the devices and returns the channel of readings.
+func (m *TemperatureMonitor) Start() <-chan TemperatureReading {
+	go m.poll()
+	return m.readings
+}
+
+// Stop stops reading the devices and closes the readings channel. It does
+// not block, so may be called from the loop reading the readings.
+func (m *TemperatureMonitor) Stop() {
+	m.poller.Stop()
+}
+
+func (m *TemperatureMonitor) poll() {
+	defer close(m.readings)
+	m.poller.every(m.clock(), m.Interval, m.cycle)
+}
+
+// cycle starts a conversion on every bus, waits for the slowest device
+// to finish and then reads each device, the buses concurrently.
+func (m *TemperatureMonitor) cycle() {
+	m.mu.Lock()
+	buses := make(map[owBus][]*Ds18x20)
+	for _, d := range m.devices {
+		b := owBus{d.Client, d.Pin}
+		buses[b] = append(buses[b], d)
+	}
+	m.mu.Unlock()
+
+	var wait time.Duration
+	for _, devices := range buses {
+		if err := devices[0].ConvertT(false); err != nil {
+			for _, d := range devices {
+				m.send(d, err)
+			}
+			delete(buses, owBus{devices[0].Client, devices[0].Pin})
+			continue
+		}
+		for _, d := range devices {
+			if t := d.ConversionTime(); t > wait {
+				wait = t
+			}
+		}
+	}
+	sleep(m.clock(), wait)
+
+	// Buses are independent, so read them all at once.
+	var wg sync.WaitGroup
+	for _, devices := range buses {
+		wg.Add(1)
+		go func(devices []*Ds18x20) {
+			defer wg.Done()
+			for _, d := range devices {
+				m.send(d, d.ReadScratchPad())
+			}
+		}(devices)
+	}
+	wg.Wait()
+}
+
+// clock returns the clock of the client of the first device, as the
+// monitor has none of its own.
+func (m *TemperatureMonitor) clock() Clock {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.devices) == 0 {
+		return SystemClock
+	}
+	return m.devices[0].Client.Clock()
+}
+
+// send delivers a reading of d, counting it as failed if err is set.
+func (m *TemperatureMonitor) send(d *Ds18x20, err error) {
+	r := TemperatureReading{Device: d, Time: m.clock().Now(), Err: err}
+	if err != nil {
+		m.mu.Lock()
+		m.errors[d]++
+		m.mu.Unlock()
+	} else {
+		r.Temperature = d.Temperature
+	}
+	pollSend(m.poller, m.readings, r)
+}
@@ -0,0 +1,131 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// thresholdSmoothing is the weight of each new reading in the moving
+// average compared against thresholds.
+const thresholdSmoothing = 0.25
+
+// ThresholdEventType is the direction of a threshold crossing.
+type ThresholdEventType byte
+
+const (
+	// ThresholdEnter is sent when the value rises to the high threshold.
+	ThresholdEnter ThresholdEventType = iota
+	// ThresholdExit is sent when the value falls back to the low threshold.
+	ThresholdExit
+)
+
+func (t ThresholdEventType) String() string {
+	switch t {
+	case ThresholdEnter:
+		return "Enter"
+	case ThresholdExit:
+		return "Exit"
+	}
+	return fmt.Sprintf("Unknown threshold event (0x%x)", byte(t))
+}
+
+// ThresholdEvent reports an analog value crossing a threshold.
+type ThresholdEvent struct {
+	// Type is the direction of the crossing.
+	Type ThresholdEventType
+	// Pin is the pin number.
+	Pin byte
+	// Value is the smoothed value which crossed the threshold.
+	Value float64
+	// Time is when the reading which crossed the threshold was received.
+	Time time.Time
+}
+
+// ThresholdWatch is a stream of threshold crossings of an analog input.
+type ThresholdWatch struct {
+	// C receives the events.
+	C <-chan ThresholdEvent
+
+	sub  *AnalogSubscription
+	stop chan bool
+	once sync.Once
+}
+
+// WatchThreshold enables reporting of an analog pin and watches an
+// exponential moving average of its scaled value. ThresholdEnter is sent
+// when the average reaches high, and ThresholdExit when it then falls to
+// low, so a value hovering around either threshold does not chatter. If
+// the first reading is already at or above high, ThresholdEnter is sent
+// straight away.
+func (c *FirmataClient) WatchThreshold(pin byte, high, low float64) (*ThresholdWatch, error) {
+	if low > high {
+		return nil, fmt.Errorf("Low threshold %v is above high threshold %v", low, high)
+	}
+	if err := c.EnableAnalogInput(uint(pin), true); err != nil {
+		return nil, err
+	}
+	out := make(chan ThresholdEvent, 10)
+	w := &ThresholdWatch{
+		C:    out,
+		sub:  c.SubscribeAnalog(10, DropOldest, pin),
+		stop: make(chan bool),
+	}
+	go func() {
+		defer close(out)
+		var avg float64
+		started, above := false, false
+		for {
+			var e AnalogEvent
+			select {
+			case ev, ok := <-w.sub.C:
+				if !ok {
+					return
+				}
+				e = ev
+			case <-w.stop:
+				return
+			}
+			if !started {
+				avg, started = e.Scaled, true
+			} else {
+				avg += (e.Scaled - avg) * thresholdSmoothing
+			}
+			var t ThresholdEventType
+			switch {
+			case !above && avg >= high:
+				above, t = true, ThresholdEnter
+			case above && avg <= low:
+				above, t = false, ThresholdExit
+			default:
+				continue
+			}
+			select {
+			case out <- ThresholdEvent{Type: t, Pin: pin, Value: avg, Time: e.Time}:
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+	return w, nil
+}
+
+// Stop stops the watch and closes C. Stopping it again does nothing.
+func (w *ThresholdWatch) Stop() {
+	w.sub.Close()
+	w.once.Do(func() { close(w.stop) })
+}
@@ -0,0 +1,87 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTimeout is matched, with errors.Is, by the error returned when the
+// board does not reply to a request in time.
+var ErrTimeout = errors.New("timed out waiting for board")
+
+// TimeoutError is returned when the board does not reply to a request
+// before the client timeout, or the deadline of the request context.
+type TimeoutError struct {
+	// Op is the request which timed out.
+	Op string
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Op, ErrTimeout.Error())
+}
+
+// Timeout returns true, so a TimeoutError satisfies net.Error style checks.
+func (e *TimeoutError) Timeout() bool {
+	return true
+}
+
+// Unwrap makes errors.Is(err, ErrTimeout) true.
+func (e *TimeoutError) Unwrap() error {
+	return ErrTimeout
+}
+
+// Is also matches context.DeadlineExceeded, as the timeout is a deadline.
+func (e *TimeoutError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+// SetTimeout sets the default time to wait for the board to reply to a
+// request. Requests made with a context that has a deadline use that
+// instead. Zero, the default, waits until the context is done.
+func (c *FirmataClient) SetTimeout(d time.Duration) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	c.timeout = d
+}
+
+// Timeout returns the default request timeout.
+func (c *FirmataClient) Timeout() time.Duration {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	return c.timeout
+}
+
+// requestContext applies the default timeout to ctx, if it has no
+// deadline of its own.
+func (c *FirmataClient) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := c.Timeout()
+	if _, ok := ctx.Deadline(); ok || timeout <= 0 {
+		return ctx, func() {}
+	}
+	return c.withTimeout(ctx, timeout)
+}
+
+// requestError returns the error for a request abandoned because ctx is
+// done.
+func requestError(ctx context.Context, op string) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return &TimeoutError{Op: op}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,129 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"code.google.com/p/log4go"
+)
+
+// TraceDirection is whether a traced frame was sent or received.
+type TraceDirection byte
+
+const (
+	// Sent is a frame sent to the board.
+	Sent TraceDirection = iota
+	// Received is a frame received from the board.
+	Received
+)
+
+func (d TraceDirection) String() string {
+	switch d {
+	case Sent:
+		return "send"
+	case Received:
+		return "recv"
+	}
+	return fmt.Sprintf("Unknown direction (0x%x)", byte(d))
+}
+
+// TraceFrame is a raw message sent to or received from the board.
+type TraceFrame struct {
+	// Direction is whether the frame was sent or received.
+	Direction TraceDirection
+	// Time is when it was sent or received.
+	Time time.Time
+	// Data is the whole frame, including the command byte and for SysEx
+	// the start and end bytes.
+	Data []byte
+}
+
+// Command returns the name of the frame command, the SysEx command for
+// SysEx frames.
+func (f TraceFrame) Command() string {
+	if len(f.Data) == 0 {
+		return "Empty frame"
+	}
+	cmd := FirmataCommand(f.Data[0])
+	if cmd == StartSysEx && len(f.Data) > 1 {
+		return "SysEx " + SysExCommand(f.Data[1]).String()
+	}
+	if cmd < StartSysEx {
+		// The low bits are the pin, port or channel.
+		cmd &= 0xF0
+	}
+	return cmd.String()
+}
+
+// String formats the frame as one line of a trace.
+func (f TraceFrame) String() string {
+	return fmt.Sprintf("%s %v %s: % x", f.Time.Format("15:04:05.000000"), f.Direction, f.Command(), f.Data)
+}
+
+// SetTrace writes every frame sent to and received from the board to w,
+// one line per frame, until called with nil.
+func (c *FirmataClient) SetTrace(w io.Writer) {
+	c.traceMu.Lock()
+	defer c.traceMu.Unlock()
+	c.trace = w
+}
+
+// observeFrame traces, counts and keeps a frame sent or received.
+func (c *FirmataClient) observeFrame(d TraceDirection, t time.Time, data []byte) {
+	c.traceFrame(d, t, data)
+	c.countFrame(d, data)
+	c.debug.frame(d, t, data)
+}
+
+// traceFrame writes a frame to the trace, if one is set.
+func (c *FirmataClient) traceFrame(d TraceDirection, t time.Time, data []byte) {
+	c.traceMu.Lock()
+	defer c.traceMu.Unlock()
+	if c.trace == nil {
+		return
+	}
+	f := TraceFrame{Direction: d, Time: t, Data: data}
+	if _, err := fmt.Fprintln(c.trace, f.String()); err != nil {
+		c.Log.Warn("Trace: %s", err.Error())
+	}
+}
+
+// logsTrace returns true if the logger records Trace messages, so the byte
+// dumps for them are only built when they will be used.
+func (c *FirmataClient) logsTrace() bool {
+	if c.Log == nil {
+		return false
+	}
+	for _, f := range *c.Log {
+		if f.Level <= log4go.TRACE {
+			return true
+		}
+	}
+	return false
+}
+
+// hexDump formats data as space separated hex bytes.
+func hexDump(data []byte) string {
+	var b strings.Builder
+	b.Grow(len(data) * 5)
+	for _, d := range data {
+		fmt.Fprintf(&b, " %#2x", d)
+	}
+	return b.String()
+}
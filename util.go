@@ -14,6 +14,10 @@
 
 package firmata
 
+import (
+	"strings"
+)
+
 func from7Bit(b0 byte, b1 byte) byte {
 	return (b0 & 0x7F) | ((b1 & 0x7F) << 7)
 }
@@ -26,64 +30,50 @@ func intto7Bit(i int) []byte {
 	return []byte{byte(i & 0x7f), byte((i >> 7) & 0x7f), byte((i >> 14) & 0x7f)}
 }
 
-func multibyteString(data []byte) (str string) {
-
-	if len(data)%2 != 0 {
-		data = append(data, 0)
-	}
-
+func multibyteString(data []byte) string {
+	var str strings.Builder
 	for i := 0; i < len(data); i = i + 2 {
-		str = str + string(from7Bit(data[i], data[i+1]))
+		var b1 byte
+		if i+1 < len(data) {
+			b1 = data[i+1]
+		}
+		str.WriteRune(rune(from7Bit(data[i], b1)))
 	}
-
-	return
+	return str.String()
 }
 
-// from7BitMulti converts 7 bit encoded data to 8 bit.
+// From7BitMulti decodes the 7 bit packed data of a SysEx reply which
+// starts with a subcommand and a pin, such as a OneWire reply, skipping
+// those two bytes.
+//
+// Deprecated: use Decode7BitMulti, which decodes all of its input.
 func From7BitMulti(data []byte) []byte {
-  var i uint
-  res := make([]byte, 0)
-  var shift uint = 0
-  for i = 0 ; int(i) < len(data)-3 ; i++ {
-    if i > 0 && i % 7 == 0 {
-      i++
-    }
-    j := i + 2
-    d := data[j] >> shift
-    d |= data[j+1] << (7-shift)
-    shift++
-    if shift > 6 {
-      shift = 0
-    }
-    res = append(res, d)
-  }
-  return res
+	if len(data) < 2 {
+		return []byte{}
+	}
+	return Decode7BitMulti(data[2:])
 }
 
-// to7BitMulti converts 8 bit encoded data to 7 bit.
+// Decode7BitMulti decodes data packed 7 bits to a byte, as sent in SysEx
+// messages, to 8 bit bytes. Left over bits which do not make a whole byte
+// are padding, and ignored.
+func Decode7BitMulti(data []byte) []byte {
+	res := make([]byte, len(data)*7/8)
+	for i := range res {
+		pos, shift := i*8/7, uint(i*8%7)
+		res[i] = (data[pos]&0x7f)>>shift | (data[pos+1]&0x7f)<<(7-shift)
+	}
+	return res
+}
+
+// To7BitMulti packs 8 bit data 7 bits to a byte, for sending in SysEx
+// messages. The last byte is padded with zero bits.
 func To7BitMulti(data []byte) []byte {
-  var i uint
-  var prev byte
-  var res []byte
-  var shift uint = 0
-  for i = 0 ; int(i) < len(data) ; i++ {
-    if shift == 0 {
-      res = append(res, data[i] & 0x7f)
-      shift++
-      prev = data[i] >> 7
-    } else {
-      res = append(res, ((data[i] << shift) & 0x7f) | prev)
-      if shift == 6 {
-        res = append(res, data[i] >> 1)
-        shift = 0
-      } else {
-        shift++
-        prev = data[i] >> (8 - shift)
-      }
-    }
-  }
-  if shift > 0 {
-    res = append(res, prev)
-  }
-  return res
+	res := make([]byte, (len(data)*8+6)/7)
+	for i, b := range data {
+		pos, shift := i*8/7, uint(i*8%7)
+		res[pos] |= (b << shift) & 0x7f
+		res[pos+1] |= b >> (7 - shift)
+	}
+	return res
 }
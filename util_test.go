@@ -0,0 +1,104 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"testing/quick"
+)
+
+func TestSevenBitMultiRoundTrip(t *testing.T) {
+	roundTrip := func(data []byte) bool {
+		packed := To7BitMulti(data)
+		if len(packed) != (len(data)*8+6)/7 {
+			t.Logf("%d bytes packed to %d", len(data), len(packed))
+			return false
+		}
+		for _, b := range packed {
+			if b&0x80 != 0 {
+				t.Logf("packed byte 0x%x has the top bit set", b)
+				return false
+			}
+		}
+		return bytes.Equal(Decode7BitMulti(packed), data)
+	}
+	if err := quick.Check(roundTrip, &quick.Config{MaxCount: 1000}); err != nil {
+		t.Error(err)
+	}
+	// Every length up to a few whole groups of 7, as quick favours longer
+	// slices.
+	for n := 0; n <= 32; n++ {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(0xff - i*37)
+		}
+		if !roundTrip(data) {
+			t.Errorf("% x did not round trip", data)
+		}
+	}
+}
+
+func TestDecode7BitMultiIgnoresPadding(t *testing.T) {
+	// The top bit of each 7 bit byte is not data.
+	got := Decode7BitMulti([]byte{0xff, 0xff})
+	if want := []byte{0xff}; !bytes.Equal(got, want) {
+		t.Errorf("Decode7BitMulti(ff ff) = % x, want % x", got, want)
+	}
+	if got := Decode7BitMulti([]byte{0x7f}); len(got) != 0 {
+		t.Errorf("Decode7BitMulti(7f) = % x, want nothing", got)
+	}
+}
+
+func TestFrom7BitMultiSkipsSubcommandAndPin(t *testing.T) {
+	data := []byte{0x28, 0xff, 0x4b, 0x46, 0x7f, 0xff, 0x0c, 0x10, 0x1c}
+	reply := append([]byte{byte(OneWireReadReply), 4}, To7BitMulti(data)...)
+	if got := From7BitMulti(reply); !bytes.Equal(got, data) {
+		t.Errorf("From7BitMulti(% x) = % x, want % x", reply, got, data)
+	}
+	if got := From7BitMulti([]byte{byte(OneWireReadReply)}); len(got) != 0 {
+		t.Errorf("From7BitMulti of a short reply = % x, want nothing", got)
+	}
+}
+
+// sevenBitSink keeps the benchmarked results from being optimised away.
+var sevenBitSink []byte
+
+func BenchmarkTo7BitMulti(b *testing.B) {
+	for _, n := range []int{8, 64, 512} {
+		data := bytes.Repeat([]byte{0xa5}, n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(n))
+			for i := 0; i < b.N; i++ {
+				sevenBitSink = To7BitMulti(data)
+			}
+		})
+	}
+}
+
+func BenchmarkDecode7BitMulti(b *testing.B) {
+	for _, n := range []int{8, 64, 512} {
+		data := To7BitMulti(bytes.Repeat([]byte{0xa5}, n))
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				sevenBitSink = Decode7BitMulti(data)
+			}
+		})
+	}
+}
@@ -0,0 +1,363 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// Vl53l0xAddress is the default I2C address of a VL53L0X.
+	Vl53l0xAddress = 0x29
+
+	vlSysrangeStart          = 0x00
+	vlSequenceConfig         = 0x01
+	vlIntermeasurementPeriod = 0x04
+	vlInterruptConfigGpio    = 0x0a
+	vlInterruptClear         = 0x0b
+	vlResultInterruptStatus  = 0x13
+	vlResultRange            = 0x1e
+	vlMinCountRateRtnLimit   = 0x44
+	vlMsrcConfigControl      = 0x60
+	vlGpioHvMuxActiveHigh    = 0x84
+	vlVhvConfigPadSclSda     = 0x89
+	vlSpadEnablesRef0        = 0xb0
+	vlRefEnStartSelect       = 0xb6
+	vlDynamicSpadNumRef      = 0x4e
+	vlDynamicSpadStartOffset = 0x4f
+	vlOscCalibrateVal        = 0xf8
+
+	// vlTimeout bounds every wait for the device.
+	vlTimeout = 500 * time.Millisecond
+)
+
+// vlTuning is the default tuning settings from the ST API, as register,
+// value pairs.
+var vlTuning = []byte{
+	0xff, 0x01, 0x00, 0x00,
+	0xff, 0x00, 0x09, 0x00, 0x10, 0x00, 0x11, 0x00,
+	0x24, 0x01, 0x25, 0xff, 0x75, 0x00,
+	0xff, 0x01, 0x4e, 0x2c, 0x48, 0x00, 0x30, 0x20,
+	0xff, 0x00, 0x30, 0x09, 0x54, 0x00, 0x31, 0x04, 0x32, 0x03, 0x40, 0x83,
+	0x46, 0x25, 0x60, 0x00, 0x27, 0x00, 0x50, 0x06, 0x51, 0x00, 0x52, 0x96,
+	0x56, 0x08, 0x57, 0x30, 0x61, 0x00, 0x62, 0x00, 0x64, 0x00, 0x65, 0x00,
+	0x66, 0xa0,
+	0xff, 0x01, 0x22, 0x32, 0x47, 0x14, 0x49, 0xff, 0x4a, 0x00,
+	0xff, 0x00, 0x7a, 0x0a, 0x7b, 0x00, 0x78, 0x21,
+	0xff, 0x01, 0x23, 0x34, 0x42, 0x00, 0x44, 0xff, 0x45, 0x26, 0x46, 0x05,
+	0x40, 0x40, 0x0e, 0x06, 0x20, 0x1a, 0x43, 0x40,
+	0xff, 0x00, 0x34, 0x03, 0x35, 0x44,
+	0xff, 0x01, 0x31, 0x04, 0x4b, 0x09, 0x4c, 0x05, 0x4d, 0x04,
+	0xff, 0x00, 0x44, 0x00, 0x45, 0x20, 0x47, 0x08, 0x48, 0x28, 0x67, 0x00,
+	0x70, 0x04, 0x71, 0x01, 0x72, 0xfe, 0x76, 0x00, 0x77, 0x00,
+	0xff, 0x01, 0x0d, 0x01,
+	0xff, 0x00, 0x80, 0x01, 0x01, 0xf8,
+	0xff, 0x01, 0x8e, 0x01, 0x00, 0x01, 0xff, 0x00, 0x80, 0x00,
+}
+
+// Vl53l0xReading is a range measurement from a Vl53l0x.
+type Vl53l0xReading struct {
+	// Time is when the measurement was read.
+	Time time.Time
+	// Range is the distance in millimetres, valid if Err is nil.
+	Range int
+	// Err is set if the measurement failed.
+	Err error
+}
+
+// Vl53l0x is an ST VL53L0X time-of-flight distance sensor.
+//
+// The sensor is configured by an undocumented register sequence, which
+// follows the ST API via the Pololu Arduino library. Every register access
+// is a Firmata round trip, so Init takes a noticeable time.
+type Vl53l0x struct {
+	// The client.
+	Client *FirmataClient
+	// The I2C address of the device.
+	Address byte
+
+	stopVariable byte
+	poller       *poller
+}
+
+// Init runs the data, static and reference calibration initialisation.
+func (d *Vl53l0x) Init() error {
+	// 2V8 I/O mode.
+	if err := d.setBits(vlVhvConfigPadSclSda, 0x01, 0x01); err != nil {
+		return err
+	}
+	err := d.writeRegs(0x88, 0x00, 0x80, 0x01, 0xff, 0x01, 0x00, 0x00)
+	if err != nil {
+		return err
+	}
+	if d.stopVariable, err = d.readReg(0x91); err != nil {
+		return err
+	}
+	if err := d.writeRegs(0x00, 0x01, 0xff, 0x00, 0x80, 0x00); err != nil {
+		return err
+	}
+
+	// Disable the MSRC and pre-range signal rate limit checks, and set the
+	// final range signal rate limit to 0.25 MCPS (9.7 fixed point).
+	if err := d.setBits(vlMsrcConfigControl, 0x12, 0x12); err != nil {
+		return err
+	}
+	if err := d.Client.I2CWrite(d.Address, vlMinCountRateRtnLimit, 0x00, 0x20); err != nil {
+		return err
+	}
+	if err := d.writeRegs(vlSequenceConfig, 0xff); err != nil {
+		return err
+	}
+
+	if err := d.initSpads(); err != nil {
+		return err
+	}
+	if err := d.writeRegs(vlTuning...); err != nil {
+		return err
+	}
+
+	// Interrupt on new sample ready, active low.
+	if err := d.writeRegs(vlInterruptConfigGpio, 0x04); err != nil {
+		return err
+	}
+	if err := d.setBits(vlGpioHvMuxActiveHigh, 0x10, 0x00); err != nil {
+		return err
+	}
+	if err := d.writeRegs(vlInterruptClear, 0x01); err != nil {
+		return err
+	}
+
+	// Disable MSRC and TCC, then run the VHV and phase calibrations.
+	if err := d.writeRegs(vlSequenceConfig, 0xe8); err != nil {
+		return err
+	}
+	if err := d.writeRegs(vlSequenceConfig, 0x01); err != nil {
+		return err
+	}
+	if err := d.refCalibration(0x40); err != nil {
+		return err
+	}
+	if err := d.writeRegs(vlSequenceConfig, 0x02); err != nil {
+		return err
+	}
+	if err := d.refCalibration(0x00); err != nil {
+		return err
+	}
+	return d.writeRegs(vlSequenceConfig, 0xe8)
+}
+
+// Range takes a single measurement, returning the distance in millimetres.
+func (d *Vl53l0x) Range() (int, error) {
+	if err := d.writeRegs(0x80, 0x01, 0xff, 0x01, 0x00, 0x00, 0x91, d.stopVariable,
+		0x00, 0x01, 0xff, 0x00, 0x80, 0x00, vlSysrangeStart, 0x01); err != nil {
+		return 0, err
+	}
+	if err := d.waitFor(vlSysrangeStart, 0x01, false); err != nil {
+		return 0, err
+	}
+	return d.readRange()
+}
+
+// StartContinuous starts back-to-back measurements, or one every period
+// if it is non-zero, delivering them on the returned channel until
+// StopContinuous is called.
+func (d *Vl53l0x) StartContinuous(period time.Duration) (<-chan Vl53l0xReading, error) {
+	if err := d.writeRegs(0x80, 0x01, 0xff, 0x01, 0x00, 0x00, 0x91, d.stopVariable,
+		0x00, 0x01, 0xff, 0x00, 0x80, 0x00); err != nil {
+		return nil, err
+	}
+	mode := byte(0x02)
+	if period > 0 {
+		ms := uint32(period / time.Millisecond)
+		cal, err := d.Client.I2CRead(d.Address, vlOscCalibrateVal, 2)
+		if err != nil {
+			return nil, err
+		}
+		if len(cal) == 2 {
+			if osc := uint32(cal[0])<<8 | uint32(cal[1]); osc != 0 {
+				ms *= osc
+			}
+		}
+		if err := d.Client.I2CWrite(d.Address, vlIntermeasurementPeriod,
+			byte(ms>>24), byte(ms>>16), byte(ms>>8), byte(ms)); err != nil {
+			return nil, err
+		}
+		mode = 0x04
+	}
+	if err := d.writeRegs(vlSysrangeStart, mode); err != nil {
+		return nil, err
+	}
+
+	readings := make(chan Vl53l0xReading, 10)
+	d.poller.Stop()
+	d.poller = newPoller()
+	go func(p *poller) {
+		defer close(readings)
+		// readRange waits for each measurement, so run back to back.
+		p.every(d.Client.Clock(), 0, func() {
+			r, err := d.readRange()
+			pollSend(p, readings, Vl53l0xReading{Time: d.Client.now(), Range: r, Err: err})
+		})
+	}(d.poller)
+	return readings, nil
+}
+
+// StopContinuous stops the measurements started by StartContinuous,
+// closing the channel. It does not wait for a reading to be taken, so may
+// be called from the loop reading them.
+func (d *Vl53l0x) StopContinuous() error {
+	d.poller.Stop()
+	return d.writeRegs(vlSysrangeStart, 0x01, 0xff, 0x01, 0x00, 0x00,
+		0x91, 0x00, 0x00, 0x01, 0xff, 0x00)
+}
+
+// readRange waits for a measurement to complete and reads it.
+func (d *Vl53l0x) readRange() (int, error) {
+	if err := d.waitFor(vlResultInterruptStatus, 0x07, true); err != nil {
+		return 0, err
+	}
+	data, err := d.Client.I2CRead(d.Address, vlResultRange, 2)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) < 2 {
+		return 0, fmt.Errorf("short range read")
+	}
+	if err := d.writeRegs(vlInterruptClear, 0x01); err != nil {
+		return 0, err
+	}
+	return int(data[0])<<8 | int(data[1]), nil
+}
+
+// initSpads reads the reference SPAD count and type from NVM and enables
+// that many SPADs in the reference map.
+func (d *Vl53l0x) initSpads() error {
+	if err := d.writeRegs(0x80, 0x01, 0xff, 0x01, 0x00, 0x00, 0xff, 0x06); err != nil {
+		return err
+	}
+	if err := d.setBits(0x83, 0x04, 0x04); err != nil {
+		return err
+	}
+	if err := d.writeRegs(0xff, 0x07, 0x81, 0x01, 0x80, 0x01, 0x94, 0x6b, 0x83, 0x00); err != nil {
+		return err
+	}
+	if err := d.waitFor(0x83, 0xff, true); err != nil {
+		return err
+	}
+	if err := d.writeRegs(0x83, 0x01); err != nil {
+		return err
+	}
+	info, err := d.readReg(0x92)
+	if err != nil {
+		return err
+	}
+	count, aperture := int(info&0x7f), info&0x80 > 0
+	if err := d.writeRegs(0x81, 0x00, 0xff, 0x06); err != nil {
+		return err
+	}
+	if err := d.setBits(0x83, 0x04, 0x00); err != nil {
+		return err
+	}
+	if err := d.writeRegs(0xff, 0x01, 0x00, 0x01, 0xff, 0x00, 0x80, 0x00); err != nil {
+		return err
+	}
+
+	spads, err := d.Client.I2CRead(d.Address, vlSpadEnablesRef0, 6)
+	if err != nil {
+		return err
+	}
+	if len(spads) < 6 {
+		return fmt.Errorf("short SPAD map read")
+	}
+	if err := d.writeRegs(0xff, 0x01, vlDynamicSpadStartOffset, 0x00,
+		vlDynamicSpadNumRef, 0x2c, 0xff, 0x00, vlRefEnStartSelect, 0xb4); err != nil {
+		return err
+	}
+	// Aperture SPADs start at 12.
+	first := 0
+	if aperture {
+		first = 12
+	}
+	enabled := 0
+	for i := 0; i < 48; i++ {
+		bit := byte(1) << uint(i%8)
+		if i < first || enabled == count {
+			spads[i/8] &^= bit
+		} else if spads[i/8]&bit > 0 {
+			enabled++
+		}
+	}
+	return d.Client.I2CWrite(d.Address, append([]byte{vlSpadEnablesRef0}, spads[:6]...)...)
+}
+
+// refCalibration runs a single reference calibration.
+func (d *Vl53l0x) refCalibration(vhvInit byte) error {
+	if err := d.writeRegs(vlSysrangeStart, 0x01|vhvInit); err != nil {
+		return err
+	}
+	if err := d.waitFor(vlResultInterruptStatus, 0x07, true); err != nil {
+		return err
+	}
+	return d.writeRegs(vlInterruptClear, 0x01, vlSysrangeStart, 0x00)
+}
+
+// waitFor polls reg until the bits in mask are all clear, or until any
+// is set if set is true.
+func (d *Vl53l0x) waitFor(reg byte, mask byte, set bool) error {
+	deadline := d.Client.now().Add(vlTimeout)
+	for {
+		v, err := d.readReg(reg)
+		if err != nil {
+			return err
+		}
+		if (v&mask > 0) == set {
+			return nil
+		}
+		if d.Client.now().After(deadline) {
+			return &TimeoutError{Op: fmt.Sprintf("VL53L0X register 0x%x", reg)}
+		}
+	}
+}
+
+// writeRegs writes a sequence of register, value pairs.
+func (d *Vl53l0x) writeRegs(pairs ...byte) error {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if err := d.Client.I2CWrite(d.Address, pairs[i], pairs[i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setBits replaces the bits in mask of reg with those of v.
+func (d *Vl53l0x) setBits(reg byte, mask byte, v byte) error {
+	old, err := d.readReg(reg)
+	if err != nil {
+		return err
+	}
+	return d.writeRegs(reg, old&^mask|v&mask)
+}
+
+func (d *Vl53l0x) readReg(reg byte) (byte, error) {
+	data, err := d.Client.I2CRead(d.Address, int(reg), 1)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) < 1 {
+		return 0, fmt.Errorf("short read of register 0x%x", reg)
+	}
+	return data[0], nil
+}
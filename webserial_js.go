@@ -0,0 +1,269 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build js && wasm
+
+package firmata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"syscall/js"
+	"time"
+)
+
+// ErrNoWebSerial is returned when the browser does not have the Web
+// Serial API, or the page is not served from a secure context.
+var ErrNoWebSerial = errors.New("Web Serial is not available")
+
+// RequestWebSerialPort asks the user to pick a serial port, returning the
+// JavaScript SerialPort. Browsers only allow it from a user gesture, such
+// as a click handler, which must not wait for it: it must be called from a
+// goroutine the handler starts.
+func RequestWebSerialPort(ctx context.Context) (js.Value, error) {
+	serial, err := webSerial()
+	if err != nil {
+		return js.Undefined(), err
+	}
+	return await(ctx, serial.Call("requestPort"))
+}
+
+// WebSerialPorts returns the serial ports the user has already allowed the
+// page to use, which can be opened without asking again.
+func WebSerialPorts(ctx context.Context) ([]js.Value, error) {
+	serial, err := webSerial()
+	if err != nil {
+		return nil, err
+	}
+	list, err := await(ctx, serial.Call("getPorts"))
+	if err != nil {
+		return nil, err
+	}
+	ports := make([]js.Value, list.Length())
+	for i := range ports {
+		ports[i] = list.Index(i)
+	}
+	return ports, nil
+}
+
+// OpenWebSerial connects to a board on a Web Serial port, as for
+// NewClientContext. In a browser dashboard:
+//
+//	button.Call("addEventListener", "click", js.FuncOf(func(js.Value, []js.Value) interface{} {
+//		go func() {
+//			port, err := firmata.RequestWebSerialPort(ctx)
+//			...
+//			client, err := firmata.OpenWebSerial(ctx, port, 57600)
+//			...
+//		}()
+//		return nil
+//	}))
+func OpenWebSerial(ctx context.Context, port js.Value, baud int, opts ...Option) (*FirmataClient, error) {
+	conn, err := DialWebSerial(ctx, port, baud)
+	if err != nil {
+		return nil, err
+	}
+	client, err := NewClientContext(ctx, conn, opts...)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	client.baud = baud
+	return client, nil
+}
+
+// WebSerialDialer returns a function opening port, for use with
+// WithReconnect.
+func WebSerialDialer(port js.Value, baud int) func() (io.ReadWriteCloser, error) {
+	return func() (io.ReadWriteCloser, error) {
+		return DialWebSerial(context.Background(), port, baud)
+	}
+}
+
+// WebSerialConn is an open Web Serial port. It is a SerialConn.
+type WebSerialConn struct {
+	port   js.Value
+	reader js.Value
+	writer js.Value
+
+	readMu  sync.Mutex
+	pending []byte
+	closed  chan bool
+	once    sync.Once
+}
+
+var _ SerialConn = (*WebSerialConn)(nil)
+
+// DialWebSerial opens port at baud.
+func DialWebSerial(ctx context.Context, port js.Value, baud int) (*WebSerialConn, error) {
+	options := js.Global().Get("Object").New()
+	options.Set("baudRate", baud)
+	if _, err := await(ctx, port.Call("open", options)); err != nil {
+		return nil, fmt.Errorf("opening serial port: %w", err)
+	}
+	c := &WebSerialConn{
+		port:   port,
+		reader: port.Get("readable").Call("getReader"),
+		writer: port.Get("writable").Call("getWriter"),
+		closed: make(chan bool),
+	}
+	// Opening the port resets most boards, give them time to start.
+	time.Sleep(1 * time.Second)
+	return c, nil
+}
+
+// Read reads data received from the port, waiting for some if there is
+// none.
+func (c *WebSerialConn) Read(b []byte) (int, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+	for len(c.pending) == 0 {
+		result, err := c.call(c.reader, "read")
+		if err != nil {
+			return 0, err
+		}
+		if result.Get("done").Bool() {
+			return 0, io.EOF
+		}
+		value := result.Get("value")
+		c.pending = make([]byte, value.Length())
+		js.CopyBytesToGo(c.pending, value)
+	}
+	n := copy(b, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+// Write sends b to the port.
+func (c *WebSerialConn) Write(b []byte) (int, error) {
+	data := js.Global().Get("Uint8Array").New(len(b))
+	js.CopyBytesToJS(data, b)
+	if _, err := c.call(c.writer, "write", data); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// SetDTR asserts or clears the DTR line.
+func (c *WebSerialConn) SetDTR(on bool) error {
+	return c.setSignal("dataTerminalReady", on)
+}
+
+// SetRTS asserts or clears the RTS line.
+func (c *WebSerialConn) SetRTS(on bool) error {
+	return c.setSignal("requestToSend", on)
+}
+
+func (c *WebSerialConn) setSignal(name string, on bool) error {
+	signals := js.Global().Get("Object").New()
+	signals.Set(name, on)
+	_, err := c.call(c.port, "setSignals", signals)
+	return err
+}
+
+// Close ends any pending read and closes the port.
+func (c *WebSerialConn) Close() error {
+	var err error
+	c.once.Do(func() {
+		close(c.closed)
+		ctx := context.Background()
+		// Cancelling the reader ends a pending read, which must finish
+		// before the lock on the stream is released.
+		await(ctx, c.reader.Call("cancel"))
+		c.readMu.Lock()
+		c.reader.Call("releaseLock")
+		c.readMu.Unlock()
+		c.writer.Call("releaseLock")
+		_, err = await(ctx, c.port.Call("close"))
+	})
+	return err
+}
+
+// call calls a method of the port, or its reader or writer, returning a
+// promise, and waits for it. The streams are released on Close, after
+// which calling them would throw.
+func (c *WebSerialConn) call(v js.Value, method string, args ...interface{}) (js.Value, error) {
+	select {
+	case <-c.closed:
+		return js.Undefined(), ErrNotConnected
+	default:
+	}
+	return await(context.Background(), v.Call(method, args...))
+}
+
+func webSerial() (js.Value, error) {
+	serial := js.Global().Get("navigator").Get("serial")
+	if serial.IsUndefined() {
+		return js.Undefined(), ErrNoWebSerial
+	}
+	return serial, nil
+}
+
+// await waits for a JavaScript promise to settle, returning its value or
+// its rejection as an error.
+func await(ctx context.Context, promise js.Value) (js.Value, error) {
+	type result struct {
+		value js.Value
+		err   error
+	}
+	done := make(chan result, 1)
+	resolve := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		v := js.Undefined()
+		if len(args) > 0 {
+			v = args[0]
+		}
+		done <- result{value: v}
+		return nil
+	})
+	reject := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		err := errors.New("promise rejected")
+		if len(args) > 0 {
+			err = jsError(args[0])
+		}
+		done <- result{err: err}
+		return nil
+	})
+	release := func() {
+		resolve.Release()
+		reject.Release()
+	}
+	promise.Call("then", resolve, reject)
+	select {
+	case r := <-done:
+		release()
+		return r.value, r.err
+	case <-ctx.Done():
+		// The functions are called when the promise settles, so are only
+		// released after.
+		go func() {
+			<-done
+			release()
+		}()
+		return js.Undefined(), ctx.Err()
+	}
+}
+
+// jsError converts a JavaScript error, such as a DOMException, to an error.
+func jsError(v js.Value) error {
+	if v.Type() == js.TypeObject && !v.Get("message").IsUndefined() {
+		if name := v.Get("name"); !name.IsUndefined() {
+			return fmt.Errorf("%s: %s", name.String(), v.Get("message").String())
+		}
+		return errors.New(v.Get("message").String())
+	}
+	return errors.New(v.String())
+}
@@ -0,0 +1,261 @@
+// Copyright 2014 Ben Buxton
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"errors"
+)
+
+// writeQueue is the default number of frames of each priority which can be
+// waiting for the writer.
+const writeQueue = 64
+
+// ErrQueueFull is returned when a frame cannot be queued for the writer,
+// with a QueueWrites policy which does not block.
+var ErrQueueFull = errors.New("write queue full")
+
+// writePriority orders the frames waiting for the writer. Frames of a
+// higher priority go first, those of the same priority in order.
+type writePriority byte
+
+const (
+	// bulkPriority is for long transfers, such as bulk I2C writes.
+	bulkPriority writePriority = iota
+	// normalPriority is for output writes and other commands.
+	normalPriority
+	// controlPriority is for resets and requests which wait for a reply.
+	controlPriority
+	numPriorities
+)
+
+// writeBufferSize is the most the writer sends in a single write, unless
+// one frame is larger.
+const writeBufferSize = 256
+
+// writeRequest is a frame for the writer, and where to send the result.
+// A request with flush set has the buffer written out with it.
+type writeRequest struct {
+	data  []byte
+	flush bool
+	done  chan error
+}
+
+// writeBuffer holds frames until the writer sends them. It is used only by
+// the writer.
+type writeBuffer struct {
+	data []byte
+	// waiting are told the result of the next write.
+	waiting []chan error
+	// flush is set when a frame asked for the buffer to be written.
+	flush bool
+	// err is the error of a write nobody waited for, returned by the
+	// next one.
+	err error
+}
+
+// add buffers a frame. Unless it asked for a flush, a frame held by
+// explicit buffering is reported written straight away.
+func (b *writeBuffer) add(w writeRequest, hold bool) {
+	b.data = append(b.data, w.data...)
+	if w.flush {
+		b.flush = true
+	}
+	if hold && !w.flush {
+		w.done <- nil
+		return
+	}
+	b.waiting = append(b.waiting, w.done)
+}
+
+// due returns true if the buffer should be written now.
+func (b *writeBuffer) due(hold bool) bool {
+	return !hold || b.flush || len(b.data) >= writeBufferSize
+}
+
+// startWriter starts the goroutine which does every write to the board,
+// so frames from concurrent callers are never interleaved.
+func (c *FirmataClient) startWriter() {
+	size := c.Queue(QueueWrites).Size
+	for i := range c.writes {
+		c.writes[i] = make(chan writeRequest, size)
+	}
+	go c.writer()
+}
+
+// writer sends the frames queued by writeFrame, highest priority first.
+// Frames which are queued together are sent in one write, and with
+// explicit buffering frames are held until a flush.
+func (c *FirmataClient) writer() {
+	var p pacer
+	b := &writeBuffer{data: make([]byte, 0, writeBufferSize)}
+	for {
+		w, ok := c.nextWrite(true)
+		if !ok {
+			return
+		}
+		hold := c.WriteBuffering()
+		b.add(w, hold)
+		for len(b.data) < writeBufferSize {
+			if w, ok = c.nextWrite(false); !ok {
+				break
+			}
+			b.add(w, hold)
+		}
+		if b.due(hold) {
+			c.flushBuffer(&p, b)
+		}
+	}
+}
+
+// nextWrite takes the next frame for the writer, of the highest priority
+// waiting. If wait is set it waits for one, otherwise it returns false if
+// there is none. It returns false once the client is closed.
+func (c *FirmataClient) nextWrite(wait bool) (writeRequest, bool) {
+	control, normal, bulk := c.writes[controlPriority], c.writes[normalPriority], c.writes[bulkPriority]
+	select {
+	case w := <-control:
+		return w, true
+	default:
+	}
+	select {
+	case w := <-control:
+		return w, true
+	case w := <-normal:
+		return w, true
+	default:
+	}
+	if !wait {
+		select {
+		case w := <-bulk:
+			return w, true
+		default:
+			return writeRequest{}, false
+		}
+	}
+	select {
+	case w := <-control:
+		return w, true
+	case w := <-normal:
+		return w, true
+	case w := <-bulk:
+		return w, true
+	case <-c.done:
+		return writeRequest{}, false
+	}
+}
+
+// flushBuffer writes out the buffer and reports the result.
+func (c *FirmataClient) flushBuffer(p *pacer, b *writeBuffer) {
+	var err error
+	if len(b.data) > 0 {
+		err = c.paceWrite(p, b.data)
+	}
+	if len(b.waiting) == 0 {
+		if b.err == nil {
+			b.err = err
+		}
+	} else {
+		if err == nil {
+			err, b.err = b.err, nil
+		}
+		for _, done := range b.waiting {
+			done <- err
+		}
+	}
+	b.data = b.data[:0]
+	b.waiting = b.waiting[:0]
+	b.flush = false
+}
+
+// write sends a whole frame to the board, waiting until it is written or,
+// with explicit buffering, buffered.
+func (c *FirmataClient) write(data []byte) error {
+	return c.writeFrame(data, false, normalPriority)
+}
+
+// writeFrame queues a frame for the writer, flushing buffered frames with
+// it if flush is set. If the queue for its priority is full it waits, or
+// with a QueueWrites policy which does not block, returns ErrQueueFull.
+func (c *FirmataClient) writeFrame(data []byte, flush bool, prio writePriority) error {
+	w := writeRequest{data: data, flush: flush, done: make(chan error, 1)}
+	ch := c.writes[prio]
+	if c.Queue(QueueWrites).Policy == Block {
+		select {
+		case ch <- w:
+		case <-c.done:
+			return ErrNotConnected
+		}
+	} else {
+		select {
+		case ch <- w:
+		case <-c.done:
+			return ErrNotConnected
+		default:
+			c.dropped(QueueWrites)
+			return ErrQueueFull
+		}
+	}
+	depth := 0
+	for _, q := range c.writes {
+		depth += len(q)
+	}
+	c.queueDepth(QueueWrites, depth)
+	select {
+	case err := <-w.done:
+		return err
+	case <-c.done:
+		return ErrNotConnected
+	}
+}
+
+// Flush writes out any buffered frames, returning the error of the write,
+// or of an earlier write of buffered frames which failed.
+func (c *FirmataClient) Flush() error {
+	if c.closed() {
+		return ErrNotConnected
+	}
+	return c.writeFrame(nil, true, controlPriority)
+}
+
+// SetWriteBuffering turns explicit write buffering on or off. When on,
+// commands are held, and report success, until Flush is called, a request
+// which waits for a reply is sent, or the buffer fills, so a sequence of
+// commands reaches the board in as few writes as possible. When off, the
+// default, commands are written as they are sent, though those sent
+// together by concurrent callers are still combined.
+func (c *FirmataClient) SetWriteBuffering(on bool) error {
+	c.stateMu.Lock()
+	c.writeBuffering = on
+	c.stateMu.Unlock()
+	if on || c.closed() {
+		return nil
+	}
+	return c.Flush()
+}
+
+// WriteBuffering returns true if explicit write buffering is on.
+func (c *FirmataClient) WriteBuffering() bool {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	return c.writeBuffering
+}
+
+// WithWriteBuffering turns on explicit write buffering once connected, as
+// for SetWriteBuffering.
+func WithWriteBuffering() Option {
+	return func(o *options) {
+		o.writeBuffering = true
+	}
+}